@@ -14,6 +14,9 @@ type Client interface {
 	CreateJob(ctx context.Context, job CreateJobRequest) (CreateJobResponse, error)
 	DescribeJob(ctx context.Context, jobID JobID) (JobStatusResponse, error)
 	CancelJob(ctx context.Context, jobID JobID) (CancelJobResponse, error)
+	JobEvents(ctx context.Context, jobID JobID) (<-chan JobEvent, error)
+	ScheduledJobs(ctx context.Context) ([]ScheduledJob, error)
+	RescheduleJob(ctx context.Context, jobID JobID, notBefore time.Time) (ScheduledJob, error)
 
 	// Presets
 	CreatePreset(ctx context.Context, preset CreatePresetRequest) (CreatePresetResponse, error)
@@ -22,6 +25,7 @@ type Client interface {
 	// Providers
 	AllProviders(ctx context.Context) (ProviderNames, error)
 	GetProvider(ctx context.Context, name ProviderName) (ProviderDescription, error)
+	SyncPresets(ctx context.Context, name ProviderName, req SyncPresetsRequest) ([]PresetSync, error)
 }
 
 const (
@@ -73,6 +77,32 @@ func (c *DefaultClient) DescribeJob(ctx context.Context, jobID JobID) (JobStatus
 	return describeResp, nil
 }
 
+// ScheduledJobs lists the jobs waiting for their scheduled time
+func (c *DefaultClient) ScheduledJobs(ctx context.Context) ([]ScheduledJob, error) {
+	c.ensure()
+
+	var jobs []ScheduledJob
+	err := c.getResource(ctx, &jobs, "/scheduledjobs")
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// RescheduleJob changes the time a scheduled job is submitted to its provider
+func (c *DefaultClient) RescheduleJob(ctx context.Context, jobID JobID, notBefore time.Time) (ScheduledJob, error) {
+	c.ensure()
+
+	var job ScheduledJob
+	err := c.putResource(ctx, RescheduleJobRequest{NotBefore: notBefore}, &job, "/jobs/"+string(jobID)+"/schedule")
+	if err != nil {
+		return ScheduledJob{}, err
+	}
+
+	return job, nil
+}
+
 // CreatePreset attempts to create a new preset based on the request definition
 func (c *DefaultClient) CreatePreset(ctx context.Context, preset CreatePresetRequest) (CreatePresetResponse, error) {
 	c.ensure()
@@ -125,6 +155,20 @@ func (c *DefaultClient) GetProvider(ctx context.Context, name ProviderName) (Pro
 	return resp, nil
 }
 
+// SyncPresets compares the copies of the presets held by a provider with the
+// stored presets, overwriting the ones that drifted unless it's a dry run
+func (c *DefaultClient) SyncPresets(ctx context.Context, name ProviderName, req SyncPresetsRequest) ([]PresetSync, error) {
+	c.ensure()
+
+	var results []PresetSync
+	err := c.postResource(ctx, req, &results, "/providers/"+string(name)+"/presets/sync")
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
 func (c *DefaultClient) ensure() {
 	if c.Client == nil {
 		c.Client = &http.Client{Timeout: defaultTimeout}
@@ -0,0 +1,126 @@
+package transcoding
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// JobEventType is the type of an event streamed for a job
+type JobEventType string
+
+const (
+	// JobEventStatus is sent when the stream starts and whenever the status changes
+	JobEventStatus JobEventType = "status"
+
+	// JobEventProgress is sent whenever the progress of the job changes
+	JobEventProgress JobEventType = "progress"
+
+	// JobEventOutput is sent whenever the outputs of the job change
+	JobEventOutput JobEventType = "output"
+
+	// JobEventError is sent when the status of the job could not be retrieved
+	JobEventError JobEventType = "error"
+)
+
+// maxJobEventSize is the size of the largest event line read from the stream,
+// statuses listing every output of large jobs
+const maxJobEventSize = 16 << 20
+
+// JobEvent is a change in the status of a job. Status holds the current status
+// of the job, except for error events which carry the Error instead
+type JobEvent struct {
+	Type   JobEventType
+	Status JobStatus
+	Error  string
+}
+
+// JobEvents streams the events of a job. The channel is closed once the job
+// reaches a terminal status, the stream is interrupted or the context is done.
+// Streams that can't be read to their end send an error event before closing
+func (c *DefaultClient) JobEvents(ctx context.Context, jobID JobID) (<-chan JobEvent, error) {
+	c.ensure()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL.String()+"/jobs/"+string(jobID)+"/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// the stream stays open for as long as the job runs, so the client
+	// timeout can't apply to it
+	streamClient := *c.Client
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("recieved a non 2xx status response, got a %s with body %q", resp.Status, string(b))
+	}
+
+	events := make(chan JobEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		var eventType, data string
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, maxJobEventSize)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			case line == "" && data != "":
+				event := parseJobEvent(JobEventType(eventType), data)
+				eventType, data = "", ""
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			select {
+			case events <- JobEvent{Type: JobEventError, Error: fmt.Sprintf("reading job events: %v", err)}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func parseJobEvent(eventType JobEventType, data string) JobEvent {
+	event := JobEvent{Type: eventType}
+	if eventType == JobEventError {
+		var payload struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			payload.Error = data
+		}
+		event.Error = payload.Error
+		return event
+	}
+	if err := json.Unmarshal([]byte(data), &event.Status); err != nil {
+		event.Type = JobEventError
+		event.Error = fmt.Sprintf("decoding %s event: %v", eventType, err)
+	}
+	return event
+}
@@ -0,0 +1,131 @@
+package transcoding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJobEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs/job-123/events" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: status\ndata: {\"status\":\"started\",\"progress\":10}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"error\":\"provider unavailable\"}\n\n")
+		fmt.Fprint(w, "event: progress\ndata: {\"status\":\"started\",\"progress\":50}\n\n")
+	}))
+	defer srv.Close()
+
+	baseURL, _ := url.Parse(srv.URL)
+	c := &DefaultClient{BaseURL: baseURL}
+
+	events, err := c.JobEvents(context.Background(), "job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []JobEvent
+	for event := range events {
+		got = append(got, event)
+	}
+
+	want := []JobEvent{
+		{Type: JobEventStatus, Status: JobStatus{Status: "started", Progress: 10}},
+		{Type: JobEventError, Error: "provider unavailable"},
+		{Type: JobEventProgress, Status: JobStatus{Status: "started", Progress: 50}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong events\nwant %+v\ngot  %+v", want, got)
+	}
+
+	_, err = c.JobEvents(context.Background(), "job-unknown")
+	if err == nil {
+		t.Error("expected an error for an unknown job")
+	}
+}
+
+func TestJobEventsLargeEvents(t *testing.T) {
+	message := strings.Repeat("a", 100<<10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: status\ndata: {\"status\":\"started\",\"statusMessage\":%q}\n\n", message)
+		fmt.Fprintf(w, "event: status\ndata: %s\n\n", strings.Repeat("a", maxJobEventSize))
+	}))
+	defer srv.Close()
+
+	baseURL, _ := url.Parse(srv.URL)
+	c := &DefaultClient{BaseURL: baseURL}
+
+	events, err := c.JobEvents(context.Background(), "job-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []JobEvent
+	for event := range events {
+		got = append(got, event)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("wrong number of events: got %d, expected 2", len(got))
+	}
+	if got[0].Type != JobEventStatus || got[0].Status.StatusMessage != message {
+		t.Errorf("expected an event larger than 64KB to be read, got a %s event", got[0].Type)
+	}
+	if got[1].Type != JobEventError || !strings.Contains(got[1].Error, "token too long") {
+		t.Errorf("expected an error event for an event over the limit, got %+v", got[1])
+	}
+}
+
+func TestDecodeLifecycleEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		want    LifecycleEvent
+		wantErr bool
+	}{
+		{
+			name: "output ready event",
+			values: map[string]interface{}{
+				LifecycleFieldVersion: "1",
+				LifecycleFieldType:    "job.output_ready",
+				LifecycleFieldPayload: `{"version":1,"type":"job.output_ready","jobId":"job-1","status":"finished","output":{"destination":"s3://bucket/job-1"}}`,
+			},
+			want: LifecycleEvent{
+				Version: 1,
+				Type:    LifecycleEventOutputReady,
+				JobID:   "job-1",
+				Status:  "finished",
+				Output:  &OutputFiles{Destination: "s3://bucket/job-1"},
+			},
+		},
+		{
+			name:    "newer schema version",
+			values:  map[string]interface{}{LifecycleFieldPayload: `{"version":2,"type":"job.created"}`},
+			wantErr: true,
+		},
+		{
+			name:    "missing payload",
+			values:  map[string]interface{}{LifecycleFieldType: "job.created"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeLifecycleEvent(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error state: %v", err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrong event\nwant %+v\ngot  %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -58,6 +58,80 @@ type AudioDownmix struct {
 	DestChannels []AudioChannel
 }
 
+// AudioTrack is an audio rendition of the adaptive streaming outputs, selected
+// from the source track at SourceTrack (starting at 0)
+type AudioTrack struct {
+	SourceTrack     uint     `json:"sourceTrack"`
+	Language        string   `json:"language"`
+	Name            string   `json:"name,omitempty"`
+	Default         bool     `json:"default,omitempty"`
+	Autoselect      bool     `json:"autoselect,omitempty"`
+	Characteristics []string `json:"characteristics,omitempty"`
+}
+
+// CaptionFormat is the format of a caption track, either a sidecar format or
+// CaptionFormatEmbedded for the CEA-608/708 captions of the source video
+type CaptionFormat = string
+
+// CaptionFormat values
+const (
+	CaptionFormatSCC      CaptionFormat = "scc"
+	CaptionFormatSRT      CaptionFormat = "srt"
+	CaptionFormatWebVTT   CaptionFormat = "webvtt"
+	CaptionFormatTTML     CaptionFormat = "ttml"
+	CaptionFormatIMSC     CaptionFormat = "imsc"
+	CaptionFormatEmbedded CaptionFormat = "embedded"
+)
+
+// Caption is a caption or subtitle track of the outputs listed in Outputs by
+// file name, or of every output when empty. Channel is the CEA-608 channel of
+// embedded captions.
+type Caption struct {
+	Source   string        `json:"source,omitempty"`
+	Format   CaptionFormat `json:"format"`
+	Language string        `json:"language"`
+	Name     string        `json:"name,omitempty"`
+	Channel  uint          `json:"channel,omitempty"`
+	Default  bool          `json:"default,omitempty"`
+	Outputs  []string      `json:"outputs,omitempty"`
+}
+
+// ImageOutputKind is the kind of images of an image output
+type ImageOutputKind = string
+
+// ImageOutputKind values
+const (
+	ImageOutputKindThumbnails ImageOutputKind = "thumbnails"
+	ImageOutputKindPoster     ImageOutputKind = "poster"
+	ImageOutputKindSprite     ImageOutputKind = "sprite"
+)
+
+// ImageOutput is an output of still images taken from the source: thumbnails
+// every Interval seconds or at the given Offsets, a poster frame, or sprite
+// sheets of Columns by Rows tiles indexed by a WebVTT file. Playlist adds
+// trick-play playlists to the HLS manifest of streaming jobs.
+type ImageOutput struct {
+	Kind     ImageOutputKind `json:"kind"`
+	FileName string          `json:"fileName"`
+	Format   string          `json:"format,omitempty"`
+	Width    uint            `json:"width,omitempty"`
+	Height   uint            `json:"height,omitempty"`
+	Interval float64         `json:"interval,omitempty"`
+	Offsets  []float64       `json:"offsets,omitempty"`
+	Columns  uint            `json:"columns,omitempty"`
+	Rows     uint            `json:"rows,omitempty"`
+	Playlist bool            `json:"playlist,omitempty"`
+}
+
+const (
+	// FileTypeImage is the type of the files of image outputs
+	FileTypeImage = "image"
+
+	// FileTypeManifest is the type of the HLS and DASH manifests of streaming
+	// outputs
+	FileTypeManifest = "manifest"
+)
+
 // File is a media file. It replaces the following objects
 // SourceInfo: Duration, Height, Width, Codec
 // CreateJobSourceInfo: Height, Width, FrameRate, File Size, ScanType
@@ -73,6 +147,21 @@ type File struct {
 	Height     int      `json:"height,omitempty"`
 	FrameRate  float64  `json:"frameRate,omitempty"`
 	ScanType   ScanType `json:"scanType,omitempty"`
+	Bitrate    int64    `json:"bitrate,omitempty"`
+
+	// StartTimecode is the timecode embedded in the first frame of a source
+	StartTimecode string `json:"startTimecode,omitempty"`
+
+	// Captions are the languages of the caption tracks carried by an output
+	Captions []string `json:"captions,omitempty"`
+
+	// Segments is the location of the segments of a streaming rendition, their
+	// number replacing the %05d verb
+	Segments string `json:"segments,omitempty"`
+
+	// Type is FileTypeImage for the files of image outputs, FileTypeManifest
+	// for manifests
+	Type string `json:"type,omitempty"`
 }
 
 type (
@@ -88,6 +177,10 @@ type (
 		// Not every provider currently supports this feature.
 		Splice timecode.Splice `json:"splice,omitempty"`
 
+		// SpliceTimecodes is a splice expressed as SMPTE timecodes, relative to the
+		// start timecode of the source. It requires the frame rate of the source.
+		SpliceTimecodes [][2]string `json:"spliceTimecodes,omitempty"`
+
 		Provider          string                      `json:"provider"`
 		ExecutionFeatures ExecutionFeatures           `json:"executionFeatures,omitempty"`
 		ExecutionEnv      ExecutionEnvironment        `json:"executionEnv,omitempty"`
@@ -97,9 +190,24 @@ type (
 		DestinationBasePath string      `json:"destinationBasePath,omitempty"`
 		Outputs             []JobOutput `json:"outputs"`
 
-		AudioDownmix            AudioDownmix `json:"audioDownmix"`
-		ExplicitKeyframeOffsets []float64    `json:"explicitKeyframeOffsets,omitempty"`
-		Labels                  []string     `json:"labels,omitempty"`
+		AudioDownmix            AudioDownmix  `json:"audioDownmix"`
+		ExplicitKeyframeOffsets []float64     `json:"explicitKeyframeOffsets,omitempty"`
+		AudioTracks             []AudioTrack  `json:"audioTracks,omitempty"`
+		Captions                []Caption     `json:"captions,omitempty"`
+		ImageOutputs            []ImageOutput `json:"imageOutputs,omitempty"`
+		Labels                  []string      `json:"labels,omitempty"`
+
+		// Priority ranges from -50 (lowest) to 50 (highest) and defaults to 0
+		Priority int `json:"priority,omitempty"`
+
+		// MaxDuration and MaxQueueTime are limits in seconds after which the job is
+		// considered stuck. The service defaults are used when omitted
+		MaxDuration  uint `json:"maxDuration,omitempty"`
+		MaxQueueTime uint `json:"maxQueueTime,omitempty"`
+
+		// NotBefore delays the submission of the job to the provider until the
+		// given time. The job reports a scheduled status until then
+		NotBefore *time.Time `json:"notBefore,omitempty"`
 	}
 	CreateJobResponse struct {
 		JobID JobID `json:"jobId"`
@@ -108,13 +216,75 @@ type (
 		JobID JobID `json:"jobId"`
 	}
 	CancelJobResponse struct{ JobStatus }
+
+	// ScheduledJob is a job waiting for its NotBefore time
+	ScheduledJob struct {
+		JobID        JobID        `json:"jobId"`
+		Name         string       `json:"name,omitempty"`
+		ProviderName ProviderName `json:"providerName"`
+		NotBefore    time.Time    `json:"notBefore"`
+		Labels       []string     `json:"labels,omitempty"`
+	}
+	RescheduleJobRequest struct {
+		NotBefore time.Time `json:"notBefore"`
+	}
 )
 
-// StreamingParams contains the configuration for media packaging
+// StreamingParams contains the configuration for media packaging. Protocol
+// is either "hls", "dash", or a comma separated list such as "hls,dash" for
+// producing several manifests from the same segments. SegmentTemplate is the
+// way DASH manifests address their segments.
 type StreamingParams struct {
-	SegmentDuration  uint   `json:"segmentDuration"`
-	Protocol         string `json:"protocol"`
-	PlaylistFileName string `json:"playlistFileName,omitempty"`
+	SegmentDuration  uint            `json:"segmentDuration"`
+	Protocol         string          `json:"protocol"`
+	PlaylistFileName string          `json:"playlistFileName,omitempty"`
+	SegmentTemplate  SegmentTemplate `json:"segmentTemplate,omitempty"`
+	Encryption       *Encryption     `json:"encryption,omitempty"`
+}
+
+// SegmentTemplate is the way the segments of DASH manifests are addressed
+type SegmentTemplate = string
+
+// SegmentTemplate values
+const (
+	SegmentTemplateNumber   SegmentTemplate = "number"
+	SegmentTemplateTimeline SegmentTemplate = "timeline"
+)
+
+// EncryptionScheme is the way the segments of streaming outputs are encrypted
+type EncryptionScheme = string
+
+// EncryptionScheme values
+const (
+	EncryptionSchemeAES128    EncryptionScheme = "aes-128"
+	EncryptionSchemeSampleAES EncryptionScheme = "sample-aes"
+	EncryptionSchemeCENC      EncryptionScheme = "cenc"
+)
+
+// DRMSystem is a DRM system licensing the keys of encrypted outputs
+type DRMSystem = string
+
+// DRMSystem values
+const (
+	DRMSystemWidevine  DRMSystem = "widevine"
+	DRMSystemPlayReady DRMSystem = "playready"
+	DRMSystemFairPlay  DRMSystem = "fairplay"
+)
+
+// Encryption configures the encryption of streaming outputs, either with a
+// static Key or with keys requested from the SPEKE key server at KeyServerURL.
+// Keys, key IDs and IVs are 32 hexadecimal characters. ResourceID defaults to
+// the ID of the job and KeyRotationInterval is in seconds.
+type Encryption struct {
+	Scheme              EncryptionScheme `json:"scheme"`
+	Key                 string           `json:"key,omitempty"`
+	KeyID               string           `json:"keyId,omitempty"`
+	IV                  string           `json:"iv,omitempty"`
+	KeyURL              string           `json:"keyUrl,omitempty"`
+	KeyServerURL        string           `json:"keyServerUrl,omitempty"`
+	ResourceID          string           `json:"resourceId,omitempty"`
+	KeyRotationInterval uint             `json:"keyRotationInterval,omitempty"`
+	DRMSystems          []DRMSystem      `json:"drmSystems,omitempty"`
 }
 
 // ExecutionEnvironment contains configurations for the environment used while transcoding
@@ -140,6 +310,11 @@ type JobStatus struct {
 	SourceInfo File `json:"sourceInfo,omitempty"`
 
 	Output OutputFiles `json:"output"`
+
+	// StalledReason is set when the job was detected as stuck, and ResubmittedAs
+	// holds the ID of the job that replaced it, if any
+	StalledReason string `json:"stalledReason,omitempty"`
+	ResubmittedAs JobID  `json:"resubmittedAs,omitempty"`
 }
 
 // JobOutput defines config parameters for single output in a job
@@ -0,0 +1,73 @@
+package transcoding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LifecycleSchemaVersion is the version of the LifecycleEvent schema published
+// on the job events stream. Events with a higher version may not be decoded
+// correctly by this package.
+const LifecycleSchemaVersion = 1
+
+// Fields of each entry of the job events Redis Stream. The payload holds the
+// JSON-encoded LifecycleEvent, the other fields allow filtering entries
+// without decoding it.
+const (
+	LifecycleFieldVersion = "version"
+	LifecycleFieldType    = "type"
+	LifecycleFieldJobID   = "jobId"
+	LifecycleFieldPayload = "payload"
+)
+
+// LifecycleEventType is the type of a job lifecycle event
+type LifecycleEventType string
+
+const (
+	LifecycleEventCreated       LifecycleEventType = "job.created"
+	LifecycleEventSubmitted     LifecycleEventType = "job.submitted"
+	LifecycleEventStatusChanged LifecycleEventType = "job.status_changed"
+	LifecycleEventOutputReady   LifecycleEventType = "job.output_ready"
+	LifecycleEventFailed        LifecycleEventType = "job.failed"
+)
+
+// LifecycleEvent is a change in the lifecycle of a job, as published on the
+// job events stream
+type LifecycleEvent struct {
+	Version       int                `json:"version"`
+	Type          LifecycleEventType `json:"type"`
+	Time          time.Time          `json:"time"`
+	JobID         JobID              `json:"jobId"`
+	ProviderName  string             `json:"providerName,omitempty"`
+	ProviderJobID string             `json:"providerJobId,omitempty"`
+	Labels        []string           `json:"labels,omitempty"`
+	Status        Status             `json:"status,omitempty"`
+	Progress      float64            `json:"progress,omitempty"`
+	Output        *OutputFiles       `json:"output,omitempty"`
+	Error         string             `json:"error,omitempty"`
+}
+
+// DecodeLifecycleEvent decodes the values of a job events stream entry, such as
+// the Values of a go-redis XMessage
+func DecodeLifecycleEvent(values map[string]interface{}) (LifecycleEvent, error) {
+	var e LifecycleEvent
+	var payload []byte
+	switch p := values[LifecycleFieldPayload].(type) {
+	case string:
+		payload = []byte(p)
+	case []byte:
+		payload = p
+	default:
+		return e, errors.New("missing event payload")
+	}
+	err := json.Unmarshal(payload, &e)
+	if err != nil {
+		return e, err
+	}
+	if e.Version > LifecycleSchemaVersion {
+		return e, fmt.Errorf("unsupported event schema version %d, expected up to %d", e.Version, LifecycleSchemaVersion)
+	}
+	return e, nil
+}
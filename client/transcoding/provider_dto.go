@@ -12,6 +12,13 @@ type ProviderDescription struct {
 	Capabilities ProviderCapabilities `json:"capabilities"`
 	Health       ProviderHealth       `json:"health"`
 	Enabled      bool                 `json:"enabled"`
+
+	// ConfigError explains why the provider is not enabled
+	ConfigError string `json:"configError,omitempty"`
+
+	// CircuitBreaker is either "open" or "half-open" when submissions to
+	// the provider are being rejected after too many consecutive failures
+	CircuitBreaker string `json:"circuitBreaker,omitempty"`
 }
 
 // Capabilities describes the available features in the provider.
@@ -26,3 +33,20 @@ type ProviderHealth struct {
 	OK      bool   `json:"ok"`
 	Message string `json:"message,omitempty"`
 }
+
+// SyncPresetsRequest selects the presets synchronized with a provider, all
+// the presets mapped to it when Presets is empty
+type SyncPresetsRequest struct {
+	DryRun  bool         `json:"dryRun"`
+	Presets []PresetName `json:"presets,omitempty"`
+}
+
+// PresetSync is the outcome of the synchronization of a preset with the copy
+// held by a provider. Status is either "inSync", "drifted", "missing",
+// "updated" or "created".
+type PresetSync struct {
+	PresetID string   `json:"presetId"`
+	Status   string   `json:"status,omitempty"`
+	Drift    []string `json:"drift,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
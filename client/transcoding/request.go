@@ -17,6 +17,10 @@ func (c *DefaultClient) postResource(ctx context.Context, resource interface{},
 	return c.reqWithMethodAndPayload(ctx, http.MethodPost, path, result, resource)
 }
 
+func (c *DefaultClient) putResource(ctx context.Context, resource interface{}, result interface{}, path string) error {
+	return c.reqWithMethodAndPayload(ctx, http.MethodPut, path, result, resource)
+}
+
 func (c *DefaultClient) removeResource(ctx context.Context, result interface{}, path string) error {
 	return c.reqWithMethodAndPayload(ctx, http.MethodDelete, path, result, nil)
 }
@@ -11,24 +11,34 @@ import (
 // Config is a struct to contain all the needed configuration for the
 // Transcoding API.
 type Config struct {
-	Server                 *server.Config
-	SwaggerManifest        string `envconfig:"SWAGGER_MANIFEST_PATH"`
-	DefaultSegmentDuration uint   `envconfig:"DEFAULT_SEGMENT_DURATION" default:"5"`
-	SentryDSN              string `envconfig:"SENTRY_DSN"`
-	Env                    string `envconfig:"ENV" default:"dev"`
-	EnableXray             bool   `envconfig:"ENABLE_XRAY"`
-	EnableXrayAWSPlugins   bool   `envconfig:"ENABLE_XRAYAWSPLUGINS"`
-	Redis                  *storage.Config
-	EncodingCom            *EncodingCom
-	ElasticTranscoder      *ElasticTranscoder
-	ElementalConductor     *ElementalConductor
-	Hybrik                 *Hybrik
-	Zencoder               *Zencoder
-	Bitmovin               *Bitmovin
-	MediaConvert           *MediaConvert
-	Flock                  *Flock
-	Log                    *logging.Config
-	Tracer                 tracing.Tracer `ignored:"true"`
+	Server                   *server.Config
+	SwaggerManifest          string `envconfig:"SWAGGER_MANIFEST_PATH"`
+	DefaultSegmentDuration   uint   `envconfig:"DEFAULT_SEGMENT_DURATION" default:"5"`
+	DefaultMaxDuration       uint   `envconfig:"DEFAULT_JOB_MAX_DURATION" default:"43200"`
+	DefaultMaxQueueTime      uint   `envconfig:"DEFAULT_JOB_MAX_QUEUE_TIME" default:"7200"`
+	JobEventsPollInterval    uint   `envconfig:"JOB_EVENTS_POLL_INTERVAL" default:"5"`
+	JobStatusCacheTTL        uint   `envconfig:"JOB_STATUS_CACHE_TTL" default:"10"`
+	RequireStreamingCaptions bool   `envconfig:"REQUIRE_STREAMING_CAPTIONS"`
+	SentryDSN                string `envconfig:"SENTRY_DSN"`
+	Env                      string `envconfig:"ENV" default:"dev"`
+	EnableXray               bool   `envconfig:"ENABLE_XRAY"`
+	EnableXrayAWSPlugins     bool   `envconfig:"ENABLE_XRAYAWSPLUGINS"`
+	Redis                    *storage.Config
+	EncodingCom              *EncodingCom
+	ElasticTranscoder        *ElasticTranscoder
+	ElementalConductor       *ElementalConductor
+	Hybrik                   *Hybrik
+	Zencoder                 *Zencoder
+	Bitmovin                 *Bitmovin
+	MediaConvert             *MediaConvert
+	Flock                    *Flock
+	Watchdog                 *Watchdog
+	Scheduler                *Scheduler
+	Events                   *Events
+	Health                   *Health
+	Retry                    *Retry
+	Log                      *logging.Config
+	Tracer                   tracing.Tracer `ignored:"true"`
 }
 
 // EncodingCom represents the set of configurations for the Encoding.com
@@ -84,6 +94,17 @@ type Bitmovin struct {
 	Destination        string `envconfig:"BITMOVIN_DESTINATION"`
 	EncodingRegion     string `envconfig:"BITMOVIN_ENCODING_REGION" default:"AWS_US_EAST_1"`
 	EncodingVersion    string `envconfig:"BITMOVIN_ENCODING_VERSION" default:"STABLE"`
+
+	// Concurrency is the maximum number of outputs, splice ranges or
+	// keyframes created concurrently for a job.
+	Concurrency uint `envconfig:"BITMOVIN_CONCURRENCY" default:"10"`
+
+	// SweepInterval is how often, in seconds, encodings that were created
+	// but never started are looked for and deleted. Zero disables sweeping.
+	SweepInterval uint `envconfig:"BITMOVIN_SWEEP_INTERVAL"`
+	// SweepMinAge is the age, in seconds, after which an encoding that
+	// wasn't started is deleted by the sweeper.
+	SweepMinAge uint `envconfig:"BITMOVIN_SWEEP_MIN_AGE" default:"3600"`
 }
 
 // Hybrik represents the set of configurations for the Hybrik
@@ -111,6 +132,27 @@ type MediaConvert struct {
 	PreferredQueueARN string `envconfig:"MEDIACONVERT_PREFERRED_QUEUE_ARN"`
 	Role              string `envconfig:"MEDIACONVERT_ROLE_ARN"`
 	Destination       string `envconfig:"MEDIACONVERT_DESTINATION"`
+
+	// NativePresets mirrors every preset into a native MediaConvert preset,
+	// referenced by the outputs of jobs, filed under PresetCategory
+	NativePresets  bool   `envconfig:"MEDIACONVERT_NATIVE_PRESETS"`
+	PresetCategory string `envconfig:"MEDIACONVERT_PRESET_CATEGORY"`
+
+	// QueueRules is a JSON list of rules routing jobs to queues by labels,
+	// priority, source size or duration, the first matching rule applying.
+	// Jobs matching no rule are sent to the preferred or default queue.
+	QueueRules string `envconfig:"MEDIACONVERT_QUEUE_RULES"`
+
+	// Acceleration is requested for sources of at least AccelerationMinSize
+	// bytes, restricted to the jobs labeled with one of AccelerationLabels when
+	// set. Jobs are resubmitted without acceleration when MediaConvert rejects
+	// it. A zero size disables acceleration.
+	AccelerationMinSize int64    `envconfig:"MEDIACONVERT_ACCELERATION_MIN_SIZE"`
+	AccelerationLabels  []string `envconfig:"MEDIACONVERT_ACCELERATION_LABELS"`
+
+	// PlaylistNaming names the manifests and segments of streaming outputs
+	// after the playlist of the job instead of its source
+	PlaylistNaming bool `envconfig:"MEDIACONVERT_PLAYLIST_NAMING"`
 }
 
 // Flock represents the set of configurations for the Flock
@@ -120,6 +162,63 @@ type Flock struct {
 	Credential string `envconfig:"FLOCK_CREDENTIAL"`
 }
 
+// Watchdog represents the set of configurations for detecting stuck jobs.
+// Durations are expressed in seconds.
+type Watchdog struct {
+	Enabled          bool   `envconfig:"WATCHDOG_ENABLED"`
+	Interval         uint   `envconfig:"WATCHDOG_INTERVAL" default:"60"`
+	Lookback         uint   `envconfig:"WATCHDOG_LOOKBACK" default:"86400"`
+	StallWindow      uint   `envconfig:"WATCHDOG_STALL_WINDOW" default:"1800"`
+	CancelStalled    bool   `envconfig:"WATCHDOG_CANCEL_STALLED"`
+	ResubmitProvider string `envconfig:"WATCHDOG_RESUBMIT_PROVIDER"`
+}
+
+// Scheduler represents the set of configurations for submitting scheduled
+// jobs. Jobs claimed for their submission longer than the claim timeout ago
+// are put back on the schedule, the claiming instance is assumed to have
+// stopped. Both are expressed in seconds.
+type Scheduler struct {
+	Interval     uint `envconfig:"SCHEDULER_INTERVAL" default:"30"`
+	ClaimTimeout uint `envconfig:"SCHEDULER_CLAIM_TIMEOUT" default:"600"`
+}
+
+// Events represents the set of configurations for publishing job lifecycle
+// events to a Redis Stream. The listed consumer groups are created when
+// missing, starting from the beginning of the stream. The status of the jobs
+// created within the poll lookback is polled at the poll interval to publish
+// their status changes. Durations are expressed in seconds.
+type Events struct {
+	StreamEnabled  bool     `envconfig:"EVENTS_STREAM_ENABLED"`
+	Stream         string   `envconfig:"EVENTS_STREAM" default:"transcode-orchestrator:job-events"`
+	MaxLen         int64    `envconfig:"EVENTS_STREAM_MAX_LEN" default:"100000"`
+	ConsumerGroups []string `envconfig:"EVENTS_CONSUMER_GROUPS"`
+	PollInterval   uint     `envconfig:"EVENTS_POLL_INTERVAL" default:"30"`
+	PollLookback   uint     `envconfig:"EVENTS_POLL_LOOKBACK" default:"86400"`
+}
+
+// Health represents the set of configurations for probing the health of
+// providers and guarding them with circuit breakers. Durations are expressed
+// in seconds. Breakers are disabled when no threshold is configured, and
+// submissions to a provider with an open breaker are sent to its failover
+// provider, configured as a list of provider:failover pairs.
+type Health struct {
+	ProbeInterval    uint              `envconfig:"HEALTH_PROBE_INTERVAL" default:"30"`
+	ProbeTimeout     uint              `envconfig:"HEALTH_PROBE_TIMEOUT" default:"5"`
+	BreakerThreshold uint              `envconfig:"CIRCUIT_BREAKER_THRESHOLD" default:"5"`
+	BreakerCooldown  uint              `envconfig:"CIRCUIT_BREAKER_COOLDOWN" default:"60"`
+	Failover         map[string]string `envconfig:"PROVIDER_FAILOVER"`
+}
+
+// Retry represents the set of configurations for retrying failed provider
+// calls. Only the listed providers retry. Delays are expressed in
+// milliseconds.
+type Retry struct {
+	Providers   []string `envconfig:"RETRY_PROVIDERS"`
+	MaxAttempts uint     `envconfig:"RETRY_MAX_ATTEMPTS" default:"3"`
+	BaseDelay   uint     `envconfig:"RETRY_BASE_DELAY" default:"250"`
+	MaxDelay    uint     `envconfig:"RETRY_MAX_DELAY" default:"5000"`
+}
+
 // LoadConfig loads the configuration of the API using environment variables.
 func LoadConfig() *Config {
 	var cfg Config
@@ -48,6 +48,9 @@ func TestLoadConfigFromEnv(t *testing.T) {
 		"BITMOVIN_AWS_STORAGE_REGION":              "US_WEST_1",
 		"BITMOVIN_ENCODING_REGION":                 "GOOGLE_EUROPE_WEST_1",
 		"BITMOVIN_ENCODING_VERSION":                "notstable",
+		"BITMOVIN_CONCURRENCY":                     "4",
+		"BITMOVIN_SWEEP_INTERVAL":                  "600",
+		"BITMOVIN_SWEEP_MIN_AGE":                   "7200",
 		"MEDIACONVERT_AWS_ACCESS_KEY_ID":           "mc-aws-access-key-id",
 		"MEDIACONVERT_AWS_SECRET_ACCESS_KEY":       "mc-aws-secret-access-key",
 		"MEDIACONVERT_AWS_REGION":                  "mc-aws-region",
@@ -56,20 +59,59 @@ func TestLoadConfigFromEnv(t *testing.T) {
 		"MEDIACONVERT_PREFERRED_QUEUE_ARN":         "arn:aws:mediaconvert:us-east-1:some-queue:queues/Preferred",
 		"MEDIACONVERT_ROLE_ARN":                    "arn:aws:iam::some-account:role/some-role",
 		"MEDIACONVERT_DESTINATION":                 "s3://mc-destination/",
+		"MEDIACONVERT_NATIVE_PRESETS":              "true",
+		"MEDIACONVERT_PRESET_CATEGORY":             "orchestrator",
+		"MEDIACONVERT_QUEUE_RULES":                 `[{"labels":["news"],"queue":"arn:aws:mediaconvert:us-east-1:some-queue:queues/News"}]`,
+		"MEDIACONVERT_ACCELERATION_MIN_SIZE":       "1000000000",
+		"MEDIACONVERT_ACCELERATION_LABELS":         "movies,series",
+		"MEDIACONVERT_PLAYLIST_NAMING":             "true",
 		"FLOCK_ENDPOINT":                           "https://flock.domain",
 		"FLOCK_CREDENTIAL":                         "secret-token",
 		"SWAGGER_MANIFEST_PATH":                    "/opt/video-transcoding-api-swagger.json",
 		"HTTP_ACCESS_LOG":                          accessLog,
 		"HTTP_PORT":                                "8080",
 		"DEFAULT_SEGMENT_DURATION":                 "3",
+		"DEFAULT_JOB_MAX_DURATION":                 "3600",
+		"DEFAULT_JOB_MAX_QUEUE_TIME":               "600",
+		"JOB_EVENTS_POLL_INTERVAL":                 "2",
+		"JOB_STATUS_CACHE_TTL":                     "30",
+		"REQUIRE_STREAMING_CAPTIONS":               "true",
+		"WATCHDOG_ENABLED":                         "true",
+		"WATCHDOG_INTERVAL":                        "30",
+		"WATCHDOG_LOOKBACK":                        "7200",
+		"WATCHDOG_STALL_WINDOW":                    "900",
+		"WATCHDOG_CANCEL_STALLED":                  "true",
+		"WATCHDOG_RESUBMIT_PROVIDER":               "hybrik",
+		"SCHEDULER_INTERVAL":                       "10",
+		"SCHEDULER_CLAIM_TIMEOUT":                  "300",
+		"EVENTS_STREAM_ENABLED":                    "true",
+		"EVENTS_STREAM":                            "job-events",
+		"EVENTS_STREAM_MAX_LEN":                    "500",
+		"EVENTS_CONSUMER_GROUPS":                   "notifier,indexer",
+		"EVENTS_POLL_INTERVAL":                     "10",
+		"EVENTS_POLL_LOOKBACK":                     "3600",
+		"HEALTH_PROBE_INTERVAL":                    "10",
+		"HEALTH_PROBE_TIMEOUT":                     "2",
+		"CIRCUIT_BREAKER_THRESHOLD":                "3",
+		"CIRCUIT_BREAKER_COOLDOWN":                 "120",
+		"PROVIDER_FAILOVER":                        "bitmovin:mediaconvert,hybrik:mediaconvert",
+		"RETRY_PROVIDERS":                          "flock,hybrik",
+		"RETRY_MAX_ATTEMPTS":                       "5",
+		"RETRY_BASE_DELAY":                         "100",
+		"RETRY_MAX_DELAY":                          "2000",
 		"LOGGING_LEVEL":                            "debug",
 	})
 	cfg := LoadConfig()
 	expectedCfg := Config{
-		SwaggerManifest:        "/opt/video-transcoding-api-swagger.json",
-		DefaultSegmentDuration: 3,
-		Env:                    "some_env",
-		SentryDSN:              "some_dsn",
+		SwaggerManifest:          "/opt/video-transcoding-api-swagger.json",
+		DefaultSegmentDuration:   3,
+		DefaultMaxDuration:       3600,
+		DefaultMaxQueueTime:      600,
+		JobEventsPollInterval:    2,
+		JobStatusCacheTTL:        30,
+		RequireStreamingCaptions: true,
+		Env:                      "some_env",
+		SentryDSN:                "some_dsn",
 		Redis: &storage.Config{
 			SentinelAddrs:      "10.10.10.10:26379,10.10.10.11:26379,10.10.10.12:26379",
 			SentinelMasterName: "super-master",
@@ -115,6 +157,9 @@ func TestLoadConfigFromEnv(t *testing.T) {
 			Destination:      "https://safe-stuff",
 			EncodingRegion:   "GOOGLE_EUROPE_WEST_1",
 			EncodingVersion:  "notstable",
+			Concurrency:      4,
+			SweepInterval:    600,
+			SweepMinAge:      7200,
 		},
 		MediaConvert: &MediaConvert{
 			AccessKeyID:       "mc-aws-access-key-id",
@@ -125,11 +170,51 @@ func TestLoadConfigFromEnv(t *testing.T) {
 			PreferredQueueARN: "arn:aws:mediaconvert:us-east-1:some-queue:queues/Preferred",
 			Role:              "arn:aws:iam::some-account:role/some-role",
 			Destination:       "s3://mc-destination/",
+			NativePresets:     true,
+			PresetCategory:    "orchestrator",
+			QueueRules: `[{"labels":["news"],` +
+				`"queue":"arn:aws:mediaconvert:us-east-1:some-queue:queues/News"}]`,
+			AccelerationMinSize: 1000000000,
+			AccelerationLabels:  []string{"movies", "series"},
+			PlaylistNaming:      true,
 		},
 		Flock: &Flock{
 			Endpoint:   "https://flock.domain",
 			Credential: "secret-token",
 		},
+		Watchdog: &Watchdog{
+			Enabled:          true,
+			Interval:         30,
+			Lookback:         7200,
+			StallWindow:      900,
+			CancelStalled:    true,
+			ResubmitProvider: "hybrik",
+		},
+		Scheduler: &Scheduler{
+			Interval:     10,
+			ClaimTimeout: 300,
+		},
+		Events: &Events{
+			StreamEnabled:  true,
+			Stream:         "job-events",
+			MaxLen:         500,
+			ConsumerGroups: []string{"notifier", "indexer"},
+			PollInterval:   10,
+			PollLookback:   3600,
+		},
+		Health: &Health{
+			ProbeInterval:    10,
+			ProbeTimeout:     2,
+			BreakerThreshold: 3,
+			BreakerCooldown:  120,
+			Failover:         map[string]string{"bitmovin": "mediaconvert", "hybrik": "mediaconvert"},
+		},
+		Retry: &Retry{
+			Providers:   []string{"flock", "hybrik"},
+			MaxAttempts: 5,
+			BaseDelay:   100,
+			MaxDelay:    2000,
+		},
 		Server: &server.Config{
 			HTTPPort:      8080,
 			HTTPAccessLog: &accessLog,
@@ -184,6 +269,10 @@ func TestLoadConfigFromEnvWithDefaults(t *testing.T) {
 		Env:                    "dev",
 		SwaggerManifest:        "/opt/video-transcoding-api-swagger.json",
 		DefaultSegmentDuration: 5,
+		DefaultMaxDuration:     43200,
+		DefaultMaxQueueTime:    7200,
+		JobEventsPollInterval:  5,
+		JobStatusCacheTTL:      10,
 		Redis: &storage.Config{
 			SentinelAddrs:      "10.10.10.10:26379,10.10.10.11:26379,10.10.10.12:26379",
 			SentinelMasterName: "super-master",
@@ -230,9 +319,37 @@ func TestLoadConfigFromEnvWithDefaults(t *testing.T) {
 			AWSStorageRegion: "US_EAST_1",
 			EncodingRegion:   "AWS_US_EAST_1",
 			EncodingVersion:  "STABLE",
+			Concurrency:      10,
+			SweepMinAge:      3600,
 		},
 		MediaConvert: &MediaConvert{},
 		Flock:        &Flock{},
+		Watchdog: &Watchdog{
+			Interval:    60,
+			Lookback:    86400,
+			StallWindow: 1800,
+		},
+		Scheduler: &Scheduler{
+			Interval:     30,
+			ClaimTimeout: 600,
+		},
+		Events: &Events{
+			Stream:       "transcode-orchestrator:job-events",
+			MaxLen:       100000,
+			PollInterval: 30,
+			PollLookback: 86400,
+		},
+		Health: &Health{
+			ProbeInterval:    30,
+			ProbeTimeout:     5,
+			BreakerThreshold: 5,
+			BreakerCooldown:  60,
+		},
+		Retry: &Retry{
+			MaxAttempts: 3,
+			BaseDelay:   250,
+			MaxDelay:    5000,
+		},
 		Server: &server.Config{
 			HTTPPort:      8080,
 			HTTPAccessLog: &accessLog,
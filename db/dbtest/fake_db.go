@@ -2,6 +2,7 @@ package dbtest
 
 import (
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/cbsinteractive/transcode-orchestrator/db"
@@ -13,6 +14,12 @@ type fakeRepository struct {
 	localpresets    map[string]*db.LocalPreset
 	presetSummaries map[string]db.PresetSummary
 	jobs            []*db.Job
+	statuses        map[string]cachedStatus
+}
+
+type cachedStatus struct {
+	status    []byte
+	expiresAt time.Time
 }
 
 // NewFakeRepository creates a new instance of the fake repository
@@ -24,6 +31,7 @@ func NewFakeRepository(triggerError bool) db.Repository {
 		presetmaps:      make(map[string]*db.PresetMap),
 		localpresets:    make(map[string]*db.LocalPreset),
 		presetSummaries: make(map[string]db.PresetSummary),
+		statuses:        make(map[string]cachedStatus),
 	}
 }
 
@@ -38,6 +46,24 @@ func (d *fakeRepository) CreateJob(job *db.Job) error {
 	return nil
 }
 
+func (d *fakeRepository) UpdateJob(job *db.Job) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	index, err := d.findJob(job.ID)
+	if err != nil {
+		return err
+	}
+	// the last status is only written by SwapJobLastStatus, and the stalled
+	// reason is never cleared
+	job.LastStatus = d.jobs[index].LastStatus
+	if reason := d.jobs[index].StalledReason; reason != "" {
+		job.StalledReason = reason
+	}
+	d.jobs[index] = job
+	return nil
+}
+
 func (d *fakeRepository) DeleteJob(job *db.Job) error {
 	if d.triggerError {
 		return errors.New("database error")
@@ -50,6 +76,7 @@ func (d *fakeRepository) DeleteJob(job *db.Job) error {
 		d.jobs[i] = d.jobs[i+1]
 	}
 	d.jobs = d.jobs[:len(d.jobs)-1]
+	delete(d.statuses, job.ID)
 	return nil
 }
 
@@ -97,6 +124,116 @@ func (d *fakeRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
 	return jobs, nil
 }
 
+func (d *fakeRepository) ListScheduledJobs(until time.Time) ([]db.Job, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	var jobs []db.Job
+	for _, job := range d.jobs {
+		if job.State != db.JobStateScheduled {
+			continue
+		}
+		if !until.IsZero() && job.NotBefore.After(until) {
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].NotBefore.Before(jobs[j].NotBefore)
+	})
+	return jobs, nil
+}
+
+func (d *fakeRepository) ClaimScheduledJob(id string, claimedAt time.Time) (bool, error) {
+	if d.triggerError {
+		return false, errors.New("database error")
+	}
+	index, err := d.findJob(id)
+	if err != nil {
+		return false, err
+	}
+	if d.jobs[index].State != db.JobStateScheduled {
+		return false, nil
+	}
+	// the stored job is replaced rather than modified, callers may hold it
+	claimed := *d.jobs[index]
+	claimed.State = db.JobStateSubmitting
+	claimed.ClaimedAt = claimedAt
+	d.jobs[index] = &claimed
+	return true, nil
+}
+
+func (d *fakeRepository) RequeueClaimedJobs(claimedBefore time.Time) ([]string, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	var requeued []string
+	for i, job := range d.jobs {
+		if job.State != db.JobStateSubmitting || !job.ClaimedAt.Before(claimedBefore) {
+			continue
+		}
+		scheduled := *job
+		scheduled.State = db.JobStateScheduled
+		scheduled.ClaimedAt = time.Time{}
+		d.jobs[i] = &scheduled
+		requeued = append(requeued, job.ID)
+	}
+	return requeued, nil
+}
+
+func (d *fakeRepository) SwapJobLastStatus(id, old, new string) (bool, error) {
+	if d.triggerError {
+		return false, errors.New("database error")
+	}
+	index, err := d.findJob(id)
+	if err != nil {
+		return false, err
+	}
+	if d.jobs[index].LastStatus != old {
+		return false, nil
+	}
+	d.jobs[index].LastStatus = new
+	return true, nil
+}
+
+func (d *fakeRepository) FlagStalledJob(id, reason string) (bool, error) {
+	if d.triggerError {
+		return false, errors.New("database error")
+	}
+	index, err := d.findJob(id)
+	if err != nil {
+		return false, err
+	}
+	if d.jobs[index].StalledReason != "" {
+		return false, nil
+	}
+	d.jobs[index].StalledReason = reason
+	return true, nil
+}
+
+func (d *fakeRepository) SaveJobStatus(jobID string, status []byte, ttl time.Duration) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	cached := cachedStatus{status: status}
+	if ttl > 0 {
+		cached.expiresAt = time.Now().Add(ttl)
+	}
+	d.statuses[jobID] = cached
+	return nil
+}
+
+func (d *fakeRepository) GetJobStatus(jobID string) ([]byte, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	cached, ok := d.statuses[jobID]
+	if !ok || (!cached.expiresAt.IsZero() && time.Now().After(cached.expiresAt)) {
+		return nil, db.ErrJobStatusNotFound
+	}
+	return cached.status, nil
+}
+
 func (d *fakeRepository) CreatePresetMap(presetmap *db.PresetMap) error {
 	if d.triggerError {
 		return errors.New("database error")
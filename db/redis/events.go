@@ -0,0 +1,152 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/event"
+	"github.com/go-redis/redis"
+)
+
+// Fields of each entry of the event stream. The payload holds the whole
+// event JSON-encoded, the other fields are there so consumers can filter
+// entries without decoding it.
+const (
+	eventFieldVersion = "version"
+	eventFieldType    = "type"
+	eventFieldJobID   = "jobId"
+	eventFieldPayload = "payload"
+)
+
+// StreamEvent is an event read back from the stream, along with its ID.
+type StreamEvent struct {
+	ID    string
+	Event event.Event
+}
+
+// EventSink publishes job lifecycle events to a Redis Stream using the
+// connection of a Redis repository.
+type EventSink struct {
+	client *redis.Client
+	cfg    config.Events
+}
+
+// NewEventSink returns an EventSink sharing the connection of the given
+// repository, which must have been created by NewRepository. The configured
+// consumer groups are created if they don't exist yet.
+func NewEventSink(repo db.Repository, cfg config.Events) (*EventSink, error) {
+	r, ok := repo.(*redisRepository)
+	if !ok {
+		return nil, errors.New("the event stream requires a redis repository")
+	}
+	s := &EventSink{client: r.storage.RedisClient(), cfg: cfg}
+	for _, group := range cfg.ConsumerGroups {
+		err := s.CreateGroup(group, "0")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Publish appends the event to the stream, trimming it to about the
+// configured length.
+func (s *EventSink) Publish(_ context.Context, e event.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.client.XAdd(&redis.XAddArgs{
+		Stream:       s.cfg.Stream,
+		MaxLenApprox: s.cfg.MaxLen,
+		Values: map[string]interface{}{
+			eventFieldVersion: e.Version,
+			eventFieldType:    string(e.Type),
+			eventFieldJobID:   e.JobID,
+			eventFieldPayload: payload,
+		},
+	}).Err()
+}
+
+// CreateGroup creates a consumer group that starts reading after the given
+// ID, "0" being the beginning of the stream and "$" its end. Creating a
+// group that already exists is not an error.
+func (s *EventSink) CreateGroup(group, startID string) error {
+	err := s.client.XGroupCreateMkStream(s.cfg.Stream, group, startID).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("creating consumer group %q: %w", group, err)
+	}
+	return nil
+}
+
+// Replay returns up to count events published after the given ID, "0"
+// replaying the stream from its beginning.
+func (s *EventSink) Replay(afterID string, count int64) ([]StreamEvent, error) {
+	streams, err := s.client.XRead(&redis.XReadArgs{
+		Streams: []string{s.cfg.Stream, afterID},
+		Count:   count,
+		Block:   -1,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return streamEvents(streams)
+}
+
+// ReadGroup returns up to count events that were not delivered to the group
+// yet, waiting for up to block for new ones. The events must be acknowledged
+// with Ack once processed.
+func (s *EventSink) ReadGroup(group, consumer string, count int64, block time.Duration) ([]StreamEvent, error) {
+	streams, err := s.client.XReadGroup(&redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{s.cfg.Stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return streamEvents(streams)
+}
+
+// Ack acknowledges the processing of the given events by the group.
+func (s *EventSink) Ack(group string, ids ...string) error {
+	return s.client.XAck(s.cfg.Stream, group, ids...).Err()
+}
+
+func streamEvents(streams []redis.XStream) ([]StreamEvent, error) {
+	var events []StreamEvent
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			e, err := decodeEvent(msg.Values)
+			if err != nil {
+				return events, fmt.Errorf("decoding event %s: %w", msg.ID, err)
+			}
+			events = append(events, StreamEvent{ID: msg.ID, Event: e})
+		}
+	}
+	return events, nil
+}
+
+func decodeEvent(values map[string]interface{}) (event.Event, error) {
+	var e event.Event
+	payload, ok := values[eventFieldPayload].(string)
+	if !ok {
+		return e, errors.New("missing payload")
+	}
+	err := json.Unmarshal([]byte(payload), &e)
+	return e, err
+}
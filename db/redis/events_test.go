@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/db/redis/storage"
+	"github.com/cbsinteractive/transcode-orchestrator/event"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/go-redis/redis"
+)
+
+const testEventStream = "test:job-events"
+
+func newTestEventSink(t *testing.T, groups ...string) *EventSink {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	defer client.Close()
+	err := deleteKeys(testEventStream, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg config.Config
+	cfg.Redis = new(storage.Config)
+	repo, err := NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewEventSink(repo, config.Events{Stream: testEventStream, MaxLen: 100, ConsumerGroups: groups})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sink
+}
+
+func TestEventSinkReplay(t *testing.T) {
+	sink := newTestEventSink(t)
+	job := db.Job{ID: "job-1", ProviderName: "fake", ProviderJobID: "provider-job-1"}
+	published := []event.Event{
+		event.New(event.TypeCreated, &job),
+		event.New(event.TypeStatusChanged, &job).WithStatus(&provider.JobStatus{Status: provider.StatusStarted, Progress: 20}),
+	}
+	for _, e := range published {
+		err := sink.Publish(context.Background(), e)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := sink.Replay("0", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != len(published) {
+		t.Fatalf("wrong number of events: want %d, got %d", len(published), len(events))
+	}
+	for i, e := range events {
+		if !reflect.DeepEqual(e.Event, published[i]) {
+			t.Errorf("wrong event %d\nwant %#v\ngot  %#v", i, published[i], e.Event)
+		}
+	}
+
+	events, err = sink.Replay(events[0].ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event.Type != event.TypeStatusChanged {
+		t.Errorf("replaying after the first event: got %+v", events)
+	}
+}
+
+func TestEventSinkConsumerGroup(t *testing.T) {
+	sink := newTestEventSink(t, "indexer")
+	job := db.Job{ID: "job-1"}
+	err := sink.Publish(context.Background(), event.New(event.TypeCreated, &job))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := sink.ReadGroup("indexer", "consumer-1", 10, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event.JobID != "job-1" {
+		t.Fatalf("wrong events read by the group: %+v", events)
+	}
+	err = sink.Ack("indexer", events[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err = sink.ReadGroup("indexer", "consumer-1", 10, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events were delivered twice to the group: %+v", events)
+	}
+
+	err = sink.CreateGroup("indexer", "0")
+	if err != nil {
+		t.Errorf("creating an existing group: %v", err)
+	}
+}
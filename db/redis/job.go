@@ -1,16 +1,88 @@
 package redis
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
 	"time"
 
+	"github.com/cbsinteractive/pkg/timecode"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/db/redis/storage"
 	"github.com/go-redis/redis"
 )
 
-const jobsSetKey = "jobs"
+const (
+	jobsSetKey          = "jobs"
+	scheduledJobsSetKey = "scheduledjobs"
+	claimedJobsSetKey   = "claimedjobs"
+	lastStatusField     = "laststatus"
+	stalledReasonField  = "stalledreason"
+	stateField          = "state"
+	claimedAtField      = "claimedat"
+
+	// saveJobAttempts is the number of attempts made to save a job modified
+	// concurrently
+	saveJobAttempts = 3
+)
+
+// swapJobFieldScript sets a field of a job when it still holds the expected
+// value, returning -1 when the job doesn't exist
+var swapJobFieldScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+local current = redis.call("HGET", KEYS[1], ARGV[1]) or ""
+if current ~= ARGV[2] then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[3])
+return 1
+`)
+
+// claimScheduledJobScript moves a job from the schedule to the claimed jobs,
+// scored by its claim time, and marks it as submitting
+var claimScheduledJobScript = redis.NewScript(`
+if redis.call("ZREM", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("ZADD", KEYS[2], ARGV[2], ARGV[1])
+redis.call("HMSET", KEYS[3], ARGV[3], ARGV[4], ARGV[5], ARGV[6])
+return 1
+`)
+
+// requeueClaimedJobScript puts a claimed job back on the schedule when it's
+// still submitting since the expected claim time, dropping it from the claimed
+// jobs when it isn't submitting anymore
+var requeueClaimedJobScript = redis.NewScript(`
+local current = redis.call("HMGET", KEYS[3], ARGV[2], ARGV[4])
+if current[1] ~= ARGV[3] then
+	redis.call("ZREM", KEYS[1], ARGV[1])
+	return 0
+end
+if current[2] ~= ARGV[5] then
+	return 0
+end
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("ZADD", KEYS[2], ARGV[6], ARGV[1])
+redis.call("HSET", KEYS[3], ARGV[2], ARGV[7])
+redis.call("HDEL", KEYS[3], ARGV[4])
+return 1
+`)
+
+// jobSpec holds the parts of a job that can't be represented in its redis hash
+// but are required to submit the job to a provider again
+type jobSpec struct {
+	SourceSplice            timecode.Splice      `json:"splice,omitempty"`
+	Outputs                 []db.TranscodeOutput `json:"outputs,omitempty"`
+	ExecutionFeatures       db.ExecutionFeatures `json:"executionFeatures,omitempty"`
+	AudioDownmix            *db.AudioDownmix     `json:"audioDownmix,omitempty"`
+	ExplicitKeyframeOffsets []float64            `json:"explicitKeyframeOffsets,omitempty"`
+	AudioTracks             []db.AudioTrack      `json:"audioTracks,omitempty"`
+	Captions                []db.Caption         `json:"captions,omitempty"`
+	ImageOutputs            []db.ImageOutput     `json:"imageOutputs,omitempty"`
+	Encryption              *db.Encryption       `json:"encryption,omitempty"`
+}
 
 func (r *redisRepository) CreateJob(job *db.Job) error {
 	if job.ID == "" {
@@ -20,19 +92,79 @@ func (r *redisRepository) CreateJob(job *db.Job) error {
 	return r.saveJob(job)
 }
 
+func (r *redisRepository) UpdateJob(job *db.Job) error {
+	n, err := r.storage.RedisClient().Exists(r.jobKey(job.ID)).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return db.ErrJobNotFound
+	}
+	return r.saveJob(job)
+}
+
 func (r *redisRepository) saveJob(job *db.Job) error {
 	fields, err := r.storage.FieldMap(job)
 	if err != nil {
 		return err
 	}
+	spec, err := json.Marshal(jobSpec{
+		SourceSplice:            job.SourceSplice,
+		Outputs:                 job.Outputs,
+		ExecutionFeatures:       job.ExecutionFeatures,
+		AudioDownmix:            job.AudioDownmix,
+		ExplicitKeyframeOffsets: job.ExplicitKeyframeOffsets,
+		AudioTracks:             job.AudioTracks,
+		Captions:                job.Captions,
+		ImageOutputs:            job.ImageOutputs,
+		Encryption:              job.StreamingParams.Encryption,
+	})
+	if err != nil {
+		return err
+	}
 	jobKey := r.jobKey(job.ID)
-	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
-		err := tx.HMSet(jobKey, fields).Err()
+	save := func(tx *redis.Tx) error {
+		// the last status is owned by SwapJobLastStatus and the stalled
+		// reason by FlagStalledJob, the job they're saved from may hold
+		// outdated ones
+		current, err := tx.HMGet(jobKey, lastStatusField, stalledReasonField).Result()
 		if err != nil {
 			return err
 		}
-		return tx.ZAddNX(jobsSetKey, redis.Z{Member: job.ID, Score: float64(job.CreationTime.UnixNano())}).Err()
-	}, jobKey)
+		delete(fields, lastStatusField)
+		if lastStatus, _ := current[0].(string); lastStatus != "" {
+			fields[lastStatusField] = lastStatus
+		}
+		if reason, _ := current[1].(string); reason != "" {
+			fields[stalledReasonField] = reason
+		}
+		_, err = tx.Pipelined(func(p redis.Pipeliner) error {
+			// the hash is replaced rather than merged so fields cleared
+			// on update, like the job state, don't linger
+			p.Del(jobKey)
+			p.HMSet(jobKey, fields)
+			p.Set(r.jobSpecKey(job.ID), spec, 0)
+			p.ZAddNX(jobsSetKey, redis.Z{Member: job.ID, Score: float64(job.CreationTime.UnixNano())})
+			if job.State == db.JobStateScheduled {
+				p.ZAdd(scheduledJobsSetKey, redis.Z{Member: job.ID, Score: float64(job.NotBefore.UnixNano())})
+			} else {
+				p.ZRem(scheduledJobsSetKey, job.ID)
+			}
+			if job.State == db.JobStateSubmitting {
+				p.ZAdd(claimedJobsSetKey, redis.Z{Member: job.ID, Score: float64(job.ClaimedAt.UnixNano())})
+			} else {
+				p.ZRem(claimedJobsSetKey, job.ID)
+			}
+			return nil
+		})
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err = r.storage.RedisClient().Watch(save, jobKey)
+		if err != redis.TxFailedErr || attempt == saveJobAttempts {
+			return err
+		}
+	}
 }
 
 func (r *redisRepository) DeleteJob(job *db.Job) error {
@@ -43,6 +175,22 @@ func (r *redisRepository) DeleteJob(job *db.Job) error {
 		}
 		return err
 	}
+	err = r.storage.RedisClient().Del(r.jobSpecKey(job.ID)).Err()
+	if err != nil {
+		return err
+	}
+	err = r.storage.RedisClient().Del(r.jobStatusKey(job.ID)).Err()
+	if err != nil {
+		return err
+	}
+	err = r.storage.RedisClient().ZRem(scheduledJobsSetKey, job.ID).Err()
+	if err != nil {
+		return err
+	}
+	err = r.storage.RedisClient().ZRem(claimedJobsSetKey, job.ID).Err()
+	if err != nil {
+		return err
+	}
 	return r.storage.RedisClient().ZRem(jobsSetKey, job.ID).Err()
 }
 
@@ -52,7 +200,35 @@ func (r *redisRepository) GetJob(id string) (*db.Job, error) {
 	if err == storage.ErrNotFound {
 		return nil, db.ErrJobNotFound
 	}
-	return &job, err
+	if err != nil {
+		return &job, err
+	}
+	return &job, r.loadJobSpec(&job)
+}
+
+func (r *redisRepository) loadJobSpec(job *db.Job) error {
+	data, err := r.storage.RedisClient().Get(r.jobSpecKey(job.ID)).Bytes()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var spec jobSpec
+	err = json.Unmarshal(data, &spec)
+	if err != nil {
+		return err
+	}
+	job.SourceSplice = spec.SourceSplice
+	job.Outputs = spec.Outputs
+	job.ExecutionFeatures = spec.ExecutionFeatures
+	job.AudioDownmix = spec.AudioDownmix
+	job.ExplicitKeyframeOffsets = spec.ExplicitKeyframeOffsets
+	job.AudioTracks = spec.AudioTracks
+	job.Captions = spec.Captions
+	job.ImageOutputs = spec.ImageOutputs
+	job.StreamingParams.Encryption = spec.Encryption
+	return nil
 }
 
 func (r *redisRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
@@ -82,6 +258,118 @@ func (r *redisRepository) ListJobs(filter db.JobFilter) ([]db.Job, error) {
 	return jobs, nil
 }
 
+func (r *redisRepository) ListScheduledJobs(until time.Time) ([]db.Job, error) {
+	rangeOpts := redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if !until.IsZero() {
+		rangeOpts.Max = strconv.FormatInt(until.UnixNano(), 10)
+	}
+	jobIDs, err := r.storage.RedisClient().ZRangeByScore(scheduledJobsSetKey, rangeOpts).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]db.Job, 0, len(jobIDs))
+	for _, id := range jobIDs {
+		job, err := r.GetJob(id)
+		if err != nil && err != db.ErrJobNotFound {
+			return nil, err
+		}
+		if job != nil {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *redisRepository) ClaimScheduledJob(id string, claimedAt time.Time) (bool, error) {
+	claimedAt = claimedAt.UTC()
+	n, err := claimScheduledJobScript.Run(r.storage.RedisClient(),
+		[]string{scheduledJobsSetKey, claimedJobsSetKey, r.jobKey(id)},
+		id, claimedAt.UnixNano(),
+		stateField, string(db.JobStateSubmitting),
+		claimedAtField, claimedAt.Format(time.RFC3339Nano),
+	).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *redisRepository) RequeueClaimedJobs(claimedBefore time.Time) ([]string, error) {
+	rangeOpts := redis.ZRangeBy{Min: "-inf", Max: "(" + strconv.FormatInt(claimedBefore.UnixNano(), 10)}
+	jobIDs, err := r.storage.RedisClient().ZRangeByScore(claimedJobsSetKey, rangeOpts).Result()
+	if err != nil {
+		return nil, err
+	}
+	var requeued []string
+	for _, id := range jobIDs {
+		job, err := r.GetJob(id)
+		if err == db.ErrJobNotFound {
+			err = r.storage.RedisClient().ZRem(claimedJobsSetKey, id).Err()
+			if err != nil {
+				return requeued, err
+			}
+			continue
+		}
+		if err != nil {
+			return requeued, err
+		}
+		// the claim time is compared so a job claimed again meanwhile
+		// isn't taken from its new claimer
+		n, err := requeueClaimedJobScript.Run(r.storage.RedisClient(),
+			[]string{claimedJobsSetKey, scheduledJobsSetKey, r.jobKey(id)},
+			id, stateField, string(db.JobStateSubmitting),
+			claimedAtField, job.ClaimedAt.Format(time.RFC3339Nano),
+			job.NotBefore.UnixNano(), string(db.JobStateScheduled),
+		).Int64()
+		if err != nil {
+			return requeued, err
+		}
+		if n == 1 {
+			requeued = append(requeued, id)
+		}
+	}
+	return requeued, nil
+}
+
+func (r *redisRepository) SwapJobLastStatus(id, old, new string) (bool, error) {
+	return r.swapJobField(id, lastStatusField, old, new)
+}
+
+func (r *redisRepository) FlagStalledJob(id, reason string) (bool, error) {
+	return r.swapJobField(id, stalledReasonField, "", reason)
+}
+
+func (r *redisRepository) swapJobField(id, field, old, new string) (bool, error) {
+	n, err := swapJobFieldScript.Run(r.storage.RedisClient(), []string{r.jobKey(id)}, field, old, new).Int64()
+	if err != nil {
+		return false, err
+	}
+	if n < 0 {
+		return false, db.ErrJobNotFound
+	}
+	return n == 1, nil
+}
+
+func (r *redisRepository) SaveJobStatus(jobID string, status []byte, ttl time.Duration) error {
+	return r.storage.RedisClient().Set(r.jobStatusKey(jobID), status, ttl).Err()
+}
+
+func (r *redisRepository) GetJobStatus(jobID string) ([]byte, error) {
+	status, err := r.storage.RedisClient().Get(r.jobStatusKey(jobID)).Bytes()
+	if err == redis.Nil {
+		return nil, db.ErrJobStatusNotFound
+	}
+	return status, err
+}
+
 func (r *redisRepository) jobKey(id string) string {
 	return "job:" + id
 }
+
+func (r *redisRepository) jobSpecKey(id string) string {
+	return "jobspec:" + id
+}
+
+func (r *redisRepository) jobStatusKey(id string) string {
+	return "jobstatus:" + id
+}
@@ -81,6 +81,9 @@ func TestCreateJob(t *testing.T) {
 		"executionenvironment_cloud":               "gcp",
 		"executionenvironment_region":              "us-east1",
 		"executionenvironment_computetags_someKey": "someVal",
+		"priority":                                 "0",
+		"maxduration":                              "0",
+		"maxqueuetime":                             "0",
 	}
 	if !reflect.DeepEqual(items, expected) {
 		pretty.Fdiff(os.Stderr, expected, items)
@@ -517,3 +520,204 @@ func TestListJobsFilteringAndLimit(t *testing.T) {
 		t.Errorf("ListJobs({}): wrong list returned. Want %#v. Got %#v", expectedJobs, gotJobs)
 	}
 }
+
+func TestJobStatus(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg config.Config
+	cfg.Redis = new(storage.Config)
+	repo, err := NewRepository(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{ID: "job-1"}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.GetJobStatus(job.ID)
+	if err != db.ErrJobStatusNotFound {
+		t.Errorf("wrong error for a job without a cached status: %v", err)
+	}
+	status := []byte(`{"status":"finished"}`)
+	err = repo.SaveJobStatus(job.ID, status, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetJobStatus(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, status) {
+		t.Errorf("wrong cached status: want %s, got %s", status, got)
+	}
+
+	err = repo.DeleteJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.GetJobStatus(job.ID)
+	if err != db.ErrJobStatusNotFound {
+		t.Errorf("cached status was not deleted with the job: %v", err)
+	}
+}
+
+func TestSwapJobLastStatus(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{ID: "job-1", ProviderName: "mediaconvert"}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	swapped, err := repo.SwapJobLastStatus(job.ID, "", "started")
+	if err != nil || !swapped {
+		t.Fatalf("expected the status to be swapped, got %t, %v", swapped, err)
+	}
+	swapped, err = repo.SwapJobLastStatus(job.ID, "", "started")
+	if err != nil || swapped {
+		t.Errorf("expected an outdated status not to be swapped, got %t, %v", swapped, err)
+	}
+
+	// updating a job loaded before the swap keeps the last status
+	job.StalledReason = "progress unchanged"
+	err = repo.UpdateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LastStatus != "started" || got.StalledReason != "progress unchanged" {
+		t.Errorf("wrong job after update: %+v", got)
+	}
+
+	_, err = repo.SwapJobLastStatus("job-2", "", "started")
+	if err != db.ErrJobNotFound {
+		t.Errorf("wrong error for a missing job: %v", err)
+	}
+}
+
+func TestFlagStalledJob(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{ID: "job-1", ProviderName: "mediaconvert"}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flagged, err := repo.FlagStalledJob(job.ID, "queued for too long")
+	if err != nil || !flagged {
+		t.Fatalf("expected the job to be flagged, got %t, %v", flagged, err)
+	}
+	flagged, err = repo.FlagStalledJob(job.ID, "progress unchanged")
+	if err != nil || flagged {
+		t.Errorf("expected a flagged job not to be flagged again, got %t, %v", flagged, err)
+	}
+
+	// updating a job loaded before it was flagged keeps the stalled reason
+	job.ResubmittedAs = "job-2"
+	err = repo.UpdateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.StalledReason != "queued for too long" || got.ResubmittedAs != "job-2" {
+		t.Errorf("wrong job after update: %+v", got)
+	}
+
+	_, err = repo.FlagStalledJob("job-2", "queued for too long")
+	if err != db.ErrJobNotFound {
+		t.Errorf("wrong error for a missing job: %v", err)
+	}
+}
+
+func TestRequeueClaimedJobs(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := time.Date(2020, 7, 1, 2, 0, 0, 0, time.UTC)
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		job := db.Job{ID: id, ProviderName: "mediaconvert", NotBefore: notBefore, State: db.JobStateScheduled}
+		err = repo.CreateJob(&job)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimedAt := time.Date(2020, 7, 1, 2, 0, 0, 123456789, time.UTC)
+	claimed, err := repo.ClaimScheduledJob("job-1", claimedAt)
+	if err != nil || !claimed {
+		t.Fatalf("expected the job to be claimed, got %t, %v", claimed, err)
+	}
+	claimed, err = repo.ClaimScheduledJob("job-1", claimedAt)
+	if err != nil || claimed {
+		t.Errorf("expected a claimed job not to be claimed again, got %t, %v", claimed, err)
+	}
+	got, err := repo.GetJob("job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != db.JobStateSubmitting || !got.ClaimedAt.Equal(claimedAt) {
+		t.Errorf("wrong claimed job: %+v", got)
+	}
+
+	// job-2 is saved after its submission, job-3 is claimed after the cutoff
+	_, err = repo.ClaimScheduledJob("job-2", claimedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.UpdateJob(&db.Job{ID: "job-2", ProviderName: "mediaconvert", ProviderJobID: "provider-job-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.ClaimScheduledJob("job-3", claimedAt.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requeued, err := repo.RequeueClaimedJobs(claimedAt.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(requeued, []string{"job-1"}) {
+		t.Errorf("wrong requeued jobs: %v", requeued)
+	}
+	scheduled, err := repo.ListScheduledJobs(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheduled) != 1 || scheduled[0].ID != "job-1" || !scheduled[0].ClaimedAt.IsZero() {
+		t.Errorf("wrong scheduled jobs: %+v", scheduled)
+	}
+	requeued, err = repo.RequeueClaimedJobs(claimedAt.Add(time.Minute))
+	if err != nil || len(requeued) > 0 {
+		t.Errorf("expected no job to be requeued again, got %v, %v", requeued, err)
+	}
+}
@@ -1,18 +1,40 @@
 package redis
 
 import (
+	"sync"
+
 	"github.com/cbsinteractive/transcode-orchestrator/config"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/db/redis/storage"
 )
 
+var (
+	reposMu sync.Mutex
+	repos   = make(map[storage.Config]*redisRepository)
+)
+
 // NewRepository creates a new Repository that uses Redis for persistence.
+//
+// Repositories are shared by Redis configuration, so the service and every
+// provider built with the same settings use a single connection pool, even
+// when the configuration is loaded again.
 func NewRepository(cfg *config.Config) (db.Repository, error) {
+	reposMu.Lock()
+	defer reposMu.Unlock()
+	if cfg.Redis != nil {
+		if repo, ok := repos[*cfg.Redis]; ok {
+			return repo, nil
+		}
+	}
 	s, err := storage.NewStorage(cfg.Redis)
 	if err != nil {
 		return nil, err
 	}
-	return &redisRepository{config: cfg, storage: s}, nil
+	repo := &redisRepository{config: cfg, storage: s}
+	if cfg.Redis != nil {
+		repos[*cfg.Redis] = repo
+	}
+	return repo, nil
 }
 
 type redisRepository struct {
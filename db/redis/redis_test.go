@@ -17,6 +17,22 @@ func cleanRedis() error {
 	if err != nil {
 		return err
 	}
+	err = deleteKeys("jobstatus:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys("jobspec:*", client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(scheduledJobsSetKey, client)
+	if err != nil {
+		return err
+	}
+	err = deleteKeys(claimedJobsSetKey, client)
+	if err != nil {
+		return err
+	}
 	err = deleteKeys(presetmapsSetKey, client)
 	if err != nil {
 		return err
@@ -174,6 +174,9 @@ func (s *Storage) structToFieldList(value reflect.Value, prefixes ...string) (ma
 			iface := fieldValue.Interface()
 			switch v := iface.(type) {
 			case time.Time:
+				if _, ok := parts.characteristics["omitempty"]; ok && v.IsZero() {
+					continue
+				}
 				strValue = v.Format(time.RFC3339Nano)
 			case []string:
 				strValue = strings.Join(v, "%%%")
@@ -271,6 +271,25 @@ func TestFieldMap(t *testing.T) {
 				"creationTime":                     "0001-01-01T00:00:00Z",
 			},
 		},
+		{
+			"JobWithStartTime",
+			Job{
+				ID:           "job2",
+				CreationTime: time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC),
+				StartTime:    time.Date(2020, 7, 1, 12, 30, 0, 0, time.UTC),
+			},
+			map[string]interface{}{
+				"source":                           "",
+				"jobID":                            "job2",
+				"providerName":                     "",
+				"providerJobID":                    "",
+				"streamingparams_segmentDuration":  "0",
+				"streamingparams_protocol":         "",
+				"streamingparams_playlistFileName": "",
+				"creationTime":                     "2020-07-01T10:00:00Z",
+				"startTime":                        "2020-07-01T12:30:00Z",
+			},
+		},
 		{
 			"LocalPreset",
 			LocalPreset{
@@ -40,6 +40,7 @@ type Job struct {
 	ProviderJobID   string            `redis-hash:"providerJobID"`
 	StreamingParams StreamingParams   `redis-hash:"streamingparams,expand"`
 	CreationTime    time.Time         `redis-hash:"creationTime"`
+	StartTime       time.Time         `redis-hash:"startTime,omitempty"`
 	SourceMedia     string            `redis-hash:"source"`
 	Outputs         []TranscodeOutput `redis-hash:"-"`
 }
@@ -32,6 +32,10 @@ var (
 
 	// ErrPresetSummaryNotFound is the error returned when the preset summary is not found
 	ErrPresetSummaryNotFound = errors.New("preset summary not found")
+
+	// ErrJobStatusNotFound is the error returned when there is no cached status
+	// for a job, or it has expired.
+	ErrJobStatusNotFound = errors.New("job status not found")
 )
 
 // Repository represents the repository for persisting types of the API.
@@ -40,15 +44,42 @@ type Repository interface {
 	PresetMapRepository
 	LocalPresetRepository
 	PresetSummaryRepository
+	JobStatusRepository
 }
 
 // JobRepository is the interface that defines the set of methods for managing Job
 // persistence.
 type JobRepository interface {
 	CreateJob(*Job) error
+	UpdateJob(*Job) error
 	DeleteJob(*Job) error
 	GetJob(id string) (*Job, error)
 	ListJobs(JobFilter) ([]Job, error)
+
+	// ListScheduledJobs returns the jobs in the scheduled state ordered by their
+	// NotBefore time. When until is not zero, only jobs due by then are returned.
+	ListScheduledJobs(until time.Time) ([]Job, error)
+
+	// ClaimScheduledJob takes a scheduled job off the schedule and moves it to the
+	// submitting state as of claimedAt, reporting whether the caller was the one
+	// to claim it and is responsible for its submission.
+	ClaimScheduledJob(id string, claimedAt time.Time) (bool, error)
+
+	// RequeueClaimedJobs puts the jobs claimed before the given time and still in
+	// the submitting state back on the schedule, returning their ids. It recovers
+	// the jobs whose claimer stopped or failed to save them.
+	RequeueClaimedJobs(claimedBefore time.Time) ([]string, error)
+
+	// SwapJobLastStatus sets the last status of a job when it still holds old,
+	// reporting whether it was swapped. The last status is only written this
+	// way, updating a job leaves it untouched.
+	SwapJobLastStatus(id, old, new string) (bool, error)
+
+	// FlagStalledJob sets the stalled reason of a job that isn't flagged yet,
+	// reporting whether the caller was the one to flag it and is responsible
+	// for handling the stalled job. Updating a job never clears its stalled
+	// reason.
+	FlagStalledJob(id, reason string) (bool, error)
 }
 
 // JobFilter contains a set of parameters for filtering the list of jobs in
@@ -87,3 +118,16 @@ type PresetSummaryRepository interface {
 	DeletePresetSummary(name string) error
 	GetPresetSummary(name string) (PresetSummary, error)
 }
+
+// JobStatusRepository provides an interface that defines the set of methods for
+// caching the statuses reported by providers, so they don't have to be queried
+// on every request. Statuses are stored encoded, as the repository doesn't know
+// about their type.
+type JobStatusRepository interface {
+	// SaveJobStatus caches the status of a job for the given ttl, a zero ttl
+	// keeping it for as long as the job exists.
+	SaveJobStatus(jobID string, status []byte, ttl time.Duration) error
+
+	// GetJobStatus returns the cached status of a job, or ErrJobStatusNotFound.
+	GetJobStatus(jobID string) ([]byte, error)
+}
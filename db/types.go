@@ -1,7 +1,10 @@
 package db
 
 import (
+	"encoding/json"
 	"errors"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/cbsinteractive/pkg/timecode"
@@ -9,6 +12,13 @@ import (
 	"github.com/gofrs/uuid"
 )
 
+const (
+	// MinJobPriority is the lowest priority accepted for a job, used for batch work
+	MinJobPriority = -50
+	// MaxJobPriority is the highest priority accepted for a job, used for urgent work
+	MaxJobPriority = 50
+)
+
 // Job represents the job that is persisted in the repository of the Transcoding
 // API.
 type Job struct {
@@ -68,13 +78,79 @@ type Job struct {
 	// AudioDownmix holds source and output channels for configuring downmixing
 	AudioDownmix *AudioDownmix `redis-hash:"-" json:"audioDownmix,omitempty"`
 
-	// ExplicitKeyframeOffsets define offsets from the beginning of the media to insert keyframes when encoding
+	// ExplicitKeyframeOffsets define offsets from the beginning of the media to insert keyframes when encoding.
+	// Offsets are in the source, they are moved along with the ranges of a splice and dropped when cut out.
 	ExplicitKeyframeOffsets []float64 `redis-hash:"-" json:"explicitKeyframeOffsets,omitempty"`
 
+	// AudioTracks define the audio renditions of the adaptive streaming outputs,
+	// when empty a single rendition is created from the default source track
+	AudioTracks []AudioTrack `redis-hash:"-" json:"audioTracks,omitempty"`
+
+	// Captions define the caption and subtitle tracks of the outputs, either from
+	// sidecar files or passed through from the source video
+	Captions []Caption `redis-hash:"-" json:"captions,omitempty"`
+
+	// ImageOutputs are the still images taken from the source alongside the
+	// outputs, for thumbnails and scrub previews
+	ImageOutputs []ImageOutput `redis-hash:"-" json:"imageOutputs,omitempty"`
+
 	// Optional list of string labels
 	Labels []string `redis-hash:"labels,omitempty" json:"labels,omitempty"`
+
+	// Priority is a normalized job priority between MinJobPriority and MaxJobPriority,
+	// providers translate it to their own native scheduling priority
+	Priority int `redis-hash:"priority,omitempty" json:"priority,omitempty"`
+
+	// MaxDuration is the number of seconds a job may run for before it is considered stuck,
+	// counted from when it started so the time it was queued isn't included
+	MaxDuration uint `redis-hash:"maxduration,omitempty" json:"maxDuration,omitempty"`
+
+	// MaxQueueTime is the number of seconds a job may stay queued before it is considered stuck
+	MaxQueueTime uint `redis-hash:"maxqueuetime,omitempty" json:"maxQueueTime,omitempty"`
+
+	// StalledReason is set by the watchdog when the job was detected as stuck
+	StalledReason string `redis-hash:"stalledreason,omitempty" json:"stalledReason,omitempty"`
+
+	// ResubmittedAs holds the ID of the job created to replace this one after it stalled
+	ResubmittedAs string `redis-hash:"resubmittedas,omitempty" json:"resubmittedAs,omitempty"`
+
+	// NotBefore is the time before which the job must not be submitted to its provider
+	NotBefore time.Time `redis-hash:"notbefore,omitempty" json:"notBefore,omitempty"`
+
+	// State is set while the job is managed by the API instead of its provider, it's
+	// empty once the job was submitted
+	State JobState `redis-hash:"state,omitempty" json:"state,omitempty"`
+
+	// ClaimedAt is the time a scheduled job was claimed for its submission, set
+	// while the job is in the submitting state
+	ClaimedAt time.Time `redis-hash:"claimedat,omitempty" json:"claimedAt,omitempty"`
+
+	// StateMessage holds details about the state, such as why a scheduled submission failed
+	StateMessage string `redis-hash:"statemessage,omitempty" json:"stateMessage,omitempty"`
+
+	// LastStatus is the last status reported by the provider, used to detect
+	// status changes
+	LastStatus string `redis-hash:"laststatus,omitempty" json:"lastStatus,omitempty"`
 }
 
+// JobState is the state of a job that has not been handed over to its provider
+type JobState string
+
+const (
+	// JobStateScheduled is the state of a job waiting for its NotBefore time
+	JobStateScheduled JobState = "scheduled"
+
+	// JobStateSubmitting is the state of a scheduled job claimed for its submission
+	// to the provider, or for an update of its schedule
+	JobStateSubmitting JobState = "submitting"
+
+	// JobStateCanceled is the state of a scheduled job canceled before its submission
+	JobStateCanceled JobState = "canceled"
+
+	// JobStateFailed is the state of a scheduled job whose submission failed
+	JobStateFailed JobState = "failed"
+)
+
 func (j Job) RootFolder() string {
 	if j.Name != "" {
 		if _, err := uuid.FromString(j.Name); err == nil {
@@ -138,7 +214,9 @@ type StreamingParams struct {
 	// required: true
 	SegmentDuration uint `redis-hash:"segmentDuration" json:"segmentDuration"`
 
-	// the protocol name (hls or dash)
+	// the protocol name (hls or dash), or a list of protocols sharing the
+	// same segments, given either as an array or as a comma separated string
+	// such as "hls,dash"
 	//
 	// required: true
 	Protocol string `redis-hash:"protocol" json:"protocol"`
@@ -146,6 +224,180 @@ type StreamingParams struct {
 	// the playlist file name
 	// required: true
 	PlaylistFileName string `redis-hash:"playlistFileName" json:"playlistFileName,omitempty"`
+
+	// SegmentTemplate is the way the segments of DASH manifests are
+	// addressed, by number when unset
+	SegmentTemplate SegmentTemplate `redis-hash:"segmentTemplate" json:"segmentTemplate,omitempty"`
+
+	// Encryption protects the segments of the outputs, which are left in the
+	// clear when unset
+	Encryption *Encryption `redis-hash:"-" json:"encryption,omitempty"`
+}
+
+// SegmentTemplate is the way the segment template of a DASH manifest addresses
+// its segments
+type SegmentTemplate = string
+
+const (
+	// SegmentTemplateNumber addresses the segments by number, all of them
+	// lasting the segment duration
+	SegmentTemplateNumber SegmentTemplate = "number"
+
+	// SegmentTemplateTimeline lists the start and duration of every segment in
+	// a segment timeline
+	SegmentTemplateTimeline SegmentTemplate = "timeline"
+)
+
+const (
+	// ProtocolHLS is the name of the HLS streaming protocol
+	ProtocolHLS = "hls"
+
+	// ProtocolDASH is the name of the DASH streaming protocol
+	ProtocolDASH = "dash"
+)
+
+// UnmarshalJSON accepts the protocol either as a string or as a list of
+// strings, stored as a comma separated string.
+func (p *StreamingParams) UnmarshalJSON(data []byte) error {
+	type params StreamingParams
+	var raw struct {
+		params
+		Protocol json.RawMessage `json:"protocol"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = StreamingParams(raw.params)
+	if len(raw.Protocol) == 0 || string(raw.Protocol) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw.Protocol, &p.Protocol); err == nil {
+		return nil
+	}
+	var protocols []string
+	if err := json.Unmarshal(raw.Protocol, &protocols); err != nil {
+		return errors.New("protocol must be a string or a list of strings")
+	}
+	p.Protocol = strings.Join(protocols, ",")
+	return nil
+}
+
+// Protocols returns the lowercased list of requested protocols, without
+// duplicates.
+func (p StreamingParams) Protocols() []string {
+	var protocols []string
+	seen := map[string]bool{}
+	for _, protocol := range strings.Split(p.Protocol, ",") {
+		protocol = strings.ToLower(strings.TrimSpace(protocol))
+		if protocol != "" && !seen[protocol] {
+			seen[protocol] = true
+			protocols = append(protocols, protocol)
+		}
+	}
+	return protocols
+}
+
+// HasProtocol returns whether the given protocol was requested.
+func (p StreamingParams) HasProtocol(protocol string) bool {
+	for _, requested := range p.Protocols() {
+		if requested == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// EncryptionScheme is the way the segments of streaming outputs are encrypted
+type EncryptionScheme = string
+
+const (
+	// EncryptionSchemeAES128 encrypts whole HLS segments with AES-128
+	EncryptionSchemeAES128 EncryptionScheme = "aes-128"
+
+	// EncryptionSchemeSampleAES encrypts the media samples of HLS segments, as
+	// required by FairPlay
+	EncryptionSchemeSampleAES EncryptionScheme = "sample-aes"
+
+	// EncryptionSchemeCENC is the common encryption of fragmented MP4 segments,
+	// as required by Widevine and PlayReady
+	EncryptionSchemeCENC EncryptionScheme = "cenc"
+)
+
+// EncryptionSchemes lists the supported encryption schemes
+var EncryptionSchemes = []EncryptionScheme{EncryptionSchemeAES128, EncryptionSchemeSampleAES, EncryptionSchemeCENC}
+
+// DRMSystem is a DRM system licensing the keys of encrypted outputs
+type DRMSystem = string
+
+const (
+	DRMSystemWidevine  DRMSystem = "widevine"
+	DRMSystemPlayReady DRMSystem = "playready"
+	DRMSystemFairPlay  DRMSystem = "fairplay"
+)
+
+// DRMSystemIDs are the DASH-IF system IDs of the DRM systems, requested from key
+// servers and signaled in manifests
+var DRMSystemIDs = map[DRMSystem]string{
+	DRMSystemWidevine:  "edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",
+	DRMSystemPlayReady: "9a04f079-9840-4286-ab92-e65be0885f95",
+	DRMSystemFairPlay:  "94ce86fb-07ff-4f43-adb8-93d2fa968ca2",
+}
+
+// Encryption configures the encryption of the segments of streaming outputs,
+// either with a static key or with keys requested from a SPEKE key server
+//
+// swagger:model
+type Encryption struct {
+	Scheme EncryptionScheme `json:"scheme"`
+
+	// Key is a static content key, as 32 hexadecimal characters
+	Key string `json:"key,omitempty"`
+
+	// KeyID identifies a static key to players, as 32 hexadecimal characters.
+	// It's required by cenc.
+	KeyID string `json:"keyId,omitempty"`
+
+	// IV is a constant initialization vector, as 32 hexadecimal characters.
+	// Providers derive one from each segment when unset.
+	IV string `json:"iv,omitempty"`
+
+	// KeyURL is the URI players get a static key from, written in HLS playlists
+	KeyURL string `json:"keyUrl,omitempty"`
+
+	// KeyServerURL is the SPEKE key server keys are requested from in place of
+	// a static key
+	KeyServerURL string `json:"keyServerUrl,omitempty"`
+
+	// ResourceID identifies the content to the key server, defaults to the ID
+	// of the job
+	ResourceID string `json:"resourceId,omitempty"`
+
+	// KeyRotationInterval is the number of seconds after which the key server
+	// is asked for a new key, a multiple of the segment duration
+	KeyRotationInterval uint `json:"keyRotationInterval,omitempty"`
+
+	// DRMSystems are the DRM systems the key server licenses the keys with,
+	// signaled in the manifests
+	DRMSystems []DRMSystem `json:"drmSystems,omitempty"`
+}
+
+// HasDRMSystem returns whether the keys are licensed with the given DRM system
+func (e Encryption) HasDRMSystem(system DRMSystem) bool {
+	for _, s := range e.DRMSystems {
+		if s == system {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemIDs returns the system IDs of the DRM systems of the encryption
+func (e Encryption) SystemIDs() []string {
+	ids := make([]string, 0, len(e.DRMSystems))
+	for _, s := range e.DRMSystems {
+		ids = append(ids, DRMSystemIDs[s])
+	}
+	return ids
 }
 
 // ScanType is a string that represents the scan type of the content.
@@ -192,6 +444,194 @@ type AudioDownmix struct {
 	DestChannels []AudioChannel
 }
 
+// AudioCharacteristicDescribesVideo is the accessibility characteristic of
+// audio description tracks
+const AudioCharacteristicDescribesVideo = "public.accessibility.describes-video"
+
+// AudioTrack is an audio rendition of the adaptive streaming outputs
+//
+// swagger:model
+type AudioTrack struct {
+	// SourceTrack is the index of the audio track in the source, starting at 0
+	SourceTrack uint `json:"sourceTrack"`
+
+	// Language is the RFC 5646 language code of the rendition, such as en or es-MX
+	Language string `json:"language"`
+
+	// Name is the display name of the rendition, defaults to the language
+	Name string `json:"name,omitempty"`
+
+	// Default marks the rendition players should pick when the user has no preference
+	Default bool `json:"default,omitempty"`
+
+	// Autoselect allows players to pick the rendition based on the user's preferences
+	Autoselect bool `json:"autoselect,omitempty"`
+
+	// Characteristics are the accessibility characteristics of the rendition,
+	// such as AudioCharacteristicDescribesVideo
+	Characteristics []string `json:"characteristics,omitempty"`
+}
+
+// DisplayName returns the name of the rendition, or its language when unnamed
+func (t AudioTrack) DisplayName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Language
+}
+
+// CaptionFormat is the format of a caption track of the source
+type CaptionFormat = string
+
+const (
+	CaptionFormatSCC    CaptionFormat = "scc"
+	CaptionFormatSRT    CaptionFormat = "srt"
+	CaptionFormatWebVTT CaptionFormat = "webvtt"
+	CaptionFormatTTML   CaptionFormat = "ttml"
+	CaptionFormatIMSC   CaptionFormat = "imsc"
+
+	// CaptionFormatEmbedded passes through the CEA-608/708 captions embedded
+	// in the video of the source
+	CaptionFormatEmbedded CaptionFormat = "embedded"
+)
+
+// CaptionFormats lists the supported caption formats
+var CaptionFormats = []CaptionFormat{
+	CaptionFormatSCC, CaptionFormatSRT, CaptionFormatWebVTT, CaptionFormatTTML, CaptionFormatIMSC, CaptionFormatEmbedded,
+}
+
+// Caption is a caption or subtitle track of the outputs. Unlike other sidecar
+// assets a job can hold any number of them, one per language or purpose.
+//
+// swagger:model
+type Caption struct {
+	// Source is the location of the sidecar file, unused for embedded captions
+	Source string `json:"source,omitempty"`
+
+	// Format of the sidecar file, or CaptionFormatEmbedded
+	Format CaptionFormat `json:"format"`
+
+	// Language is the RFC 5646 language code of the track, such as en or es-MX
+	Language string `json:"language"`
+
+	// Name is the display name of the track, defaults to the language
+	Name string `json:"name,omitempty"`
+
+	// Channel is the CEA-608 channel of embedded captions, from 1 to 4, defaults to 1
+	Channel uint `json:"channel,omitempty"`
+
+	// Default marks the track players should pick when the user has no preference
+	Default bool `json:"default,omitempty"`
+
+	// Outputs are the file names of the outputs carrying the track, the track is
+	// added to every output, and so to the whole streaming ladder, when empty
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// DisplayName returns the name of the track, or its language when unnamed
+func (c Caption) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Language
+}
+
+// AppliesTo returns whether the output with the given file name carries the track
+func (c Caption) AppliesTo(fileName string) bool {
+	if len(c.Outputs) == 0 {
+		return true
+	}
+	for _, output := range c.Outputs {
+		if output == fileName {
+			return true
+		}
+	}
+	return false
+}
+
+// CEA608Channel returns the channel of embedded captions
+func (c Caption) CEA608Channel() uint {
+	if c.Channel == 0 {
+		return 1
+	}
+	return c.Channel
+}
+
+// ImageOutputKind is the kind of images of an image output
+type ImageOutputKind = string
+
+const (
+	// ImageOutputKindThumbnails takes a thumbnail every Interval seconds, or at
+	// each of the Offsets
+	ImageOutputKindThumbnails ImageOutputKind = "thumbnails"
+
+	// ImageOutputKindPoster takes a single poster frame, at the first of the
+	// Offsets or at the start of the source
+	ImageOutputKindPoster ImageOutputKind = "poster"
+
+	// ImageOutputKindSprite tiles a thumbnail every Interval seconds in sprite
+	// sheets, indexed by a WebVTT file
+	ImageOutputKindSprite ImageOutputKind = "sprite"
+)
+
+// ImageOutputKinds lists the supported kinds of image outputs
+var ImageOutputKinds = []ImageOutputKind{ImageOutputKindThumbnails, ImageOutputKindPoster, ImageOutputKindSprite}
+
+// ImageFormat is the file format of the images of an image output
+type ImageFormat = string
+
+const (
+	ImageFormatJPEG ImageFormat = "jpg"
+	ImageFormatPNG  ImageFormat = "png"
+)
+
+// ImageOutput is an output of still images taken from the source video
+//
+// swagger:model
+type ImageOutput struct {
+	Kind ImageOutputKind `json:"kind"`
+
+	// FileName is the name of the images. Providers number the thumbnails and
+	// sprite sheets after it.
+	FileName string `json:"fileName"`
+
+	// Format of the images, defaults to jpg
+	Format ImageFormat `json:"format,omitempty"`
+
+	// Width and Height of the images, or of the tiles of a sprite sheet. The
+	// source dimensions are kept when unset, the aspect ratio when only one is set.
+	Width  uint `json:"width,omitempty"`
+	Height uint `json:"height,omitempty"`
+
+	// Interval is the time between two thumbnails or sprite tiles, in seconds
+	Interval float64 `json:"interval,omitempty"`
+
+	// Offsets are the times of the thumbnails, or of the poster frame, in seconds
+	Offsets []float64 `json:"offsets,omitempty"`
+
+	// Columns and Rows are the number of tiles of a sprite sheet, further tiles
+	// being written to additional sheets
+	Columns uint `json:"columns,omitempty"`
+	Rows    uint `json:"rows,omitempty"`
+
+	// Playlist adds trick-play playlists to the HLS manifest of a streaming job,
+	// I-frame or image playlists depending on what the provider supports
+	Playlist bool `json:"playlist,omitempty"`
+}
+
+// ImageFormat returns the file format of the images
+func (o ImageOutput) ImageFormat() ImageFormat {
+	if o.Format == "" {
+		return ImageFormatJPEG
+	}
+	return o.Format
+}
+
+// VTTFileName returns the name of the WebVTT index of a sprite sheet
+func (o ImageOutput) VTTFileName() string {
+	return strings.TrimSuffix(o.FileName, path.Ext(o.FileName)) + ".vtt"
+}
+
 // File represents basic information about the source that may be of aid to providers
 //
 // swagger:model
@@ -201,6 +641,13 @@ type File struct {
 	FrameRate float64  `redis-hash:"framerate,omitempty" json:"frameRate,omitempty"`
 	FileSize  int64    `redis-hash:"filesize,omitempty" json:"fileSize,omitempty"`
 	ScanType  ScanType `redis-hash:"scantype,omitempty" json:"scanType,omitempty"`
+
+	// Duration of the source, splice ranges must fall within it when set
+	Duration time.Duration `redis-hash:"duration,omitempty" json:"duration,omitempty"`
+
+	// StartTimecode is the timecode embedded in the first frame of the source,
+	// SMPTE splice ranges being relative to it. It defaults to 00:00:00:00.
+	StartTimecode string `redis-hash:"starttimecode,omitempty" json:"startTimecode,omitempty"`
 }
 
 // ExecutionFeatures is a map whose key is a custom feature name and value is a json string
@@ -1,7 +1,9 @@
 package db
 
 import (
+	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -32,3 +34,95 @@ func TestOutputOptionsValidation(t *testing.T) {
 		}
 	}
 }
+
+func TestStreamingParamsProtocols(t *testing.T) {
+	var tests = []struct {
+		testCase string
+		json     string
+		want     StreamingParams
+		wantErr  bool
+	}{
+		{
+			"single protocol",
+			`{"protocol":"hls","segmentDuration":4}`,
+			StreamingParams{Protocol: "hls", SegmentDuration: 4},
+			false,
+		},
+		{
+			"list of protocols",
+			`{"protocol":["hls","dash"],"playlistFileName":"index.m3u8"}`,
+			StreamingParams{Protocol: "hls,dash", PlaylistFileName: "index.m3u8"},
+			false,
+		},
+		{
+			"invalid protocol",
+			`{"protocol":4}`,
+			StreamingParams{},
+			true,
+		},
+	}
+	for _, test := range tests {
+		var params StreamingParams
+		err := json.Unmarshal([]byte(test.json), &params)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error: %v", test.testCase, err)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(params, test.want) {
+			t.Errorf("%s: wrong params\nWant %#v\nGot  %#v", test.testCase, test.want, params)
+		}
+	}
+
+	params := StreamingParams{Protocol: " HLS, dash,hls"}
+	if g, e := params.Protocols(), []string{"hls", "dash"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong protocols\nWant %#v\nGot  %#v", e, g)
+	}
+	if !params.HasProtocol(ProtocolDASH) || params.HasProtocol("smooth") {
+		t.Errorf("wrong protocols reported for %q", params.Protocol)
+	}
+}
+
+func TestCaptionAppliesTo(t *testing.T) {
+	ladder := Caption{Format: CaptionFormatWebVTT, Language: "en"}
+	if !ladder.AppliesTo("hls/1080p.m3u8") || !ladder.AppliesTo("video.mp4") {
+		t.Error("a caption without outputs should apply to every output")
+	}
+
+	caption := Caption{Format: CaptionFormatEmbedded, Language: "en", Outputs: []string{"video.mp4"}}
+	if !caption.AppliesTo("video.mp4") || caption.AppliesTo("hls/1080p.m3u8") {
+		t.Errorf("wrong outputs for a caption declared on %v", caption.Outputs)
+	}
+	if g, e := caption.CEA608Channel(), uint(1); g != e {
+		t.Errorf("wrong default channel: got %d, expected %d", g, e)
+	}
+	if g, e := caption.DisplayName(), "en"; g != e {
+		t.Errorf("wrong display name: got %q, expected %q", g, e)
+	}
+}
+
+func TestImageOutputDefaults(t *testing.T) {
+	sprite := ImageOutput{Kind: ImageOutputKindSprite, FileName: "previews/sprite.jpg", Interval: 2}
+	if g, e := sprite.ImageFormat(), ImageFormatJPEG; g != e {
+		t.Errorf("wrong default format: got %q, expected %q", g, e)
+	}
+	if g, e := sprite.VTTFileName(), "previews/sprite.vtt"; g != e {
+		t.Errorf("wrong index file name: got %q, expected %q", g, e)
+	}
+
+	png := ImageOutput{Kind: ImageOutputKindPoster, FileName: "poster.png", Format: ImageFormatPNG}
+	if g, e := png.ImageFormat(), ImageFormatPNG; g != e {
+		t.Errorf("wrong format: got %q, expected %q", g, e)
+	}
+}
+
+func TestEncryptionDRMSystems(t *testing.T) {
+	e := Encryption{Scheme: EncryptionSchemeCENC, DRMSystems: []DRMSystem{DRMSystemWidevine, DRMSystemPlayReady}}
+	if !e.HasDRMSystem(DRMSystemPlayReady) || e.HasDRMSystem(DRMSystemFairPlay) {
+		t.Errorf("wrong drm systems for %v", e.DRMSystems)
+	}
+
+	want := []string{"edef8ba9-79d6-4ace-a3c8-27dcd51d21ed", "9a04f079-9840-4286-ab92-e65be0885f95"}
+	if g := e.SystemIDs(); !reflect.DeepEqual(g, want) {
+		t.Errorf("wrong system ids: got %v, expected %v", g, want)
+	}
+}
@@ -0,0 +1,93 @@
+// Package event defines the lifecycle events published for transcoding jobs
+// and the sinks they are published to.
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// SchemaVersion is the version of the Event schema. It must be bumped, and
+// the schema published in the client package updated, on any change that is
+// not backwards compatible.
+const SchemaVersion = 1
+
+// Type is the type of a job lifecycle event.
+type Type string
+
+const (
+	// TypeCreated is published when a job is accepted by the API, either
+	// for immediate or scheduled submission.
+	TypeCreated = Type("job.created")
+
+	// TypeSubmitted is published when a job is handed over to its provider.
+	TypeSubmitted = Type("job.submitted")
+
+	// TypeStatusChanged is published when the status reported by the
+	// provider changes.
+	TypeStatusChanged = Type("job.status_changed")
+
+	// TypeOutputReady is published when a job finishes and its outputs are
+	// available.
+	TypeOutputReady = Type("job.output_ready")
+
+	// TypeFailed is published when a job fails, either on the provider or
+	// before it could be submitted.
+	TypeFailed = Type("job.failed")
+)
+
+// Event is a change in the lifecycle of a job.
+type Event struct {
+	Version       int                 `json:"version"`
+	Type          Type                `json:"type"`
+	Time          time.Time           `json:"time"`
+	JobID         string              `json:"jobId"`
+	ProviderName  string              `json:"providerName,omitempty"`
+	ProviderJobID string              `json:"providerJobId,omitempty"`
+	Labels        []string            `json:"labels,omitempty"`
+	Status        provider.Status     `json:"status,omitempty"`
+	Progress      float64             `json:"progress,omitempty"`
+	Output        *provider.JobOutput `json:"output,omitempty"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// New returns an event of the given type for the job.
+func New(t Type, job *db.Job) Event {
+	return Event{
+		Version:       SchemaVersion,
+		Type:          t,
+		Time:          time.Now().UTC(),
+		JobID:         job.ID,
+		ProviderName:  job.ProviderName,
+		ProviderJobID: job.ProviderJobID,
+		Labels:        job.Labels,
+	}
+}
+
+// WithStatus returns a copy of the event carrying the given job status.
+func (e Event) WithStatus(status *provider.JobStatus) Event {
+	e.Status = status.Status
+	e.Progress = status.Progress
+	if len(status.Output.Files) > 0 || status.Output.Destination != "" {
+		output := status.Output
+		e.Output = &output
+	}
+	if status.Status == provider.StatusFailed {
+		e.Error = status.StatusMessage
+	}
+	return e
+}
+
+// Sink receives job lifecycle events.
+type Sink interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// NoopSink is a Sink that discards every event.
+type NoopSink struct{}
+
+// Publish does nothing
+func (NoopSink) Publish(context.Context, Event) error { return nil }
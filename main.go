@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/cbsinteractive/transcode-orchestrator/config"
@@ -12,6 +16,7 @@ import (
 	_ "github.com/cbsinteractive/transcode-orchestrator/provider/mediaconvert"
 	"github.com/cbsinteractive/transcode-orchestrator/service"
 	"github.com/google/gops/agent"
+	"github.com/sirupsen/logrus"
 	"github.com/zsiec/pkg/tracing"
 	"github.com/zsiec/pkg/xrayutil"
 )
@@ -46,6 +51,17 @@ func main() {
 	if err != nil {
 		logger.Fatal("unable to initialize service: ", err)
 	}
+	err = service.StartProviders(context.Background())
+	if err != nil {
+		logger.WithError(err).Error("some providers failed to start")
+	}
+	defer service.CloseProviders()
+	go reloadProvidersOnHangup(service, cfg.Tracer, logger)
+	go service.RunProviderHealthChecks(context.Background())
+	go service.RunWatchdog(context.Background())
+	go service.RunScheduler(context.Background())
+	go service.RunStatusObserver(context.Background())
+
 	err = server.Register(service)
 	if err != nil {
 		logger.Fatal("unable to register service: ", err)
@@ -55,3 +71,20 @@ func main() {
 		logger.Fatal("server encountered a fatal error: ", err)
 	}
 }
+
+// reloadProvidersOnHangup rebuilds the providers with a freshly loaded
+// configuration every time the process receives a SIGHUP.
+func reloadProvidersOnHangup(svc *service.TranscodingService, tracer tracing.Tracer, logger *logrus.Logger) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	for range ch {
+		cfg := config.LoadConfig()
+		cfg.Tracer = tracer
+		err := svc.ReloadProviders(cfg)
+		if err != nil {
+			logger.WithError(err).Error("reloading providers")
+			continue
+		}
+		logger.Info("providers reloaded")
+	}
+}
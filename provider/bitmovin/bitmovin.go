@@ -6,23 +6,27 @@ import (
 
 	"net/url"
 	"path"
-	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bitmovin/bitmovin-api-sdk-go"
 	"github.com/bitmovin/bitmovin-api-sdk-go/common"
 	"github.com/bitmovin/bitmovin-api-sdk-go/model"
 	"github.com/bitmovin/bitmovin-api-sdk-go/query"
+	"github.com/cbsinteractive/pkg/timecode"
 	"github.com/cbsinteractive/transcode-orchestrator/config"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/db/redis"
 	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/cleanup"
 	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/configuration"
 	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/container"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/pool"
 	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/status"
 	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/storage"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/zsiec/pkg/tracing"
 )
 
@@ -31,8 +35,6 @@ type containerSvc struct {
 	statusEnricher container.StatusEnricher
 }
 
-type cfgStore string
-
 type mediaContainer = string
 
 const (
@@ -53,17 +55,23 @@ const (
 	containerMP4     mediaContainer = "mp4"
 	containerMOV     mediaContainer = "mov"
 	containerCMAFHLS mediaContainer = "cmafhls"
+	containerDASH    mediaContainer = "mpd"
 
-	cfgStoreH264AAC   cfgStore = "h264aac"
-	cfgStoreH265AAC   cfgStore = "h265aac"
-	cfgStoreVP8Vorbis cfgStore = "vp8vorbis"
-	cfgStoreH264      cfgStore = "h264"
-	cfgStoreH265      cfgStore = "h265"
-	cfgStoreAV1       cfgStore = "av1"
-	cfgStoreAAC       cfgStore = "aac"
-	cfgStoreOpus      cfgStore = "opus"
+	defaultEncodingPriority = 50
 )
 
+// codecsByContainer lists the codecs each container can hold. Streaming
+// presets are packaged in TS, fMP4 or CMAF segments depending on their codecs
+// and the protocols of the job.
+var codecsByContainer = map[mediaContainer][]string{
+	containerMP4:     {codecH264, codecH265, codecAV1, codecAAC, codecOpus},
+	containerMOV:     {codecH264, codecH265, codecAAC},
+	containerWebM:    {codecVP8, codecAV1, codecVorbis, codecOpus},
+	containerHLS:     {codecH264, codecH265, codecAV1, codecAAC, codecOpus},
+	containerCMAFHLS: {codecH264, codecH265, codecAV1, codecAAC, codecOpus},
+	containerDASH:    {codecH264, codecH265, codecAV1, codecAAC, codecOpus},
+}
+
 func init() {
 	_ = provider.Register(Name, bitmovinFactory)
 }
@@ -149,33 +157,56 @@ func bitmovinFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
 		repo:        dbRepo,
 		providerCfg: cfg.Bitmovin,
 		tracer:      tracer,
-		cfgStores: map[cfgStore]configuration.Store{
-			cfgStoreH264:      configuration.NewH264(api, dbRepo),
-			cfgStoreH265:      configuration.NewH265(api, dbRepo),
-			cfgStoreAV1:       configuration.NewAV1(api, dbRepo),
-			cfgStoreH264AAC:   configuration.NewH264AAC(api, dbRepo),
-			cfgStoreH265AAC:   configuration.NewH265AAC(api, dbRepo),
-			cfgStoreVP8Vorbis: configuration.NewVP8Vorbis(api, dbRepo),
-			cfgStoreAAC:       configuration.NewAAC(api, dbRepo),
-			cfgStoreOpus:      configuration.NewOpus(api, dbRepo),
+		retry:       provider.NewRetryPolicy(Name, cfg),
+		sweeper: &cleanup.Sweeper{
+			Encodings:  api.Encoding.Encodings,
+			CustomData: api.Encoding.Encodings.Customdata,
+			Manifests:  api.Encoding.Manifests.Hls,
+			DASH:       api.Encoding.Manifests.Dash,
+			MinAge:     time.Duration(cfg.Bitmovin.SweepMinAge) * time.Second,
+		},
+		videoCodecs: map[string]configuration.Codec{
+			codecH264: configuration.NewH264(api),
+			codecH265: configuration.NewH265(api),
+			codecAV1:  configuration.NewAV1(api),
+			codecVP8:  configuration.NewVP8(api),
+		},
+		audioCodecs: map[string]configuration.Codec{
+			codecAAC:    configuration.NewAAC(api),
+			codecOpus:   configuration.NewOpus(api),
+			codecVorbis: configuration.NewVorbis(api),
 		},
 		containerSvcs: map[mediaContainer]containerSvc{
 			containerHLS: {
 				assembler: container.NewHLSAssembler(container.HLSContainerAPI{
-					HLSAudioMedia: api.Encoding.Manifests.Hls.Media.Audio,
-					TSMuxing:      api.Encoding.Encodings.Muxings.Ts,
-					HLSStreams:    api.Encoding.Manifests.Hls.Streams,
+					HLSAudioMedia:      api.Encoding.Manifests.Hls.Media.Audio,
+					TSMuxing:           api.Encoding.Encodings.Muxings.Ts,
+					HLSStreams:         api.Encoding.Manifests.Hls.Streams,
+					HLSIFramePlaylists: api.Encoding.Manifests.Hls.Streams.Iframe,
 				}),
 				statusEnricher: container.NewHLSStatusEnricher(api),
 			},
 			containerCMAFHLS: {
 				assembler: container.NewCMAFAssembler(container.CMAFContainerAPI{
-					HLSAudioMedia: api.Encoding.Manifests.Hls.Media.Audio,
-					CMAFMuxing:    api.Encoding.Encodings.Muxings.Cmaf,
-					HLSStreams:    api.Encoding.Manifests.Hls.Streams,
+					HLSAudioMedia:          api.Encoding.Manifests.Hls.Media.Audio,
+					CMAFMuxing:             api.Encoding.Encodings.Muxings.Cmaf,
+					HLSStreams:             api.Encoding.Manifests.Hls.Streams,
+					DASHRepresentations:    api.Encoding.Manifests.Dash.Periods.Adaptationsets.Representations.Cmaf,
+					HLSIFramePlaylists:     api.Encoding.Manifests.Hls.Streams.Iframe,
+					DASHDRMRepresentations: api.Encoding.Manifests.Dash.Periods.Adaptationsets.Representations.Cmaf.Drm,
+					DASHContentProtections: api.Encoding.Manifests.Dash.Periods.Adaptationsets.Representations.Cmaf.Drm.Contentprotection,
 				}),
 				statusEnricher: container.NewCMAFStatusEnricher(api),
 			},
+			containerDASH: {
+				assembler: container.NewDASHAssembler(container.DASHContainerAPI{
+					FMP4Muxing:             api.Encoding.Encodings.Muxings.Fmp4,
+					DASHRepresentations:    api.Encoding.Manifests.Dash.Periods.Adaptationsets.Representations.Fmp4,
+					DASHDRMRepresentations: api.Encoding.Manifests.Dash.Periods.Adaptationsets.Representations.Fmp4.Drm,
+					DASHContentProtections: api.Encoding.Manifests.Dash.Periods.Adaptationsets.Representations.Fmp4.Drm.Contentprotection,
+				}),
+				statusEnricher: container.NewDASHStatusEnricher(api),
+			},
 			containerWebM: {
 				assembler:      container.NewProgressiveWebMAssembler(api),
 				statusEnricher: container.NewProgressiveWebMStatusEnricher(api),
@@ -195,14 +226,109 @@ func bitmovinFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
 type bitmovinProvider struct {
 	api           *bitmovin.BitmovinApi
 	providerCfg   *config.Bitmovin
-	cfgStores     map[cfgStore]configuration.Store
+	videoCodecs   map[string]configuration.Codec
+	audioCodecs   map[string]configuration.Codec
 	containerSvcs map[mediaContainer]containerSvc
 	repo          db.Repository
 	tracer        tracing.Tracer
+	retry         *provider.RetryPolicy
+	sweeper       *cleanup.Sweeper
+	stopSweeping  context.CancelFunc
 	presetMutex   sync.Mutex
+
+	perTitleRenditions perTitleCache
+}
+
+// Start starts sweeping the encodings left behind by failed submissions, when
+// a sweep interval is configured.
+func (p *bitmovinProvider) Start(context.Context) error {
+	if p.providerCfg.SweepInterval == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stopSweeping = cancel
+	go p.sweep(ctx, time.Duration(p.providerCfg.SweepInterval)*time.Second)
+	return nil
+}
+
+// Close stops sweeping.
+func (p *bitmovinProvider) Close() error {
+	if p.stopSweeping != nil {
+		p.stopSweeping()
+	}
+	return nil
+}
+
+func (p *bitmovinProvider) sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := p.sweeper.Sweep()
+			logger := logrus.WithField("provider", Name)
+			if err != nil {
+				logger.WithError(err).Error("sweeping abandoned encodings")
+			}
+			if len(deleted) > 0 {
+				logger.WithField("encodings", deleted).Info("deleted abandoned encodings")
+			}
+		}
+	}
+}
+
+// call runs fn according to the retry policy of the provider. Calls creating
+// resources are not idempotent, they are only retried when rate limited.
+func (p *bitmovinProvider) call(ctx context.Context, op string, idempotent bool, fn func() error) error {
+	return p.retry.Do(ctx, op, idempotent, func(context.Context) error {
+		return retryableError(fn())
+	})
+}
+
+// deleter returns a function deleting a resource according to the retry
+// policy of the provider. Deletions are idempotent, and they are not
+// interrupted when ctx is canceled, since they usually run because of a
+// failure.
+func (p *bitmovinProvider) deleter(ctx context.Context, op string, fn func() error) func() error {
+	return func() error {
+		return p.call(detachedContext{ctx}, op, true, fn)
+	}
+}
+
+// detachedContext carries the values of its parent, such as the tracing
+// segment, without being canceled along with it
+type detachedContext struct {
+	parent context.Context
 }
 
-func (p *bitmovinProvider) Transcode(ctx context.Context, job *db.Job) (*provider.JobStatus, error) {
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// retryableError makes the errors of the Bitmovin API calls worth retrying
+// retryable
+func retryableError(err error) error {
+	if bitmovinErr, ok := err.(common.BitmovinError); ok && bitmovinErr.HttpStatusCode != nil {
+		return provider.StatusError(*bitmovinErr.HttpStatusCode, 0, err)
+	}
+	if err != nil {
+		return provider.TransportError(err)
+	}
+	return nil
+}
+
+// Transcode creates the encoding along with its streams and muxings, and starts
+// it. The resources created are deleted if the submission fails before the
+// encoding is started.
+func (p *bitmovinProvider) Transcode(ctx context.Context, job *db.Job) (_ *provider.JobStatus, err error) {
+	splice := provider.FrameAccurateSplice(job.SourceSplice, job.SourceInfo.FrameRate)
+	if err := provider.ValidateSplice(splice, job.SourceInfo); err != nil {
+		return nil, fmt.Errorf("splice: %w", err)
+	}
+
 	presets := make([]db.PresetSummary, len(job.Outputs))
 	for idx, output := range job.Outputs {
 		summary, err := p.repo.GetPresetSummary(output.Preset.Name)
@@ -213,32 +339,87 @@ func (p *bitmovinProvider) Transcode(ctx context.Context, job *db.Job) (*provide
 		presets[idx] = summary
 	}
 
-	inputID, mediaPath, err := p.inputFrom(ctx, job)
+	srcInputID, mediaPath, err := p.inputFrom(ctx, job)
 	if err != nil {
 		return nil, err
 	}
+	inputID := srcInputID
 
 	outputID, destPath, err := p.outputFrom(ctx, job)
 	if err != nil {
 		return nil, err
 	}
 
-	var generatingHLS bool
+	var streaming, withAudio, withVideo bool
 	for _, preset := range presets {
-		if preset.Container == containerHLS {
-			generatingHLS = true
+		if isStreamingContainer(preset.Container) {
+			streaming = true
+			withAudio = withAudio || preset.HasAudio()
+			withVideo = withVideo || preset.HasVideo()
+		}
+	}
+	// HLS remains the default for jobs that don't request DASH
+	generatingDASH := streaming && job.StreamingParams.HasProtocol(db.ProtocolDASH)
+	generatingHLS := streaming && (job.StreamingParams.HasProtocol(db.ProtocolHLS) || !generatingDASH)
+
+	// audio tracks are added as renditions of the HLS audio groups, they are
+	// not mapped to DASH adaptation sets yet
+	audioTracks := len(job.AudioTracks) > 0 && streaming && withAudio
+	if audioTracks && generatingDASH {
+		return nil, errors.New("audio tracks are only supported with hls")
+	}
+
+	perTitle, err := perTitleFrom(job.ExecutionFeatures)
+	if err != nil {
+		return nil, err
+	}
+	var perTitleH264, perTitleH265 bool
+	if perTitle != nil {
+		perTitleH264, perTitleH265, err = validatePerTitle(job, presets, generatingDASH)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	if err := validateCaptions(job, presets, generatingDASH); err != nil {
+		return nil, err
+	}
+
+	if err := validateImageOutputs(job, presets, perTitle != nil); err != nil {
+		return nil, err
+	}
+
+	if err := validateEncryption(job, presets, generatingHLS, generatingDASH, perTitle != nil); err != nil {
+		return nil, err
+	}
+	imagesOutput := imageStreamOutput(presets)
+
+	tracker := &cleanup.Tracker{}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if rollbackErr := tracker.Rollback(); rollbackErr != nil {
+			err = fmt.Errorf("%w (cleaning up: %s)", err, rollbackErr)
+		}
+	}()
+
 	var manifestID, manifestMasterPath, manifestMasterFilename string
-	if generatingHLS {
+	if streaming {
 		manifestMasterPath = path.Dir(path.Join(destPath, job.StreamingParams.PlaylistFileName))
 		manifestMasterFilename = path.Base(job.StreamingParams.PlaylistFileName)
-
+	}
+	// the renditions of per-title encodings are only known once encoded, their
+	// HLS manifest is generated by Bitmovin after the encoding is created
+	if generatingHLS && perTitle == nil {
 		subSeg := p.tracer.BeginSubsegment(ctx, "bitmovin-create-hls-manifest")
-		hlsManifest, err := p.api.Encoding.Manifests.Hls.Create(model.HlsManifest{
-			ManifestName: manifestMasterFilename,
-			Outputs:      []model.EncodingOutput{storage.EncodingOutputFrom(outputID, manifestMasterPath)},
+		var hlsManifest *model.HlsManifest
+		err := p.call(ctx, "bitmovin-create-hls-manifest", false, func() (err error) {
+			hlsManifest, err = p.api.Encoding.Manifests.Hls.Create(model.HlsManifest{
+				ManifestName: manifestMasterFilename,
+				Outputs:      []model.EncodingOutput{storage.EncodingOutputFrom(outputID, manifestMasterPath)},
+			})
+			return err
 		})
 		if err != nil {
 			subSeg.Close(err)
@@ -247,13 +428,38 @@ func (p *bitmovinProvider) Transcode(ctx context.Context, job *db.Job) (*provide
 		subSeg.Close(nil)
 
 		manifestID = hlsManifest.Id
+		tracker.Track("hls manifest", manifestID, p.deleter(ctx, "bitmovin-delete-hls-manifest", func() error {
+			_, err := p.api.Encoding.Manifests.Hls.Delete(manifestID)
+			return err
+		}))
 	}
 
-	encCustomData := make(map[string]map[string]interface{})
-	if manifestID != "" {
-		encCustomData[container.CustomDataKeyManifest] = map[string]interface{}{
-			container.CustomDataKeyManifestID: manifestID,
+	var dashManifest *container.DASHManifest
+	if generatingDASH {
+		// the DASH manifest sits next to the HLS playlist when both are
+		// generated from the same segments
+		dashFilename := strings.TrimSuffix(manifestMasterFilename, path.Ext(manifestMasterFilename)) + ".mpd"
+
+		subSeg := p.tracer.BeginSubsegment(ctx, "bitmovin-create-dash-manifest")
+		dashManifest, err = p.createDASHManifest(ctx, tracker, outputID, manifestMasterPath, dashFilename, withAudio, withVideo)
+		subSeg.Close(err)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating dash manifest")
+		}
+	}
+
+	encCustomData := map[string]map[string]interface{}{
+		cleanup.CustomDataKeyOrchestrator: {cleanup.CustomDataKeyJobID: job.ID},
+	}
+	if manifestID != "" || dashManifest != nil {
+		manifests := map[string]interface{}{}
+		if manifestID != "" {
+			manifests[container.CustomDataKeyManifestID] = manifestID
+		}
+		if dashManifest != nil {
+			manifests[container.CustomDataKeyDASHManifestID] = dashManifest.ID
 		}
+		encCustomData[container.CustomDataKeyManifest] = manifests
 	}
 
 	infrastructureSettings, encodingCloudRegion, err := p.encodingInfrastructureFrom(job)
@@ -267,170 +473,163 @@ func (p *bitmovinProvider) Transcode(ctx context.Context, job *db.Job) (*provide
 	}
 
 	subSeg := p.tracer.BeginSubsegment(ctx, "bitmovin-create-encoding")
-	enc, err := p.api.Encoding.Encodings.Create(model.Encoding{
-		Name:           jobName,
-		CustomData:     &encCustomData,
-		CloudRegion:    encodingCloudRegion,
-		EncoderVersion: p.providerCfg.EncodingVersion,
-		Infrastructure: infrastructureSettings,
-		Labels:         job.Labels,
+	var enc *model.Encoding
+	err = p.call(ctx, "bitmovin-create-encoding", false, func() (err error) {
+		enc, err = p.api.Encoding.Encodings.Create(model.Encoding{
+			Name:           jobName,
+			CustomData:     &encCustomData,
+			CloudRegion:    encodingCloudRegion,
+			EncoderVersion: p.providerCfg.EncodingVersion,
+			Infrastructure: infrastructureSettings,
+			Labels:         job.Labels,
+		})
+		return err
 	})
 	if err != nil {
 		subSeg.Close(err)
 		return nil, errors.Wrap(err, "creating encoding")
 	}
 	subSeg.Close(nil)
+	tracker.Track("encoding", enc.Id, p.deleter(ctx, "bitmovin-delete-encoding", func() error {
+		_, err := p.api.Encoding.Encodings.Delete(enc.Id)
+		return err
+	}))
+
+	encrypt := p.encrypter(ctx, tracker, enc.Id, job.StreamingParams.Encryption)
 
 	subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-create-ingest")
-	inputID, err = func(inputID string) (string, error) {
-		istream, err := p.api.Encoding.Encodings.InputStreams.Ingest.Create(enc.Id, model.IngestInputStream{
-			InputId:       inputID,
-			InputPath:     mediaPath,
-			SelectionMode: model.StreamSelectionMode_AUTO,
-		})
-		if err != nil {
-			return inputID, err
-		}
-		return istream.Id, err
-	}(inputID)
+	inputID, err = p.createIngest(ctx, tracker, enc.Id, inputID, mediaPath, nil)
 	subSeg.Close(err)
 	if err != nil {
 		return nil, fmt.Errorf("ingest: %v", err)
 	}
 
 	subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-create-concatenated-splice")
-	inputID, err = func(inputID string) (string, error) {
-		if len(job.SourceSplice) == 0 {
-			return inputID, nil
-		}
-
-		type work struct {
-			pos        int32
-			start, dur float64
-			id         string
-			err        error
-		}
-		workc := make(chan work, len(job.SourceSplice))
-
-		// splice each range concurrently
-		for i, r := range job.SourceSplice {
-			w := work{
-				pos:   int32(i),
-				start: r[0],
-				dur:   r[1] - r[0],
-			}
-			go func() {
-				// NOTE(as): don't use the timecode "api", it seems to look for a real
-				// timecode track in the source. If it doesn't find it, it just doesn't trim
-				// the clip and provides no logging or errors. For this "api", it wants
-				// start, duration; not start, end, and it also wants pointers
-				splice, err := p.api.Encoding.Encodings.InputStreams.Trimming.TimeBased.Create(enc.Id, model.TimeBasedTrimmingInputStream{
-					InputStreamId: inputID,
-					Offset:        &w.start,
-					Duration:      &w.dur,
-				})
-				if splice != nil {
-					w.id = splice.Id
-				}
-				w.err = err
-				workc <- w
-			}()
-		}
-
-		if cap(workc) == 1 {
-			// NOTE(as): turns out bitmovin complains if you run the equivalent of:
-			// 'cat input0.mp4 > input.mp4'  because there's only one input0.mp4
-			w := <-workc
-			if w.err != nil {
-				return inputID, fmt.Errorf("trim: range#%d: %w", 1, w.err)
-			}
-			// can't concatenate, need special case for one input splice
-			return w.id, nil
-		}
+	inputID, err = p.spliceInput(ctx, tracker, enc.Id, inputID, splice)
+	subSeg.Close(err)
+	if err != nil {
+		return nil, fmt.Errorf("splice: %w", err)
+	}
 
-		// collect the results serially
-		cat := []model.ConcatenationInputConfiguration{}
-		for i := 0; i < cap(workc); i++ {
-			w := <-workc
-			if w.err != nil {
-				return inputID, fmt.Errorf("trim: range#%d: %w", w.pos, w.err)
-			}
-			main := i == 0
-			cat = append(cat, model.ConcatenationInputConfiguration{
-				IsMain:        &main,
-				InputStreamId: w.id,
-				Position:      &w.pos,
-			})
+	// the audio tracks of the streaming outputs are encoded once per audio
+	// configuration and shared by the video renditions referencing it
+	if audioTracks {
+		subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-create-audio-tracks")
+		err = p.createAudioTracks(ctx, audioTracksCfg{
+			presets:            presets,
+			encodingID:         enc.Id,
+			inputID:            srcInputID,
+			mediaPath:          mediaPath,
+			outputID:           outputID,
+			manifestID:         manifestID,
+			manifestMasterPath: manifestMasterPath,
+			splice:             splice,
+			job:                job,
+			encrypt:            encrypt,
+			tracker:            tracker,
+		})
+		subSeg.Close(err)
+		if err != nil {
+			return nil, fmt.Errorf("creating audio tracks: %w", err)
 		}
+	}
 
-		// although there are position markers in the struct,
-		// sort it just in case, this makes the logging consistent too
-		sort.Slice(cat, func(i, j int) bool {
-			return *cat[i].Position < *cat[j].Position
-		})
-		c, err := p.api.Encoding.Encodings.InputStreams.Concatenation.Create(enc.Id, model.ConcatenationInputStream{
-			Concatenation: cat,
+	// captions are encoded once and referenced by the video renditions
+	// carrying them through their subtitles group
+	var subtitlesGroups map[string]string
+	if len(job.Captions) > 0 {
+		subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-create-captions")
+		subtitlesGroups, err = p.createCaptions(ctx, captionsCfg{
+			encodingID:         enc.Id,
+			inputID:            srcInputID,
+			mediaPath:          mediaPath,
+			outputID:           outputID,
+			manifestID:         manifestID,
+			manifestMasterPath: manifestMasterPath,
+			splice:             splice,
+			job:                job,
+			tracker:            tracker,
 		})
+		subSeg.Close(err)
 		if err != nil {
-			return inputID, fmt.Errorf("concatenation: %v", err)
+			return nil, fmt.Errorf("creating captions: %w", err)
 		}
-		return c.Id, nil
-	}(inputID)
-	subSeg.Close(err)
-	if err != nil {
-		return nil, fmt.Errorf("splice: %w", err)
 	}
 
-	var wg sync.WaitGroup
-	errorc := make(chan error)
-
 	subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-create-outputs")
-	for idx, o := range job.Outputs {
-		wg.Add(1)
-		go p.createOutput(outputCfg{
-			preset:             presets[idx],
+	err = pool.Run(ctx, int(p.providerCfg.Concurrency), len(job.Outputs), func(ctx context.Context, i int) error {
+		err := p.createOutput(ctx, outputCfg{
+			preset:             presets[i],
 			encodingID:         enc.Id,
 			audioIn:            inputID,
 			videoIn:            inputID,
 			outputID:           outputID,
-			outputFilename:     o.FileName,
+			outputFilename:     job.Outputs[i].FileName,
 			destPath:           destPath,
 			manifestID:         manifestID,
 			manifestMasterPath: manifestMasterPath,
+			dashManifest:       dashManifest,
+			audioTracks:        audioTracks,
+			subtitlesGroup:     subtitlesGroups[job.Outputs[i].FileName],
+			images:             len(job.ImageOutputs) > 0 && i == imagesOutput,
+			perTitle:           perTitle,
 			job:                job,
-		}, &wg, errorc)
+			encrypt:            encrypt,
+			tracker:            tracker,
+		})
+		if err != nil {
+			return fmt.Errorf("output#%d %q: %w", i, job.Outputs[i].FileName, err)
+		}
+		return nil
+	})
+	subSeg.Close(err)
+	if err != nil {
+		return nil, fmt.Errorf("creating outputs: %w", err)
 	}
 
-	go func() {
-		wg.Wait()
-		close(errorc)
-	}()
-
-	for err := range errorc {
+	if generatingHLS && perTitle != nil {
+		subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-create-default-hls-manifest")
+		manifestID, err = p.createDefaultHLSManifest(ctx, tracker, enc.Id, outputID, manifestMasterPath, manifestMasterFilename)
 		subSeg.Close(err)
 		if err != nil {
-			return nil, err
+			return nil, errors.Wrap(err, "creating default master manifest")
 		}
 	}
-	subSeg.Close(nil)
 
-	var vodHLSManifests []model.ManifestResource
+	var vodHLSManifests, vodDASHManifests []model.ManifestResource
 	if generatingHLS && manifestID != "" {
 		vodHLSManifests = []model.ManifestResource{{ManifestId: manifestID}}
 	}
+	if dashManifest != nil {
+		vodDASHManifests = []model.ManifestResource{{ManifestId: dashManifest.ID}}
+	}
 
-	// note (ts): temporarily removing keyframe creation due to conflicts with splicing causing Bitmovin failures
-	//if o := job.ExplicitKeyframeOffsets; len(o) > 0 {
-	//	subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-create-keyframes")
-	//	if err = p.createExplicitKeyframes(enc.Id, o); err != nil {
-	//		subSeg.Close(err)
-	//		return nil, fmt.Errorf("creating keyframes: %w", err)
-	//	}
-	//	subSeg.Close(nil)
-	//}
+	// keyframes are placed on the timeline of the spliced input, offsets in the
+	// parts of the source that are cut out being dropped
+	if o := provider.SplicedOffsets(job.ExplicitKeyframeOffsets, splice); len(o) > 0 {
+		subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-create-keyframes")
+		if err = p.createExplicitKeyframes(ctx, enc.Id, o, streaming); err != nil {
+			subSeg.Close(err)
+			return nil, fmt.Errorf("creating keyframes: %w", err)
+		}
+		subSeg.Close(nil)
+	}
 
 	subSeg = p.tracer.BeginSubsegment(ctx, "bitmovin-start-encoding")
-	encResp, err := p.api.Encoding.Encodings.Start(enc.Id, model.StartEncodingRequest{VodHlsManifests: vodHLSManifests})
+	startReq := model.StartEncodingRequest{
+		VodHlsManifests:  vodHLSManifests,
+		VodDashManifests: vodDASHManifests,
+		Scheduling:       schedulingFrom(job.Priority),
+	}
+	if perTitle != nil {
+		startReq.PerTitle = perTitle.startConfig(perTitleH264, perTitleH265)
+	}
+
+	var encResp *model.BitmovinResponse
+	err = p.call(ctx, "bitmovin-start-encoding", false, func() (err error) {
+		encResp, err = p.api.Encoding.Encodings.Start(enc.Id, startReq)
+		return err
+	})
 	if err != nil {
 		subSeg.Close(err)
 		return nil, errors.Wrap(err, "starting encoding job")
@@ -453,63 +652,86 @@ type outputCfg struct {
 	outputFilename     string
 	manifestID         string
 	manifestMasterPath string
+	dashManifest       *container.DASHManifest
 	job                *db.Job
+	tracker            *cleanup.Tracker
+
+	// audioTracks is set when the audio of the streaming outputs is created
+	// separately by createAudioTracks
+	audioTracks bool
+
+	// subtitlesGroup is the HLS group of the captions of the output, if any
+	subtitlesGroup string
+
+	// images is set on the output whose video stream the image outputs of
+	// the job are taken from
+	images bool
+
+	// perTitle is set when the video streams are per-title templates
+	perTitle *PerTitle
+
+	// encrypt encrypts the streaming outputs, if set
+	encrypt container.EncryptFunc
 }
 
-func (p *bitmovinProvider) createOutput(cfg outputCfg, wg *sync.WaitGroup, errorc chan error) {
-	defer wg.Done()
+func (p *bitmovinProvider) createOutput(ctx context.Context, cfg outputCfg) error {
 	var audioMuxingStream, videoMuxingStream model.MuxingStream
 
-	if audCfgID := cfg.preset.AudioConfigID; audCfgID != "" {
-		audStream, err := p.api.Encoding.Encodings.Streams.Create(cfg.encodingID, model.Stream{
-			CodecConfigId: audCfgID,
-			InputStreams:  []model.StreamInput{{InputStreamId: cfg.audioIn}},
-		})
+	audCfgID := cfg.preset.AudioConfigID
+	if audCfgID != "" && !(cfg.audioTracks && isStreamingContainer(cfg.preset.Container)) {
+		audStreamID, err := p.createAudioStream(ctx, cfg.tracker, cfg.encodingID, audCfgID, cfg.audioIn, cfg.preset.AudioFilters)
 		if err != nil {
-			errorc <- errors.Wrap(err, "adding audio stream to the encoding")
-			return
-		}
-
-		for i, filter := range cfg.preset.AudioFilters {
-			_, err = p.api.Encoding.Encodings.Streams.Filters.Create(cfg.encodingID, audStream.Id, []model.StreamFilter{
-				{Id: filter, Position: bitmovin.Int32Ptr(int32(i))},
-			})
-			if err != nil {
-				errorc <- fmt.Errorf("adding filter %s to audio stream: %w", filter, err)
-				return
-			}
+			return err
 		}
-
-		audioMuxingStream = model.MuxingStream{StreamId: audStream.Id}
+		audioMuxingStream = model.MuxingStream{StreamId: audStreamID}
 	}
 
 	if vidCfgID := cfg.preset.VideoConfigID; vidCfgID != "" {
-		vidStream, err := p.api.Encoding.Encodings.Streams.Create(cfg.encodingID, model.Stream{
+		stream := model.Stream{
 			CodecConfigId: vidCfgID,
 			InputStreams:  []model.StreamInput{{InputStreamId: cfg.videoIn}},
+		}
+		if cfg.perTitle != nil {
+			stream.Mode = cfg.perTitle.streamMode()
+		}
+
+		var vidStream *model.Stream
+		err := p.call(ctx, "bitmovin-create-video-stream", false, func() (err error) {
+			vidStream, err = p.api.Encoding.Encodings.Streams.Create(cfg.encodingID, stream)
+			return err
 		})
 		if err != nil {
-			errorc <- errors.Wrap(err, "adding video stream to the encoding")
-			return
+			return errors.Wrap(err, "adding video stream to the encoding")
 		}
+		cfg.tracker.Track("video stream", vidStream.Id, p.deleter(ctx, "bitmovin-delete-video-stream", func() error {
+			_, err := p.api.Encoding.Encodings.Streams.Delete(cfg.encodingID, vidStream.Id)
+			return err
+		}))
 
 		for i, filter := range cfg.preset.VideoFilters {
-			_, err = p.api.Encoding.Encodings.Streams.Filters.Create(cfg.encodingID, vidStream.Id, []model.StreamFilter{
-				{Id: filter, Position: bitmovin.Int32Ptr(int32(i))},
+			err = p.call(ctx, "bitmovin-create-video-stream-filter", false, func() error {
+				_, err := p.api.Encoding.Encodings.Streams.Filters.Create(cfg.encodingID, vidStream.Id, []model.StreamFilter{
+					{Id: filter, Position: bitmovin.Int32Ptr(int32(i))},
+				})
+				return err
 			})
 			if err != nil {
-				errorc <- fmt.Errorf("adding filter %s to video stream: %w", filter, err)
-				return
+				return fmt.Errorf("adding filter %s to video stream: %w", filter, err)
+			}
+		}
+
+		if cfg.images {
+			if err := p.createImages(ctx, cfg, vidStream.Id); err != nil {
+				return err
 			}
 		}
 
 		videoMuxingStream = model.MuxingStream{StreamId: vidStream.Id}
 	}
 
-	contnrSvcs, err := p.containerServicesFrom(cfg.preset.Container, model.CodecConfigType(cfg.preset.VideoCodec))
+	contnrSvcs, err := p.containerServicesFrom(cfg.preset, cfg.manifestID != "", cfg.dashManifest != nil)
 	if err != nil {
-		errorc <- err
-		return
+		return err
 	}
 
 	if err = contnrSvcs.assembler.Assemble(container.AssemblerCfg{
@@ -523,11 +745,281 @@ func (p *bitmovinProvider) createOutput(cfg outputCfg, wg *sync.WaitGroup, error
 		VidMuxingStream:    videoMuxingStream,
 		ManifestID:         cfg.manifestID,
 		ManifestMasterPath: cfg.manifestMasterPath,
+		DASH:               cfg.dashManifest,
+		SubtitlesGroup:     cfg.subtitlesGroup,
+		IFramePlaylist:     iFramePlaylists(cfg.job),
+		PerTitle:           cfg.perTitle != nil,
 		SegDuration:        cfg.job.StreamingParams.SegmentDuration,
+		Encrypt:            cfg.encrypt,
+		Tracker:            cfg.tracker,
 	}); err != nil {
-		errorc <- err
-		return
+		return err
 	}
+
+	return nil
+}
+
+// validatePerTitle checks that the outputs of a job can be encoded per-title
+// and reports the codecs of its video templates
+func validatePerTitle(job *db.Job, presets []db.PresetSummary, dash bool) (h264, h265 bool, err error) {
+	if dash {
+		return false, false, errors.New("per-title encoding is only supported with hls")
+	}
+	if len(job.AudioTracks) > 0 {
+		return false, false, errors.New("per-title encoding doesn't support audio tracks")
+	}
+	if len(job.Captions) > 0 {
+		return false, false, errors.New("per-title encoding doesn't support captions")
+	}
+
+	for _, preset := range presets {
+		if !isStreamingContainer(preset.Container) {
+			return false, false, fmt.Errorf("per-title encoding doesn't support %q outputs", preset.Container)
+		}
+		switch strings.ToLower(preset.VideoCodec) {
+		case "":
+		case codecH264:
+			h264 = true
+		case codecH265:
+			h265 = true
+		default:
+			return false, false, fmt.Errorf("per-title encoding doesn't support the %q video codec", strings.ToLower(preset.VideoCodec))
+		}
+	}
+	if !h264 && !h265 {
+		return false, false, errors.New("per-title encoding requires a video output")
+	}
+
+	return h264, h265, nil
+}
+
+// createDefaultHLSManifest creates an HLS manifest generated by Bitmovin from
+// all the muxings of the encoding
+func (p *bitmovinProvider) createDefaultHLSManifest(ctx context.Context, tracker *cleanup.Tracker, encodingID, outputID, manifestPath, filename string) (string, error) {
+	var manifest *model.HlsManifestDefault
+	err := p.call(ctx, "bitmovin-create-default-hls-manifest", false, func() (err error) {
+		manifest, err = p.api.Encoding.Manifests.Hls.Default.Create(model.HlsManifestDefault{
+			EncodingId:   encodingID,
+			ManifestName: filename,
+			Version:      model.HlsManifestDefaultVersion_V1,
+			Outputs:      []model.EncodingOutput{storage.EncodingOutputFrom(outputID, manifestPath)},
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	tracker.Track("hls manifest", manifest.Id, p.deleter(ctx, "bitmovin-delete-hls-manifest", func() error {
+		_, err := p.api.Encoding.Manifests.Hls.Delete(manifest.Id)
+		return err
+	}))
+	return manifest.Id, nil
+}
+
+func (p *bitmovinProvider) createAudioStream(ctx context.Context, tracker *cleanup.Tracker, encodingID, audCfgID, inputStreamID string, filters []string) (string, error) {
+	var audStream *model.Stream
+	err := p.call(ctx, "bitmovin-create-audio-stream", false, func() (err error) {
+		audStream, err = p.api.Encoding.Encodings.Streams.Create(encodingID, model.Stream{
+			CodecConfigId: audCfgID,
+			InputStreams:  []model.StreamInput{{InputStreamId: inputStreamID}},
+		})
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "adding audio stream to the encoding")
+	}
+	tracker.Track("audio stream", audStream.Id, p.deleter(ctx, "bitmovin-delete-audio-stream", func() error {
+		_, err := p.api.Encoding.Encodings.Streams.Delete(encodingID, audStream.Id)
+		return err
+	}))
+
+	for i, filter := range filters {
+		err = p.call(ctx, "bitmovin-create-audio-stream-filter", false, func() error {
+			_, err := p.api.Encoding.Encodings.Streams.Filters.Create(encodingID, audStream.Id, []model.StreamFilter{
+				{Id: filter, Position: bitmovin.Int32Ptr(int32(i))},
+			})
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("adding filter %s to audio stream: %w", filter, err)
+		}
+	}
+
+	return audStream.Id, nil
+}
+
+type audioTracksCfg struct {
+	presets            []db.PresetSummary
+	encodingID         string
+	inputID            string
+	mediaPath          string
+	outputID           string
+	manifestID         string
+	manifestMasterPath string
+	splice             timecode.Splice
+	job                *db.Job
+	encrypt            container.EncryptFunc
+	tracker            *cleanup.Tracker
+}
+
+// createAudioTracks creates the audio tracks of the job for every audio
+// configuration of the streaming outputs, each track being ingested from its
+// own source audio track
+func (p *bitmovinProvider) createAudioTracks(ctx context.Context, cfg audioTracksCfg) error {
+	inputs := map[uint]string{}
+	for _, t := range cfg.job.AudioTracks {
+		if _, ok := inputs[t.SourceTrack]; ok {
+			continue
+		}
+		position := int32(t.SourceTrack)
+		inputID, err := p.createIngest(ctx, cfg.tracker, cfg.encodingID, cfg.inputID, cfg.mediaPath, &position)
+		if err != nil {
+			return fmt.Errorf("ingesting source track %d: %w", t.SourceTrack, err)
+		}
+		inputID, err = p.spliceInput(ctx, cfg.tracker, cfg.encodingID, inputID, cfg.splice)
+		if err != nil {
+			return fmt.Errorf("splicing source track %d: %w", t.SourceTrack, err)
+		}
+		inputs[t.SourceTrack] = inputID
+	}
+
+	var groups []db.PresetSummary
+	seen := map[string]bool{}
+	for _, preset := range cfg.presets {
+		if preset.AudioConfigID == "" || !isStreamingContainer(preset.Container) || seen[preset.AudioConfigID] {
+			continue
+		}
+		seen[preset.AudioConfigID] = true
+		groups = append(groups, preset)
+	}
+
+	return pool.Run(ctx, int(p.providerCfg.Concurrency), len(groups), func(ctx context.Context, i int) error {
+		preset := groups[i]
+		tracks := make([]container.AudioTrack, len(cfg.job.AudioTracks))
+		for j, t := range cfg.job.AudioTracks {
+			streamID, err := p.createAudioStream(ctx, cfg.tracker, cfg.encodingID, preset.AudioConfigID, inputs[t.SourceTrack], preset.AudioFilters)
+			if err != nil {
+				return fmt.Errorf("audio track %q: %w", t.DisplayName(), err)
+			}
+			tracks[j] = container.AudioTrack{
+				MuxingStream:    model.MuxingStream{StreamId: streamID},
+				Language:        t.Language,
+				Name:            t.DisplayName(),
+				Default:         t.Default,
+				Autoselect:      t.Autoselect,
+				Characteristics: t.Characteristics,
+			}
+		}
+
+		contnrSvcs, err := p.containerServicesFrom(preset, cfg.manifestID != "", false)
+		if err != nil {
+			return err
+		}
+
+		return contnrSvcs.assembler.Assemble(container.AssemblerCfg{
+			EncID:              cfg.encodingID,
+			OutputID:           cfg.outputID,
+			AudCfgID:           preset.AudioConfigID,
+			AudioTracks:        tracks,
+			ManifestID:         cfg.manifestID,
+			ManifestMasterPath: cfg.manifestMasterPath,
+			SegDuration:        cfg.job.StreamingParams.SegmentDuration,
+			Encrypt:            cfg.encrypt,
+			Tracker:            cfg.tracker,
+		})
+	})
+}
+
+// createIngest creates an ingest input stream of the media, selecting the
+// audio track at position when set and the default streams otherwise
+func (p *bitmovinProvider) createIngest(ctx context.Context, tracker *cleanup.Tracker, encodingID, inputID, mediaPath string, position *int32) (string, error) {
+	ingest := model.IngestInputStream{
+		InputId:       inputID,
+		InputPath:     mediaPath,
+		SelectionMode: model.StreamSelectionMode_AUTO,
+	}
+	if position != nil {
+		ingest.SelectionMode = model.StreamSelectionMode_AUDIO_RELATIVE
+		ingest.Position = position
+	}
+
+	var istream *model.IngestInputStream
+	err := p.call(ctx, "bitmovin-create-ingest", false, func() (err error) {
+		istream, err = p.api.Encoding.Encodings.InputStreams.Ingest.Create(encodingID, ingest)
+		return err
+	})
+	if err != nil {
+		return inputID, err
+	}
+	tracker.Track("ingest input stream", istream.Id, p.deleter(ctx, "bitmovin-delete-ingest", func() error {
+		_, err := p.api.Encoding.Encodings.InputStreams.Ingest.Delete(encodingID, istream.Id)
+		return err
+	}))
+	return istream.Id, nil
+}
+
+// spliceInput trims the ranges of the splice from the input stream and
+// concatenates them, returning the ID of the resulting input stream. The ranges
+// are expected to fall on frames of the source so the trims are frame accurate.
+func (p *bitmovinProvider) spliceInput(ctx context.Context, tracker *cleanup.Tracker, encodingID, inputStreamID string, splice timecode.Splice) (string, error) {
+	if len(splice) == 0 {
+		return inputStreamID, nil
+	}
+
+	// splice the ranges concurrently
+	ids := make([]string, len(splice))
+	err := pool.Run(ctx, int(p.providerCfg.Concurrency), len(splice), func(ctx context.Context, i int) error {
+		start, dur := splice[i][0], splice[i][1]-splice[i][0]
+		// NOTE(as): don't use the timecode "api", it seems to look for a real
+		// timecode track in the source. If it doesn't find it, it just doesn't trim
+		// the clip and provides no logging or errors. For this "api", it wants
+		// start, duration; not start, end, and it also wants pointers
+		trimmed, err := p.api.Encoding.Encodings.InputStreams.Trimming.TimeBased.Create(encodingID, model.TimeBasedTrimmingInputStream{
+			InputStreamId: inputStreamID,
+			Offset:        &start,
+			Duration:      &dur,
+		})
+		if err != nil {
+			return fmt.Errorf("trim: range#%d: %w", i, err)
+		}
+		ids[i] = trimmed.Id
+		tracker.Track("trimming input stream", trimmed.Id, p.deleter(ctx, "bitmovin-delete-trimming", func() error {
+			_, err := p.api.Encoding.Encodings.InputStreams.Trimming.TimeBased.Delete(encodingID, trimmed.Id)
+			return err
+		}))
+		return nil
+	})
+	if err != nil {
+		return inputStreamID, err
+	}
+
+	if len(ids) == 1 {
+		// NOTE(as): turns out bitmovin complains if you run the equivalent of:
+		// 'cat input0.mp4 > input.mp4'  because there's only one input0.mp4
+		// can't concatenate, need special case for one input splice
+		return ids[0], nil
+	}
+
+	cat := make([]model.ConcatenationInputConfiguration, len(ids))
+	for i, id := range ids {
+		main, pos := i == 0, int32(i)
+		cat[i] = model.ConcatenationInputConfiguration{
+			IsMain:        &main,
+			InputStreamId: id,
+			Position:      &pos,
+		}
+	}
+	c, err := p.api.Encoding.Encodings.InputStreams.Concatenation.Create(encodingID, model.ConcatenationInputStream{
+		Concatenation: cat,
+	})
+	if err != nil {
+		return inputStreamID, fmt.Errorf("concatenation: %v", err)
+	}
+	tracker.Track("concatenation input stream", c.Id, p.deleter(ctx, "bitmovin-delete-concatenation", func() error {
+		_, err := p.api.Encoding.Encodings.InputStreams.Concatenation.Delete(encodingID, c.Id)
+		return err
+	}))
+	return c.Id, nil
 }
 
 func (p *bitmovinProvider) inputFrom(ctx context.Context, job *db.Job) (inputID string, srcPath string, err error) {
@@ -584,11 +1076,13 @@ func (p *bitmovinProvider) outputFrom(ctx context.Context, job *db.Job) (inputID
 	return outputID, destPath, nil
 }
 
-func (p *bitmovinProvider) containerServicesFrom(mediaContainer string, cfgType model.CodecConfigType) (containerSvc, error) {
-	if cfgType == model.CodecConfigType_H265 && mediaContainer == containerHLS {
-		mediaContainer = containerCMAFHLS
-	}
-
+// containerServicesFrom returns the services creating the outputs of the given
+// preset. The muxing of streaming outputs depends on the manifests they are
+// added to: HLS alone uses TS segments, DASH alone uses fMP4 segments, and CMAF
+// segments are shared when both are generated. Codecs that can't be carried in
+// TS segments always use CMAF.
+func (p *bitmovinProvider) containerServicesFrom(preset db.PresetSummary, hls, dash bool) (containerSvc, error) {
+	mediaContainer := containerFrom(preset, hls, dash)
 	containerSvcs, ok := p.containerSvcs[mediaContainer]
 	if !ok {
 		return containerSvc{}, fmt.Errorf("unknown container format %q", mediaContainer)
@@ -597,6 +1091,109 @@ func (p *bitmovinProvider) containerServicesFrom(mediaContainer string, cfgType
 	return containerSvcs, nil
 }
 
+// tsCompatible reports whether the codecs of a preset can be muxed into TS segments
+// containerFrom returns the container the outputs of a preset are muxed in,
+// streaming outputs being muxed according to the manifests generated
+func containerFrom(preset db.PresetSummary, hls, dash bool) mediaContainer {
+	if !isStreamingContainer(preset.Container) {
+		return preset.Container
+	}
+	switch {
+	case !tsCompatible(preset), hls && dash:
+		return containerCMAFHLS
+	case dash:
+		return containerDASH
+	case hls:
+		return containerHLS
+	}
+	return preset.Container
+}
+
+func tsCompatible(preset db.PresetSummary) bool {
+	vcodec, acodec := strings.ToLower(preset.VideoCodec), strings.ToLower(preset.AudioCodec)
+	return (vcodec == "" || vcodec == codecH264) && (acodec == "" || acodec == codecAAC)
+}
+
+func isStreamingContainer(mediaContainer string) bool {
+	switch mediaContainer {
+	case containerHLS, containerDASH, containerCMAFHLS:
+		return true
+	}
+	return false
+}
+
+// createDASHManifest creates a DASH manifest with a single period, holding an
+// adaptation set for the video and another for the audio representations.
+func (p *bitmovinProvider) createDASHManifest(ctx context.Context, tracker *cleanup.Tracker, outputID, manifestPath, filename string, withAudio, withVideo bool) (*container.DASHManifest, error) {
+	dash := p.api.Encoding.Manifests.Dash
+
+	var manifest *model.DashManifest
+	err := p.call(ctx, "bitmovin-create-dash-manifest", false, func() (err error) {
+		manifest, err = dash.Create(model.DashManifest{
+			ManifestName: filename,
+			Profile:      model.DashProfile_ON_DEMAND,
+			Outputs:      []model.EncodingOutput{storage.EncodingOutputFrom(outputID, manifestPath)},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	tracker.Track("dash manifest", manifest.Id, p.deleter(ctx, "bitmovin-delete-dash-manifest", func() error {
+		_, err := dash.Delete(manifest.Id)
+		return err
+	}))
+	m := container.DASHManifest{ID: manifest.Id}
+
+	var period *model.Period
+	err = p.call(ctx, "bitmovin-create-dash-period", false, func() (err error) {
+		period, err = dash.Periods.Create(m.ID, model.Period{})
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating period")
+	}
+	tracker.Track("dash period", period.Id, p.deleter(ctx, "bitmovin-delete-dash-period", func() error {
+		_, err := dash.Periods.Delete(m.ID, period.Id)
+		return err
+	}))
+	m.PeriodID = period.Id
+
+	if withVideo {
+		var set *model.VideoAdaptationSet
+		err = p.call(ctx, "bitmovin-create-dash-video-adaptation-set", false, func() (err error) {
+			set, err = dash.Periods.Adaptationsets.Video.Create(m.ID, m.PeriodID, model.VideoAdaptationSet{})
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating video adaptation set")
+		}
+		tracker.Track("dash video adaptation set", set.Id, p.deleter(ctx, "bitmovin-delete-dash-video-adaptation-set", func() error {
+			_, err := dash.Periods.Adaptationsets.Video.Delete(m.ID, m.PeriodID, set.Id)
+			return err
+		}))
+		m.VideoAdaptationSetID = set.Id
+	}
+
+	if withAudio {
+		var set *model.AudioAdaptationSet
+		err = p.call(ctx, "bitmovin-create-dash-audio-adaptation-set", false, func() (err error) {
+			set, err = dash.Periods.Adaptationsets.Audio.Create(m.ID, m.PeriodID, model.AudioAdaptationSet{Lang: "en"})
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating audio adaptation set")
+		}
+		tracker.Track("dash audio adaptation set", set.Id, p.deleter(ctx, "bitmovin-delete-dash-audio-adaptation-set", func() error {
+			_, err := dash.Periods.Adaptationsets.Audio.Delete(m.ID, m.PeriodID, set.Id)
+			return err
+		}))
+		m.AudioAdaptationSetID = set.Id
+	}
+
+	return &m, nil
+}
+
 func (p *bitmovinProvider) encodingCloudRegionFrom(job *db.Job) (model.CloudRegion, error) {
 	if cloud, region := job.ExecutionEnv.Cloud, job.ExecutionEnv.Region; cloud+region != "" {
 		regions, found := regionByCloud[cloud]
@@ -631,39 +1228,36 @@ func (p *bitmovinProvider) encodingInfrastructureFrom(job *db.Job) (*model.Infra
 	return nil, encodingCloudRegion, nil
 }
 
-func (p *bitmovinProvider) createExplicitKeyframes(encodingID string, offsets []float64) error {
-	if len(offsets) == 0 {
+// schedulingFrom shifts a job priority onto Bitmovin's 0 to 100 scheduling priority,
+// where the default of 50 is used when no priority is set
+func schedulingFrom(priority int) *model.Scheduling {
+	if priority == 0 {
 		return nil
 	}
 
-	type work struct {
-		offset float64
-		err    error
-	}
-
-	workc := make(chan work, len(offsets))
-
-	for _, o := range offsets {
-		w := work{offset: o}
-		go func() {
-			_, w.err = p.api.Encoding.Encodings.Keyframes.Create(encodingID, model.Keyframe{Time: &w.offset})
-			workc <- w
-		}()
-	}
-
-	for i := 0; i < cap(workc); i++ {
-		w := <-workc
-		if w.err != nil {
-			return w.err
-		}
-	}
+	encPriority := int32(defaultEncodingPriority + priority)
+	return &model.Scheduling{Priority: &encPriority}
+}
 
-	return nil
+// createExplicitKeyframes inserts keyframes at the offsets of the encoding, which
+// also start new segments when segmentCut is set
+func (p *bitmovinProvider) createExplicitKeyframes(ctx context.Context, encodingID string, offsets []float64, segmentCut bool) error {
+	return pool.Run(ctx, int(p.providerCfg.Concurrency), len(offsets), func(ctx context.Context, i int) error {
+		offset := offsets[i]
+		return p.call(ctx, "bitmovin-create-keyframe", false, func() error {
+			_, err := p.api.Encoding.Encodings.Keyframes.Create(encodingID, model.Keyframe{Time: &offset, SegmentCut: &segmentCut})
+			return err
+		})
+	})
 }
 
 func (p *bitmovinProvider) JobStatus(ctx context.Context, job *db.Job) (*provider.JobStatus, error) {
 	subSeg := p.tracer.BeginSubsegment(ctx, "bitmovin-create-get-encoding-status")
-	task, err := p.api.Encoding.Encodings.Status(job.ProviderJobID)
+	var task *model.ModelTask
+	err := p.call(ctx, "bitmovin-get-encoding-status", true, func() (err error) {
+		task, err = p.api.Encoding.Encodings.Status(job.ProviderJobID)
+		return err
+	})
 	if err != nil {
 		subSeg.Close(err)
 		return nil, errors.Wrap(err, "retrieving encoding status")
@@ -697,6 +1291,36 @@ func (p *bitmovinProvider) JobStatus(ctx context.Context, job *db.Job) (*provide
 			return nil, errors.Wrap(err, "enriching status with source info")
 		}
 
+		if perTitle, _ := perTitleFrom(job.ExecutionFeatures); perTitle != nil {
+			if files, ok := p.perTitleRenditions.get(job.ProviderJobID); ok {
+				s.Output.Files = append(s.Output.Files, files...)
+			} else {
+				enriched := len(s.Output.Files)
+				s, err = status.EnrichPerTitleRenditions(p.api, s, job.RootFolder())
+				if err != nil {
+					subSeg.Close(err)
+					return nil, errors.Wrap(err, "enriching status with per-title renditions")
+				}
+				p.perTitleRenditions.add(job.ProviderJobID, append([]provider.OutputFile(nil), s.Output.Files[enriched:]...))
+			}
+		}
+
+		if len(job.Captions) > 0 {
+			languages := make([]string, len(job.Captions))
+			for i, c := range job.Captions {
+				languages[i] = c.Language
+			}
+			s, err = status.EnrichCaptionRenditions(p.api, s, job.RootFolder(), languages)
+			if err != nil {
+				subSeg.Close(err)
+				return nil, errors.Wrap(err, "enriching status with caption renditions")
+			}
+		}
+
+		if len(job.ImageOutputs) > 0 {
+			s.Output.Files = append(s.Output.Files, imageFilesFrom(job, s.Output.Destination, s.SourceInfo.Duration)...)
+		}
+
 		// TODO: it would be better to know which containers to include in this fetch
 		// rather than iterating over all supported containers
 		for _, svcs := range p.containerSvcs {
@@ -714,7 +1338,10 @@ func (p *bitmovinProvider) JobStatus(ctx context.Context, job *db.Job) (*provide
 
 func (p *bitmovinProvider) CancelJob(ctx context.Context, id string) error {
 	subSeg := p.tracer.BeginSubsegment(ctx, "bitmovin-delete-job")
-	_, err := p.api.Encoding.Encodings.Stop(id)
+	err := p.call(ctx, "bitmovin-stop-encoding", true, func() error {
+		_, err := p.api.Encoding.Encodings.Stop(id)
+		return err
+	})
 	subSeg.Close(err)
 
 	return err
@@ -728,6 +1355,10 @@ func (p *bitmovinProvider) CreatePreset(_ context.Context, preset db.Preset) (st
 		return existing.Name, nil
 	}
 
+	if err := validateContainerCodecs(preset.Container, preset.Video.Codec, preset.Audio.Codec); err != nil {
+		return "", err
+	}
+
 	svc, err := p.cfgServiceFrom(preset.Video.Codec, preset.Audio.Codec)
 	if err != nil {
 		return "", err
@@ -853,24 +1484,46 @@ func (p *bitmovinProvider) Capabilities() provider.Capabilities {
 func (p *bitmovinProvider) cfgServiceFrom(vcodec, acodec string) (configuration.Store, error) {
 	vcodec, acodec = strings.ToLower(vcodec), strings.ToLower(acodec)
 
-	switch vcodec + acodec {
-	case codecH264 + codecAAC:
-		return p.cfgStores[cfgStoreH264AAC], nil
-	case codecH265 + codecAAC:
-		return p.cfgStores[cfgStoreH265AAC], nil
-	case codecVP8 + codecVorbis:
-		return p.cfgStores[cfgStoreVP8Vorbis], nil
-	case codecH264:
-		return p.cfgStores[cfgStoreH264], nil
-	case codecH265:
-		return p.cfgStores[cfgStoreH265], nil
-	case codecAV1:
-		return p.cfgStores[cfgStoreAV1], nil
-	case codecAAC:
-		return p.cfgStores[cfgStoreAAC], nil
-	case codecOpus:
-		return p.cfgStores[cfgStoreOpus], nil
-	}
-
-	return nil, fmt.Errorf("the pair of vcodec: %q and acodec: %q is not yet supported", vcodec, acodec)
+	var video, audio configuration.Codec
+	if vcodec != "" {
+		var ok bool
+		if video, ok = p.videoCodecs[vcodec]; !ok {
+			return nil, fmt.Errorf("video codec %q is not supported", vcodec)
+		}
+	}
+	if acodec != "" {
+		var ok bool
+		if audio, ok = p.audioCodecs[acodec]; !ok {
+			return nil, fmt.Errorf("audio codec %q is not supported", acodec)
+		}
+	}
+
+	return configuration.NewPair(video, audio, p.repo)
+}
+
+// validateContainerCodecs checks that the codecs of a preset can be muxed
+// into its container
+func validateContainerCodecs(mediaContainer, vcodec, acodec string) error {
+	codecs, ok := codecsByContainer[strings.ToLower(mediaContainer)]
+	if !ok {
+		return fmt.Errorf("container %q is not supported", mediaContainer)
+	}
+
+	for _, c := range []string{vcodec, acodec} {
+		if c == "" {
+			continue
+		}
+		supported := false
+		for _, codec := range codecs {
+			if strings.EqualFold(c, codec) {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("codec %q is not supported in container %q", strings.ToLower(c), mediaContainer)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,316 @@
+package bitmovin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go"
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/pkg/timecode"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/cleanup"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/pool"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/storage"
+)
+
+const subtitlesGroupID = "captions"
+
+// captionFileTypes are the sidecar caption formats Bitmovin reads
+var captionFileTypes = map[db.CaptionFormat]model.FileInputStreamType{
+	db.CaptionFormatWebVTT: model.FileInputStreamType_WEBVTT,
+	db.CaptionFormatTTML:   model.FileInputStreamType_TTML,
+	db.CaptionFormatIMSC:   model.FileInputStreamType_TTML,
+}
+
+// cea608Channels are the channels embedded captions can be read from
+var cea608Channels = map[uint]model.Cea608ChannelType{
+	1: model.Cea608ChannelType_CC1,
+	3: model.Cea608ChannelType_CC3,
+}
+
+// validateCaptions checks that the captions of a job can be added to its
+// outputs, as WebVTT renditions of the HLS manifest
+func validateCaptions(job *db.Job, presets []db.PresetSummary, dash bool) error {
+	if len(job.Captions) == 0 {
+		return nil
+	}
+	if dash {
+		return errors.New("captions are only supported with hls")
+	}
+
+	for _, c := range job.Captions {
+		if c.Format == db.CaptionFormatEmbedded {
+			if _, ok := cea608Channels[c.CEA608Channel()]; !ok {
+				return fmt.Errorf("embedded captions can only be read from CEA-608 channels 1 and 3, not %d", c.CEA608Channel())
+			}
+			continue
+		}
+		if _, ok := captionFileTypes[c.Format]; !ok {
+			return fmt.Errorf("%s captions are not supported", c.Format)
+		}
+	}
+
+	for i, output := range job.Outputs {
+		if len(provider.Captions(job.Captions, output.FileName)) > 0 && !isStreamingContainer(presets[i].Container) {
+			return fmt.Errorf("captions are only supported on streaming outputs, not on %q", output.FileName)
+		}
+	}
+	return nil
+}
+
+// subtitlesGroup is a group of HLS subtitle renditions, referenced by the
+// video streams carrying the same captions
+type subtitlesGroup struct {
+	id       string
+	captions []int
+}
+
+// subtitlesGroupsFrom returns the subtitles groups of a job along with the
+// group of each of its outputs, by file name. The outputs carrying the same
+// captions share a group, named after its position when there are several.
+func subtitlesGroupsFrom(job *db.Job) ([]subtitlesGroup, map[string]string) {
+	var groups []subtitlesGroup
+	byKey := map[string]int{}
+	outputGroups := map[string]int{}
+	for _, output := range job.Outputs {
+		var captions []int
+		for i, c := range job.Captions {
+			if c.AppliesTo(output.FileName) {
+				captions = append(captions, i)
+			}
+		}
+		if len(captions) == 0 {
+			continue
+		}
+
+		key := fmt.Sprint(captions)
+		idx, ok := byKey[key]
+		if !ok {
+			idx = len(groups)
+			byKey[key] = idx
+			groups = append(groups, subtitlesGroup{captions: captions})
+		}
+		outputGroups[output.FileName] = idx
+	}
+
+	for i := range groups {
+		groups[i].id = subtitlesGroupID
+		if len(groups) > 1 {
+			groups[i].id = fmt.Sprintf("%s_%d", subtitlesGroupID, i)
+		}
+	}
+
+	byOutput := make(map[string]string, len(outputGroups))
+	for filename, idx := range outputGroups {
+		byOutput[filename] = groups[idx].id
+	}
+	return groups, byOutput
+}
+
+type captionsCfg struct {
+	encodingID         string
+	inputID            string
+	mediaPath          string
+	outputID           string
+	manifestID         string
+	manifestMasterPath string
+	splice             timecode.Splice
+	job                *db.Job
+	tracker            *cleanup.Tracker
+}
+
+// createCaptions creates a WebVTT rendition of every caption of the job, added
+// to the subtitles groups of the HLS manifest, and returns the group of each
+// output by file name
+func (p *bitmovinProvider) createCaptions(ctx context.Context, cfg captionsCfg) (map[string]string, error) {
+	groups, byOutput := subtitlesGroupsFrom(cfg.job)
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	var vttCfg *model.WebVttConfiguration
+	err := p.call(ctx, "bitmovin-create-webvtt-configuration", false, func() (err error) {
+		vttCfg, err = p.api.Encoding.Configurations.Subtitles.Webvtt.Create(model.WebVttConfiguration{
+			Name: fmt.Sprintf("%s captions", cfg.job.ID),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating webvtt configuration: %w", err)
+	}
+	cfg.tracker.Track("webvtt configuration", vttCfg.Id, p.deleter(ctx, "bitmovin-delete-webvtt-configuration", func() error {
+		_, err := p.api.Encoding.Configurations.Subtitles.Webvtt.Delete(vttCfg.Id)
+		return err
+	}))
+
+	err = pool.Run(ctx, int(p.providerCfg.Concurrency), len(cfg.job.Captions), func(ctx context.Context, i int) error {
+		c := cfg.job.Captions[i]
+
+		var captionGroups []string
+		for _, g := range groups {
+			for _, idx := range g.captions {
+				if idx == i {
+					captionGroups = append(captionGroups, g.id)
+				}
+			}
+		}
+		if len(captionGroups) == 0 {
+			return nil
+		}
+
+		if err := p.createCaption(ctx, cfg, c, i, vttCfg.Id, captionGroups); err != nil {
+			return fmt.Errorf("caption %q: %w", c.DisplayName(), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return byOutput, nil
+}
+
+// createCaption creates the WebVTT rendition of the caption at index i of the
+// job, added to each of the given subtitles groups
+func (p *bitmovinProvider) createCaption(ctx context.Context, cfg captionsCfg, c db.Caption, i int, vttCfgID string, groups []string) error {
+	inputStreamID, err := p.captionInputStream(ctx, cfg, c)
+	if err != nil {
+		return err
+	}
+	inputStreamID, err = p.spliceInput(ctx, cfg.tracker, cfg.encodingID, inputStreamID, cfg.splice)
+	if err != nil {
+		return fmt.Errorf("splicing: %w", err)
+	}
+
+	var stream *model.Stream
+	err = p.call(ctx, "bitmovin-create-caption-stream", false, func() (err error) {
+		stream, err = p.api.Encoding.Encodings.Streams.Create(cfg.encodingID, model.Stream{
+			CodecConfigId: vttCfgID,
+			InputStreams:  []model.StreamInput{{InputStreamId: inputStreamID}},
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("adding caption stream to the encoding: %w", err)
+	}
+	cfg.tracker.Track("caption stream", stream.Id, p.deleter(ctx, "bitmovin-delete-caption-stream", func() error {
+		_, err := p.api.Encoding.Encodings.Streams.Delete(cfg.encodingID, stream.Id)
+		return err
+	}))
+
+	renditionPath := fmt.Sprintf("%s_%d", subtitlesGroupID, i)
+	var muxing *model.ChunkedTextMuxing
+	err = p.call(ctx, "bitmovin-create-chunked-text-muxing", false, func() (err error) {
+		muxing, err = p.api.Encoding.Encodings.Muxings.ChunkedText.Create(cfg.encodingID, model.ChunkedTextMuxing{
+			SegmentLength: bitmovin.Float64Ptr(float64(cfg.job.StreamingParams.SegmentDuration)),
+			SegmentNaming: "seg_%number%.vtt",
+			Streams:       []model.MuxingStream{{StreamId: stream.Id}},
+			Outputs: []model.EncodingOutput{
+				storage.EncodingOutputFrom(cfg.outputID, path.Join(cfg.manifestMasterPath, renditionPath)),
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("creating chunked text muxing: %w", err)
+	}
+	cfg.tracker.Track("chunked text muxing", muxing.Id, p.deleter(ctx, "bitmovin-delete-chunked-text-muxing", func() error {
+		_, err := p.api.Encoding.Encodings.Muxings.ChunkedText.Delete(cfg.encodingID, muxing.Id)
+		return err
+	}))
+
+	for _, group := range groups {
+		var media *model.SubtitlesMediaInfo
+		err = p.call(ctx, "bitmovin-create-hls-subtitles-media", false, func() (err error) {
+			media, err = p.api.Encoding.Manifests.Hls.Media.Subtitles.Create(cfg.manifestID, model.SubtitlesMediaInfo{
+				GroupId:     group,
+				Language:    c.Language,
+				Name:        c.DisplayName(),
+				IsDefault:   boolPtr(c.Default),
+				Autoselect:  boolPtr(true),
+				Forced:      boolPtr(false),
+				SegmentPath: renditionPath,
+				Uri:         renditionPath + ".m3u8",
+				EncodingId:  cfg.encodingID,
+				StreamId:    stream.Id,
+				MuxingId:    muxing.Id,
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("creating subtitles media: %w", err)
+		}
+		cfg.tracker.Track("hls subtitles media", media.Id, p.deleter(ctx, "bitmovin-delete-hls-subtitles-media", func() error {
+			_, err := p.api.Encoding.Manifests.Hls.Media.Subtitles.Delete(cfg.manifestID, media.Id)
+			return err
+		}))
+	}
+
+	return nil
+}
+
+// captionInputStream creates the input stream of a caption, read from the
+// CEA-608 captions of the source or from its sidecar file
+func (p *bitmovinProvider) captionInputStream(ctx context.Context, cfg captionsCfg, c db.Caption) (string, error) {
+	if c.Format == db.CaptionFormatEmbedded {
+		var istream *model.Cea608CaptionInputStream
+		err := p.call(ctx, "bitmovin-create-cea608-input-stream", false, func() (err error) {
+			istream, err = p.api.Encoding.Encodings.InputStreams.Captions.Cea608.Create(cfg.encodingID, model.Cea608CaptionInputStream{
+				InputId:   cfg.inputID,
+				InputPath: cfg.mediaPath,
+				Channel:   cea608Channels[c.CEA608Channel()],
+			})
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("creating cea-608 input stream: %w", err)
+		}
+		cfg.tracker.Track("cea-608 input stream", istream.Id, p.deleter(ctx, "bitmovin-delete-cea608-input-stream", func() error {
+			_, err := p.api.Encoding.Encodings.InputStreams.Captions.Cea608.Delete(cfg.encodingID, istream.Id)
+			return err
+		}))
+		return istream.Id, nil
+	}
+
+	inputPath, err := storage.PathFrom(c.Source)
+	if err != nil {
+		return "", err
+	}
+	inputID := cfg.job.ExecutionEnv.InputAlias
+	if inputID == "" {
+		inputID, err = storage.NewInput(c.Source, storage.InputAPI{
+			S3:    p.api.Encoding.Inputs.S3,
+			GCS:   p.api.Encoding.Inputs.Gcs,
+			HTTP:  p.api.Encoding.Inputs.Http,
+			HTTPS: p.api.Encoding.Inputs.Https,
+		}, p.providerCfg)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var istream *model.FileInputStream
+	err = p.call(ctx, "bitmovin-create-file-input-stream", false, func() (err error) {
+		istream, err = p.api.Encoding.Encodings.InputStreams.File.Create(cfg.encodingID, model.FileInputStream{
+			InputId:   inputID,
+			InputPath: inputPath,
+			FileType:  captionFileTypes[c.Format],
+		})
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating file input stream: %w", err)
+	}
+	cfg.tracker.Track("file input stream", istream.Id, p.deleter(ctx, "bitmovin-delete-file-input-stream", func() error {
+		_, err := p.api.Encoding.Encodings.InputStreams.File.Delete(cfg.encodingID, istream.Id)
+		return err
+	}))
+	return istream.Id, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
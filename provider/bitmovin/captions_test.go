@@ -0,0 +1,92 @@
+package bitmovin
+
+import (
+	"testing"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateCaptions(t *testing.T) {
+	outputs := []db.TranscodeOutput{{FileName: "hls/720p.m3u8"}, {FileName: "video.mp4"}}
+	presets := []db.PresetSummary{{Container: "m3u8"}, {Container: "mp4"}}
+	hlsOnly := []string{"hls/720p.m3u8"}
+
+	tests := []struct {
+		name     string
+		captions []db.Caption
+		dash     bool
+		wantErr  string
+	}{
+		{
+			name: "sidecar and embedded captions of streaming outputs are valid",
+			captions: []db.Caption{
+				{Format: db.CaptionFormatWebVTT, Language: "en", Outputs: hlsOnly},
+				{Format: db.CaptionFormatEmbedded, Language: "es", Channel: 3, Outputs: hlsOnly},
+			},
+		},
+		{
+			name:     "captions are rejected with dash",
+			captions: []db.Caption{{Format: db.CaptionFormatWebVTT, Outputs: hlsOnly}},
+			dash:     true,
+			wantErr:  "captions are only supported with hls",
+		},
+		{
+			name:     "scc sidecars are not supported",
+			captions: []db.Caption{{Format: db.CaptionFormatSCC, Outputs: hlsOnly}},
+			wantErr:  "scc captions are not supported",
+		},
+		{
+			name:     "embedded captions are only read from channels 1 and 3",
+			captions: []db.Caption{{Format: db.CaptionFormatEmbedded, Channel: 2, Outputs: hlsOnly}},
+			wantErr:  "embedded captions can only be read from CEA-608 channels 1 and 3, not 2",
+		},
+		{
+			name:     "captions of progressive outputs are rejected",
+			captions: []db.Caption{{Format: db.CaptionFormatWebVTT}},
+			wantErr:  `captions are only supported on streaming outputs, not on "video.mp4"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCaptions(&db.Job{Outputs: outputs, Captions: tt.captions}, presets, tt.dash)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubtitlesGroupsFrom(t *testing.T) {
+	job := &db.Job{
+		Outputs: []db.TranscodeOutput{{FileName: "1080p.m3u8"}, {FileName: "720p.m3u8"}, {FileName: "360p.m3u8"}},
+		Captions: []db.Caption{
+			{Format: db.CaptionFormatWebVTT, Language: "en"},
+			{Format: db.CaptionFormatWebVTT, Language: "fr", Outputs: []string{"1080p.m3u8", "720p.m3u8"}},
+		},
+	}
+
+	groups, byOutput := subtitlesGroupsFrom(job)
+
+	wantGroups := []subtitlesGroup{{id: "captions_0", captions: []int{0, 1}}, {id: "captions_1", captions: []int{0}}}
+	if diff := cmp.Diff(wantGroups, groups, cmp.AllowUnexported(subtitlesGroup{})); diff != "" {
+		t.Errorf("wrong groups: %s", diff)
+	}
+
+	wantByOutput := map[string]string{"1080p.m3u8": "captions_0", "720p.m3u8": "captions_0", "360p.m3u8": "captions_1"}
+	if diff := cmp.Diff(wantByOutput, byOutput); diff != "" {
+		t.Errorf("wrong groups of the outputs: %s", diff)
+	}
+
+	job.Captions = job.Captions[:1]
+	if groups, _ := subtitlesGroupsFrom(job); len(groups) != 1 || groups[0].id != "captions" {
+		t.Errorf("wrong single group: got %+v", groups)
+	}
+}
@@ -0,0 +1,167 @@
+package bitmovin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/cleanup"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/container"
+)
+
+var aesEncryptionMethods = map[db.EncryptionScheme]model.AesEncryptionMethod{
+	db.EncryptionSchemeAES128:    model.AesEncryptionMethod_AES_128,
+	db.EncryptionSchemeSampleAES: model.AesEncryptionMethod_SAMPLE_AES,
+}
+
+// validateEncryption checks that the outputs of a job can be encrypted, which
+// is only done for streaming outputs. HLS outputs in TS segments take AES
+// keys, DASH outputs in fMP4 segments take clear keys or DRM systems, and CMAF
+// outputs need keys from a key server.
+func validateEncryption(job *db.Job, presets []db.PresetSummary, hls, dash, perTitle bool) error {
+	e := job.StreamingParams.Encryption
+	if e == nil {
+		return nil
+	}
+	if e.KeyRotationInterval > 0 {
+		return errors.New("encryption: key rotation is not supported")
+	}
+	if perTitle {
+		return errors.New("encryption: per-title encodings can't be encrypted")
+	}
+
+	for _, preset := range presets {
+		switch containerFrom(preset, hls, dash) {
+		case containerHLS:
+			if _, ok := aesEncryptionMethods[e.Scheme]; !ok {
+				return fmt.Errorf("encryption: %s needs cmaf outputs", e.Scheme)
+			}
+		case containerDASH:
+			if e.KeyServerURL != "" && len(e.DRMSystems) == 0 {
+				return errors.New("encryption: dash outputs need drm systems")
+			}
+		case containerCMAFHLS:
+			if e.Scheme == db.EncryptionSchemeAES128 {
+				return fmt.Errorf("encryption: %s needs hls outputs in ts segments", e.Scheme)
+			}
+			if e.KeyServerURL == "" {
+				return errors.New("encryption: cmaf outputs need a key server")
+			}
+			if len(e.DRMSystems) == 0 {
+				return errors.New("encryption: cmaf outputs need drm systems")
+			}
+		default:
+			return fmt.Errorf("encryption: %s outputs can't be encrypted", preset.Container)
+		}
+	}
+	return nil
+}
+
+// drmDeleter deletes the DRM configurations of a type of muxing
+type drmDeleter interface {
+	Delete(encodingID, muxingID, drmID string) (*model.BitmovinResponse, error)
+}
+
+// encrypter returns the function creating the DRM configurations of the
+// muxings of an encoding, or nil when its outputs aren't encrypted
+func (p *bitmovinProvider) encrypter(ctx context.Context, tracker *cleanup.Tracker, encodingID string, e *db.Encryption) container.EncryptFunc {
+	if e == nil {
+		return nil
+	}
+
+	return func(muxing container.MuxingType, muxingID string, output model.EncodingOutput) (string, error) {
+		var (
+			drmID   string
+			deleter drmDeleter
+		)
+		err := p.call(ctx, "bitmovin-create-drm", false, func() (err error) {
+			drmID, deleter, err = p.createDRM(encodingID, muxing, muxingID, e, []model.EncodingOutput{output})
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		tracker.Track("drm", drmID, p.deleter(ctx, "bitmovin-delete-drm", func() error {
+			_, err := deleter.Delete(encodingID, muxingID, drmID)
+			return err
+		}))
+		return drmID, nil
+	}
+}
+
+// createDRM creates the DRM configuration of a muxing writing its encrypted
+// segments to outputs, with keys from the key server of the encryption or its
+// static key otherwise
+func (p *bitmovinProvider) createDRM(encodingID string, muxing container.MuxingType, muxingID string, e *db.Encryption, outputs []model.EncodingOutput) (string, drmDeleter, error) {
+	muxings := p.api.Encoding.Encodings.Muxings
+
+	if e.KeyServerURL != "" {
+		var (
+			drm *model.SpekeDrm
+			api drmDeleter
+			err error
+		)
+		switch muxing {
+		case container.MuxingTS:
+			drm, err = muxings.Ts.Drm.Speke.Create(encodingID, muxingID, spekeDRMFrom(e, outputs))
+			api = muxings.Ts.Drm.Speke
+		case container.MuxingFMP4:
+			drm, err = muxings.Fmp4.Drm.Speke.Create(encodingID, muxingID, spekeDRMFrom(e, outputs))
+			api = muxings.Fmp4.Drm.Speke
+		default:
+			drm, err = muxings.Cmaf.Drm.Speke.Create(encodingID, muxingID, spekeDRMFrom(e, outputs))
+			api = muxings.Cmaf.Drm.Speke
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return drm.Id, api, nil
+	}
+
+	switch muxing {
+	case container.MuxingTS:
+		drm, err := muxings.Ts.Drm.Aes.Create(encodingID, muxingID, model.AesEncryptionDrm{
+			Key:        e.Key,
+			Iv:         e.IV,
+			KeyFileUri: e.KeyURL,
+			Method:     aesEncryptionMethods[e.Scheme],
+			Outputs:    outputs,
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		return drm.Id, muxings.Ts.Drm.Aes, nil
+	case container.MuxingFMP4:
+		drm, err := muxings.Fmp4.Drm.Clearkey.Create(encodingID, muxingID, model.ClearKeyDrm{
+			Key:     e.Key,
+			Kid:     e.KeyID,
+			Outputs: outputs,
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		return drm.Id, muxings.Fmp4.Drm.Clearkey, nil
+	}
+	return "", nil, fmt.Errorf("%s muxings need a key server", muxing)
+}
+
+// spekeDRMFrom returns the configuration of the keys requested by Bitmovin
+// from the key server of an encryption, for the DRM systems it signals or for
+// clear AES keys
+func spekeDRMFrom(e *db.Encryption, outputs []model.EncodingOutput) model.SpekeDrm {
+	systemIDs := e.SystemIDs()
+	if len(systemIDs) == 0 {
+		systemIDs = []string{provider.AES128SystemID}
+	}
+	return model.SpekeDrm{
+		ContentId: e.ResourceID,
+		Kid:       e.KeyID,
+		Iv:        e.IV,
+		Provider:  &model.SpekeDrmProvider{Url: e.KeyServerURL},
+		SystemIds: systemIDs,
+		Outputs:   outputs,
+	}
+}
@@ -0,0 +1,142 @@
+package bitmovin
+
+import (
+	"testing"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateEncryption(t *testing.T) {
+	const key, keyServer = "3c1fa5d8b8e5c6a2f0b3d9e1a7c4f6b2", "https://keys.example.com/speke/v1.0/copyProtection"
+	h264 := []db.PresetSummary{{Container: "m3u8", VideoCodec: "h264", AudioCodec: "aac"}}
+	hevc := []db.PresetSummary{{Container: "m3u8", VideoCodec: "h265", AudioCodec: "aac"}}
+
+	tests := []struct {
+		name       string
+		encryption *db.Encryption
+		presets    []db.PresetSummary
+		hls, dash  bool
+		perTitle   bool
+		wantErr    string
+	}{
+		{
+			name:    "outputs in the clear are valid",
+			presets: append(h264, db.PresetSummary{Container: "mp4"}),
+			hls:     true,
+		},
+		{
+			name:       "hls outputs in ts segments take static aes keys",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, Key: key, KeyURL: "https://keys.example.com/key"},
+			presets:    h264,
+			hls:        true,
+		},
+		{
+			name:       "dash outputs in fmp4 segments take clear keys",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeCENC, Key: key, KeyID: key},
+			presets:    h264,
+			dash:       true,
+		},
+		{
+			name: "cmaf outputs take drm systems from a key server",
+			encryption: &db.Encryption{
+				Scheme: db.EncryptionSchemeCENC, KeyServerURL: keyServer, DRMSystems: []db.DRMSystem{db.DRMSystemWidevine},
+			},
+			presets: h264,
+			hls:     true,
+			dash:    true,
+		},
+		{
+			name:       "cenc can't be used in ts segments",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeCENC, KeyServerURL: keyServer},
+			presets:    h264,
+			hls:        true,
+			wantErr:    "encryption: cenc needs cmaf outputs",
+		},
+		{
+			name:       "aes-128 can't be used in cmaf segments",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: keyServer},
+			presets:    hevc,
+			hls:        true,
+			wantErr:    "encryption: aes-128 needs hls outputs in ts segments",
+		},
+		{
+			name:       "cmaf outputs need a key server",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeSampleAES, Key: key, KeyURL: "skd://key"},
+			presets:    hevc,
+			hls:        true,
+			wantErr:    "encryption: cmaf outputs need a key server",
+		},
+		{
+			name:       "dash outputs need drm systems from a key server",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeCENC, KeyServerURL: keyServer},
+			presets:    h264,
+			dash:       true,
+			wantErr:    "encryption: dash outputs need drm systems",
+		},
+		{
+			name:       "progressive outputs can't be encrypted",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, Key: key, KeyURL: "https://keys.example.com/key"},
+			presets:    append(h264, db.PresetSummary{Container: "mp4"}),
+			hls:        true,
+			wantErr:    "encryption: mp4 outputs can't be encrypted",
+		},
+		{
+			name:       "keys aren't rotated",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: keyServer, KeyRotationInterval: 60},
+			presets:    h264,
+			hls:        true,
+			wantErr:    "encryption: key rotation is not supported",
+		},
+		{
+			name:       "per-title encodings can't be encrypted",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: keyServer},
+			presets:    h264,
+			hls:        true,
+			perTitle:   true,
+			wantErr:    "encryption: per-title encodings can't be encrypted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &db.Job{StreamingParams: db.StreamingParams{Encryption: tt.encryption}}
+			err := validateEncryption(job, tt.presets, tt.hls, tt.dash, tt.perTitle)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSpekeDRMFrom(t *testing.T) {
+	outputs := []model.EncodingOutput{{OutputId: "output-1", OutputPath: "hls/720p"}}
+
+	got := spekeDRMFrom(&db.Encryption{
+		Scheme:       db.EncryptionSchemeSampleAES,
+		KeyServerURL: "https://keys.example.com",
+		ResourceID:   "job-1",
+		DRMSystems:   []db.DRMSystem{db.DRMSystemFairPlay},
+	}, outputs)
+	want := model.SpekeDrm{
+		ContentId: "job-1",
+		Provider:  &model.SpekeDrmProvider{Url: "https://keys.example.com"},
+		SystemIds: []string{"94ce86fb-07ff-4f43-adb8-93d2fa968ca2"},
+		Outputs:   outputs,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong speke drm: %s", diff)
+	}
+
+	aes := spekeDRMFrom(&db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: "https://keys.example.com"}, outputs)
+	if g, e := aes.SystemIds, []string{"3ea8778f-7742-4bf9-b18f-e834b2acbd47"}; !cmp.Equal(g, e) {
+		t.Errorf("wrong system ids: got %v, expected %v", g, e)
+	}
+}
@@ -0,0 +1,153 @@
+package bitmovin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+const featurePerTitle = "perTitle"
+
+// perTitleCacheSize is the number of finished encodings whose per-title
+// renditions are remembered
+const perTitleCacheSize = 500
+
+// PerTitle holds the parameters of per-title encoding. The video presets of the
+// job are the templates of the ladder, Bitmovin choosing the renditions that
+// are actually produced from the complexity of the content.
+type PerTitle struct {
+	// MinBitrate and MaxBitrate bound the bitrates of the renditions, in bps
+	MinBitrate int32 `json:"minBitrate,omitempty"`
+	MaxBitrate int32 `json:"maxBitrate,omitempty"`
+
+	// MinBitrateStepSize and MaxBitrateStepSize bound the ratio between the
+	// bitrates of two consecutive renditions
+	MinBitrateStepSize float64 `json:"minBitrateStepSize,omitempty"`
+	MaxBitrateStepSize float64 `json:"maxBitrateStepSize,omitempty"`
+
+	// TargetQualityCRF is the constant rate factor the renditions should match
+	TargetQualityCRF float64 `json:"targetQualityCrf,omitempty"`
+
+	// ComplexityFactor scales the bitrates computed from the complexity of the content
+	ComplexityFactor float64 `json:"complexityFactor,omitempty"`
+
+	// FixedResolution keeps the resolutions of the presets, only the bitrates
+	// of the renditions being chosen. Resolutions are chosen as well otherwise,
+	// the per-title configuration of the Bitmovin API not bounding them.
+	FixedResolution bool `json:"fixedResolution,omitempty"`
+}
+
+// perTitleFrom returns the per-title parameters of the execution features, or
+// nil if per-title encoding isn't requested
+func perTitleFrom(features db.ExecutionFeatures) (*PerTitle, error) {
+	definition, ok := features[featurePerTitle]
+	if !ok {
+		return nil, nil
+	}
+
+	featureJSON, err := json.Marshal(definition)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal per-title cfg to json: %v", err)
+	}
+
+	var perTitle PerTitle
+	if err = json.Unmarshal(featureJSON, &perTitle); err != nil {
+		return nil, fmt.Errorf("could not unmarshal %q into PerTitle feature: %v", definition, err)
+	}
+
+	if perTitle.MinBitrate < 0 || perTitle.MaxBitrate < 0 {
+		return nil, errors.New("per-title bitrates must be positive")
+	}
+	if perTitle.MaxBitrate > 0 && perTitle.MinBitrate > perTitle.MaxBitrate {
+		return nil, fmt.Errorf("per-title minimum bitrate %d is above the maximum bitrate %d", perTitle.MinBitrate, perTitle.MaxBitrate)
+	}
+
+	return &perTitle, nil
+}
+
+// streamMode returns the mode of the video streams used as templates
+func (pt *PerTitle) streamMode() model.StreamMode {
+	if pt.FixedResolution {
+		return model.StreamMode_PER_TITLE_TEMPLATE_FIXED_RESOLUTION
+	}
+	return model.StreamMode_PER_TITLE_TEMPLATE
+}
+
+// startConfig returns the per-title configuration of the encoding for the
+// codecs of its templates
+func (pt *PerTitle) startConfig(h264, h265 bool) *model.PerTitle {
+	var cfg model.PerTitle
+	if h264 {
+		cfg.H264Configuration = &model.H264PerTitleConfiguration{
+			MinBitrate:         int32PtrOrNil(pt.MinBitrate),
+			MaxBitrate:         int32PtrOrNil(pt.MaxBitrate),
+			MinBitrateStepSize: floatPtrOrNil(pt.MinBitrateStepSize),
+			MaxBitrateStepSize: floatPtrOrNil(pt.MaxBitrateStepSize),
+			TargetQualityCrf:   floatPtrOrNil(pt.TargetQualityCRF),
+			ComplexityFactor:   floatPtrOrNil(pt.ComplexityFactor),
+		}
+	}
+	if h265 {
+		cfg.H265Configuration = &model.H265PerTitleConfiguration{
+			MinBitrate:         int32PtrOrNil(pt.MinBitrate),
+			MaxBitrate:         int32PtrOrNil(pt.MaxBitrate),
+			MinBitrateStepSize: floatPtrOrNil(pt.MinBitrateStepSize),
+			MaxBitrateStepSize: floatPtrOrNil(pt.MaxBitrateStepSize),
+			TargetQualityCrf:   floatPtrOrNil(pt.TargetQualityCRF),
+			ComplexityFactor:   floatPtrOrNil(pt.ComplexityFactor),
+		}
+	}
+	return &cfg
+}
+
+// perTitleCache remembers the per-title renditions of finished encodings, which
+// don't change anymore, so they're listed once rather than on every status
+// check. The oldest encodings are forgotten once the cache is full.
+type perTitleCache struct {
+	mu    sync.Mutex
+	files map[string][]provider.OutputFile
+	order []string
+}
+
+func (c *perTitleCache) get(encodingID string) ([]provider.OutputFile, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files, ok := c.files[encodingID]
+	return files, ok
+}
+
+func (c *perTitleCache) add(encodingID string, files []provider.OutputFile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.files == nil {
+		c.files = make(map[string][]provider.OutputFile)
+	}
+	if _, ok := c.files[encodingID]; ok {
+		return
+	}
+	if len(c.order) == perTitleCacheSize {
+		delete(c.files, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.files[encodingID] = files
+	c.order = append(c.order, encodingID)
+}
+
+func int32PtrOrNil(i int32) *int32 {
+	if i == 0 {
+		return nil
+	}
+	return &i
+}
+
+func floatPtrOrNil(f float64) *float64 {
+	if f == 0 {
+		return nil
+	}
+	return &f
+}
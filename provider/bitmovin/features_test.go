@@ -0,0 +1,166 @@
+package bitmovin
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPerTitleFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		features db.ExecutionFeatures
+		want     *PerTitle
+		wantErr  string
+	}{
+		{
+			name:     "per-title encoding isn't requested",
+			features: db.ExecutionFeatures{"segmentedRendering": map[string]interface{}{"duration": 50}},
+		},
+		{
+			name: "the per-title parameters are parsed",
+			features: db.ExecutionFeatures{featurePerTitle: map[string]interface{}{
+				"minBitrate":       300000,
+				"maxBitrate":       6000000,
+				"targetQualityCrf": 23,
+				"fixedResolution":  true,
+			}},
+			want: &PerTitle{MinBitrate: 300000, MaxBitrate: 6000000, TargetQualityCRF: 23, FixedResolution: true},
+		},
+		{
+			name:     "an empty definition uses the defaults of the provider",
+			features: db.ExecutionFeatures{featurePerTitle: map[string]interface{}{}},
+			want:     &PerTitle{},
+		},
+		{
+			name:     "the minimum bitrate can't be above the maximum",
+			features: db.ExecutionFeatures{featurePerTitle: map[string]interface{}{"minBitrate": 500000, "maxBitrate": 400000}},
+			wantErr:  "per-title minimum bitrate 500000 is above the maximum bitrate 400000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := perTitleFrom(tt.features)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong per-title parameters: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPerTitleStartConfig(t *testing.T) {
+	pt := PerTitle{MinBitrate: 300000, TargetQualityCRF: 23}
+
+	cfg := pt.startConfig(true, false)
+	if cfg.H265Configuration != nil {
+		t.Error("unexpected h265 configuration")
+	}
+	minBitrate, crf := int32(300000), float64(23)
+	expected := &model.H264PerTitleConfiguration{MinBitrate: &minBitrate, TargetQualityCrf: &crf}
+	if diff := cmp.Diff(expected, cfg.H264Configuration); diff != "" {
+		t.Errorf("wrong h264 configuration: %s", diff)
+	}
+
+	if g, e := pt.streamMode(), model.StreamMode_PER_TITLE_TEMPLATE; g != e {
+		t.Errorf("wrong stream mode: got %q, expected %q", g, e)
+	}
+	pt.FixedResolution = true
+	if g, e := pt.streamMode(), model.StreamMode_PER_TITLE_TEMPLATE_FIXED_RESOLUTION; g != e {
+		t.Errorf("wrong stream mode: got %q, expected %q", g, e)
+	}
+}
+
+func TestValidatePerTitle(t *testing.T) {
+	tests := []struct {
+		name               string
+		job                db.Job
+		presets            []db.PresetSummary
+		dash               bool
+		wantH264, wantH265 bool
+		wantErr            string
+	}{
+		{
+			name: "hls ladder with audio",
+			presets: []db.PresetSummary{
+				{Container: containerHLS, VideoCodec: "H264", AudioCodec: "AAC"},
+				{Container: containerHLS, VideoCodec: "H265"},
+			},
+			wantH264: true,
+			wantH265: true,
+		},
+		{
+			name:    "progressive outputs are rejected",
+			presets: []db.PresetSummary{{Container: containerMP4, VideoCodec: "H264"}},
+			wantErr: `per-title encoding doesn't support "mp4" outputs`,
+		},
+		{
+			name:    "other codecs are rejected",
+			presets: []db.PresetSummary{{Container: containerHLS, VideoCodec: "AV1"}},
+			wantErr: `per-title encoding doesn't support the "av1" video codec`,
+		},
+		{
+			name:    "dash is rejected",
+			presets: []db.PresetSummary{{Container: containerHLS, VideoCodec: "H264"}},
+			dash:    true,
+			wantErr: "per-title encoding is only supported with hls",
+		},
+		{
+			name:    "audio only jobs are rejected",
+			presets: []db.PresetSummary{{Container: containerHLS, AudioCodec: "AAC"}},
+			wantErr: "per-title encoding requires a video output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h264, h265, err := validatePerTitle(&tt.job, tt.presets, tt.dash)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if h264 != tt.wantH264 || h265 != tt.wantH265 {
+				t.Errorf("wrong codecs: got h264=%t h265=%t, expected h264=%t h265=%t", h264, h265, tt.wantH264, tt.wantH265)
+			}
+		})
+	}
+}
+
+func TestPerTitleCache(t *testing.T) {
+	var c perTitleCache
+	if _, ok := c.get("enc-0"); ok {
+		t.Fatal("expected an empty cache")
+	}
+
+	for i := 0; i <= perTitleCacheSize; i++ {
+		c.add(fmt.Sprintf("enc-%d", i), []provider.OutputFile{{Path: fmt.Sprintf("enc-%d/video.m3u8", i)}})
+	}
+	if _, ok := c.get("enc-0"); ok {
+		t.Error("expected the oldest encoding to be forgotten once the cache is full")
+	}
+	files, ok := c.get("enc-1")
+	if !ok {
+		t.Fatal("expected the other encodings to be remembered")
+	}
+	if diff := cmp.Diff([]provider.OutputFile{{Path: "enc-1/video.m3u8"}}, files); diff != "" {
+		t.Errorf("wrong renditions: %s", diff)
+	}
+}
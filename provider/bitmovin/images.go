@@ -0,0 +1,237 @@
+package bitmovin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go"
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/storage"
+)
+
+// minThumbnailInterval is the shortest interval Bitmovin takes thumbnails at
+const minThumbnailInterval = 1
+
+// validateImageOutputs checks that the image outputs of a job can be taken from
+// one of its video streams
+func validateImageOutputs(job *db.Job, presets []db.PresetSummary, perTitle bool) error {
+	if len(job.ImageOutputs) == 0 {
+		return nil
+	}
+	if perTitle {
+		return errors.New("image outputs are not supported with per-title encoding")
+	}
+	if imageStreamOutput(presets) < 0 {
+		return errors.New("image outputs need an output with video")
+	}
+
+	for _, o := range job.ImageOutputs {
+		switch o.Kind {
+		case db.ImageOutputKindThumbnails, db.ImageOutputKindPoster:
+			if o.Height == 0 {
+				return fmt.Errorf("image output %q needs a height, the width follows the aspect ratio", o.FileName)
+			}
+			if o.Interval > 0 && o.Interval < minThumbnailInterval {
+				return fmt.Errorf("image output %q can't take thumbnails more than once per second", o.FileName)
+			}
+		case db.ImageOutputKindSprite:
+			if o.Width == 0 || o.Height == 0 {
+				return fmt.Errorf("sprite %q needs a width and a height", o.FileName)
+			}
+		}
+	}
+	return nil
+}
+
+// imageStreamOutput returns the index of the output whose video stream the
+// images are taken from, the first one with video, or -1
+func imageStreamOutput(presets []db.PresetSummary) int {
+	for i, preset := range presets {
+		if preset.HasVideo() {
+			return i
+		}
+	}
+	return -1
+}
+
+// iFramePlaylists returns whether the HLS video streams of a job get I-frame
+// playlists for trick-play
+func iFramePlaylists(job *db.Job) bool {
+	for _, o := range job.ImageOutputs {
+		if o.Playlist {
+			return true
+		}
+	}
+	return false
+}
+
+// createImages adds the thumbnails and sprites of the image outputs of a job to
+// a video stream of the encoding, at offsets moved along with the splice
+func (p *bitmovinProvider) createImages(ctx context.Context, cfg outputCfg, streamID string) error {
+	splice := provider.FrameAccurateSplice(cfg.job.SourceSplice, cfg.job.SourceInfo.FrameRate)
+	for _, o := range cfg.job.ImageOutputs {
+		output := storage.EncodingOutputFrom(cfg.outputID, path.Join(cfg.destPath, path.Dir(o.FileName)))
+
+		if o.Kind == db.ImageOutputKindSprite {
+			var sprite *model.Sprite
+			err := p.call(ctx, "bitmovin-create-sprite", false, func() (err error) {
+				sprite, err = p.api.Encoding.Encodings.Streams.Sprites.Create(cfg.encodingID, streamID, spriteFrom(o, output))
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("creating sprite %q: %w", o.FileName, err)
+			}
+			cfg.tracker.Track("sprite", sprite.Id, p.deleter(ctx, "bitmovin-delete-sprite", func() error {
+				_, err := p.api.Encoding.Encodings.Streams.Sprites.Delete(cfg.encodingID, streamID, sprite.Id)
+				return err
+			}))
+			continue
+		}
+
+		thumbnail, err := thumbnailFrom(o, provider.SplicedOffsets(o.Offsets, splice), output)
+		if err != nil {
+			return fmt.Errorf("image output %q: %w", o.FileName, err)
+		}
+		var created *model.Thumbnail
+		err = p.call(ctx, "bitmovin-create-thumbnail", false, func() (err error) {
+			created, err = p.api.Encoding.Encodings.Streams.Thumbnails.Create(cfg.encodingID, streamID, thumbnail)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("creating thumbnails %q: %w", o.FileName, err)
+		}
+		cfg.tracker.Track("thumbnail", created.Id, p.deleter(ctx, "bitmovin-delete-thumbnail", func() error {
+			_, err := p.api.Encoding.Encodings.Streams.Thumbnails.Delete(cfg.encodingID, streamID, created.Id)
+			return err
+		}))
+	}
+	return nil
+}
+
+// thumbnailFrom returns the thumbnails of an image output, numbered by Bitmovin
+// after their position. A poster is a single thumbnail named after the output.
+func thumbnailFrom(o db.ImageOutput, offsets []float64, output model.EncodingOutput) (model.Thumbnail, error) {
+	thumbnail := model.Thumbnail{
+		Height:  bitmovin.Int32Ptr(int32(o.Height)),
+		Pattern: numberedFileName(o),
+		Outputs: []model.EncodingOutput{output},
+	}
+
+	switch {
+	case o.Kind == db.ImageOutputKindPoster:
+		if len(o.Offsets) > 0 && len(offsets) == 0 {
+			return model.Thumbnail{}, errors.New("the poster offset is cut out by the splice")
+		}
+		thumbnail.Pattern = path.Base(o.FileName)
+		thumbnail.Positions = []float64{0}
+		if len(offsets) > 0 {
+			thumbnail.Positions = offsets[:1]
+		}
+		thumbnail.Unit = model.ThumbnailUnit_SECONDS
+	case len(o.Offsets) > 0:
+		if len(offsets) == 0 {
+			return model.Thumbnail{}, errors.New("every offset is cut out by the splice")
+		}
+		thumbnail.Positions = offsets
+		thumbnail.Unit = model.ThumbnailUnit_SECONDS
+	default:
+		thumbnail.Interval = bitmovin.Float64Ptr(o.Interval)
+	}
+	return thumbnail, nil
+}
+
+// spriteFrom returns the sprite of an image output, tiles being written to
+// several numbered sheets when it has columns and rows
+func spriteFrom(o db.ImageOutput, output model.EncodingOutput) model.Sprite {
+	sprite := model.Sprite{
+		Width:      bitmovin.Int32Ptr(int32(o.Width)),
+		Height:     bitmovin.Int32Ptr(int32(o.Height)),
+		Unit:       model.SpriteUnit_SECONDS,
+		Distance:   bitmovin.Float64Ptr(o.Interval),
+		SpriteName: path.Base(o.FileName),
+		VttName:    path.Base(o.VTTFileName()),
+		Outputs:    []model.EncodingOutput{output},
+	}
+	if perSheet := o.Columns * o.Rows; perSheet > 0 {
+		sprite.SpriteName = numberedFileName(o)
+		sprite.ImagesPerFile = bitmovin.Int32Ptr(int32(perSheet))
+	}
+	return sprite
+}
+
+// numberedFileName returns the base name of an image output with a number
+// placeholder before its extension
+func numberedFileName(o db.ImageOutput) string {
+	name := path.Base(o.FileName)
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "_%number%" + ext
+}
+
+// imageFilesFrom returns the files of the image outputs of a job, written under
+// destination. Images taken at an interval are only listed when the duration of
+// the output is known.
+func imageFilesFrom(job *db.Job, destination string, duration time.Duration) []provider.OutputFile {
+	splice := provider.FrameAccurateSplice(job.SourceSplice, job.SourceInfo.FrameRate)
+	if len(splice) > 0 {
+		duration = splice.Size()
+	}
+
+	var files []provider.OutputFile
+	add := func(o db.ImageOutput, name string) {
+		files = append(files, provider.OutputFile{
+			Path:      destination + path.Join(path.Dir(o.FileName), name),
+			Container: strings.TrimPrefix(path.Ext(name), "."),
+			Type:      provider.OutputFileTypeImage,
+			Width:     int64(o.Width),
+			Height:    int64(o.Height),
+		})
+	}
+
+	for _, o := range job.ImageOutputs {
+		var count int
+		if o.Interval > 0 {
+			count = int(math.Ceil(duration.Seconds() / o.Interval))
+		}
+
+		switch {
+		case o.Kind == db.ImageOutputKindPoster:
+			add(o, path.Base(o.FileName))
+		case o.Kind == db.ImageOutputKindSprite:
+			add(o, path.Base(o.VTTFileName()))
+			if perSheet := int(o.Columns * o.Rows); perSheet > 0 {
+				for i := 0; i < (count+perSheet-1)/perSheet; i++ {
+					add(o, strings.Replace(numberedFileName(o), "%number%", strconv.Itoa(i), 1))
+				}
+			} else {
+				add(o, path.Base(o.FileName))
+			}
+		case len(o.Offsets) > 0:
+			for _, offset := range provider.SplicedOffsets(o.Offsets, splice) {
+				add(o, strings.Replace(numberedFileName(o), "%number%", thumbnailPosition(offset), 1))
+			}
+		default:
+			for i := 0; i < count; i++ {
+				add(o, strings.Replace(numberedFileName(o), "%number%", thumbnailPosition(float64(i)*o.Interval), 1))
+			}
+		}
+	}
+	return files
+}
+
+// thumbnailPosition formats a position the way Bitmovin numbers thumbnails,
+// 25.5 seconds becoming 25_5
+func thumbnailPosition(seconds float64) string {
+	s := strconv.FormatFloat(seconds, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return strings.Replace(s, ".", "_", 1)
+}
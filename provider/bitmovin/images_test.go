@@ -0,0 +1,201 @@
+package bitmovin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go"
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/pkg/timecode"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateImageOutputs(t *testing.T) {
+	video := []db.PresetSummary{{Container: "mp4", VideoConfigID: "video"}}
+
+	tests := []struct {
+		name     string
+		outputs  []db.ImageOutput
+		presets  []db.PresetSummary
+		perTitle bool
+		wantErr  string
+	}{
+		{
+			name: "thumbnails with a height and sprites with dimensions are valid",
+			outputs: []db.ImageOutput{
+				{Kind: db.ImageOutputKindThumbnails, FileName: "t.jpg", Height: 180, Interval: 5},
+				{Kind: db.ImageOutputKindSprite, FileName: "s.jpg", Width: 160, Height: 90, Interval: 2},
+			},
+			presets: video,
+		},
+		{
+			name:     "per-title encodings can't have images",
+			outputs:  []db.ImageOutput{{Kind: db.ImageOutputKindPoster, FileName: "p.jpg", Height: 720}},
+			presets:  video,
+			perTitle: true,
+			wantErr:  "image outputs are not supported with per-title encoding",
+		},
+		{
+			name:    "images need a video stream",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindPoster, FileName: "p.jpg", Height: 720}},
+			presets: []db.PresetSummary{{Container: "mp4", AudioConfigID: "audio"}},
+			wantErr: "image outputs need an output with video",
+		},
+		{
+			name:    "thumbnails need a height",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindThumbnails, FileName: "t.jpg", Width: 320, Interval: 5}},
+			presets: video,
+			wantErr: `image output "t.jpg" needs a height, the width follows the aspect ratio`,
+		},
+		{
+			name:    "thumbnails are taken at most once per second",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindThumbnails, FileName: "t.jpg", Height: 180, Interval: 0.5}},
+			presets: video,
+			wantErr: `image output "t.jpg" can't take thumbnails more than once per second`,
+		},
+		{
+			name:    "sprites need both dimensions",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindSprite, FileName: "s.jpg", Height: 90, Interval: 2}},
+			presets: video,
+			wantErr: `sprite "s.jpg" needs a width and a height`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateImageOutputs(&db.Job{ImageOutputs: tt.outputs}, tt.presets, tt.perTitle)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestThumbnailFrom(t *testing.T) {
+	output := model.EncodingOutput{OutputId: "output", OutputPath: "job/thumbs"}
+
+	tests := []struct {
+		name    string
+		image   db.ImageOutput
+		offsets []float64
+		want    model.Thumbnail
+		wantErr string
+	}{
+		{
+			name:  "thumbnails at an interval",
+			image: db.ImageOutput{Kind: db.ImageOutputKindThumbnails, FileName: "thumbs/thumb.jpg", Height: 180, Interval: 10},
+			want: model.Thumbnail{
+				Height:   bitmovin.Int32Ptr(180),
+				Pattern:  "thumb_%number%.jpg",
+				Interval: bitmovin.Float64Ptr(10),
+				Outputs:  []model.EncodingOutput{output},
+			},
+		},
+		{
+			name:    "thumbnails at spliced offsets",
+			image:   db.ImageOutput{Kind: db.ImageOutputKindThumbnails, FileName: "thumbs/thumb.png", Height: 180, Offsets: []float64{5, 40}},
+			offsets: []float64{5, 20},
+			want: model.Thumbnail{
+				Height:    bitmovin.Int32Ptr(180),
+				Pattern:   "thumb_%number%.png",
+				Positions: []float64{5, 20},
+				Unit:      model.ThumbnailUnit_SECONDS,
+				Outputs:   []model.EncodingOutput{output},
+			},
+		},
+		{
+			name:  "posters default to the start",
+			image: db.ImageOutput{Kind: db.ImageOutputKindPoster, FileName: "poster.jpg", Height: 720},
+			want: model.Thumbnail{
+				Height:    bitmovin.Int32Ptr(720),
+				Pattern:   "poster.jpg",
+				Positions: []float64{0},
+				Unit:      model.ThumbnailUnit_SECONDS,
+				Outputs:   []model.EncodingOutput{output},
+			},
+		},
+		{
+			name:    "offsets cut out by the splice are rejected",
+			image:   db.ImageOutput{Kind: db.ImageOutputKindThumbnails, FileName: "thumb.jpg", Height: 180, Offsets: []float64{12}},
+			wantErr: "every offset is cut out by the splice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := thumbnailFrom(tt.image, tt.offsets, output)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong thumbnail: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSpriteFrom(t *testing.T) {
+	output := model.EncodingOutput{OutputId: "output", OutputPath: "job/sprites"}
+	image := db.ImageOutput{Kind: db.ImageOutputKindSprite, FileName: "sprites/sprite.jpg", Width: 160, Height: 90, Interval: 2, Columns: 10, Rows: 5}
+
+	want := model.Sprite{
+		Width:         bitmovin.Int32Ptr(160),
+		Height:        bitmovin.Int32Ptr(90),
+		Unit:          model.SpriteUnit_SECONDS,
+		Distance:      bitmovin.Float64Ptr(2),
+		SpriteName:    "sprite_%number%.jpg",
+		VttName:       "sprite.vtt",
+		ImagesPerFile: bitmovin.Int32Ptr(50),
+		Outputs:       []model.EncodingOutput{output},
+	}
+	if diff := cmp.Diff(want, spriteFrom(image, output)); diff != "" {
+		t.Errorf("wrong sprite: %s", diff)
+	}
+
+	image.Columns, image.Rows = 0, 0
+	if g, e := spriteFrom(image, output).SpriteName, "sprite.jpg"; g != e {
+		t.Errorf("wrong single sheet name: got %q, expected %q", g, e)
+	}
+}
+
+func TestImageFilesFrom(t *testing.T) {
+	job := &db.Job{
+		SourceSplice: timecode.Splice{{0, 5}},
+		ImageOutputs: []db.ImageOutput{
+			{Kind: db.ImageOutputKindThumbnails, FileName: "thumbs/thumb.jpg", Height: 180, Interval: 2},
+			{Kind: db.ImageOutputKindPoster, FileName: "poster.png", Height: 720},
+			{Kind: db.ImageOutputKindSprite, FileName: "sprite.jpg", Width: 160, Height: 90, Interval: 1, Columns: 2, Rows: 2},
+		},
+	}
+
+	image := func(path, container string, width, height int64) provider.OutputFile {
+		return provider.OutputFile{Path: path, Container: container, Type: provider.OutputFileTypeImage, Width: width, Height: height}
+	}
+	want := []provider.OutputFile{
+		image("s3://bucket/job/thumbs/thumb_0_0.jpg", "jpg", 0, 180),
+		image("s3://bucket/job/thumbs/thumb_2_0.jpg", "jpg", 0, 180),
+		image("s3://bucket/job/thumbs/thumb_4_0.jpg", "jpg", 0, 180),
+		image("s3://bucket/job/poster.png", "png", 0, 720),
+		image("s3://bucket/job/sprite.vtt", "vtt", 160, 90),
+		image("s3://bucket/job/sprite_0.jpg", "jpg", 160, 90),
+		image("s3://bucket/job/sprite_1.jpg", "jpg", 160, 90),
+	}
+
+	if diff := cmp.Diff(want, imageFilesFrom(job, "s3://bucket/job/", time.Minute)); diff != "" {
+		t.Errorf("wrong files: %s", diff)
+	}
+}
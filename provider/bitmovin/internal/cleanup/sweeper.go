@@ -0,0 +1,139 @@
+package cleanup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/bitmovin/bitmovin-api-sdk-go/pagination"
+	"github.com/bitmovin/bitmovin-api-sdk-go/query"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/container"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CustomDataKeyOrchestrator is the custom data key marking the encodings
+	// created by the orchestrator
+	CustomDataKeyOrchestrator = "orchestrator"
+	// CustomDataKeyJobID is the key used to store the ID of the job an
+	// encoding was created for
+	CustomDataKeyJobID = "jobId"
+
+	listPageSize = 100
+)
+
+// EncodingsAPI contains methods for finding and deleting encodings
+type EncodingsAPI interface {
+	List(...func(*query.EncodingListQueryParams)) (*pagination.EncodingsListPagination, error)
+	Delete(string) (*model.BitmovinResponse, error)
+}
+
+// CustomDataAPI contains methods for retrieving the custom data of encodings
+type CustomDataAPI interface {
+	Get(string) (*model.CustomData, error)
+}
+
+// ManifestsAPI contains methods for deleting manifests
+type ManifestsAPI interface {
+	Delete(string) (*model.BitmovinResponse, error)
+}
+
+// Sweeper finds the encodings the orchestrator created but never started,
+// because the submission failed without being able to clean up after itself,
+// and deletes them along with their manifests.
+type Sweeper struct {
+	Encodings  EncodingsAPI
+	CustomData CustomDataAPI
+	Manifests  ManifestsAPI
+	DASH       ManifestsAPI
+
+	// MinAge is the age after which an encoding that wasn't started is
+	// considered abandoned
+	MinAge time.Duration
+	Now    func() time.Time
+}
+
+// Sweep deletes the abandoned encodings and returns their IDs. Failing to
+// delete an encoding doesn't prevent the others from being deleted.
+func (s *Sweeper) Sweep() ([]string, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	cutoff := now().Add(-s.MinAge)
+
+	var stale []model.Encoding
+	for offset := int32(0); ; {
+		page, err := s.Encodings.List(func(params *query.EncodingListQueryParams) {
+			params.Status = string(model.Status_CREATED)
+			params.Sort = "createdAt:asc"
+			params.Offset = offset
+			params.Limit = listPageSize
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "listing created encodings")
+		}
+		done := len(page.Items) == 0
+		for _, enc := range page.Items {
+			if enc.CreatedAt == nil || !enc.CreatedAt.Before(cutoff) {
+				done = true
+				break
+			}
+			stale = append(stale, enc)
+		}
+		offset += int32(len(page.Items))
+		if done || (page.TotalCount != nil && int64(offset) >= *page.TotalCount) {
+			break
+		}
+	}
+
+	var deleted, errs []string
+	for _, enc := range stale {
+		ok, err := s.delete(enc.Id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("encoding %q: %s", enc.Id, err))
+		}
+		if ok {
+			deleted = append(deleted, enc.Id)
+		}
+	}
+	if len(errs) > 0 {
+		return deleted, fmt.Errorf("sweeping encodings: %s", strings.Join(errs, "; "))
+	}
+	return deleted, nil
+}
+
+// delete deletes the encoding and its manifests if it was created by the
+// orchestrator, and reports whether the encoding was deleted
+func (s *Sweeper) delete(encodingID string) (bool, error) {
+	data, err := s.CustomData.Get(encodingID)
+	if err != nil {
+		return false, errors.Wrap(err, "retrieving custom data")
+	}
+	if _, err := types.CustomDataStringValAtKeys(data.CustomData, CustomDataKeyOrchestrator, CustomDataKeyJobID); err != nil {
+		return false, nil
+	}
+
+	if _, err = s.Encodings.Delete(encodingID); err != nil {
+		return false, errors.Wrap(err, "deleting encoding")
+	}
+	manifests := []struct {
+		key string
+		api ManifestsAPI
+	}{
+		{container.CustomDataKeyManifestID, s.Manifests},
+		{container.CustomDataKeyDASHManifestID, s.DASH},
+	}
+	for _, m := range manifests {
+		manifestID, err := types.CustomDataStringValAtKeys(data.CustomData, container.CustomDataKeyManifest, m.key)
+		if err != nil || manifestID == "" || m.api == nil {
+			continue
+		}
+		if _, err = m.api.Delete(manifestID); err != nil {
+			return true, errors.Wrapf(err, "deleting manifest %q", manifestID)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,123 @@
+package cleanup
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/bitmovin/bitmovin-api-sdk-go/pagination"
+	"github.com/bitmovin/bitmovin-api-sdk-go/query"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSweeperSweep(t *testing.T) {
+	now := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	createdAt := func(age time.Duration) *time.Time {
+		t := now.Add(-age)
+		return &t
+	}
+
+	encodings := &fakeEncodingsAPI{
+		encodings: []model.Encoding{
+			{Id: "abandoned-hls", CreatedAt: createdAt(3 * time.Hour)},
+			{Id: "not-ours", CreatedAt: createdAt(3 * time.Hour)},
+			{Id: "undeletable", CreatedAt: createdAt(2 * time.Hour)},
+			{Id: "abandoned", CreatedAt: createdAt(2 * time.Hour)},
+			{Id: "recent", CreatedAt: createdAt(10 * time.Minute)},
+		},
+		deleteErrs: map[string]error{"undeletable": errors.New("forbidden")},
+	}
+	orchestrator := map[string]interface{}{CustomDataKeyJobID: "job-id"}
+	customData := fakeCustomDataAPI{
+		"abandoned-hls": {
+			CustomDataKeyOrchestrator: orchestrator,
+			"manifest":                {"id": "manifest-id", "dashId": "dash-manifest-id"},
+		},
+		"not-ours":    {},
+		"undeletable": {CustomDataKeyOrchestrator: orchestrator},
+		"abandoned":   {CustomDataKeyOrchestrator: orchestrator},
+		"recent":      {CustomDataKeyOrchestrator: orchestrator},
+	}
+	manifests, dashManifests := &fakeManifestsAPI{}, &fakeManifestsAPI{}
+
+	sweeper := Sweeper{
+		Encodings:  encodings,
+		CustomData: customData,
+		Manifests:  manifests,
+		DASH:       dashManifests,
+		MinAge:     time.Hour,
+		Now:        func() time.Time { return now },
+	}
+	deleted, err := sweeper.Sweep()
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if g, e := err.Error(), `sweeping encodings: encoding "undeletable": deleting encoding: forbidden`; g != e {
+		t.Errorf("wrong error: got %q, expected %q", g, e)
+	}
+	if diff := cmp.Diff([]string{"abandoned-hls", "abandoned"}, deleted); diff != "" {
+		t.Errorf("wrong deleted encodings: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"abandoned-hls", "abandoned"}, encodings.deleted); diff != "" {
+		t.Errorf("wrong encodings deleted from the api: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"manifest-id"}, manifests.deleted); diff != "" {
+		t.Errorf("wrong manifests deleted: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"dash-manifest-id"}, dashManifests.deleted); diff != "" {
+		t.Errorf("wrong dash manifests deleted: %s", diff)
+	}
+	if g, e := encodings.params.Status, string(model.Status_CREATED); g != e {
+		t.Errorf("wrong status filter: got %q, expected %q", g, e)
+	}
+}
+
+type fakeEncodingsAPI struct {
+	encodings  []model.Encoding
+	deleteErrs map[string]error
+	deleted    []string
+	params     query.EncodingListQueryParams
+}
+
+func (a *fakeEncodingsAPI) List(queryParams ...func(*query.EncodingListQueryParams)) (*pagination.EncodingsListPagination, error) {
+	params := query.EncodingListQueryParams{}
+	for _, fn := range queryParams {
+		fn(&params)
+	}
+	a.params = params
+
+	total := int64(len(a.encodings))
+	page := &pagination.EncodingsListPagination{TotalCount: &total}
+	for i := params.Offset; i < int32(total) && i < params.Offset+2; i++ {
+		page.Items = append(page.Items, a.encodings[i])
+	}
+	return page, nil
+}
+
+func (a *fakeEncodingsAPI) Delete(id string) (*model.BitmovinResponse, error) {
+	if err := a.deleteErrs[id]; err != nil {
+		return nil, err
+	}
+	a.deleted = append(a.deleted, id)
+	return &model.BitmovinResponse{Id: id}, nil
+}
+
+type fakeCustomDataAPI map[string]map[string]map[string]interface{}
+
+func (a fakeCustomDataAPI) Get(id string) (*model.CustomData, error) {
+	data, ok := a[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &model.CustomData{CustomData: &data}, nil
+}
+
+type fakeManifestsAPI struct {
+	deleted []string
+}
+
+func (a *fakeManifestsAPI) Delete(id string) (*model.BitmovinResponse, error) {
+	a.deleted = append(a.deleted, id)
+	return &model.BitmovinResponse{Id: id}, nil
+}
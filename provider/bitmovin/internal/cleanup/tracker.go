@@ -0,0 +1,56 @@
+package cleanup
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Tracker records the resources created while submitting a job, so they can
+// be deleted if the submission fails halfway through. It is safe for
+// concurrent use.
+type Tracker struct {
+	mu        sync.Mutex
+	resources []resource
+}
+
+type resource struct {
+	kind, id string
+	del      func() error
+}
+
+// Track records a created resource along with the function deleting it.
+func (t *Tracker) Track(kind, id string, del func() error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resources = append(t.resources, resource{kind: kind, id: id, del: del})
+}
+
+// Len returns the number of tracked resources.
+func (t *Tracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.resources)
+}
+
+// Rollback deletes the tracked resources in the reverse order of their
+// creation, carrying on when a deletion fails. The returned error lists the
+// resources that could not be deleted.
+func (t *Tracker) Rollback() error {
+	t.mu.Lock()
+	resources := t.resources
+	t.resources = nil
+	t.mu.Unlock()
+
+	var errs []string
+	for i := len(resources) - 1; i >= 0; i-- {
+		r := resources[i]
+		if err := r.del(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s %q: %s", r.kind, r.id, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("deleting resources: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
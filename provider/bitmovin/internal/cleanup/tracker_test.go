@@ -0,0 +1,46 @@
+package cleanup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTrackerRollback(t *testing.T) {
+	var deleted []string
+	deleter := func(id string, err error) func() error {
+		return func() error {
+			deleted = append(deleted, id)
+			return err
+		}
+	}
+
+	tracker := &Tracker{}
+	tracker.Track("hls manifest", "manifest-id", deleter("manifest-id", nil))
+	tracker.Track("encoding", "encoding-id", deleter("encoding-id", errors.New("encoding is running")))
+	tracker.Track("ingest input stream", "ingest-id", deleter("ingest-id", nil))
+	tracker.Track("audio stream", "stream-id", deleter("stream-id", errors.New("not found")))
+
+	err := tracker.Rollback()
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if g, e := err.Error(), `deleting resources: audio stream "stream-id": not found; encoding "encoding-id": encoding is running`; g != e {
+		t.Errorf("wrong error: got %q, expected %q", g, e)
+	}
+	if diff := cmp.Diff([]string{"stream-id", "ingest-id", "encoding-id", "manifest-id"}, deleted); diff != "" {
+		t.Errorf("resources were not deleted in reverse order: %s", diff)
+	}
+
+	if g := tracker.Len(); g != 0 {
+		t.Errorf("expected no resources to be tracked after a rollback, got %d", g)
+	}
+	deleted = nil
+	if err = tracker.Rollback(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("resources were deleted twice: %v", deleted)
+	}
+}
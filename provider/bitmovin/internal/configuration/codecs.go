@@ -0,0 +1,97 @@
+package configuration
+
+import (
+	"github.com/bitmovin/bitmovin-api-sdk-go"
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/configuration/codec"
+)
+
+// Codec manages the configurations of a single video or audio codec
+type Codec interface {
+	Type() model.CodecConfigType
+	Create(preset db.Preset) (string, error)
+	Delete(cfgID string) error
+}
+
+type codecFuncs struct {
+	typ    model.CodecConfigType
+	create func(db.Preset) (string, error)
+	delete func(string) (*model.BitmovinResponse, error)
+}
+
+func (c codecFuncs) Type() model.CodecConfigType {
+	return c.typ
+}
+
+func (c codecFuncs) Create(preset db.Preset) (string, error) {
+	return c.create(preset)
+}
+
+func (c codecFuncs) Delete(cfgID string) error {
+	_, err := c.delete(cfgID)
+	return err
+}
+
+// NewH264 returns a codec managing h.264 configurations
+func NewH264(api *bitmovin.BitmovinApi) Codec {
+	return codecFuncs{
+		typ:    model.CodecConfigType_H264,
+		create: func(preset db.Preset) (string, error) { return codec.NewH264(api, preset) },
+		delete: api.Encoding.Configurations.Video.H264.Delete,
+	}
+}
+
+// NewH265 returns a codec managing h.265 configurations
+func NewH265(api *bitmovin.BitmovinApi) Codec {
+	return codecFuncs{
+		typ:    model.CodecConfigType_H265,
+		create: func(preset db.Preset) (string, error) { return codec.NewH265(api, preset) },
+		delete: api.Encoding.Configurations.Video.H265.Delete,
+	}
+}
+
+// NewAV1 returns a codec managing AV1 configurations
+func NewAV1(api *bitmovin.BitmovinApi) Codec {
+	return codecFuncs{
+		typ:    model.CodecConfigType_AV1,
+		create: func(preset db.Preset) (string, error) { return codec.NewAV1(api, preset) },
+		delete: api.Encoding.Configurations.Video.Av1.Delete,
+	}
+}
+
+// NewVP8 returns a codec managing VP8 configurations
+func NewVP8(api *bitmovin.BitmovinApi) Codec {
+	return codecFuncs{
+		typ:    model.CodecConfigType_VP8,
+		create: func(preset db.Preset) (string, error) { return codec.NewVP8(api, preset) },
+		delete: api.Encoding.Configurations.Video.Vp8.Delete,
+	}
+}
+
+// NewAAC returns a codec managing AAC configurations
+func NewAAC(api *bitmovin.BitmovinApi) Codec {
+	return codecFuncs{
+		typ:    model.CodecConfigType_AAC,
+		create: func(preset db.Preset) (string, error) { return codec.NewAAC(api, preset.Audio.Bitrate) },
+		delete: api.Encoding.Configurations.Audio.Aac.Delete,
+	}
+}
+
+// NewOpus returns a codec managing Opus configurations
+func NewOpus(api *bitmovin.BitmovinApi) Codec {
+	return codecFuncs{
+		typ:    model.CodecConfigType_OPUS,
+		create: func(preset db.Preset) (string, error) { return codec.NewOpus(api, preset.Audio.Bitrate) },
+		delete: api.Encoding.Configurations.Audio.Opus.Delete,
+	}
+}
+
+// NewVorbis returns a codec managing Vorbis configurations
+func NewVorbis(api *bitmovin.BitmovinApi) Codec {
+	return codecFuncs{
+		typ:    model.CodecConfigType_VORBIS,
+		create: func(preset db.Preset) (string, error) { return codec.NewVorbis(api, preset.Audio.Bitrate) },
+		delete: api.Encoding.Configurations.Audio.Vorbis.Delete,
+	}
+}
@@ -0,0 +1,90 @@
+package configuration
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+// Pair is a configuration service for content in any combination of a video
+// codec and an audio codec, either of them being optional
+type Pair struct {
+	video, audio Codec
+	repo         db.PresetSummaryRepository
+}
+
+// NewPair returns a service for managing the configurations of a video and an
+// audio codec, a nil codec meaning the content has no such track
+func NewPair(video, audio Codec, repo db.PresetSummaryRepository) (*Pair, error) {
+	if video == nil && audio == nil {
+		return nil, errors.New("a video or an audio codec is required")
+	}
+	return &Pair{video: video, audio: audio, repo: repo}, nil
+}
+
+// Create will create the audio and video configurations based on a preset,
+// the audio configuration is removed if the video one can't be created
+func (c *Pair) Create(preset db.Preset) (db.PresetSummary, error) {
+	summary := db.PresetSummary{
+		Name:      preset.Name,
+		Container: preset.Container,
+	}
+
+	if c.audio != nil {
+		audCfgID, err := c.audio.Create(preset)
+		if err != nil {
+			return db.PresetSummary{}, err
+		}
+		summary.AudioCodec = string(c.audio.Type())
+		summary.AudioConfigID = audCfgID
+	}
+
+	if c.video != nil {
+		vidCfgID, err := c.video.Create(preset)
+		if err != nil {
+			if summary.AudioConfigID != "" {
+				if delErr := c.audio.Delete(summary.AudioConfigID); delErr != nil {
+					err = fmt.Errorf("%w (removing the audio config: %s)", err, delErr)
+				}
+			}
+			return db.PresetSummary{}, err
+		}
+		summary.VideoCodec = string(c.video.Type())
+		summary.VideoConfigID = vidCfgID
+	}
+
+	return summary, nil
+}
+
+// Get retrieves a stored db.PresetSummary by its name
+func (c *Pair) Get(presetName string) (db.PresetSummary, error) {
+	return c.repo.GetPresetSummary(presetName)
+}
+
+// Delete removes the audio / video configurations
+func (c *Pair) Delete(presetName string) error {
+	summary, err := c.Get(presetName)
+	if err != nil {
+		return err
+	}
+
+	if c.audio != nil && summary.AudioConfigID != "" {
+		if err = c.audio.Delete(summary.AudioConfigID); err != nil {
+			return fmt.Errorf("removing the audio config: %w", err)
+		}
+	}
+
+	if c.video != nil && summary.VideoConfigID != "" {
+		if err = c.video.Delete(summary.VideoConfigID); err != nil {
+			return fmt.Errorf("removing the video config: %w", err)
+		}
+	}
+
+	err = c.repo.DeletePresetSummary(presetName)
+	if err != nil {
+		return fmt.Errorf("deleting preset summary: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPairCreate(t *testing.T) {
+	preset := db.Preset{Name: "preset", Container: "mp4"}
+
+	tests := []struct {
+		name         string
+		video, audio *fakeCodec
+		wantSummary  db.PresetSummary
+		wantErr      string
+		wantDeleted  []string
+	}{
+		{
+			name:  "a video and an audio codec are combined",
+			video: &fakeCodec{typ: model.CodecConfigType_AV1, id: "videoID"},
+			audio: &fakeCodec{typ: model.CodecConfigType_OPUS, id: "audioID"},
+			wantSummary: db.PresetSummary{
+				Name:          "preset",
+				Container:     "mp4",
+				VideoCodec:    "AV1",
+				VideoConfigID: "videoID",
+				AudioCodec:    "OPUS",
+				AudioConfigID: "audioID",
+			},
+		},
+		{
+			name:  "a video only preset has no audio config",
+			video: &fakeCodec{typ: model.CodecConfigType_H264, id: "videoID"},
+			wantSummary: db.PresetSummary{
+				Name:          "preset",
+				Container:     "mp4",
+				VideoCodec:    "H264",
+				VideoConfigID: "videoID",
+			},
+		},
+		{
+			name:  "an audio only preset has no video config",
+			audio: &fakeCodec{typ: model.CodecConfigType_AAC, id: "audioID"},
+			wantSummary: db.PresetSummary{
+				Name:          "preset",
+				Container:     "mp4",
+				AudioCodec:    "AAC",
+				AudioConfigID: "audioID",
+			},
+		},
+		{
+			name:        "the audio config is removed when the video config can't be created",
+			video:       &fakeCodec{typ: model.CodecConfigType_H265, err: errors.New("invalid profile")},
+			audio:       &fakeCodec{typ: model.CodecConfigType_AAC, id: "audioID"},
+			wantErr:     "invalid profile",
+			wantDeleted: []string{"audioID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var video, audio Codec
+			if tt.video != nil {
+				video = tt.video
+			}
+			if tt.audio != nil {
+				audio = tt.audio
+			}
+			pair, err := NewPair(video, audio, &fakeRepo{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			summary, err := pair.Create(preset)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				if diff := cmp.Diff(tt.wantDeleted, tt.audio.deleted); diff != "" {
+					t.Errorf("wrong deleted audio configs: %s", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantSummary, summary); diff != "" {
+				t.Errorf("wrong summary: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPairDelete(t *testing.T) {
+	video := &fakeCodec{typ: model.CodecConfigType_H264}
+	audio := &fakeCodec{typ: model.CodecConfigType_OPUS}
+	repo := &fakeRepo{summaries: map[string]db.PresetSummary{
+		"preset": {Name: "preset", VideoConfigID: "videoID", AudioConfigID: "audioID"},
+	}}
+	pair, err := NewPair(video, audio, repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := pair.Delete("preset"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"videoID"}, video.deleted); diff != "" {
+		t.Errorf("wrong deleted video configs: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"audioID"}, audio.deleted); diff != "" {
+		t.Errorf("wrong deleted audio configs: %s", diff)
+	}
+	if _, ok := repo.summaries["preset"]; ok {
+		t.Error("the preset summary was not deleted")
+	}
+}
+
+func TestNewPairWithoutCodecs(t *testing.T) {
+	if _, err := NewPair(nil, nil, &fakeRepo{}); err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
+
+type fakeCodec struct {
+	typ     model.CodecConfigType
+	id      string
+	err     error
+	deleted []string
+}
+
+func (c *fakeCodec) Type() model.CodecConfigType { return c.typ }
+
+func (c *fakeCodec) Create(db.Preset) (string, error) { return c.id, c.err }
+
+func (c *fakeCodec) Delete(cfgID string) error {
+	c.deleted = append(c.deleted, cfgID)
+	return nil
+}
+
+type fakeRepo struct {
+	summaries map[string]db.PresetSummary
+}
+
+func (r *fakeRepo) CreatePresetSummary(summary *db.PresetSummary) error {
+	r.summaries[summary.Name] = *summary
+	return nil
+}
+
+func (r *fakeRepo) DeletePresetSummary(name string) error {
+	delete(r.summaries, name)
+	return nil
+}
+
+func (r *fakeRepo) GetPresetSummary(name string) (db.PresetSummary, error) {
+	summary, ok := r.summaries[name]
+	if !ok {
+		return db.PresetSummary{}, db.ErrPresetSummaryNotFound
+	}
+	return summary, nil
+}
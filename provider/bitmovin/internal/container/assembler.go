@@ -1,6 +1,11 @@
 package container
 
-import "github.com/bitmovin/bitmovin-api-sdk-go/model"
+import (
+	"fmt"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/pkg/errors"
+)
 
 // Assembler is responsible for creating all resources for a given container output
 type Assembler interface {
@@ -18,6 +23,146 @@ type AssemblerCfg struct {
 	ManifestID                       string
 	ManifestMasterPath               string
 	SegDuration                      uint
+
+	// DASH is the DASH manifest the outputs are added to, if any
+	DASH *DASHManifest
+
+	// AudioTracks are the audio renditions added to the HLS manifest under
+	// the AudCfgID group, in place of the single rendition of AudMuxingStream
+	AudioTracks []AudioTrack
+
+	// SubtitlesGroup is the group of HLS subtitle renditions the video
+	// stream references, if any
+	SubtitlesGroup string
+
+	// IFramePlaylist adds an I-frame playlist of the video stream to the
+	// HLS manifest, for trick-play
+	IFramePlaylist bool
+
+	// PerTitle is set when the video stream is a per-title template, the
+	// renditions produced from it are written to their own directory
+	PerTitle bool
+
+	// Encrypt encrypts the segments of the muxings, if set. The segments are
+	// then written to the output by the DRM configuration it creates rather
+	// than by the muxing.
+	Encrypt EncryptFunc
+
+	// Tracker records the created resources, if set
+	Tracker ResourceTracker
+}
+
+// MuxingType is the type of a muxing whose segments are encrypted
+type MuxingType string
+
+// MuxingType values
+const (
+	MuxingTS   MuxingType = "ts"
+	MuxingFMP4 MuxingType = "fmp4"
+	MuxingCMAF MuxingType = "cmaf"
+)
+
+// EncryptFunc creates the DRM configuration of a muxing, writing its encrypted
+// segments to output, and returns its ID
+type EncryptFunc func(muxing MuxingType, muxingID string, output model.EncodingOutput) (drmID string, err error)
+
+// muxingOutputs returns the outputs of a muxing writing its segments to output,
+// which are left to the DRM configuration of encrypted muxings
+func muxingOutputs(cfg AssemblerCfg, output model.EncodingOutput) []model.EncodingOutput {
+	if cfg.Encrypt != nil {
+		return nil
+	}
+	return []model.EncodingOutput{output}
+}
+
+// encrypt encrypts the segments of a muxing when the cfg is encrypted,
+// returning the ID of its DRM configuration
+func encrypt(cfg AssemblerCfg, muxing MuxingType, muxingID string, output model.EncodingOutput) (string, error) {
+	if cfg.Encrypt == nil {
+		return "", nil
+	}
+	drmID, err := cfg.Encrypt(muxing, muxingID, output)
+	if err != nil {
+		return "", errors.Wrapf(err, "encrypting %s muxing", muxing)
+	}
+	return drmID, nil
+}
+
+// DASHManifest identifies the period and adaptation sets of a DASH manifest
+// the representations of the outputs are added to
+type DASHManifest struct {
+	ID                   string
+	PeriodID             string
+	VideoAdaptationSetID string
+	AudioAdaptationSetID string
+}
+
+// AudioTrack is an audio rendition of the HLS manifest encoded from its own stream
+type AudioTrack struct {
+	MuxingStream    model.MuxingStream
+	Language        string
+	Name            string
+	Default         bool
+	Autoselect      bool
+	Characteristics []string
+}
+
+// audioRendition is an audio rendition along with the name of the directory
+// and playlist its segments are written to
+type audioRendition struct {
+	AudioTrack
+	path string
+}
+
+// audioRenditionsFrom returns the audio renditions of a cfg, falling back to a
+// single rendition of AudMuxingStream when no audio tracks are defined
+func audioRenditionsFrom(cfg AssemblerCfg) []audioRendition {
+	if cfg.AudCfgID == "" {
+		return nil
+	}
+
+	if len(cfg.AudioTracks) == 0 {
+		if (cfg.AudMuxingStream == model.MuxingStream{}) {
+			return nil
+		}
+		return []audioRendition{{
+			AudioTrack: AudioTrack{
+				MuxingStream:    cfg.AudMuxingStream,
+				Language:        "en",
+				Name:            cfg.AudCfgID,
+				Characteristics: []string{"public.accessibility.describes-video"},
+			},
+			path: cfg.AudCfgID,
+		}}
+	}
+
+	renditions := make([]audioRendition, len(cfg.AudioTracks))
+	for i, t := range cfg.AudioTracks {
+		renditions[i] = audioRendition{AudioTrack: t, path: fmt.Sprintf("%s_%d", cfg.AudCfgID, i)}
+	}
+	return renditions
+}
+
+// videoPathFrom returns the name of the directory the video segments of a cfg
+// are written to. The renditions of per-title templates are only known once
+// encoded, their directory is named by Bitmovin from placeholders.
+func videoPathFrom(cfg AssemblerCfg) string {
+	if cfg.PerTitle {
+		return cfg.VidCfgID + "_{height}p_{bitrate}"
+	}
+	return cfg.VidCfgID
+}
+
+// ResourceTracker records the resources created for an encoding along with the
+// function deleting them, so they can be cleaned up if the submission fails
+type ResourceTracker interface {
+	Track(kind, id string, del func() error)
+}
+
+func track(cfg AssemblerCfg, kind, id string, del func() error) {
+	if cfg.Tracker != nil {
+		cfg.Tracker.Track(kind, id, del)
+	}
 }
 
 func streamsFrom(cfg AssemblerCfg) []model.MuxingStream {
@@ -13,7 +13,8 @@ import (
 	"github.com/pkg/errors"
 )
 
-// CMAFAssembler is an assembler that creates HLS outputs based on a cfg
+// CMAFAssembler is an assembler that creates HLS and DASH outputs sharing the
+// same CMAF segments based on a cfg
 type CMAFAssembler struct {
 	api CMAFContainerAPI
 }
@@ -23,74 +24,158 @@ func NewCMAFAssembler(api CMAFContainerAPI) *CMAFAssembler {
 	return &CMAFAssembler{api: api}
 }
 
-// Assemble creates HLS outputs
+// Assemble creates CMAF outputs, added to the HLS manifest when its ID is set
+// and to the DASH manifest when set
 func (a *CMAFAssembler) Assemble(cfg AssemblerCfg) error {
-	if cfg.AudCfgID != "" {
+	for _, rendition := range audioRenditionsFrom(cfg) {
+		output := storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, rendition.path))
 		audCMAFMuxing, err := a.api.CMAFMuxing.Create(cfg.EncID, model.CmafMuxing{
 			SegmentLength: floatToPtr(float64(cfg.SegDuration)),
 			SegmentNaming: "seg_%number%.m4a",
-			Streams:       []model.MuxingStream{cfg.AudMuxingStream},
-			Outputs: []model.EncodingOutput{
-				storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, cfg.AudCfgID)),
-			},
+			Streams:       []model.MuxingStream{rendition.MuxingStream},
+			Outputs:       muxingOutputs(cfg, output),
 		})
 		if err != nil {
 			return errors.Wrap(err, "creating audio cmaf muxing")
 		}
-
-		_, err = a.api.HLSAudioMedia.Create(cfg.ManifestID, model.AudioMediaInfo{
-			Uri:             cfg.AudCfgID + ".m3u8",
-			GroupId:         cfg.AudCfgID,
-			Language:        "en",
-			Name:            cfg.AudCfgID,
-			IsDefault:       boolToPtr(false),
-			Autoselect:      boolToPtr(false),
-			Forced:          boolToPtr(false),
-			SegmentPath:     cfg.AudCfgID,
-			Characteristics: []string{"public.accessibility.describes-video"},
-			EncodingId:      cfg.EncID,
-			StreamId:        cfg.AudMuxingStream.StreamId,
-			MuxingId:        audCMAFMuxing.Id,
+		track(cfg, "cmaf muxing", audCMAFMuxing.Id, func() error {
+			_, err := a.api.CMAFMuxing.Delete(cfg.EncID, audCMAFMuxing.Id)
+			return err
 		})
+
+		drmID, err := encrypt(cfg, MuxingCMAF, audCMAFMuxing.Id, output)
 		if err != nil {
-			return errors.Wrap(err, "creating audio media")
+			return err
+		}
+
+		if cfg.ManifestID != "" {
+			audioMedia, err := a.api.HLSAudioMedia.Create(cfg.ManifestID, audioMediaInfoFrom(cfg, rendition, audCMAFMuxing.Id, drmID, rendition.path))
+			if err != nil {
+				return errors.Wrap(err, "creating audio media")
+			}
+			track(cfg, "hls audio media", audioMedia.Id, func() error {
+				_, err := a.api.HLSAudioMedia.Delete(cfg.ManifestID, audioMedia.Id)
+				return err
+			})
+		}
+
+		if cfg.DASH != nil {
+			err = a.addDASHRepresentation(cfg, "audio", rendition.path, audCMAFMuxing.Id, drmID, cfg.DASH.AudioAdaptationSetID)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	if cfg.VidCfgID != "" {
+		output := storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, videoPathFrom(cfg)))
 		vidCMAFMuxing, err := a.api.CMAFMuxing.Create(cfg.EncID, model.CmafMuxing{
 			SegmentLength: floatToPtr(float64(cfg.SegDuration)),
 			SegmentNaming: "seg_%number%.m4v",
 			Streams:       []model.MuxingStream{cfg.VidMuxingStream},
-			Outputs: []model.EncodingOutput{
-				storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, cfg.VidCfgID)),
-			},
+			Outputs:       muxingOutputs(cfg, output),
 		})
 		if err != nil {
 			return errors.Wrap(err, "creating video cmaf muxing")
 		}
+		track(cfg, "cmaf muxing", vidCMAFMuxing.Id, func() error {
+			_, err := a.api.CMAFMuxing.Delete(cfg.EncID, vidCMAFMuxing.Id)
+			return err
+		})
 
-		vidSegLoc, err := filepath.Rel(path.Dir(path.Join(cfg.DestPath, cfg.OutputFilename)), path.Join(cfg.ManifestMasterPath, cfg.VidCfgID))
+		drmID, err := encrypt(cfg, MuxingCMAF, vidCMAFMuxing.Id, output)
 		if err != nil {
-			return errors.Wrap(err, "constructing video segment location")
+			return err
 		}
 
-		_, err = a.api.HLSStreams.Create(cfg.ManifestID, model.StreamInfo{
-			Audio:       cfg.AudCfgID,
-			Uri:         fmt.Sprintf("%s.m3u8", cfg.VidCfgID),
-			SegmentPath: vidSegLoc,
-			EncodingId:  cfg.EncID,
-			StreamId:    cfg.VidMuxingStream.StreamId,
-			MuxingId:    vidCMAFMuxing.Id,
-		})
-		if err != nil {
-			return errors.Wrap(err, "creating video stream info")
+		if cfg.ManifestID != "" {
+			vidSegLoc, err := filepath.Rel(path.Dir(path.Join(cfg.DestPath, cfg.OutputFilename)), path.Join(cfg.ManifestMasterPath, cfg.VidCfgID))
+			if err != nil {
+				return errors.Wrap(err, "constructing video segment location")
+			}
+
+			streamInfo, err := a.api.HLSStreams.Create(cfg.ManifestID, model.StreamInfo{
+				Audio:       cfg.AudCfgID,
+				Subtitles:   cfg.SubtitlesGroup,
+				Uri:         fmt.Sprintf("%s.m3u8", cfg.VidCfgID),
+				SegmentPath: vidSegLoc,
+				EncodingId:  cfg.EncID,
+				StreamId:    cfg.VidMuxingStream.StreamId,
+				MuxingId:    vidCMAFMuxing.Id,
+				DrmId:       drmID,
+			})
+			if err != nil {
+				return errors.Wrap(err, "creating video stream info")
+			}
+			track(cfg, "hls stream info", streamInfo.Id, func() error {
+				_, err := a.api.HLSStreams.Delete(cfg.ManifestID, streamInfo.Id)
+				return err
+			})
+
+			if cfg.IFramePlaylist {
+				if err := createIFramePlaylist(a.api.HLSIFramePlaylists, cfg, streamInfo.Id); err != nil {
+					return err
+				}
+			}
+		}
+
+		if cfg.DASH != nil {
+			err = a.addDASHRepresentation(cfg, "video", cfg.VidCfgID, vidCMAFMuxing.Id, drmID, cfg.DASH.VideoAdaptationSetID)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+func (a *CMAFAssembler) addDASHRepresentation(cfg AssemblerCfg, kind, cfgID, muxingID, drmID, adaptationSetID string) error {
+	if drmID != "" {
+		return a.addDASHDRMRepresentation(cfg, kind, cfgID, muxingID, drmID, adaptationSetID)
+	}
+
+	representation, err := a.api.DASHRepresentations.Create(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, model.DashCmafRepresentation{
+		Type:        model.DashRepresentationType_TEMPLATE,
+		EncodingId:  cfg.EncID,
+		MuxingId:    muxingID,
+		SegmentPath: cfgID,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating %s dash representation", kind)
+	}
+	track(cfg, "dash representation", representation.Id, func() error {
+		_, err := a.api.DASHRepresentations.Delete(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, representation.Id)
+		return err
+	})
+	return nil
+}
+
+// addDASHDRMRepresentation adds the representation of a muxing encrypted by
+// the DRM configuration drmID, signaling its content protection
+func (a *CMAFAssembler) addDASHDRMRepresentation(cfg AssemblerCfg, kind, cfgID, muxingID, drmID, adaptationSetID string) error {
+	if a.api.DASHDRMRepresentations == nil || a.api.DASHContentProtections == nil {
+		return errors.New("creating encrypted dash representation: no api to create it with")
+	}
+
+	representation, err := a.api.DASHDRMRepresentations.Create(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, model.DashCmafDrmRepresentation{
+		Type:        model.DashRepresentationType_TEMPLATE,
+		EncodingId:  cfg.EncID,
+		MuxingId:    muxingID,
+		DrmId:       drmID,
+		SegmentPath: cfgID,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating %s dash representation", kind)
+	}
+	track(cfg, "dash representation", representation.Id, func() error {
+		_, err := a.api.DASHDRMRepresentations.Delete(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, representation.Id)
+		return err
+	})
+
+	return createContentProtection(a.api.DASHContentProtections, cfg, kind, adaptationSetID, representation.Id, muxingID, drmID)
+}
+
 // CMAFStatusEnricher is responsible for adding output HLS info to a job status
 type CMAFStatusEnricher struct {
 	api *bitmovin.BitmovinApi
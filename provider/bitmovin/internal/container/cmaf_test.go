@@ -208,6 +208,117 @@ func TestCMAFAssembler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "a config with hls and dash manifests adds the cmaf segments to both manifests",
+			cfg: func() AssemblerCfg {
+				cfg := defaultAssemblerCfg
+				cfg.DASH = &DASHManifest{
+					ID:                   "testDASHManifestID",
+					PeriodID:             "testPeriodID",
+					VideoAdaptationSetID: "testVideoAdaptationSetID",
+					AudioAdaptationSetID: "testAudioAdaptationSetID",
+				}
+				return cfg
+			}(),
+			api: cmafContainerAPI(),
+			assertParams: func(t *testing.T, api CMAFContainerAPI) {
+				if g, e := api.CMAFMuxing.(*fakeCMAFMuxingAPI).numInvocations, 2; g != e {
+					t.Errorf("invalid number of calls to the CMAF muxing api: got %d, expected %d", g, e)
+				}
+				if g, e := api.HLSStreams.(*fakeHLSStreamsAPI).numInvocations, 1; g != e {
+					t.Errorf("invalid number of calls to the HLS streams api: got %d, expected %d", g, e)
+				}
+				if g, e := api.HLSAudioMedia.(*fakeHLSAudioMediaAPI).numInvocations, 1; g != e {
+					t.Errorf("invalid number of calls to the HLS audio media api: got %d, expected %d", g, e)
+				}
+
+				expected := []dashRepresentation{
+					{
+						manifestID: "testDASHManifestID", periodID: "testPeriodID", adaptationSetID: "testAudioAdaptationSetID",
+						representation: model.DashCmafRepresentation{
+							Type: model.DashRepresentationType_TEMPLATE, EncodingId: "testEncID", SegmentPath: "testAudCfgID",
+						},
+					},
+					{
+						manifestID: "testDASHManifestID", periodID: "testPeriodID", adaptationSetID: "testVideoAdaptationSetID",
+						representation: model.DashCmafRepresentation{
+							Type: model.DashRepresentationType_TEMPLATE, EncodingId: "testEncID", SegmentPath: "testVidCfgID",
+						},
+					},
+				}
+				if g, e := api.DASHRepresentations.(*fakeDASHCMAFRepresentationsAPI).invocations, expected; !reflect.DeepEqual(g, e) {
+					t.Errorf("invalid dash representations: got  %v\nexpected %v\ndiff %v", g, e, cmp.Diff(g, e, cmp.AllowUnexported(dashRepresentation{})))
+				}
+			},
+		},
+		{
+			name: "a config with only a dash manifest doesn't add the segments to an hls manifest",
+			cfg: func() AssemblerCfg {
+				cfg := defaultAssemblerCfg
+				cfg.ManifestID = ""
+				cfg.DASH = &DASHManifest{ID: "testDASHManifestID", PeriodID: "testPeriodID"}
+				return cfg
+			}(),
+			api: cmafContainerAPI(),
+			assertParams: func(t *testing.T, api CMAFContainerAPI) {
+				if g := api.HLSStreams.(*fakeHLSStreamsAPI).numInvocations + api.HLSAudioMedia.(*fakeHLSAudioMediaAPI).numInvocations; g != 0 {
+					t.Errorf("unexpected calls to the HLS apis: got %d", g)
+				}
+				if g, e := len(api.DASHRepresentations.(*fakeDASHCMAFRepresentationsAPI).invocations), 2; g != e {
+					t.Errorf("invalid number of dash representations: got %d, expected %d", g, e)
+				}
+			},
+		},
+		{
+			name: "encrypted muxings share their drm configuration between the hls and dash manifests",
+			cfg: func() AssemblerCfg {
+				cfg := defaultAssemblerCfg
+				cfg.AudCfgID = ""
+				cfg.DASH = &DASHManifest{ID: "testDASHManifestID", PeriodID: "testPeriodID", VideoAdaptationSetID: "testVideoAdaptationSetID"}
+				cfg.Encrypt = (&fakeEncrypter{}).encrypt
+				return cfg
+			}(),
+			api: cmafContainerAPI(),
+			assertParams: func(t *testing.T, api CMAFContainerAPI) {
+				drmID := "cmaf:test/master/manifest/path/testVidCfgID"
+				if g := api.CMAFMuxing.(*fakeCMAFMuxingAPI).invocationDetails[0].muxing.Outputs; g != nil {
+					t.Errorf("expected no outputs on encrypted muxings, got %v", g)
+				}
+				if g, e := api.HLSStreams.(*fakeHLSStreamsAPI).invocationDetails[0].streamInfo.DrmId, drmID; g != e {
+					t.Errorf("invalid stream info drm: got %q, expected %q", g, e)
+				}
+
+				expectedRepresentations := []dashRepresentation{{
+					manifestID: "testDASHManifestID", periodID: "testPeriodID", adaptationSetID: "testVideoAdaptationSetID",
+					representation: model.DashCmafDrmRepresentation{
+						Type: model.DashRepresentationType_TEMPLATE, EncodingId: "testEncID", DrmId: drmID, SegmentPath: "testVidCfgID",
+					},
+				}}
+				if g, e := api.DASHDRMRepresentations.(*fakeDASHCMAFDRMRepresentationsAPI).invocations, expectedRepresentations; !reflect.DeepEqual(g, e) {
+					t.Errorf("invalid dash drm representations: got  %v\nexpected %v", g, e)
+				}
+				expectedProtections := []model.ContentProtection{{EncodingId: "testEncID", DrmId: drmID}}
+				if g, e := api.DASHContentProtections.(*fakeDASHContentProtectionsAPI).protections, expectedProtections; !reflect.DeepEqual(g, e) {
+					t.Errorf("invalid content protections: got  %v\nexpected %v", g, e)
+				}
+			},
+		},
+		{
+			name: "when no api creates encrypted dash representations, a useful error is returned",
+			cfg: func() AssemblerCfg {
+				cfg := defaultAssemblerCfg
+				cfg.DASH = &DASHManifest{ID: "testDASHManifestID", PeriodID: "testPeriodID"}
+				cfg.Encrypt = (&fakeEncrypter{}).encrypt
+				return cfg
+			}(),
+			api: CMAFContainerAPI{
+				HLSAudioMedia:       &fakeHLSAudioMediaAPI{},
+				CMAFMuxing:          &fakeCMAFMuxingAPI{},
+				HLSStreams:          &fakeHLSStreamsAPI{},
+				DASHRepresentations: &fakeDASHCMAFRepresentationsAPI{},
+			},
+			wantErr: "creating encrypted dash representation: no api to create it with",
+		},
 		{
 			name: "when the ts muxing api is erroring, a useful error is returned",
 			cfg:  defaultAssemblerCfg,
@@ -257,12 +368,41 @@ func TestCMAFAssembler(t *testing.T) {
 
 func cmafContainerAPI() CMAFContainerAPI {
 	return CMAFContainerAPI{
-		HLSAudioMedia: &fakeHLSAudioMediaAPI{},
-		CMAFMuxing:    &fakeCMAFMuxingAPI{},
-		HLSStreams:    &fakeHLSStreamsAPI{},
+		HLSAudioMedia:          &fakeHLSAudioMediaAPI{},
+		CMAFMuxing:             &fakeCMAFMuxingAPI{},
+		HLSStreams:             &fakeHLSStreamsAPI{},
+		DASHRepresentations:    &fakeDASHCMAFRepresentationsAPI{},
+		DASHDRMRepresentations: &fakeDASHCMAFDRMRepresentationsAPI{},
+		DASHContentProtections: &fakeDASHContentProtectionsAPI{},
 	}
 }
 
+type fakeDASHCMAFRepresentationsAPI struct {
+	invocations []dashRepresentation
+}
+
+func (a *fakeDASHCMAFRepresentationsAPI) Create(manifestID, periodID, adaptationSetID string, representation model.DashCmafRepresentation) (*model.DashCmafRepresentation, error) {
+	a.invocations = append(a.invocations, dashRepresentation{manifestID, periodID, adaptationSetID, representation})
+	return &representation, nil
+}
+
+func (a *fakeDASHCMAFRepresentationsAPI) Delete(string, string, string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
+type fakeDASHCMAFDRMRepresentationsAPI struct {
+	invocations []dashRepresentation
+}
+
+func (a *fakeDASHCMAFDRMRepresentationsAPI) Create(manifestID, periodID, adaptationSetID string, representation model.DashCmafDrmRepresentation) (*model.DashCmafDrmRepresentation, error) {
+	a.invocations = append(a.invocations, dashRepresentation{manifestID, periodID, adaptationSetID, representation})
+	return &representation, nil
+}
+
+func (a *fakeDASHCMAFDRMRepresentationsAPI) Delete(string, string, string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
 type fakeCMAFMuxingAPI struct {
 	forceErr          bool
 	numInvocations    int
@@ -284,3 +424,7 @@ func (a *fakeCMAFMuxingAPI) Create(encodingID string, cmafMuxing model.CmafMuxin
 
 	return &cmafMuxing, nil
 }
+
+func (a *fakeCMAFMuxingAPI) Delete(string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
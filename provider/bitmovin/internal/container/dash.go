@@ -0,0 +1,160 @@
+package container
+
+import (
+	"path"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go"
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/storage"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/bitmovin/internal/types"
+	"github.com/pkg/errors"
+)
+
+// CustomDataKeyDASHManifestID is the key used to store the ID of the DASH
+// manifest in an encoding, under CustomDataKeyManifest
+const CustomDataKeyDASHManifestID = "dashId"
+
+// DASHAssembler is an assembler that creates DASH outputs using fMP4 muxings
+// based on a cfg
+type DASHAssembler struct {
+	api DASHContainerAPI
+}
+
+// NewDASHAssembler creates and returns a DASHAssembler
+func NewDASHAssembler(api DASHContainerAPI) *DASHAssembler {
+	return &DASHAssembler{api: api}
+}
+
+// Assemble creates DASH outputs
+func (a *DASHAssembler) Assemble(cfg AssemblerCfg) error {
+	if cfg.DASH == nil {
+		return errors.New("dash outputs require a dash manifest")
+	}
+
+	if cfg.AudCfgID != "" {
+		err := a.assemble(cfg, "audio", cfg.AudCfgID, cfg.AudMuxingStream, cfg.DASH.AudioAdaptationSetID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.VidCfgID != "" {
+		err := a.assemble(cfg, "video", cfg.VidCfgID, cfg.VidMuxingStream, cfg.DASH.VideoAdaptationSetID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *DASHAssembler) assemble(cfg AssemblerCfg, kind, cfgID string, stream model.MuxingStream, adaptationSetID string) error {
+	output := storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, cfgID))
+	muxing, err := a.api.FMP4Muxing.Create(cfg.EncID, model.Fmp4Muxing{
+		SegmentLength:   floatToPtr(float64(cfg.SegDuration)),
+		SegmentNaming:   "seg_%number%.m4s",
+		InitSegmentName: "init.mp4",
+		Streams:         []model.MuxingStream{stream},
+		Outputs:         muxingOutputs(cfg, output),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating %s fmp4 muxing", kind)
+	}
+	track(cfg, "fmp4 muxing", muxing.Id, func() error {
+		_, err := a.api.FMP4Muxing.Delete(cfg.EncID, muxing.Id)
+		return err
+	})
+
+	drmID, err := encrypt(cfg, MuxingFMP4, muxing.Id, output)
+	if err != nil {
+		return err
+	}
+	if drmID != "" {
+		return a.assembleDRM(cfg, kind, cfgID, muxing.Id, drmID, adaptationSetID)
+	}
+
+	representation, err := a.api.DASHRepresentations.Create(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, model.DashFmp4Representation{
+		Type:        model.DashRepresentationType_TEMPLATE,
+		EncodingId:  cfg.EncID,
+		MuxingId:    muxing.Id,
+		SegmentPath: cfgID,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating %s dash representation", kind)
+	}
+	track(cfg, "dash representation", representation.Id, func() error {
+		_, err := a.api.DASHRepresentations.Delete(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, representation.Id)
+		return err
+	})
+
+	return nil
+}
+
+// assembleDRM adds the representation of a muxing encrypted by the DRM
+// configuration drmID, signaling its content protection
+func (a *DASHAssembler) assembleDRM(cfg AssemblerCfg, kind, cfgID, muxingID, drmID, adaptationSetID string) error {
+	if a.api.DASHDRMRepresentations == nil || a.api.DASHContentProtections == nil {
+		return errors.New("creating encrypted dash representation: no api to create it with")
+	}
+
+	representation, err := a.api.DASHDRMRepresentations.Create(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, model.DashFmp4DrmRepresentation{
+		Type:        model.DashRepresentationType_TEMPLATE,
+		EncodingId:  cfg.EncID,
+		MuxingId:    muxingID,
+		DrmId:       drmID,
+		SegmentPath: cfgID,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating %s dash representation", kind)
+	}
+	track(cfg, "dash representation", representation.Id, func() error {
+		_, err := a.api.DASHDRMRepresentations.Delete(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, representation.Id)
+		return err
+	})
+
+	return createContentProtection(a.api.DASHContentProtections, cfg, kind, adaptationSetID, representation.Id, muxingID, drmID)
+}
+
+// createContentProtection signals the DRM configuration drmID encrypting the
+// segments of an encrypted DASH representation
+func createContentProtection(api DASHContentProtectionsAPI, cfg AssemblerCfg, kind, adaptationSetID, representationID, muxingID, drmID string) error {
+	protection, err := api.Create(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, representationID, model.ContentProtection{
+		EncodingId: cfg.EncID,
+		MuxingId:   muxingID,
+		DrmId:      drmID,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating %s dash content protection", kind)
+	}
+	track(cfg, "dash content protection", protection.Id, func() error {
+		_, err := api.Delete(cfg.DASH.ID, cfg.DASH.PeriodID, adaptationSetID, representationID, protection.Id)
+		return err
+	})
+	return nil
+}
+
+// DASHStatusEnricher is responsible for adding output DASH info to a job status
+type DASHStatusEnricher struct {
+	api *bitmovin.BitmovinApi
+}
+
+// NewDASHStatusEnricher creates and returns a DASHStatusEnricher
+func NewDASHStatusEnricher(api *bitmovin.BitmovinApi) *DASHStatusEnricher {
+	return &DASHStatusEnricher{api: api}
+}
+
+// Enrich populates information about the DASH manifest if it exists
+func (e *DASHStatusEnricher) Enrich(s provider.JobStatus) (provider.JobStatus, error) {
+	data, err := e.api.Encoding.Encodings.Customdata.Get(s.ProviderJobID)
+	if err != nil {
+		return s, errors.Wrap(err, "retrieving the encoding from the Bitmovin API")
+	}
+
+	manifestID, err := types.CustomDataStringValAtKeys(data.CustomData, CustomDataKeyManifest, CustomDataKeyDASHManifestID)
+	if err == nil && manifestID != "" {
+		s.ProviderStatus["dashManifestStatus"] = model.Status_FINISHED
+	}
+
+	return s, nil
+}
@@ -0,0 +1,230 @@
+package container
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/cbsinteractive/transcode-orchestrator/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDASHAssembler(t *testing.T) {
+	defaultAssemblerCfg := AssemblerCfg{
+		EncID:    "testEncID",
+		OutputID: "testOutputID",
+		AudCfgID: "testAudCfgID",
+		VidCfgID: "testVidCfgID",
+		AudMuxingStream: model.MuxingStream{
+			StreamId: "testAudStreamID",
+		},
+		VidMuxingStream: model.MuxingStream{
+			StreamId: "testVidStreamID",
+		},
+		ManifestMasterPath: "test/master/manifest/path",
+		DASH: &DASHManifest{
+			ID:                   "testManifestID",
+			PeriodID:             "testPeriodID",
+			VideoAdaptationSetID: "testVideoAdaptationSetID",
+			AudioAdaptationSetID: "testAudioAdaptationSetID",
+		},
+		SegDuration: 6,
+	}
+
+	tests := []struct {
+		name         string
+		cfg          AssemblerCfg
+		api          DASHContainerAPI
+		wantErr      string
+		assertParams func(*testing.T, DASHContainerAPI)
+	}{
+		{
+			name: "a dash config with video and audio result in the correct calls to the DASHContainerAPI",
+			cfg:  defaultAssemblerCfg,
+			api:  dashContainerAPI(),
+			assertParams: func(t *testing.T, api DASHContainerAPI) {
+				expectedMuxings := []model.Fmp4Muxing{
+					{
+						SegmentLength:   floatToPtr(6),
+						SegmentNaming:   "seg_%number%.m4s",
+						InitSegmentName: "init.mp4",
+						Streams:         []model.MuxingStream{{StreamId: "testAudStreamID"}},
+						Outputs: []model.EncodingOutput{{
+							OutputId:   "testOutputID",
+							OutputPath: "test/master/manifest/path/testAudCfgID",
+							Acl:        []model.AclEntry{{Permission: "PRIVATE"}},
+						}},
+					},
+					{
+						SegmentLength:   floatToPtr(6),
+						SegmentNaming:   "seg_%number%.m4s",
+						InitSegmentName: "init.mp4",
+						Streams:         []model.MuxingStream{{StreamId: "testVidStreamID"}},
+						Outputs: []model.EncodingOutput{{
+							OutputId:   "testOutputID",
+							OutputPath: "test/master/manifest/path/testVidCfgID",
+							Acl:        []model.AclEntry{{Permission: "PRIVATE"}},
+						}},
+					},
+				}
+				if g, e := api.FMP4Muxing.(*fakeFMP4MuxingAPI).muxings, expectedMuxings; !reflect.DeepEqual(g, e) {
+					t.Errorf("invalid fmp4 muxings: got  %v\nexpected %v\ndiff %v", g, e, cmp.Diff(g, e))
+				}
+
+				expectedRepresentations := []dashRepresentation{
+					{
+						manifestID: "testManifestID", periodID: "testPeriodID", adaptationSetID: "testAudioAdaptationSetID",
+						representation: model.DashFmp4Representation{
+							Type: model.DashRepresentationType_TEMPLATE, EncodingId: "testEncID", SegmentPath: "testAudCfgID",
+						},
+					},
+					{
+						manifestID: "testManifestID", periodID: "testPeriodID", adaptationSetID: "testVideoAdaptationSetID",
+						representation: model.DashFmp4Representation{
+							Type: model.DashRepresentationType_TEMPLATE, EncodingId: "testEncID", SegmentPath: "testVidCfgID",
+						},
+					},
+				}
+				if g, e := api.DASHRepresentations.(*fakeDASHFMP4RepresentationsAPI).invocations, expectedRepresentations; !reflect.DeepEqual(g, e) {
+					t.Errorf("invalid dash representations: got  %v\nexpected %v\ndiff %v", g, e, cmp.Diff(g, e, cmp.AllowUnexported(dashRepresentation{})))
+				}
+			},
+		},
+		{
+			name: "encrypted muxings are added as drm representations signaling their content protection",
+			cfg: func() AssemblerCfg {
+				cfg := defaultAssemblerCfg
+				cfg.AudCfgID = ""
+				cfg.Encrypt = (&fakeEncrypter{}).encrypt
+				return cfg
+			}(),
+			api: dashContainerAPI(),
+			assertParams: func(t *testing.T, api DASHContainerAPI) {
+				if g := api.FMP4Muxing.(*fakeFMP4MuxingAPI).muxings[0].Outputs; g != nil {
+					t.Errorf("expected no outputs on encrypted muxings, got %v", g)
+				}
+				if g := api.DASHRepresentations.(*fakeDASHFMP4RepresentationsAPI).invocations; len(g) > 0 {
+					t.Errorf("expected no clear representations, got %v", g)
+				}
+
+				drmID := "fmp4:test/master/manifest/path/testVidCfgID"
+				expectedRepresentations := []dashRepresentation{{
+					manifestID: "testManifestID", periodID: "testPeriodID", adaptationSetID: "testVideoAdaptationSetID",
+					representation: model.DashFmp4DrmRepresentation{
+						Type: model.DashRepresentationType_TEMPLATE, EncodingId: "testEncID", DrmId: drmID, SegmentPath: "testVidCfgID",
+					},
+				}}
+				if g, e := api.DASHDRMRepresentations.(*fakeDASHFMP4DRMRepresentationsAPI).invocations, expectedRepresentations; !reflect.DeepEqual(g, e) {
+					t.Errorf("invalid dash drm representations: got  %v\nexpected %v", g, e)
+				}
+				expectedProtections := []model.ContentProtection{{EncodingId: "testEncID", DrmId: drmID}}
+				if g, e := api.DASHContentProtections.(*fakeDASHContentProtectionsAPI).protections, expectedProtections; !reflect.DeepEqual(g, e) {
+					t.Errorf("invalid content protections: got  %v\nexpected %v", g, e)
+				}
+			},
+		},
+		{
+			name: "when no dash manifest is set, a useful error is returned",
+			cfg: func() AssemblerCfg {
+				cfg := defaultAssemblerCfg
+				cfg.DASH = nil
+				return cfg
+			}(),
+			api:     dashContainerAPI(),
+			wantErr: "dash outputs require a dash manifest",
+		},
+		{
+			name: "when the fmp4 muxing api is erroring, a useful error is returned",
+			cfg:  defaultAssemblerCfg,
+			api: DASHContainerAPI{
+				FMP4Muxing:          &fakeFMP4MuxingAPI{forceErr: true},
+				DASHRepresentations: &fakeDASHFMP4RepresentationsAPI{},
+			},
+			wantErr: "creating audio fmp4 muxing: forced by test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewDASHAssembler(tt.api).Assemble(tt.cfg)
+			if shouldReturn := test.AssertWantErr(err, tt.wantErr, "Assemble()", t); shouldReturn {
+				return
+			}
+
+			if paramsAssertion := tt.assertParams; paramsAssertion != nil {
+				paramsAssertion(t, tt.api)
+			}
+		})
+	}
+}
+
+func dashContainerAPI() DASHContainerAPI {
+	return DASHContainerAPI{
+		FMP4Muxing:             &fakeFMP4MuxingAPI{},
+		DASHRepresentations:    &fakeDASHFMP4RepresentationsAPI{},
+		DASHDRMRepresentations: &fakeDASHFMP4DRMRepresentationsAPI{},
+		DASHContentProtections: &fakeDASHContentProtectionsAPI{},
+	}
+}
+
+type dashRepresentation struct {
+	manifestID, periodID, adaptationSetID string
+	representation                        interface{}
+}
+
+type fakeFMP4MuxingAPI struct {
+	forceErr bool
+	muxings  []model.Fmp4Muxing
+}
+
+func (a *fakeFMP4MuxingAPI) Create(_ string, muxing model.Fmp4Muxing) (*model.Fmp4Muxing, error) {
+	if a.forceErr {
+		return nil, errors.New("forced by test")
+	}
+	a.muxings = append(a.muxings, muxing)
+	return &muxing, nil
+}
+
+func (a *fakeFMP4MuxingAPI) Delete(string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
+type fakeDASHFMP4RepresentationsAPI struct {
+	invocations []dashRepresentation
+}
+
+func (a *fakeDASHFMP4RepresentationsAPI) Create(manifestID, periodID, adaptationSetID string, representation model.DashFmp4Representation) (*model.DashFmp4Representation, error) {
+	a.invocations = append(a.invocations, dashRepresentation{manifestID, periodID, adaptationSetID, representation})
+	return &representation, nil
+}
+
+func (a *fakeDASHFMP4RepresentationsAPI) Delete(string, string, string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
+type fakeDASHFMP4DRMRepresentationsAPI struct {
+	invocations []dashRepresentation
+}
+
+func (a *fakeDASHFMP4DRMRepresentationsAPI) Create(manifestID, periodID, adaptationSetID string, representation model.DashFmp4DrmRepresentation) (*model.DashFmp4DrmRepresentation, error) {
+	a.invocations = append(a.invocations, dashRepresentation{manifestID, periodID, adaptationSetID, representation})
+	return &representation, nil
+}
+
+func (a *fakeDASHFMP4DRMRepresentationsAPI) Delete(string, string, string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
+type fakeDASHContentProtectionsAPI struct {
+	protections []model.ContentProtection
+}
+
+func (a *fakeDASHContentProtectionsAPI) Create(_, _, _, _ string, protection model.ContentProtection) (*model.ContentProtection, error) {
+	a.protections = append(a.protections, protection)
+	return &protection, nil
+}
+
+func (a *fakeDASHContentProtectionsAPI) Delete(string, string, string, string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
@@ -29,69 +29,112 @@ func NewHLSAssembler(api HLSContainerAPI) *HLSAssembler {
 	return &HLSAssembler{api: api}
 }
 
-// Assemble creates HLS outputs
+// Assemble creates HLS outputs, added to the HLS manifest when its ID is set
 func (a *HLSAssembler) Assemble(cfg AssemblerCfg) error {
-	if cfg.AudCfgID != "" {
+	for _, rendition := range audioRenditionsFrom(cfg) {
+		output := storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, rendition.path))
 		audTSMuxing, err := a.api.TSMuxing.Create(cfg.EncID, model.TsMuxing{
 			SegmentLength: floatToPtr(float64(cfg.SegDuration)),
 			SegmentNaming: "seg_%number%.ts",
-			Streams:       []model.MuxingStream{cfg.AudMuxingStream},
-			Outputs: []model.EncodingOutput{
-				storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, cfg.AudCfgID)),
-			},
+			Streams:       []model.MuxingStream{rendition.MuxingStream},
+			Outputs:       muxingOutputs(cfg, output),
 		})
 		if err != nil {
 			return errors.Wrap(err, "creating audio ts muxing")
 		}
-
-		_, err = a.api.HLSAudioMedia.Create(cfg.ManifestID, model.AudioMediaInfo{
-			Uri:             cfg.AudCfgID + ".m3u8",
-			GroupId:         cfg.AudCfgID,
-			Language:        "en",
-			Name:            cfg.AudCfgID,
-			IsDefault:       boolToPtr(false),
-			Autoselect:      boolToPtr(false),
-			Forced:          boolToPtr(false),
-			SegmentPath:     cfg.AudCfgID,
-			Characteristics: []string{"public.accessibility.describes-video"},
-			EncodingId:      cfg.EncID,
-			StreamId:        cfg.AudMuxingStream.StreamId,
-			MuxingId:        audTSMuxing.Id,
+		track(cfg, "ts muxing", audTSMuxing.Id, func() error {
+			_, err := a.api.TSMuxing.Delete(cfg.EncID, audTSMuxing.Id)
+			return err
 		})
+
+		drmID, err := encrypt(cfg, MuxingTS, audTSMuxing.Id, output)
 		if err != nil {
-			return errors.Wrap(err, "creating audio media")
+			return err
+		}
+
+		if cfg.ManifestID != "" {
+			audioMedia, err := a.api.HLSAudioMedia.Create(cfg.ManifestID, audioMediaInfoFrom(cfg, rendition, audTSMuxing.Id, drmID, rendition.path))
+			if err != nil {
+				return errors.Wrap(err, "creating audio media")
+			}
+			track(cfg, "hls audio media", audioMedia.Id, func() error {
+				_, err := a.api.HLSAudioMedia.Delete(cfg.ManifestID, audioMedia.Id)
+				return err
+			})
 		}
 	}
 
 	if cfg.VidCfgID != "" {
+		output := storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, videoPathFrom(cfg)))
 		vidTSMuxing, err := a.api.TSMuxing.Create(cfg.EncID, model.TsMuxing{
 			SegmentLength: floatToPtr(float64(cfg.SegDuration)),
 			SegmentNaming: "seg_%number%.ts",
 			Streams:       []model.MuxingStream{cfg.VidMuxingStream},
-			Outputs: []model.EncodingOutput{
-				storage.EncodingOutputFrom(cfg.OutputID, path.Join(cfg.ManifestMasterPath, cfg.VidCfgID)),
-			},
+			Outputs:       muxingOutputs(cfg, output),
 		})
 		if err != nil {
 			return errors.Wrap(err, "creating video ts muxing")
 		}
-
-		_, err = a.api.HLSStreams.Create(cfg.ManifestID, model.StreamInfo{
-			Audio:       cfg.AudCfgID,
-			Uri:         fmt.Sprintf("%s.m3u8", cfg.VidCfgID),
-			SegmentPath: cfg.VidCfgID,
-			EncodingId:  cfg.EncID,
-			StreamId:    cfg.VidMuxingStream.StreamId,
-			MuxingId:    vidTSMuxing.Id,
+		track(cfg, "ts muxing", vidTSMuxing.Id, func() error {
+			_, err := a.api.TSMuxing.Delete(cfg.EncID, vidTSMuxing.Id)
+			return err
 		})
+
+		drmID, err := encrypt(cfg, MuxingTS, vidTSMuxing.Id, output)
 		if err != nil {
-			return errors.Wrap(err, "creating video stream info")
+			return err
+		}
+
+		if cfg.ManifestID != "" {
+			streamInfo, err := a.api.HLSStreams.Create(cfg.ManifestID, model.StreamInfo{
+				Audio:       cfg.AudCfgID,
+				Subtitles:   cfg.SubtitlesGroup,
+				Uri:         fmt.Sprintf("%s.m3u8", cfg.VidCfgID),
+				SegmentPath: cfg.VidCfgID,
+				EncodingId:  cfg.EncID,
+				StreamId:    cfg.VidMuxingStream.StreamId,
+				MuxingId:    vidTSMuxing.Id,
+				DrmId:       drmID,
+			})
+			if err != nil {
+				return errors.Wrap(err, "creating video stream info")
+			}
+			track(cfg, "hls stream info", streamInfo.Id, func() error {
+				_, err := a.api.HLSStreams.Delete(cfg.ManifestID, streamInfo.Id)
+				return err
+			})
+
+			if cfg.IFramePlaylist {
+				if err := createIFramePlaylist(a.api.HLSIFramePlaylists, cfg, streamInfo.Id); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
+// createIFramePlaylist adds the I-frame playlist of a video stream of the HLS
+// manifest, next to its media playlist
+func createIFramePlaylist(api HLSIFramePlaylistsAPI, cfg AssemblerCfg, streamInfoID string) error {
+	if api == nil {
+		return errors.New("creating i-frame playlist: no api to create it with")
+	}
+
+	playlist, err := api.Create(cfg.ManifestID, streamInfoID, model.IFramePlaylist{
+		Filename: fmt.Sprintf("%s_iframes.m3u8", cfg.VidCfgID),
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating i-frame playlist")
+	}
+	track(cfg, "hls i-frame playlist", playlist.Id, func() error {
+		_, err := api.Delete(cfg.ManifestID, streamInfoID, playlist.Id)
+		return err
+	})
+	return nil
+}
+
 // HLSStatusEnricher is responsible for adding output HLS info to a job status
 type HLSStatusEnricher struct {
 	api *bitmovin.BitmovinApi
@@ -117,6 +160,27 @@ func (e *HLSStatusEnricher) Enrich(s provider.JobStatus) (provider.JobStatus, er
 	return s, nil
 }
 
+// audioMediaInfoFrom returns the HLS audio media of a rendition in the
+// AudCfgID group of a cfg, with its segments at segmentPath, encrypted by the
+// DRM configuration drmID if set
+func audioMediaInfoFrom(cfg AssemblerCfg, rendition audioRendition, muxingID, drmID, segmentPath string) model.AudioMediaInfo {
+	return model.AudioMediaInfo{
+		Uri:             rendition.path + ".m3u8",
+		GroupId:         cfg.AudCfgID,
+		Language:        rendition.Language,
+		Name:            rendition.Name,
+		IsDefault:       boolToPtr(rendition.Default),
+		Autoselect:      boolToPtr(rendition.Autoselect),
+		Forced:          boolToPtr(false),
+		SegmentPath:     segmentPath,
+		Characteristics: rendition.Characteristics,
+		EncodingId:      cfg.EncID,
+		StreamId:        rendition.MuxingStream.StreamId,
+		MuxingId:        muxingID,
+		DrmId:           drmID,
+	}
+}
+
 func floatToPtr(f float64) *float64 {
 	return &f
 }
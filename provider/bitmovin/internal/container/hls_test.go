@@ -135,6 +135,123 @@ func TestNewHLSAssembler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "an hls config with audio tracks results in one audio media per track",
+			cfg: AssemblerCfg{
+				EncID:    "testEncID",
+				OutputID: "testOutputID",
+				AudCfgID: "testAudCfgID",
+				AudioTracks: []AudioTrack{
+					{
+						MuxingStream: model.MuxingStream{StreamId: "testEnglishStreamID"},
+						Language:     "en",
+						Name:         "English",
+						Default:      true,
+						Autoselect:   true,
+					},
+					{
+						MuxingStream:    model.MuxingStream{StreamId: "testDescribedStreamID"},
+						Language:        "en",
+						Name:            "English (AD)",
+						Characteristics: []string{"public.accessibility.describes-video"},
+					},
+				},
+				ManifestID:         "testManifestID",
+				ManifestMasterPath: "test/master/manifest/path",
+				SegDuration:        88,
+			},
+			api: hlsContainerAPI(),
+			assertParams: func(t *testing.T, api HLSContainerAPI) {
+				tsMuxingAPI := api.TSMuxing.(*fakeTSMuxingAPI)
+				hlsStreamsAPI := api.HLSStreams.(*fakeHLSStreamsAPI)
+				hlsAudioMediaAPI := api.HLSAudioMedia.(*fakeHLSAudioMediaAPI)
+
+				if g, e := tsMuxingAPI.numInvocations, 2; g != e {
+					t.Errorf("invalid number of calls to the TS muxing api: got %d, expected %d", g, e)
+					return
+				}
+
+				if g, e := tsMuxingAPI.invocationDetails[1].muxing.Outputs[0].OutputPath, "test/master/manifest/path/testAudCfgID_1"; g != e {
+					t.Errorf("invalid audio muxing output path: got %q, expected %q", g, e)
+				}
+
+				if g, e := hlsStreamsAPI.numInvocations, 0; g != e {
+					t.Errorf("invalid number of calls to the HLS streams api: got %d, expected %d", g, e)
+				}
+
+				if g, e := hlsAudioMediaAPI.numInvocations, 2; g != e {
+					t.Errorf("invalid number of calls to the HLS audio media api: got %d, expected %d", g, e)
+					return
+				}
+
+				expectedHLSAudioMedia := []model.AudioMediaInfo{
+					{
+						GroupId:     "testAudCfgID",
+						Language:    "en",
+						Name:        "English",
+						IsDefault:   boolToPtr(true),
+						Autoselect:  boolToPtr(true),
+						SegmentPath: "testAudCfgID_0",
+						EncodingId:  "testEncID",
+						StreamId:    "testEnglishStreamID",
+						Uri:         "testAudCfgID_0.m3u8",
+						Forced:      boolToPtr(false),
+					},
+					{
+						GroupId:         "testAudCfgID",
+						Language:        "en",
+						Name:            "English (AD)",
+						IsDefault:       boolToPtr(false),
+						Autoselect:      boolToPtr(false),
+						Characteristics: []string{"public.accessibility.describes-video"},
+						SegmentPath:     "testAudCfgID_1",
+						EncodingId:      "testEncID",
+						StreamId:        "testDescribedStreamID",
+						Uri:             "testAudCfgID_1.m3u8",
+						Forced:          boolToPtr(false),
+					},
+				}
+
+				for i, e := range expectedHLSAudioMedia {
+					if g := hlsAudioMediaAPI.invocationDetails[i].mediaInfo; !reflect.DeepEqual(g, e) {
+						t.Errorf("invalid hls audio media #%d: got  %v\nexpected %v\ndiff %v", i, g, e, cmp.Diff(g, e))
+					}
+				}
+			},
+		},
+		{
+			name: "a per-title template without manifest only results in muxings",
+			cfg: AssemblerCfg{
+				EncID:              "testEncID",
+				OutputID:           "testOutputID",
+				AudCfgID:           "testAudCfgID",
+				VidCfgID:           "testVidCfgID",
+				AudMuxingStream:    model.MuxingStream{StreamId: "testAudStreamID"},
+				VidMuxingStream:    model.MuxingStream{StreamId: "testVidStreamID"},
+				ManifestMasterPath: "test/master/manifest/path",
+				SegDuration:        88,
+				PerTitle:           true,
+			},
+			api: hlsContainerAPI(),
+			assertParams: func(t *testing.T, api HLSContainerAPI) {
+				tsMuxingAPI := api.TSMuxing.(*fakeTSMuxingAPI)
+				hlsStreamsAPI := api.HLSStreams.(*fakeHLSStreamsAPI)
+				hlsAudioMediaAPI := api.HLSAudioMedia.(*fakeHLSAudioMediaAPI)
+
+				if g, e := tsMuxingAPI.numInvocations, 2; g != e {
+					t.Errorf("invalid number of calls to the TS muxing api: got %d, expected %d", g, e)
+					return
+				}
+
+				if g, e := tsMuxingAPI.invocationDetails[1].muxing.Outputs[0].OutputPath, "test/master/manifest/path/testVidCfgID_{height}p_{bitrate}"; g != e {
+					t.Errorf("invalid video muxing output path: got %q, expected %q", g, e)
+				}
+
+				if g := hlsStreamsAPI.numInvocations + hlsAudioMediaAPI.numInvocations; g != 0 {
+					t.Errorf("unexpected calls to the HLS manifest apis: got %d", g)
+				}
+			},
+		},
 		{
 			name: "an hls config with only video results in the correct calls to the HLSContainerAPI",
 			cfg: AssemblerCfg{
@@ -208,6 +325,89 @@ func TestNewHLSAssembler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "video streams reference the subtitles group of their captions",
+			cfg: AssemblerCfg{
+				EncID:              "testEncID",
+				OutputID:           "testOutputID",
+				VidCfgID:           "testVidCfgID",
+				VidMuxingStream:    model.MuxingStream{StreamId: "testVidStreamID"},
+				ManifestID:         "testManifestID",
+				ManifestMasterPath: "test/master/manifest/path",
+				SubtitlesGroup:     "captions",
+				SegDuration:        88,
+			},
+			api: hlsContainerAPI(),
+			assertParams: func(t *testing.T, api HLSContainerAPI) {
+				hlsStreamsAPI := api.HLSStreams.(*fakeHLSStreamsAPI)
+
+				if g, e := hlsStreamsAPI.numInvocations, 1; g != e {
+					t.Errorf("invalid number of calls to the HLS streams api: got %d, expected %d", g, e)
+					return
+				}
+
+				if g, e := hlsStreamsAPI.invocationDetails[0].streamInfo.Subtitles, "captions"; g != e {
+					t.Errorf("invalid subtitles group: got %q, expected %q", g, e)
+				}
+			},
+		},
+		{
+			name: "an hls config with an i-frame playlist adds it to the video stream",
+			cfg: AssemblerCfg{
+				EncID:              "testEncID",
+				OutputID:           "testOutputID",
+				VidCfgID:           "testVidCfgID",
+				VidMuxingStream:    model.MuxingStream{StreamId: "testVidStreamID"},
+				ManifestID:         "testManifestID",
+				ManifestMasterPath: "test/master/manifest/path",
+				IFramePlaylist:     true,
+				SegDuration:        88,
+			},
+			api: hlsContainerAPI(),
+			assertParams: func(t *testing.T, api HLSContainerAPI) {
+				iframeAPI := api.HLSIFramePlaylists.(*fakeHLSIFramePlaylistsAPI)
+
+				if g, e := len(iframeAPI.playlists), 1; g != e {
+					t.Fatalf("invalid number of calls to the HLS i-frame playlists api: got %d, expected %d", g, e)
+				}
+
+				if g, e := iframeAPI.playlists[0], (model.IFramePlaylist{Filename: "testVidCfgID_iframes.m3u8"}); g != e {
+					t.Errorf("invalid i-frame playlist: got %+v, expected %+v", g, e)
+				}
+			},
+		},
+		{
+			name: "encrypted muxings leave their segments to their drm configuration referenced by the manifest",
+			cfg: func() AssemblerCfg {
+				cfg := defaultAssemblerCfg
+				cfg.Encrypt = (&fakeEncrypter{}).encrypt
+				return cfg
+			}(),
+			api: hlsContainerAPI(),
+			assertParams: func(t *testing.T, api HLSContainerAPI) {
+				for _, d := range api.TSMuxing.(*fakeTSMuxingAPI).invocationDetails {
+					if d.muxing.Outputs != nil {
+						t.Errorf("expected no outputs on encrypted muxings, got %v", d.muxing.Outputs)
+					}
+				}
+				if g, e := api.HLSStreams.(*fakeHLSStreamsAPI).invocationDetails[0].streamInfo.DrmId, "ts:test/master/manifest/path/testVidCfgID"; g != e {
+					t.Errorf("invalid stream info drm: got %q, expected %q", g, e)
+				}
+				if g, e := api.HLSAudioMedia.(*fakeHLSAudioMediaAPI).invocationDetails[0].mediaInfo.DrmId, "ts:test/master/manifest/path/testAudCfgID"; g != e {
+					t.Errorf("invalid audio media drm: got %q, expected %q", g, e)
+				}
+			},
+		},
+		{
+			name: "when the muxings can't be encrypted, a useful error is returned",
+			cfg: func() AssemblerCfg {
+				cfg := defaultAssemblerCfg
+				cfg.Encrypt = (&fakeEncrypter{forceErr: true}).encrypt
+				return cfg
+			}(),
+			api:     hlsContainerAPI(),
+			wantErr: "encrypting ts muxing: forced by test",
+		},
 		{
 			name: "when the ts muxing api is erroring, a useful error is returned",
 			cfg:  defaultAssemblerCfg,
@@ -257,9 +457,10 @@ func TestNewHLSAssembler(t *testing.T) {
 
 func hlsContainerAPI() HLSContainerAPI {
 	return HLSContainerAPI{
-		HLSAudioMedia: &fakeHLSAudioMediaAPI{},
-		TSMuxing:      &fakeTSMuxingAPI{},
-		HLSStreams:    &fakeHLSStreamsAPI{},
+		HLSAudioMedia:      &fakeHLSAudioMediaAPI{},
+		TSMuxing:           &fakeTSMuxingAPI{},
+		HLSStreams:         &fakeHLSStreamsAPI{},
+		HLSIFramePlaylists: &fakeHLSIFramePlaylistsAPI{},
 	}
 }
 
@@ -328,3 +529,41 @@ func (a *fakeHLSStreamsAPI) Create(manifestID string, streamInfo model.StreamInf
 
 	return &streamInfo, nil
 }
+
+func (a *fakeTSMuxingAPI) Delete(string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
+func (a *fakeHLSAudioMediaAPI) Delete(string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
+func (a *fakeHLSStreamsAPI) Delete(string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
+type fakeHLSIFramePlaylistsAPI struct {
+	playlists []model.IFramePlaylist
+}
+
+func (a *fakeHLSIFramePlaylistsAPI) Create(_, _ string, playlist model.IFramePlaylist) (*model.IFramePlaylist, error) {
+	a.playlists = append(a.playlists, playlist)
+	return &playlist, nil
+}
+
+func (a *fakeHLSIFramePlaylistsAPI) Delete(string, string, string) (*model.BitmovinResponse, error) {
+	return &model.BitmovinResponse{}, nil
+}
+
+// fakeEncrypter encrypts muxings with DRM configurations named after their
+// muxing type and the path of their segments
+type fakeEncrypter struct {
+	forceErr bool
+}
+
+func (e *fakeEncrypter) encrypt(muxing MuxingType, _ string, output model.EncodingOutput) (string, error) {
+	if e.forceErr {
+		return "", errors.New("forced by test")
+	}
+	return string(muxing) + ":" + output.OutputPath, nil
+}
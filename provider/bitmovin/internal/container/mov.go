@@ -23,7 +23,7 @@ func NewMOVAssembler(api *bitmovin.BitmovinApi) *MOVAssembler {
 
 // Assemble creates MOV outputs
 func (a *MOVAssembler) Assemble(cfg AssemblerCfg) error {
-	_, err := a.api.Encoding.Encodings.Muxings.ProgressiveMov.Create(cfg.EncID, model.ProgressiveMovMuxing{
+	muxing, err := a.api.Encoding.Encodings.Muxings.ProgressiveMov.Create(cfg.EncID, model.ProgressiveMovMuxing{
 		Filename:             path.Base(cfg.OutputFilename),
 		Streams:              streamsFrom(cfg),
 		StreamConditionsMode: model.StreamConditionsMode_DROP_STREAM,
@@ -34,6 +34,10 @@ func (a *MOVAssembler) Assemble(cfg AssemblerCfg) error {
 	if err != nil {
 		return errors.Wrap(err, "creating mov muxing")
 	}
+	track(cfg, "mov muxing", muxing.Id, func() error {
+		_, err := a.api.Encoding.Encodings.Muxings.ProgressiveMov.Delete(cfg.EncID, muxing.Id)
+		return err
+	})
 
 	return nil
 }
@@ -23,7 +23,7 @@ func NewMP4Assembler(api *bitmovin.BitmovinApi) *MP4Assembler {
 
 // Assemble creates MP4 outputs
 func (a *MP4Assembler) Assemble(cfg AssemblerCfg) error {
-	_, err := a.api.Encoding.Encodings.Muxings.Mp4.Create(cfg.EncID, model.Mp4Muxing{
+	muxing, err := a.api.Encoding.Encodings.Muxings.Mp4.Create(cfg.EncID, model.Mp4Muxing{
 		Filename:             path.Base(cfg.OutputFilename),
 		Streams:              streamsFrom(cfg),
 		StreamConditionsMode: model.StreamConditionsMode_DROP_STREAM,
@@ -34,6 +34,10 @@ func (a *MP4Assembler) Assemble(cfg AssemblerCfg) error {
 	if err != nil {
 		return errors.Wrap(err, "creating mp4 muxing")
 	}
+	track(cfg, "mp4 muxing", muxing.Id, func() error {
+		_, err := a.api.Encoding.Encodings.Muxings.Mp4.Delete(cfg.EncID, muxing.Id)
+		return err
+	})
 
 	return nil
 }
@@ -23,7 +23,7 @@ func NewProgressiveWebMAssembler(api *bitmovin.BitmovinApi) *ProgressiveWebMAsse
 
 // Assemble creates ProgressiveWebM outputs
 func (a *ProgressiveWebMAssembler) Assemble(cfg AssemblerCfg) error {
-	_, err := a.api.Encoding.Encodings.Muxings.ProgressiveWebm.Create(cfg.EncID, model.ProgressiveWebmMuxing{
+	muxing, err := a.api.Encoding.Encodings.Muxings.ProgressiveWebm.Create(cfg.EncID, model.ProgressiveWebmMuxing{
 		Filename:             path.Base(cfg.OutputFilename),
 		Streams:              streamsFrom(cfg),
 		StreamConditionsMode: model.StreamConditionsMode_DROP_STREAM,
@@ -34,6 +34,10 @@ func (a *ProgressiveWebMAssembler) Assemble(cfg AssemblerCfg) error {
 	if err != nil {
 		return errors.Wrap(err, "creating progressive webm muxing")
 	}
+	track(cfg, "progressive webm muxing", muxing.Id, func() error {
+		_, err := a.api.Encoding.Encodings.Muxings.ProgressiveWebm.Delete(cfg.EncID, muxing.Id)
+		return err
+	})
 
 	return nil
 }
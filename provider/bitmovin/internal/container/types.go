@@ -7,33 +7,108 @@ type HLSContainerAPI struct {
 	HLSAudioMedia HLSAudioMediaAPI
 	TSMuxing      TSMuxingAPI
 	HLSStreams    HLSStreamsAPI
+
+	// HLSIFramePlaylists is only needed for outputs with I-frame playlists
+	HLSIFramePlaylists HLSIFramePlaylistsAPI
 }
 
 // HLSAudioMediaAPI contains methods for managing HLS Media Audio objects
 type HLSAudioMediaAPI interface {
 	Create(string, model.AudioMediaInfo) (*model.AudioMediaInfo, error)
+	Delete(manifestID, mediaID string) (*model.BitmovinResponse, error)
 }
 
 // TSMuxingAPI contains methods for managing TS muxing objects
 type TSMuxingAPI interface {
 	Create(string, model.TsMuxing) (*model.TsMuxing, error)
+	Delete(encodingID, muxingID string) (*model.BitmovinResponse, error)
 }
 
 // HLSStreamsAPI contains methods for managing HLS stream objects
 type HLSStreamsAPI interface {
 	Create(string, model.StreamInfo) (*model.StreamInfo, error)
+	Delete(manifestID, streamID string) (*model.BitmovinResponse, error)
+}
+
+// HLSIFramePlaylistsAPI contains methods for managing the I-frame playlists
+// of HLS streams
+type HLSIFramePlaylistsAPI interface {
+	Create(manifestID, streamID string, playlist model.IFramePlaylist) (*model.IFramePlaylist, error)
+	Delete(manifestID, streamID, playlistID string) (*model.BitmovinResponse, error)
 }
 
 // CMAFContainerAPI holds underlying api interfaces for CMAF outputs
 type CMAFContainerAPI struct {
-	HLSAudioMedia HLSAudioMediaAPI
-	CMAFMuxing    CMAFMuxingAPI
-	HLSStreams    HLSStreamsAPI
+	HLSAudioMedia       HLSAudioMediaAPI
+	CMAFMuxing          CMAFMuxingAPI
+	HLSStreams          HLSStreamsAPI
+	DASHRepresentations DASHCMAFRepresentationsAPI
+
+	// HLSIFramePlaylists is only needed for outputs with I-frame playlists
+	HLSIFramePlaylists HLSIFramePlaylistsAPI
+
+	// DASHDRMRepresentations and DASHContentProtections are only needed for
+	// encrypted outputs
+	DASHDRMRepresentations DASHCMAFDRMRepresentationsAPI
+	DASHContentProtections DASHContentProtectionsAPI
 }
 
 // CMAFMuxingAPI contains methods for managing CMAF muxing objects
 type CMAFMuxingAPI interface {
 	Create(string, model.CmafMuxing) (*model.CmafMuxing, error)
+	Delete(encodingID, muxingID string) (*model.BitmovinResponse, error)
+}
+
+// DASHContainerAPI holds underlying api interfaces for DASH outputs
+type DASHContainerAPI struct {
+	FMP4Muxing          FMP4MuxingAPI
+	DASHRepresentations DASHFMP4RepresentationsAPI
+
+	// DASHDRMRepresentations and DASHContentProtections are only needed for
+	// encrypted outputs
+	DASHDRMRepresentations DASHFMP4DRMRepresentationsAPI
+	DASHContentProtections DASHContentProtectionsAPI
+}
+
+// FMP4MuxingAPI contains methods for managing fMP4 muxing objects
+type FMP4MuxingAPI interface {
+	Create(string, model.Fmp4Muxing) (*model.Fmp4Muxing, error)
+	Delete(encodingID, muxingID string) (*model.BitmovinResponse, error)
+}
+
+// DASHFMP4RepresentationsAPI contains methods for managing the fMP4
+// representations of DASH manifests
+type DASHFMP4RepresentationsAPI interface {
+	Create(manifestID, periodID, adaptationSetID string, representation model.DashFmp4Representation) (*model.DashFmp4Representation, error)
+	Delete(manifestID, periodID, adaptationSetID, representationID string) (*model.BitmovinResponse, error)
+}
+
+// DASHCMAFRepresentationsAPI contains methods for managing the CMAF
+// representations of DASH manifests
+type DASHCMAFRepresentationsAPI interface {
+	Create(manifestID, periodID, adaptationSetID string, representation model.DashCmafRepresentation) (*model.DashCmafRepresentation, error)
+	Delete(manifestID, periodID, adaptationSetID, representationID string) (*model.BitmovinResponse, error)
+}
+
+// DASHFMP4DRMRepresentationsAPI contains methods for managing the encrypted
+// fMP4 representations of DASH manifests
+type DASHFMP4DRMRepresentationsAPI interface {
+	Create(manifestID, periodID, adaptationSetID string, representation model.DashFmp4DrmRepresentation) (*model.DashFmp4DrmRepresentation, error)
+	Delete(manifestID, periodID, adaptationSetID, representationID string) (*model.BitmovinResponse, error)
+}
+
+// DASHCMAFDRMRepresentationsAPI contains methods for managing the encrypted
+// CMAF representations of DASH manifests
+type DASHCMAFDRMRepresentationsAPI interface {
+	Create(manifestID, periodID, adaptationSetID string, representation model.DashCmafDrmRepresentation) (*model.DashCmafDrmRepresentation, error)
+	Delete(manifestID, periodID, adaptationSetID, representationID string) (*model.BitmovinResponse, error)
+}
+
+// DASHContentProtectionsAPI contains methods for managing the content
+// protections signaled by the encrypted representations of DASH manifests
+type DASHContentProtectionsAPI interface {
+	Create(manifestID, periodID, adaptationSetID, representationID string, protection model.ContentProtection) (*model.ContentProtection, error)
+	Delete(manifestID, periodID, adaptationSetID, representationID, protectionID string) (*model.BitmovinResponse, error)
 }
 
 func int64Value(i *int64) int64 {
@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Errors holds the errors of the failed tasks, in the order of their index
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Run calls fn for every index in [0, n), with at most size calls running
+// concurrently. The context given to fn is canceled as soon as a call fails,
+// and the tasks that haven't started yet are skipped. Run waits for the
+// running calls to return before returning.
+//
+// A single failure is returned as is, several failures are returned as
+// Errors. Failures caused by the cancellation are not reported.
+func Run(ctx context.Context, size, n int, fn func(ctx context.Context, i int) error) error {
+	if size <= 0 || size > n {
+		size = n
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, n)
+	tasks := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < size; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				if err := fn(ctx, i); err != nil {
+					errs[i] = err
+					cancel()
+				}
+			}
+		}()
+	}
+
+	fed := 0
+feed:
+	for ; fed < n; fed++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case tasks <- fed:
+		}
+	}
+	close(tasks)
+	wg.Wait()
+
+	var failed Errors
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			failed = append(failed, err)
+		}
+	}
+	switch len(failed) {
+	case 0:
+		// nothing failed on its own, the parent context may have been
+		// canceled before every task ran
+		if fed < n || hasErr(errs) {
+			return ctx.Err()
+		}
+		return nil
+	case 1:
+		return failed[0]
+	}
+	return failed
+}
+
+func hasErr(errs []error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBoundsConcurrency(t *testing.T) {
+	var running, maxRunning, calls int32
+	err := Run(context.Background(), 3, 20, func(context.Context, int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 20 {
+		t.Errorf("wrong number of calls: got %d, expected 20", calls)
+	}
+	if maxRunning > 3 {
+		t.Errorf("too many concurrent calls: got %d, expected at most 3", maxRunning)
+	}
+}
+
+func TestRunStopsOnError(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	err := Run(context.Background(), 2, 50, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		switch i {
+		case 0:
+			<-release
+			return errors.New("throttled")
+		case 1:
+			close(release)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "throttled" {
+		t.Errorf("wrong error: got %v, expected the failure of the first task", err)
+	}
+	if calls > 4 {
+		t.Errorf("remaining tasks were not skipped: got %d calls", calls)
+	}
+}
+
+func TestRunAggregatesErrors(t *testing.T) {
+	release := make(chan struct{})
+	var ready int32
+	err := Run(context.Background(), 3, 3, func(ctx context.Context, i int) error {
+		if atomic.AddInt32(&ready, 1) == 3 {
+			close(release)
+		}
+		<-release
+		if i == 1 {
+			return nil
+		}
+		return errors.New("task failed")
+	})
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("wrong error type: %#v", err)
+	}
+	if g, e := errs.Error(), "task failed; task failed"; g != e {
+		t.Errorf("wrong error: got %q, expected %q", g, e)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Run(ctx, 2, 5, func(context.Context, int) error {
+		t.Error("unexpected call")
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("wrong error: got %v, expected %v", err, context.Canceled)
+	}
+}
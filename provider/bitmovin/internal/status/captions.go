@@ -0,0 +1,48 @@
+package status
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go"
+	"github.com/bitmovin/bitmovin-api-sdk-go/query"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/pkg/errors"
+)
+
+// EnrichCaptionRenditions adds the WebVTT caption renditions of an encoding to
+// the output files of a job status. The rendition of the caption at index i is
+// written to the captions_i directory, its language being languages[i].
+func EnrichCaptionRenditions(api *bitmovin.BitmovinApi, s provider.JobStatus, rootFolder string, languages []string) (provider.JobStatus, error) {
+	for offset, total := int32(0), int64(1); int64(offset) < total; {
+		resp, err := api.Encoding.Encodings.Muxings.ChunkedText.List(s.ProviderJobID, func(params *query.ChunkedTextMuxingListQueryParams) {
+			params.Offset = offset
+			params.Limit = listPageSize
+		})
+		if err != nil {
+			return s, errors.Wrap(err, "retrieving chunked text muxings from the Bitmovin API")
+		}
+		for _, muxing := range resp.Items {
+			if len(muxing.Outputs) == 0 {
+				continue
+			}
+			outputPath := muxing.Outputs[0].OutputPath
+
+			var i int
+			if _, err := fmt.Sscanf(path.Base(outputPath), "captions_%d", &i); err != nil || i >= len(languages) {
+				continue
+			}
+			s.Output.Files = append(s.Output.Files, provider.OutputFile{
+				Path:      s.Output.Destination + relativePath(outputPath, rootFolder),
+				Container: "webvtt",
+				Captions:  []string{languages[i]},
+			})
+		}
+		total, offset = int64Value(resp.TotalCount), offset+int32(len(resp.Items))
+		if len(resp.Items) == 0 {
+			break
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,149 @@
+package status
+
+import (
+	"strings"
+
+	"github.com/bitmovin/bitmovin-api-sdk-go"
+	"github.com/bitmovin/bitmovin-api-sdk-go/model"
+	"github.com/bitmovin/bitmovin-api-sdk-go/query"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/pkg/errors"
+)
+
+const listPageSize = 100
+
+// EnrichPerTitleRenditions adds the renditions produced by a per-title encoding
+// to the output files of a job status, with their resolution and bitrate. Their
+// paths are made relative to the rootFolder of the job.
+func EnrichPerTitleRenditions(api *bitmovin.BitmovinApi, s provider.JobStatus, rootFolder string) (provider.JobStatus, error) {
+	results := map[string]model.Stream{}
+	err := listAll(func(offset int32) (int, *int64, error) {
+		resp, err := api.Encoding.Encodings.Streams.List(s.ProviderJobID, func(params *query.StreamListQueryParams) {
+			params.Offset = offset
+			params.Limit = listPageSize
+		})
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "retrieving streams from the Bitmovin API")
+		}
+		for _, stream := range resp.Items {
+			if stream.Mode == model.StreamMode_PER_TITLE_RESULT {
+				results[stream.Id] = stream
+			}
+		}
+		return len(resp.Items), resp.TotalCount, nil
+	})
+	if err != nil {
+		return s, err
+	}
+
+	var renditions []rendition
+	err = listAll(func(offset int32) (int, *int64, error) {
+		resp, err := api.Encoding.Encodings.Muxings.Ts.List(s.ProviderJobID, func(params *query.TsMuxingListQueryParams) {
+			params.Offset = offset
+			params.Limit = listPageSize
+		})
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "retrieving TS muxings from the Bitmovin API")
+		}
+		for _, muxing := range resp.Items {
+			renditions = append(renditions, rendition{"ts", muxing.Streams, muxing.Outputs, muxing.AvgBitrate})
+		}
+		return len(resp.Items), resp.TotalCount, nil
+	})
+	if err != nil {
+		return s, err
+	}
+	err = listAll(func(offset int32) (int, *int64, error) {
+		resp, err := api.Encoding.Encodings.Muxings.Cmaf.List(s.ProviderJobID, func(params *query.CmafMuxingListQueryParams) {
+			params.Offset = offset
+			params.Limit = listPageSize
+		})
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "retrieving CMAF muxings from the Bitmovin API")
+		}
+		for _, muxing := range resp.Items {
+			renditions = append(renditions, rendition{"cmaf", muxing.Streams, muxing.Outputs, muxing.AvgBitrate})
+		}
+		return len(resp.Items), resp.TotalCount, nil
+	})
+	if err != nil {
+		return s, err
+	}
+
+	codecs := map[string]string{}
+	for _, r := range renditions {
+		if len(r.streams) == 0 || len(r.outputs) == 0 {
+			continue
+		}
+		stream, ok := results[r.streams[0].StreamId]
+		if !ok {
+			continue
+		}
+
+		codec, ok := codecs[stream.CodecConfigId]
+		if !ok {
+			cfgType, err := api.Encoding.Configurations.Type.Get(stream.CodecConfigId)
+			if err != nil {
+				return s, errors.Wrapf(err, "retrieving the type of codec config %q", stream.CodecConfigId)
+			}
+			codec = strings.ToLower(string(cfgType.Type))
+			codecs[stream.CodecConfigId] = codec
+		}
+
+		var width, height int64
+		if settings := stream.AppliedSettings; settings != nil {
+			width, height = int64(int32Value(settings.Width)), int64(int32Value(settings.Height))
+		}
+
+		s.Output.Files = append(s.Output.Files, provider.OutputFile{
+			Path:       s.Output.Destination + relativePath(r.outputs[0].OutputPath, rootFolder),
+			Container:  r.container,
+			VideoCodec: codec,
+			Width:      width,
+			Height:     height,
+			Bitrate:    int64Value(r.bitrate),
+		})
+	}
+
+	return s, nil
+}
+
+// listAll calls list with the offset of every page of a listing until all of
+// its items were retrieved, list returning the number of items of the page and
+// the total count of the listing
+func listAll(list func(offset int32) (int, *int64, error)) error {
+	for offset, total := int32(0), int64(1); int64(offset) < total; {
+		n, count, err := list(offset)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		total, offset = int64Value(count), offset+int32(n)
+	}
+	return nil
+}
+
+type rendition struct {
+	container string
+	streams   []model.MuxingStream
+	outputs   []model.EncodingOutput
+	bitrate   *int64
+}
+
+// relativePath returns the part of an output path following the root folder
+// of the job, the path being returned as is if it doesn't contain it
+func relativePath(outputPath, rootFolder string) string {
+	if i := strings.Index(outputPath, rootFolder+"/"); i >= 0 {
+		return outputPath[i+len(rootFolder)+1:]
+	}
+	return strings.TrimLeft(outputPath, "/")
+}
+
+func int64Value(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+// BreakerState is the state of the circuit breaker of a provider.
+type BreakerState string
+
+const (
+	// BreakerClosed is the state of a breaker letting every call through.
+	BreakerClosed = BreakerState("closed")
+
+	// BreakerOpen is the state of a breaker rejecting submissions, after
+	// too many consecutive failures.
+	BreakerOpen = BreakerState("open")
+
+	// BreakerHalfOpen is the state of a breaker letting a single submission
+	// through once the cool-down is over, to find out whether the provider
+	// recovered.
+	BreakerHalfOpen = BreakerState("half-open")
+)
+
+// CircuitOpenError is returned when a job is submitted to a provider whose
+// circuit breaker is open.
+type CircuitOpenError struct {
+	Provider string
+	Until    time.Time
+}
+
+func (e CircuitOpenError) Error() string {
+	return fmt.Sprintf("provider %q is unavailable after too many consecutive failures, retry after %s",
+		e.Provider, e.Until.Format(time.RFC3339))
+}
+
+// Breaker is a circuit breaker that opens after a number of consecutive
+// failures of a provider, and half-opens once the cool-down is over.
+type Breaker struct {
+	name      string
+	threshold uint
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures uint
+	openedAt time.Time
+	trial    bool
+}
+
+// NewBreaker returns a closed breaker for the given provider.
+func NewBreaker(name string, threshold uint, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     BreakerClosed,
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.cooledDown() {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Allow returns a CircuitOpenError when the call should not go through.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.cooledDown() {
+		b.state = BreakerHalfOpen
+	}
+	switch b.state {
+	case BreakerOpen:
+		return CircuitOpenError{Provider: b.name, Until: b.openedAt.Add(b.cooldown)}
+	case BreakerHalfOpen:
+		if b.trial {
+			return CircuitOpenError{Provider: b.name, Until: b.openedAt.Add(b.cooldown)}
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// Record records the outcome of a call to the provider. Errors that don't
+// tell anything about the availability of the provider only release the
+// trial call of a half-open breaker.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil && !isAvailabilityError(err) {
+		b.trial = false
+		return
+	}
+	if err == nil {
+		if b.state != BreakerOpen {
+			b.state = BreakerClosed
+			b.failures = 0
+			b.trial = false
+		}
+		return
+	}
+	b.failures++
+	if b.state == BreakerOpen {
+		// the cool-down runs from the failure that opened the breaker
+		return
+	}
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.trial = false
+	}
+}
+
+func (b *Breaker) cooledDown() bool {
+	return !b.now().Before(b.openedAt.Add(b.cooldown))
+}
+
+// isAvailabilityError reports whether the error is caused by the provider
+// being unavailable: server errors, rate limiting, timeouts and transport
+// errors. Other errors, like the ones of invalid jobs, don't tell anything
+// about the provider.
+func isAvailabilityError(err error) bool {
+	var unavailable UnavailableError
+	var netErr net.Error
+	var awsErr awserr.RequestFailure
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.As(err, &unavailable),
+		errors.As(err, &netErr),
+		errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.As(err, &awsErr):
+		return awsErr.StatusCode() >= 500 || awsErr.StatusCode() == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// guardedProvider records the outcome of the submissions and status calls of a
+// provider in its breaker, rejecting submissions while the breaker is open.
+type guardedProvider struct {
+	TranscodingProvider
+	breaker *Breaker
+}
+
+func (p *guardedProvider) Transcode(ctx context.Context, job *db.Job) (*JobStatus, error) {
+	if err := p.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	status, err := p.TranscodingProvider.Transcode(ctx, job)
+	p.breaker.Record(err)
+	return status, err
+}
+
+func (p *guardedProvider) JobStatus(ctx context.Context, job *db.Job) (*JobStatus, error) {
+	status, err := p.TranscodingProvider.JobStatus(ctx, job)
+	p.breaker.Record(err)
+	return status, err
+}
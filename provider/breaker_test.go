@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2020, 7, 1, 2, 0, 0, 0, time.UTC)
+	b := NewBreaker("fake", 2, time.Minute)
+	b.now = func() time.Time { return now }
+	unavailable := StatusError(503, 0, errors.New("service unavailable"))
+
+	b.Record(unavailable)
+	b.Record(ErrPresetMapNotFound)
+	b.Record(JobNotFoundError{ID: "job-123"})
+	b.Record(StatusError(400, 0, errors.New("invalid job")))
+	b.Record(errors.New("unsupported codec"))
+	if err := b.Allow(); err != nil {
+		t.Fatalf("breaker opened too early: %v", err)
+	}
+	b.Record(unavailable)
+	if g, e := b.State(), BreakerOpen; g != e {
+		t.Fatalf("wrong state: want %q, got %q", e, g)
+	}
+	err := b.Allow()
+	if _, ok := err.(CircuitOpenError); !ok {
+		t.Fatalf("wrong error: %#v", err)
+	}
+
+	// failures of the jobs still running don't delay the trial call
+	now = now.Add(30 * time.Second)
+	b.Record(unavailable)
+	now = now.Add(30 * time.Second)
+	if g, e := b.State(), BreakerHalfOpen; g != e {
+		t.Fatalf("wrong state: want %q, got %q", e, g)
+	}
+	if err = b.Allow(); err != nil {
+		t.Fatalf("trial call was rejected: %v", err)
+	}
+	if err = b.Allow(); err == nil {
+		t.Fatal("expected a single trial call to go through")
+	}
+	b.Record(unavailable)
+	if g, e := b.State(), BreakerOpen; g != e {
+		t.Fatalf("failed trial should open the breaker again: want %q, got %q", e, g)
+	}
+
+	now = now.Add(time.Minute)
+	if err = b.Allow(); err != nil {
+		t.Fatalf("trial call was rejected: %v", err)
+	}
+	b.Record(nil)
+	if g, e := b.State(), BreakerClosed; g != e {
+		t.Fatalf("successful trial should close the breaker: want %q, got %q", e, g)
+	}
+	b.Record(unavailable)
+	if err = b.Allow(); err != nil {
+		t.Errorf("failures were not reset when closing the breaker: %v", err)
+	}
+}
+
+func TestIsAvailabilityError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server errors", err: StatusError(502, 0, errors.New("bad gateway")), want: true},
+		{name: "rate limiting", err: StatusError(429, 0, errors.New("too many requests")), want: true},
+		{name: "client errors", err: StatusError(422, 0, errors.New("invalid preset"))},
+		{name: "dial errors", err: TransportError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), want: true},
+		{name: "timeouts", err: fmt.Errorf("fetching status: %w", context.DeadlineExceeded), want: true},
+		{name: "aws server errors", err: awserr.NewRequestFailure(awserr.New("InternalServerError", "oops", nil), 500, "req"), want: true},
+		{name: "aws validation errors", err: awserr.NewRequestFailure(awserr.New("BadRequestException", "invalid", nil), 400, "req")},
+		{name: "canceled calls", err: context.Canceled},
+		{name: "validation errors", err: errors.New("unsupported codec")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAvailabilityError(tt.err); got != tt.want {
+				t.Errorf("wrong classification of %v: want %t, got %t", tt.err, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"path"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+// Captions returns the captions carried by the output with the given file name
+func Captions(captions []db.Caption, fileName string) []db.Caption {
+	var applied []db.Caption
+	for _, c := range captions {
+		if c.AppliesTo(fileName) {
+			applied = append(applied, c)
+		}
+	}
+	return applied
+}
+
+// CaptionLanguages returns the languages of the captions carried by the output
+// file at filePath, matched to the outputs of the job by file name
+func CaptionLanguages(job *db.Job, filePath string) []string {
+	name := path.Base(filePath)
+	for _, output := range job.Outputs {
+		if path.Base(output.FileName) != name {
+			continue
+		}
+		var languages []string
+		for _, c := range Captions(job.Captions, output.FileName) {
+			languages = append(languages, c.Language)
+		}
+		return languages
+	}
+	return nil
+}
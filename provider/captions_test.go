@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCaptionLanguages(t *testing.T) {
+	job := &db.Job{
+		Outputs: []db.TranscodeOutput{{FileName: "hls/1080p.m3u8"}, {FileName: "video.mp4"}},
+		Captions: []db.Caption{
+			{Format: db.CaptionFormatWebVTT, Language: "en"},
+			{Format: db.CaptionFormatEmbedded, Language: "es", Outputs: []string{"video.mp4"}},
+		},
+	}
+
+	tests := []struct {
+		name, path string
+		want       []string
+	}{
+		{name: "ladder captions", path: "s3://bucket/job/hls/1080p.m3u8", want: []string{"en"}},
+		{name: "output captions", path: "s3://bucket/job/video.mp4", want: []string{"en", "es"}},
+		{name: "unknown file", path: "s3://bucket/job/report.txt", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, CaptionLanguages(job, tt.path)); diff != "" {
+				t.Errorf("wrong languages: %s", diff)
+			}
+		})
+	}
+}
@@ -9,6 +9,13 @@ type Description struct {
 	Capabilities Capabilities `json:"capabilities"`
 	Health       Health       `json:"health"`
 	Enabled      bool         `json:"enabled"`
+
+	// ConfigError explains why the provider is not enabled
+	ConfigError string `json:"configError,omitempty"`
+
+	// CircuitBreaker is set when the circuit breaker of the provider is not
+	// closed
+	CircuitBreaker BreakerState `json:"circuitBreaker,omitempty"`
 }
 
 // Capabilities describes the available features in the provider. It specificie
@@ -41,9 +41,10 @@ type JobRequest struct {
 }
 
 type JobSpec struct {
-	Source  string      `json:"source"`
-	Outputs []JobOutput `json:"outputs"`
-	Labels  []string    `json:"labels,omitempty"`
+	Source   string      `json:"source"`
+	Outputs  []JobOutput `json:"outputs"`
+	Labels   []string    `json:"labels,omitempty"`
+	Priority int         `json:"priority,omitempty"`
 }
 
 type JobOutput struct {
@@ -138,6 +139,11 @@ func (p *flock) Transcode(ctx context.Context, job *db.Job) (*provider.JobStatus
 }
 
 func (p *flock) flockJobRequestFrom(ctx context.Context, job *db.Job) (*JobRequest, error) {
+	// flock doesn't encrypt its outputs, which mustn't be shipped in the clear
+	if job.StreamingParams.Encryption != nil {
+		return nil, errors.New("encryption is not supported")
+	}
+
 	presets := []db.Preset{}
 	for _, output := range job.Outputs {
 		presetName := output.Preset.Name
@@ -156,6 +162,7 @@ func (p *flock) flockJobRequestFrom(ctx context.Context, job *db.Job) (*JobReque
 
 	var jobReq JobRequest
 	jobReq.Job.Source = job.SourceMedia
+	jobReq.Job.Priority = job.Priority
 
 	for _, label := range job.Labels {
 		jobReq.Job.Labels = append(jobReq.Job.Labels, label)
@@ -415,9 +422,10 @@ func flockFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
 		return nil, fmt.Errorf("error initializing flock wrapper: %s", err)
 	}
 
+	retry := provider.NewRetryPolicy(Name, cfg)
 	return &flock{
 		cfg:        cfg.Flock,
 		repository: dbRepo,
-		client:     &http.Client{Timeout: time.Second * 30},
+		client:     &http.Client{Timeout: time.Second * 30, Transport: retry.Transport(nil)},
 	}, nil
 }
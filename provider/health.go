@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultHealthcheckTimeout is the time given to a provider healthcheck when no
+// timeout is configured.
+const DefaultHealthcheckTimeout = 5 * time.Second
+
+// ContextHealthchecker is implemented by providers whose healthcheck can be
+// canceled through a context.
+type ContextHealthchecker interface {
+	HealthcheckContext(context.Context) error
+}
+
+type healthResult struct {
+	health    Health
+	checkedAt time.Time
+}
+
+// checkHealth runs the healthcheck of the provider, reporting it as unhealthy
+// when it doesn't complete within the timeout.
+func checkHealth(ctx context.Context, p TranscodingProvider, timeout time.Duration) Health {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		if hc, ok := p.(ContextHealthchecker); ok {
+			errc <- hc.HealthcheckContext(ctx)
+			return
+		}
+		errc <- p.Healthcheck()
+	}()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			return Health{OK: false, Message: err.Error()}
+		}
+		return Health{OK: true}
+	case <-ctx.Done():
+		return Health{OK: false, Message: fmt.Sprintf("healthcheck timed out after %s", timeout)}
+	}
+}
@@ -0,0 +1,123 @@
+package hybrik
+
+import (
+	"fmt"
+
+	"github.com/cbsinteractive/hybrik-sdk-go"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+const (
+	assetContentsKindSubtitle = "subtitle"
+
+	subtitleFormatWebVTT    = "webvtt"
+	subtitleFormatTimedText = "timed_text"
+)
+
+// subtitleSourceFormats maps caption formats to the formats Hybrik reads sidecar
+// subtitles in
+var subtitleSourceFormats = map[db.CaptionFormat]string{
+	db.CaptionFormatSCC:    "scc",
+	db.CaptionFormatSRT:    "srt",
+	db.CaptionFormatWebVTT: "webvtt",
+	db.CaptionFormatTTML:   "ttml",
+	db.CaptionFormatIMSC:   "imsc1",
+}
+
+// subtitleContents describes the subtitles of an asset, which the sdk can't
+// express with its asset contents
+type subtitleContents struct {
+	Kind    string                  `json:"kind"`
+	Payload subtitleContentsPayload `json:"payload"`
+}
+
+type subtitleContentsPayload struct {
+	Format   string `json:"format"`
+	Language string `json:"language,omitempty"`
+}
+
+// subtitleAssetPayload is a sidecar subtitle asset of the source element
+type subtitleAssetPayload struct {
+	hybrik.AssetPayload
+	Contents []subtitleContents `json:"contents"`
+	Trim     *sourceTrim        `json:"trim,omitempty"`
+}
+
+// subtitleTarget is a subtitle track of a transcode target
+type subtitleTarget struct {
+	Format   string `json:"format"`
+	Language string `json:"language,omitempty"`
+}
+
+// captionedTranscodeTarget is a transcode target carrying subtitle tracks, which
+// aren't exposed by the sdk
+type captionedTranscodeTarget struct {
+	hybrik.TranscodeTarget
+	Subtitle []subtitleTarget `json:"subtitle,omitempty"`
+}
+
+// subtitleAssetsFrom returns the source assets of the sidecar captions of a job.
+// Captions embedded in the source are read from the media itself.
+func (p *hybrikProvider) subtitleAssetsFrom(job *db.Job) ([]subtitleAssetPayload, error) {
+	var assets []subtitleAssetPayload
+	for _, c := range job.Captions {
+		if c.Format == db.CaptionFormatEmbedded {
+			continue
+		}
+
+		format, ok := subtitleSourceFormats[c.Format]
+		if !ok {
+			return nil, fmt.Errorf("%s captions are not supported as a source", c.Format)
+		}
+
+		storageProvider, err := storageProviderFrom(c.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		assets = append(assets, subtitleAssetPayload{
+			AssetPayload: p.assetPayloadFrom(storageProvider, c.Source, nil, job.ExecutionEnv.InputAlias),
+			Contents: []subtitleContents{{
+				Kind:    assetContentsKindSubtitle,
+				Payload: subtitleContentsPayload{Format: format, Language: c.Language},
+			}},
+		})
+	}
+	return assets, nil
+}
+
+// captionedTargetsFrom adds the captions of an output to its transcode targets,
+// as WebVTT for HLS and as timed text for the other containers
+func captionedTargetsFrom(payload hybrik.TranscodePayload, captions []db.Caption, filename string) (interface{}, error) {
+	if len(captions) == 0 {
+		return payload.Targets, nil
+	}
+
+	transcodeTargets, ok := payload.Targets.([]hybrik.TranscodeTarget)
+	if !ok {
+		return nil, fmt.Errorf("targets are not TranscodeTargets: %v", payload.LocationTargetPayload.Targets)
+	}
+
+	captionedTargets := make([]captionedTranscodeTarget, len(transcodeTargets))
+	for i, target := range transcodeTargets {
+		captionedTargets[i] = captionedTranscodeTarget{TranscodeTarget: target}
+
+		var format string
+		switch target.Container.Kind {
+		case hls:
+			format = subtitleFormatWebVTT
+		case "mp4", "mov":
+			format = subtitleFormatTimedText
+		default:
+			return nil, fmt.Errorf("captions can't be carried by %s output %q", target.Container.Kind, filename)
+		}
+
+		for _, c := range captions {
+			captionedTargets[i].Subtitle = append(captionedTargets[i].Subtitle, subtitleTarget{
+				Format:   format,
+				Language: c.Language,
+			})
+		}
+	}
+	return captionedTargets, nil
+}
@@ -0,0 +1,73 @@
+package hybrik
+
+import (
+	"testing"
+
+	"github.com/cbsinteractive/hybrik-sdk-go"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCaptionedTargetsFrom(t *testing.T) {
+	captions := []db.Caption{{Format: db.CaptionFormatEmbedded, Language: "en"}, {Format: db.CaptionFormatSRT, Language: "fr"}}
+
+	tests := []struct {
+		name      string
+		container string
+		captions  []db.Caption
+		want      interface{}
+		wantErr   string
+	}{
+		{
+			name:      "targets are left untouched without captions",
+			container: "mp4",
+			want:      []hybrik.TranscodeTarget{{Container: hybrik.TranscodeContainer{Kind: "mp4"}}},
+		},
+		{
+			name:      "mp4 outputs carry timed text",
+			container: "mp4",
+			captions:  captions,
+			want: []captionedTranscodeTarget{{
+				TranscodeTarget: hybrik.TranscodeTarget{Container: hybrik.TranscodeContainer{Kind: "mp4"}},
+				Subtitle:        []subtitleTarget{{Format: "timed_text", Language: "en"}, {Format: "timed_text", Language: "fr"}},
+			}},
+		},
+		{
+			name:      "hls outputs carry webvtt",
+			container: hls,
+			captions:  captions[1:],
+			want: []captionedTranscodeTarget{{
+				TranscodeTarget: hybrik.TranscodeTarget{Container: hybrik.TranscodeContainer{Kind: hls}},
+				Subtitle:        []subtitleTarget{{Format: "webvtt", Language: "fr"}},
+			}},
+		},
+		{
+			name:      "webm outputs can't carry captions",
+			container: "webm",
+			captions:  captions,
+			wantErr:   `captions can't be carried by webm output "output.webm"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := hybrik.TranscodePayload{LocationTargetPayload: hybrik.LocationTargetPayload{
+				Targets: []hybrik.TranscodeTarget{{Container: hybrik.TranscodeContainer{Kind: tt.container}}},
+			}}
+
+			got, err := captionedTargetsFrom(payload, tt.captions, "output."+tt.container)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong targets: %s", diff)
+			}
+		})
+	}
+}
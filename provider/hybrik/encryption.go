@@ -0,0 +1,82 @@
+package hybrik
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cbsinteractive/hybrik-sdk-go"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// keyServerTimeout bounds the requests of content keys to key servers
+const keyServerTimeout = 30 * time.Second
+
+// encryptionSchemas are the Hybrik schemas of the encryption schemes
+var encryptionSchemas = map[db.EncryptionScheme]string{
+	db.EncryptionSchemeAES128:    "aes-128-cbc",
+	db.EncryptionSchemeSampleAES: "sample-aes",
+	db.EncryptionSchemeCENC:      "mpeg-cenc",
+}
+
+// packageEncryption encrypts the segments written by the packager
+type packageEncryption struct {
+	Enabled bool   `json:"enabled"`
+	Schema  string `json:"schema"`
+	KeyID   string `json:"key_id,omitempty"`
+	Key     string `json:"key"`
+	IV      string `json:"iv,omitempty"`
+	KeyURL  string `json:"key_url,omitempty"`
+}
+
+// encryptedPackagePayload is a package payload with an encryption, which isn't
+// exposed by the sdk
+type encryptedPackagePayload struct {
+	hybrik.PackagePayload
+	Encryption *packageEncryption `json:"encryption,omitempty"`
+}
+
+// packageEncryptionFrom returns the encryption of the packaged segments of a job.
+// Hybrik only takes static keys, keys from a key server are requested before
+// submitting the job, and DRM systems can't be signaled.
+func (p *hybrikProvider) packageEncryptionFrom(ctx context.Context, job *db.Job) (*packageEncryption, error) {
+	e := job.StreamingParams.Encryption
+	if e == nil {
+		return nil, nil
+	}
+
+	if _, found := supportedPackagingProtocols[strings.ToLower(job.StreamingParams.Protocol)]; !found {
+		return nil, fmt.Errorf("encryption: protocol %q can't be packaged", job.StreamingParams.Protocol)
+	}
+	schema, ok := encryptionSchemas[e.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("encryption: %s is not supported", e.Scheme)
+	}
+	if len(e.DRMSystems) > 0 {
+		return nil, errors.New("encryption: drm systems are not supported")
+	}
+	if e.KeyRotationInterval > 0 {
+		return nil, errors.New("encryption: key rotation is not supported")
+	}
+
+	key := provider.ContentKey{KeyID: e.KeyID, Key: e.Key, URL: e.KeyURL}
+	if e.KeyServerURL != "" {
+		var err error
+		key, err = provider.RequestContentKey(ctx, p.keys, *e)
+		if err != nil {
+			return nil, fmt.Errorf("encryption: %w", err)
+		}
+	}
+
+	return &packageEncryption{
+		Enabled: true,
+		Schema:  schema,
+		KeyID:   key.KeyID,
+		Key:     key.Key,
+		IV:      e.IV,
+		KeyURL:  key.URL,
+	}, nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -56,8 +58,12 @@ func init() {
 
 type hybrikProvider struct {
 	c          hwrapper.ClientInterface
+	retry      *provider.RetryPolicy
 	config     *config.Hybrik
 	repository db.Repository
+
+	// keys requests content keys from key servers
+	keys *http.Client
 }
 
 func (p hybrikProvider) String() string {
@@ -82,10 +88,16 @@ func hybrikTranscoderFactory(cfg *config.Config) (provider.TranscodingProvider,
 		return nil, fmt.Errorf("error initializing hybrik wrapper: %s", err)
 	}
 
+	retry := provider.NewRetryPolicy(Name, cfg)
 	return &hybrikProvider{
 		c:          api,
+		retry:      retry,
 		config:     cfg.Hybrik,
 		repository: dbRepo,
+		keys: &http.Client{
+			Timeout:   keyServerTimeout,
+			Transport: retry.Transport(http.DefaultTransport),
+		},
 	}, nil
 }
 
@@ -95,7 +107,11 @@ func (p *hybrikProvider) Transcode(ctx context.Context, job *db.Job) (*provider.
 		return &provider.JobStatus{}, err
 	}
 
-	id, err := p.c.QueueJob(cj)
+	var id string
+	err = p.retry.Do(ctx, "hybrik-queue-job", false, func(context.Context) (err error) {
+		id, err = p.c.QueueJob(cj)
+		return retryableError(err)
+	})
 	if err != nil {
 		return &provider.JobStatus{}, err
 	}
@@ -145,15 +161,24 @@ func (p *hybrikProvider) createJobReqFrom(ctx context.Context, job *db.Job) (hwr
 	cfg := jobCfg{
 		jobID:          job.ID,
 		sourceLocation: srcLocation,
+		sourceSplice:   job.SourceSplice,
+		keyframeOffsets: provider.SplicedOffsets(job.ExplicitKeyframeOffsets,
+			provider.FrameAccurateSplice(job.SourceSplice, job.SourceInfo.FrameRate)),
 		destination: storageLocation{
 			provider: destStorageProvider,
 			path:     fmt.Sprintf("%s/%s", destinationPath, job.RootFolder()),
 		},
+		captions:             job.Captions,
 		streamingParams:      job.StreamingParams,
 		executionEnvironment: job.ExecutionEnv,
 		source:               srcElement,
 	}
 
+	cfg.encryption, err = p.packageEncryptionFrom(ctx, job)
+	if err != nil {
+		return hwrapper.CreateJob{}, err
+	}
+
 	execFeatures, err := executionFeaturesFrom(job, srcLocation.provider)
 	if err != nil {
 		return hwrapper.CreateJob{}, err
@@ -207,9 +232,27 @@ func (p *hybrikProvider) createJobReqFrom(ctx context.Context, job *db.Job) (hwr
 		prevElements = elementGroup
 	}
 
+	// images are taken from the source on their own, they are not packaged
+	imageElements, err := p.imageElementsFrom(job, cfg)
+	if err != nil {
+		return hwrapper.CreateJob{}, err
+	}
+	if len(imageElements) > 0 {
+		toSuccessElements := []hwrapper.ToSuccess{}
+		for _, element := range imageElements {
+			toSuccessElements = append(toSuccessElements, hwrapper.ToSuccess{Element: element.UID})
+		}
+		connections = append(connections, hwrapper.Connection{
+			From: []hwrapper.ConnectionFrom{{Element: cfg.source.UID}},
+			To:   hwrapper.ConnectionTo{Success: toSuccessElements},
+		})
+		allTaskElements = append(allTaskElements, imageElements...)
+	}
+
 	// create the full job structure
 	cj := hwrapper.CreateJob{
-		Name: fmt.Sprintf("Job %s [%s]", cfg.jobID, path.Base(cfg.sourceLocation.path)),
+		Name:     fmt.Sprintf("Job %s [%s]", cfg.jobID, path.Base(cfg.sourceLocation.path)),
+		Priority: priorityFrom(job.Priority),
 		Payload: hwrapper.CreateJobPayload{
 			Elements:    append([]hwrapper.Element{cfg.source}, allTaskElements...),
 			Connections: connections,
@@ -234,8 +277,12 @@ func (p *hybrikProvider) destinationForJob(job *db.Job) string {
 	return p.config.Destination
 }
 
-func (p *hybrikProvider) JobStatus(_ context.Context, job *db.Job) (*provider.JobStatus, error) {
-	ji, err := p.c.GetJobInfo(job.ProviderJobID)
+func (p *hybrikProvider) JobStatus(ctx context.Context, job *db.Job) (*provider.JobStatus, error) {
+	var ji hwrapper.JobInfo
+	err := p.retry.Do(ctx, "hybrik-get-job-info", true, func(context.Context) (err error) {
+		ji, err = p.c.GetJobInfo(job.ProviderJobID)
+		return retryableError(err)
+	})
 	if err != nil {
 		return &provider.JobStatus{}, err
 	}
@@ -258,7 +305,11 @@ func (p *hybrikProvider) JobStatus(_ context.Context, job *db.Job) (*provider.Jo
 
 	var output provider.JobOutput
 	if status == provider.StatusFailed || status == provider.StatusFinished {
-		result, err := p.c.GetJobResult(job.ProviderJobID)
+		var result hwrapper.JobResultResponse
+		err := p.retry.Do(ctx, "hybrik-get-job-result", true, func(context.Context) (err error) {
+			result, err = p.c.GetJobResult(job.ProviderJobID)
+			return retryableError(err)
+		})
 		if err != nil {
 			return &provider.JobStatus{}, err
 		}
@@ -273,6 +324,10 @@ func (p *hybrikProvider) JobStatus(_ context.Context, job *db.Job) (*provider.Jo
 				output.Files = append(output.Files, files...)
 			}
 		}
+
+		for i := range output.Files {
+			output.Files[i].Captions = provider.CaptionLanguages(job, output.Files[i].Path)
+		}
 	}
 
 	return &provider.JobStatus{
@@ -318,8 +373,27 @@ func executionFeaturesFrom(job *db.Job, storageProvider storageProvider) (execut
 	return features, nil
 }
 
-func (p *hybrikProvider) CancelJob(_ context.Context, id string) error {
-	return p.c.StopJob(id)
+func (p *hybrikProvider) CancelJob(ctx context.Context, id string) error {
+	return p.retry.Do(ctx, "hybrik-stop-job", true, func(context.Context) error {
+		return retryableError(p.c.StopJob(id))
+	})
+}
+
+// hybrikStatusCode matches the status code the Hybrik client prefixes the
+// errors of failed API calls with
+var hybrikStatusCode = regexp.MustCompile(`^(\d{3}) - `)
+
+// retryableError makes the errors of the Hybrik API calls worth retrying
+// retryable
+func retryableError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if m := hybrikStatusCode.FindStringSubmatch(err.Error()); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return provider.StatusError(status, 0, err)
+	}
+	return provider.TransportError(err)
 }
 
 func (p *hybrikProvider) CreatePreset(_ context.Context, preset db.Preset) (string, error) {
@@ -2,13 +2,17 @@ package hybrik
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 
 	"github.com/cbsinteractive/hybrik-sdk-go"
+	"github.com/cbsinteractive/pkg/timecode"
 	"github.com/cbsinteractive/transcode-orchestrator/config"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/db/dbtest"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/speketest"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -886,6 +890,9 @@ func TestHybrikProvider_presetsToTranscodeJob(t *testing.T) {
 }
 
 func TestHybrikProvider_presetsToTranscodeJob_fields(t *testing.T) {
+	keyServer := speketest.NewServer("3c1fa5d8b8e5c6a2f0b3d9e1a7c4f6b2", "https://keys.example.com/jobID")
+	defer keyServer.Close()
+
 	tests := []struct {
 		name        string
 		jobModifier func(job db.Job) db.Job
@@ -956,6 +963,53 @@ func TestHybrikProvider_presetsToTranscodeJob_fields(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "when a priority is specified, it is scaled to the hybrik job priority",
+			jobModifier: func(job db.Job) db.Job {
+				job.Priority = db.MaxJobPriority
+				return job
+			},
+			assertion: func(createJob hybrik.CreateJob, t *testing.T) {
+				if g, e := createJob.Priority, 254; g != e {
+					t.Errorf("job priority: got %d, expected %d", g, e)
+				}
+			},
+		},
+		{
+			name: "when explicit keyframes are specified, they are forced on the spliced timeline",
+			jobModifier: func(job db.Job) db.Job {
+				job.SourceSplice = timecode.Splice{{2, 5}, {10, 30}}
+				job.ExplicitKeyframeOffsets = []float64{1, 4, 12.5}
+				return job
+			},
+			assertion: func(createJob hybrik.CreateJob, t *testing.T) {
+				payload, ok := createJob.Payload.Elements[1].Payload.(hybrik.TranscodePayload)
+				if !ok {
+					t.Fatal("could not find a transcode payload in the job")
+				}
+
+				targets, ok := payload.Targets.([]hybrik.TranscodeTarget)
+				if !ok || len(targets) == 0 {
+					t.Fatal("could not find a transcode target in the job")
+				}
+
+				if g, e := targets[0].Video.FFMPEGArgs, " -force_key_frames 2,5.5"; g != e {
+					t.Errorf("wrong ffmpeg args: got %q, expected %q", g, e)
+				}
+			},
+		},
+		{
+			name: "when a low priority is specified, it is scaled to the hybrik job priority",
+			jobModifier: func(job db.Job) db.Job {
+				job.Priority = db.MinJobPriority
+				return job
+			},
+			assertion: func(createJob hybrik.CreateJob, t *testing.T) {
+				if g, e := createJob.Priority, 1; g != e {
+					t.Errorf("job priority: got %d, expected %d", g, e)
+				}
+			},
+		},
 		{
 			name: "when custom compute tags are specified, the right tags are added to the output",
 			jobModifier: func(job db.Job) db.Job {
@@ -1144,6 +1198,75 @@ func TestHybrikProvider_presetsToTranscodeJob_fields(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "when encryption with a key server is specified, the package task encrypts with the requested key",
+			jobModifier: func(job db.Job) db.Job {
+				job.StreamingParams = db.StreamingParams{
+					SegmentDuration: 4,
+					Protocol:        "hls",
+					Encryption: &db.Encryption{
+						Scheme:       db.EncryptionSchemeAES128,
+						KeyID:        "0123456789abcdef0123456789abcdef",
+						KeyServerURL: keyServer.URL,
+						ResourceID:   "jobID",
+					},
+				}
+
+				return job
+			},
+			assertion: func(createJob hybrik.CreateJob, t *testing.T) {
+				payload, ok := createJob.Payload.Elements[len(createJob.Payload.Elements)-1].Payload.(encryptedPackagePayload)
+				if !ok {
+					t.Fatal("expected an encrypted package payload")
+				}
+
+				expectEncryption := &packageEncryption{
+					Enabled: true,
+					Schema:  "aes-128-cbc",
+					KeyID:   "0123456789abcdef0123456789abcdef",
+					Key:     "3c1fa5d8b8e5c6a2f0b3d9e1a7c4f6b2",
+					KeyURL:  "https://keys.example.com/jobID",
+				}
+				if g, e := payload.Encryption, expectEncryption; !reflect.DeepEqual(g, e) {
+					t.Fatalf("wrong package encryption\nWant %+v\nGot %+v\nDiff %s", e, g, cmp.Diff(e, g))
+				}
+				if g, e := payload.Kind, "hls"; g != e {
+					t.Errorf("wrong package kind: got %q, expected %q", g, e)
+				}
+			},
+		},
+		{
+			name: "when encryption with drm systems is specified, an error is returned",
+			jobModifier: func(job db.Job) db.Job {
+				job.StreamingParams = db.StreamingParams{
+					Protocol: "dash",
+					Encryption: &db.Encryption{
+						Scheme:       db.EncryptionSchemeCENC,
+						KeyServerURL: keyServer.URL,
+						DRMSystems:   []db.DRMSystem{db.DRMSystemWidevine},
+					},
+				}
+
+				return job
+			},
+			wantErrMsg: "encryption: drm systems are not supported",
+		},
+		{
+			name: "when encryption is specified without a packaged protocol, an error is returned",
+			jobModifier: func(job db.Job) db.Job {
+				job.StreamingParams = db.StreamingParams{
+					Protocol: "hls,dash",
+					Encryption: &db.Encryption{
+						Scheme: db.EncryptionSchemeCENC,
+						Key:    "3c1fa5d8b8e5c6a2f0b3d9e1a7c4f6b2",
+						KeyID:  "0123456789abcdef0123456789abcdef",
+					},
+				}
+
+				return job
+			},
+			wantErrMsg: `encryption: protocol "hls,dash" can't be packaged`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1165,7 +1288,7 @@ func TestHybrikProvider_presetsToTranscodeJob_fields(t *testing.T) {
 
 			modifiedJob := tt.jobModifier(defaultJob)
 			got, err := p.createJobReqFrom(context.Background(), &modifiedJob)
-			if err != nil && tt.wantErrMsg != err.Error() {
+			if (err != nil || tt.wantErrMsg != "") && (err == nil || tt.wantErrMsg != err.Error()) {
 				t.Errorf("hybrikProvider.presetsToTranscodeJob() error = %v, wantErr %q", err, tt.wantErrMsg)
 				return
 			}
@@ -1190,3 +1313,40 @@ func fakeDBWithPreset(preset db.Preset) (db.Repository, error) {
 func intToPtr(i int) *int {
 	return &i
 }
+
+func TestHybrikProvider_retryableError(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		wantRetryable    bool
+		wantNotProcessed bool
+	}{
+		{
+			name:          "server errors are retryable",
+			err:           errors.New("502 - POST /jobs: bad gateway"),
+			wantRetryable: true,
+		},
+		{
+			name:             "rate limited calls were not processed",
+			err:              errors.New("429 - POST /jobs: too many requests"),
+			wantRetryable:    true,
+			wantNotProcessed: true,
+		},
+		{
+			name: "client errors are not retryable",
+			err:  errors.New("400 - POST /jobs: invalid job"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryable, ok := retryableError(tt.err).(provider.RetryableError)
+			if ok != tt.wantRetryable {
+				t.Fatalf("retryableError() retryable: got %t, expected %t", ok, tt.wantRetryable)
+			}
+			if retryable.NotProcessed != tt.wantNotProcessed {
+				t.Errorf("retryableError() not processed: got %t, expected %t", retryable.NotProcessed, tt.wantNotProcessed)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package hybrik
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cbsinteractive/hybrik-sdk-go"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+const (
+	imageElementIDTemplate = "image_task_%d"
+
+	// imageSequencePattern numbers the images of thumbnails outputs
+	imageSequencePattern = "_%05d"
+)
+
+// imageCodecs are the video codecs images are encoded with, by format
+var imageCodecs = map[db.ImageFormat]string{
+	db.ImageFormatJPEG: "mjpeg",
+	db.ImageFormatPNG:  "png",
+}
+
+// imageElementsFrom returns a transcode element for each image output of a job,
+// reading frames from the source alongside the other transcodes. Offsets are
+// moved along with the splice of the source. HLS packaging always includes
+// I-frame playlists, so trick-play playlists need nothing more.
+func (p *hybrikProvider) imageElementsFrom(job *db.Job, cfg jobCfg) ([]hybrik.Element, error) {
+	splice := provider.FrameAccurateSplice(job.SourceSplice, job.SourceInfo.FrameRate)
+
+	var elements []hybrik.Element
+	for i, o := range job.ImageOutputs {
+		target, err := imageTargetFrom(o, provider.SplicedOffsets(o.Offsets, splice))
+		if err != nil {
+			return nil, fmt.Errorf("image output %q: %w", o.FileName, err)
+		}
+
+		tags := []string{}
+		if tag, found := cfg.computeTags[db.ComputeClassTranscodeDefault]; found {
+			tags = append(tags, tag)
+		}
+
+		elements = append(elements, hybrik.Element{
+			UID:  fmt.Sprintf(imageElementIDTemplate, i),
+			Kind: elementKindTranscode,
+			Task: &hybrik.ElementTaskOptions{
+				Tags: tags,
+				Name: fmt.Sprintf("Images - %s", o.FileName),
+			},
+			Payload: hybrik.TranscodePayload{
+				LocationTargetPayload: hybrik.LocationTargetPayload{
+					Location: p.transcodeLocationFrom(cfg.destination, cfg.executionEnvironment.OutputAlias),
+					Targets:  []hybrik.TranscodeTarget{target},
+				},
+			},
+		})
+	}
+	return elements, nil
+}
+
+// imageTargetFrom returns the target of an image output, selecting frames at
+// the interval or offsets of thumbnails and the single frame of a poster
+func imageTargetFrom(o db.ImageOutput, offsets []float64) (hybrik.TranscodeTarget, error) {
+	codec, ok := imageCodecs[o.ImageFormat()]
+	if !ok {
+		return hybrik.TranscodeTarget{}, fmt.Errorf("%s images are not supported", o.ImageFormat())
+	}
+
+	video := &hybrik.VideoTarget{Codec: codec}
+	if o.Width > 0 {
+		width := int(o.Width)
+		video.Width = &width
+	}
+	if o.Height > 0 {
+		height := int(o.Height)
+		video.Height = &height
+	}
+
+	filePattern := o.FileName
+	switch o.Kind {
+	case db.ImageOutputKindThumbnails:
+		if len(o.Offsets) > 0 && len(offsets) == 0 {
+			return hybrik.TranscodeTarget{}, errors.New("every offset is cut out by the splice")
+		}
+		ext := path.Ext(o.FileName)
+		filePattern = strings.TrimSuffix(o.FileName, ext) + imageSequencePattern + ext
+		if len(offsets) > 0 {
+			video.FFMPEGArgs = fmt.Sprintf("-vf %s -vsync vfr", frameSelectFilterFrom(offsets))
+		} else {
+			video.FrameRate = frameRateFromInterval(o.Interval)
+		}
+	case db.ImageOutputKindPoster:
+		var offset float64
+		if len(o.Offsets) > 0 {
+			if len(offsets) == 0 {
+				return hybrik.TranscodeTarget{}, errors.New("the poster offset is cut out by the splice")
+			}
+			offset = offsets[0]
+		}
+		video.FFMPEGArgs = fmt.Sprintf("-vf %s -vsync vfr -frames:v 1", frameSelectFilterFrom([]float64{offset}))
+	default:
+		return hybrik.TranscodeTarget{}, fmt.Errorf("%s outputs are not supported", o.Kind)
+	}
+
+	return hybrik.TranscodeTarget{
+		FilePattern:   filePattern,
+		ExistingFiles: "replace",
+		Container:     hybrik.TranscodeContainer{Kind: o.ImageFormat()},
+		Video:         video,
+	}, nil
+}
+
+// frameSelectFilterFrom returns an ffmpeg filter selecting the first frame at
+// or after each of the offsets
+func frameSelectFilterFrom(offsets []float64) string {
+	terms := make([]string, len(offsets))
+	for i, offset := range offsets {
+		o := strconv.FormatFloat(offset, 'f', -1, 64)
+		terms[i] = fmt.Sprintf(`gte(t\,%s)*(isnan(prev_pts)+lt(prev_pts*TB\,%s))`, o, o)
+	}
+	return fmt.Sprintf("select='%s'", strings.Join(terms, "+"))
+}
+
+// frameRateFromInterval returns the frame rate taking a frame every interval
+// seconds, as a fraction in milliseconds
+func frameRateFromInterval(interval float64) string {
+	ms := int64(math.Round(interval * 1000))
+	if ms < 1 {
+		ms = 1
+	}
+	d := gcd(1000, ms)
+	return fmt.Sprintf("%d/%d", 1000/d, ms/d)
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,88 @@
+package hybrik
+
+import (
+	"testing"
+
+	"github.com/cbsinteractive/hybrik-sdk-go"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImageTargetFrom(t *testing.T) {
+	width := 320
+
+	tests := []struct {
+		name    string
+		output  db.ImageOutput
+		offsets []float64
+		want    hybrik.TranscodeTarget
+		wantErr string
+	}{
+		{
+			name:   "thumbnails at an interval are numbered",
+			output: db.ImageOutput{Kind: db.ImageOutputKindThumbnails, FileName: "thumbs/thumb.jpg", Interval: 2.5, Width: 320},
+			want: hybrik.TranscodeTarget{
+				FilePattern:   "thumbs/thumb_%05d.jpg",
+				ExistingFiles: "replace",
+				Container:     hybrik.TranscodeContainer{Kind: "jpg"},
+				Video:         &hybrik.VideoTarget{Codec: "mjpeg", Width: &width, FrameRate: "2/5"},
+			},
+		},
+		{
+			name:    "thumbnails at offsets select a frame at each of them",
+			output:  db.ImageOutput{Kind: db.ImageOutputKindThumbnails, FileName: "thumb.png", Format: db.ImageFormatPNG, Offsets: []float64{1, 5}},
+			offsets: []float64{1, 5.5},
+			want: hybrik.TranscodeTarget{
+				FilePattern:   "thumb_%05d.png",
+				ExistingFiles: "replace",
+				Container:     hybrik.TranscodeContainer{Kind: "png"},
+				Video: &hybrik.VideoTarget{
+					Codec: "png",
+					FFMPEGArgs: `-vf select='gte(t\,1)*(isnan(prev_pts)+lt(prev_pts*TB\,1))+` +
+						`gte(t\,5.5)*(isnan(prev_pts)+lt(prev_pts*TB\,5.5))' -vsync vfr`,
+				},
+			},
+		},
+		{
+			name:   "posters default to the first frame",
+			output: db.ImageOutput{Kind: db.ImageOutputKindPoster, FileName: "poster.jpg"},
+			want: hybrik.TranscodeTarget{
+				FilePattern:   "poster.jpg",
+				ExistingFiles: "replace",
+				Container:     hybrik.TranscodeContainer{Kind: "jpg"},
+				Video: &hybrik.VideoTarget{
+					Codec:      "mjpeg",
+					FFMPEGArgs: `-vf select='gte(t\,0)*(isnan(prev_pts)+lt(prev_pts*TB\,0))' -vsync vfr -frames:v 1`,
+				},
+			},
+		},
+		{
+			name:    "posters cut out by the splice are rejected",
+			output:  db.ImageOutput{Kind: db.ImageOutputKindPoster, FileName: "poster.jpg", Offsets: []float64{30}},
+			wantErr: "the poster offset is cut out by the splice",
+		},
+		{
+			name:    "sprites are not supported",
+			output:  db.ImageOutput{Kind: db.ImageOutputKindSprite, FileName: "sprite.jpg", Interval: 5},
+			wantErr: "sprite outputs are not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imageTargetFrom(tt.output, tt.offsets)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong target: %s", diff)
+			}
+		})
+	}
+}
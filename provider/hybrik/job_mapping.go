@@ -2,22 +2,29 @@ package hybrik
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
 
 	"github.com/cbsinteractive/hybrik-sdk-go"
+	"github.com/cbsinteractive/pkg/timecode"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
 )
 
 type jobCfg struct {
 	jobID                string
 	destination          storageLocation
 	sourceLocation       storageLocation
+	sourceSplice         timecode.Splice
+	keyframeOffsets      []float64
+	captions             []db.Caption
 	source               hybrik.Element
 	elementGroups        [][]hybrik.Element
 	outputCfgs           map[string]outputCfg
 	streamingParams      db.StreamingParams
+	encryption           *packageEncryption
 	executionEnvironment db.ExecutionEnvironment
 	executionFeatures    executionFeatures
 	computeTags          map[db.ComputeClass]string
@@ -36,8 +43,30 @@ const (
 	imfManifestExtension = ".xml"
 
 	srcOptionResolveManifestKey = "resolve_manifest"
+
+	srcPayloadKindAssetURLs    = "asset_urls"
+	srcPayloadKindAssetComplex = "asset_complex"
+	assetComplexKindSequence   = "sequence"
+	assetComponentKindName     = "name"
+
+	defaultJobPriority = 100
+	minJobPriority     = 1
+	maxJobPriority     = 254
 )
 
+// priorityFrom scales a job priority onto Hybrik's 1 to 254 range, keeping a zero
+// priority unset so Hybrik applies its default of 100
+func priorityFrom(priority int) int {
+	switch {
+	case priority > 0:
+		return defaultJobPriority + priority*(maxJobPriority-defaultJobPriority)/db.MaxJobPriority
+	case priority < 0:
+		return defaultJobPriority + priority*(defaultJobPriority-minJobPriority)/-db.MinJobPriority
+	default:
+		return 0
+	}
+}
+
 func (p *hybrikProvider) srcFrom(job *db.Job, src storageLocation) (hybrik.Element, error) {
 	sourceAsset := p.assetPayloadFrom(src.provider, src.path, nil, job.ExecutionEnv.InputAlias)
 
@@ -63,16 +92,151 @@ func (p *hybrikProvider) srcFrom(job *db.Job, src storageLocation) (hybrik.Eleme
 		}}, job.ExecutionEnv.InputAlias))
 	}
 
+	splice := provider.FrameAccurateSplice(job.SourceSplice, job.SourceInfo.FrameRate)
+	if err := provider.ValidateSplice(splice, job.SourceInfo); err != nil {
+		return hybrik.Element{}, err
+	}
+
+	subtitles, err := p.subtitleAssetsFrom(job)
+	if err != nil {
+		return hybrik.Element{}, err
+	}
+
+	payload, err := splicedSrcPayloadFrom(assets, subtitles, splice)
+	if err != nil {
+		return hybrik.Element{}, err
+	}
+
 	return hybrik.Element{
-		UID:  "source_file",
-		Kind: elementKindSource,
-		Payload: hybrik.ElementPayload{
-			Kind:    "asset_urls",
-			Payload: assets,
-		},
+		UID:     "source_file",
+		Kind:    elementKindSource,
+		Payload: payload,
 	}, nil
 }
 
+// sourceTrim restricts an asset to a range of its timeline, in seconds
+type sourceTrim struct {
+	InpointSec  float64 `json:"inpoint_sec"`
+	OutpointSec float64 `json:"outpoint_sec"`
+}
+
+// trimmedAssetPayload is an asset_urls entry with a trim, which isn't exposed by the sdk
+type trimmedAssetPayload struct {
+	hybrik.AssetPayload
+	Trim *sourceTrim `json:"trim,omitempty"`
+}
+
+// assetComplexPayload describes a source made of several asset versions, a
+// sequence playing them one after the other
+type assetComplexPayload struct {
+	Kind          string         `json:"kind"`
+	AssetVersions []assetVersion `json:"asset_versions"`
+}
+
+type assetVersion struct {
+	VersionUID      string           `json:"version_uid"`
+	AssetComponents []assetComponent `json:"asset_components"`
+}
+
+type assetComponent struct {
+	Kind         string                   `json:"kind"`
+	ComponentUID string                   `json:"component_uid"`
+	Name         string                   `json:"name"`
+	Location     hybrik.TranscodeLocation `json:"location"`
+	Trim         *sourceTrim              `json:"trim,omitempty"`
+	Contents     interface{}              `json:"contents,omitempty"`
+}
+
+// splicedSrcPayloadFrom returns the payload of the source element for a splice. A
+// single range trims the assets, several ranges are trimmed and concatenated in an
+// asset_complex sequence. Sidecar assets are trimmed along with the media so they
+// stay aligned with it, subtitles included.
+func splicedSrcPayloadFrom(assets []hybrik.AssetPayload, subtitles []subtitleAssetPayload, splice timecode.Splice) (hybrik.ElementPayload, error) {
+	if len(splice) == 0 {
+		if len(subtitles) == 0 {
+			return hybrik.ElementPayload{Kind: srcPayloadKindAssetURLs, Payload: assets}, nil
+		}
+
+		payload := []interface{}{}
+		for _, asset := range assets {
+			payload = append(payload, asset)
+		}
+		for _, subtitle := range subtitles {
+			payload = append(payload, subtitle)
+		}
+		return hybrik.ElementPayload{Kind: srcPayloadKindAssetURLs, Payload: payload}, nil
+	}
+
+	trims := make([]*sourceTrim, len(splice))
+	for i, r := range splice {
+		trims[i] = &sourceTrim{InpointSec: r[0], OutpointSec: r[1]}
+	}
+
+	if len(trims) == 1 {
+		trimmed := []interface{}{}
+		for _, asset := range assets {
+			trimmed = append(trimmed, trimmedAssetPayload{AssetPayload: asset, Trim: trims[0]})
+		}
+		for _, subtitle := range subtitles {
+			subtitle.Trim = trims[0]
+			trimmed = append(trimmed, subtitle)
+		}
+		return hybrik.ElementPayload{Kind: srcPayloadKindAssetURLs, Payload: trimmed}, nil
+	}
+
+	for _, asset := range assets {
+		if _, ok := asset.Options[srcOptionResolveManifestKey]; ok {
+			return hybrik.ElementPayload{}, errors.New("splicing several ranges of an IMF source is not supported")
+		}
+	}
+
+	sequence := assetComplexPayload{Kind: assetComplexKindSequence}
+	for i, trim := range trims {
+		version := assetVersion{VersionUID: fmt.Sprintf("splice_%d", i)}
+		for j, asset := range assets {
+			component := assetComponentFrom(asset, fmt.Sprintf("splice_%d_asset_%d", i, j), trim)
+			if len(asset.Contents) > 0 {
+				component.Contents = asset.Contents
+			}
+			version.AssetComponents = append(version.AssetComponents, component)
+		}
+		for j, subtitle := range subtitles {
+			component := assetComponentFrom(subtitle.AssetPayload, fmt.Sprintf("splice_%d_subtitle_%d", i, j), trim)
+			component.Contents = subtitle.Contents
+			version.AssetComponents = append(version.AssetComponents, component)
+		}
+		sequence.AssetVersions = append(sequence.AssetVersions, version)
+	}
+
+	return hybrik.ElementPayload{Kind: srcPayloadKindAssetComplex, Payload: sequence}, nil
+}
+
+// assetComponentFrom returns the component of an asset in a version of an asset_complex
+func assetComponentFrom(asset hybrik.AssetPayload, uid string, trim *sourceTrim) assetComponent {
+	dir, name := splitAssetURL(asset.URL)
+	return assetComponent{
+		Kind:         assetComponentKindName,
+		ComponentUID: uid,
+		Name:         name,
+		Location: hybrik.TranscodeLocation{
+			StorageProvider: asset.StorageProvider,
+			Path:            dir,
+			Access:          asset.Access,
+		},
+		Trim: trim,
+	}
+}
+
+// splitAssetURL splits an asset url into its location and its file name, without
+// cleaning the scheme of the url as path.Split would
+func splitAssetURL(url string) (string, string) {
+	i := strings.LastIndex(url, "/")
+	if i < 0 {
+		return "", url
+	}
+	return url[:i], url[i+1:]
+}
+
 func (p *hybrikProvider) outputCfgsFrom(ctx context.Context, job *db.Job) (map[string]outputCfg, error) {
 	presets := map[string]outputCfg{}
 
@@ -14,6 +14,7 @@ import (
 type taskWithOutputMatcher struct {
 	kind     string
 	uidRegex *regexp.Regexp
+	fileType string
 }
 
 var tasksWithOutputsMatchers []taskWithOutputMatcher
@@ -39,6 +40,11 @@ func init() {
 		log.Panicf("compiling the package regex: %v", err)
 	}
 
+	imageRegex, err := regexp.Compile(`image_task_[\d]+$`)
+	if err != nil {
+		log.Panicf("compiling the image regex: %v", err)
+	}
+
 	combinerRegex, err := regexp.Compile(`combiner_[\d]+$`)
 	if err != nil {
 		log.Panicf("compiling the combiner regex: %v", err)
@@ -48,6 +54,7 @@ func init() {
 		{kind: "Dolby Vision", uidRegex: doViPostProcessRegex},
 		{kind: "Dolby Vision", uidRegex: doViTranscodeRegex},
 		{kind: "Transcode", uidRegex: transcodeRegex},
+		{kind: "Transcode", uidRegex: imageRegex, fileType: provider.OutputFileTypeImage},
 		{kind: "Package", uidRegex: packageRegex},
 		{kind: "Combine Segments", uidRegex: combinerRegex},
 	}
@@ -55,7 +62,8 @@ func init() {
 
 func filesFrom(task hybrik.TaskResult) ([]provider.OutputFile, bool, error) {
 	// ensure the task type results in outputs
-	if !taskHasOutputs(task, tasksWithOutputsMatchers) {
+	matcher, found := taskOutputMatcher(task, tasksWithOutputsMatchers)
+	if !found {
 		return nil, false, nil
 	}
 
@@ -68,6 +76,7 @@ func filesFrom(task hybrik.TaskResult) ([]provider.OutputFile, bool, error) {
 					Path:      fmt.Sprintf("%s/%s", normalizedPath, component.Name),
 					Container: containerFrom(component),
 					FileSize:  int64(component.Descriptor.Size),
+					Type:      matcher.fileType,
 				})
 			}
 		}
@@ -90,16 +99,16 @@ func containerFrom(component hybrik.AssetComponentResult) string {
 	return strings.Replace(path.Ext(component.Name), ".", "", -1)
 }
 
-func taskHasOutputs(task hybrik.TaskResult, matchers []taskWithOutputMatcher) bool {
+func taskOutputMatcher(task hybrik.TaskResult, matchers []taskWithOutputMatcher) (taskWithOutputMatcher, bool) {
 	for _, matcher := range matchers {
 		if matcher.kind != task.Kind {
 			continue
 		}
 
 		if matcher.uidRegex.Match([]byte(task.UID)) {
-			return true
+			return matcher, true
 		}
 	}
 
-	return false
+	return taskWithOutputMatcher{}, false
 }
@@ -102,6 +102,24 @@ func Test_filesFrom(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "marks the files of image tasks as images",
+			file: "testdata/task_status_image.json",
+			outputFiles: []provider.OutputFile{
+				{
+					Path:      "s3://vtg-tsymborski-test-bucket/encodes/blackmonday/thumb_00001.jpg",
+					Container: "jpg",
+					FileSize:  24317,
+					Type:      provider.OutputFileTypeImage,
+				},
+				{
+					Path:      "s3://vtg-tsymborski-test-bucket/encodes/blackmonday/thumb_00002.jpg",
+					Container: "jpg",
+					FileSize:  23980,
+					Type:      provider.OutputFileTypeImage,
+				},
+			},
+		},
 		{
 			name:                 "does not find outputs in files that are not recognized as containing them",
 			file:                 "testdata/task_status_no_outputs.json",
@@ -1,6 +1,7 @@
 package hybrik
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/cbsinteractive/hybrik-sdk-go"
@@ -35,9 +36,11 @@ const (
 )
 
 func (p *hybrikProvider) dolbyVisionLegacyElementAssembler(cfg jobCfg) ([][]hybrik.Element, error) {
+	presets := map[string]db.Preset{}
 	presetsWithoutAudio := map[string]db.Preset{}
 	for _, outputCfg := range cfg.outputCfgs {
 		preset := outputCfg.localPreset
+		presets[outputCfg.filename] = preset
 
 		// removing audio so we can processing this separately
 		preset.Audio = db.AudioPreset{}
@@ -79,12 +82,43 @@ func (p *hybrikProvider) dolbyVisionLegacyElementAssembler(cfg jobCfg) ([][]hybr
 		path:     fmt.Sprintf(doViSourceDemuxOutputPathTmpl, cfg.destination.path),
 	}, cfg.executionEnvironment.OutputAlias)
 
-	return [][]hybrik.Element{{{
+	elementaryStreams := []hybrik.DoViMP4MuxElementaryStream{{
+		AssetURL: p.assetURLFrom(storageLocation{
+			provider: cfg.sourceLocation.provider,
+			path:     cfg.sourceLocation.path,
+		}, cfg.executionEnvironment.OutputAlias),
+		ExtractAudio:    true,
+		ExtractLocation: &demuxOutputStorageLocation,
+		ExtractTask: &hybrik.DoViMP4MuxExtractTask{
+			RetryMethod: retryMethodRetry,
+			Retry: hybrik.Retry{
+				Count:    retryCountDefault,
+				DelaySec: retryDelayDefault,
+			},
+			Name: "Demux Audio",
+		},
+	}}
+
+	elementGroups := [][]hybrik.Element{}
+	task := &hybrik.ElementTaskOptions{Tags: []string{preprocComputeTag}}
+	if len(cfg.sourceSplice) > 0 {
+		// demuxing would take the audio of the whole source, it is transcoded
+		// from the spliced source instead
+		audioElements, streams, err := p.splicedAudioStreamsFrom(presets, cfg)
+		if err != nil {
+			return nil, err
+		}
+		elementaryStreams = streams
+		if len(audioElements) > 0 {
+			elementGroups = append(elementGroups, audioElements)
+			task.SourceElementUIDs = []string{cfg.source.UID}
+		}
+	}
+
+	return append(elementGroups, []hybrik.Element{{
 		UID:  dolbyVisionElementID,
 		Kind: elementKindDolbyVision,
-		Task: &hybrik.ElementTaskOptions{
-			Tags: []string{preprocComputeTag},
-		},
+		Task: task,
 		Payload: hybrik.DolbyVisionTaskPayload{
 			Module:  doViModuleProfile,
 			Profile: doViProfile5,
@@ -163,24 +197,36 @@ func (p *hybrikProvider) dolbyVisionLegacyElementAssembler(cfg jobCfg) ([][]hybr
 						}, cfg.executionEnvironment.OutputAlias),
 						FilePattern: doViMP4MuxQCFilenameDefault,
 					},
-					ElementaryStreams: []hybrik.DoViMP4MuxElementaryStream{{
-						AssetURL: p.assetURLFrom(storageLocation{
-							provider: cfg.sourceLocation.provider,
-							path:     cfg.sourceLocation.path,
-						}, cfg.executionEnvironment.OutputAlias),
-						ExtractAudio:    true,
-						ExtractLocation: &demuxOutputStorageLocation,
-						ExtractTask: &hybrik.DoViMP4MuxExtractTask{
-							RetryMethod: retryMethodRetry,
-							Retry: hybrik.Retry{
-								Count:    retryCountDefault,
-								DelaySec: retryDelayDefault,
-							},
-							Name: "Demux Audio",
-						},
-					}},
+					ElementaryStreams: elementaryStreams,
 				},
 			},
 		},
-	}}}, nil
+	}}), nil
+}
+
+// splicedAudioStreamsFrom returns the elements transcoding the audio of a spliced
+// source and the elementary streams muxing it into the outputs. The legacy task
+// muxes the same streams into every output so they must share their audio settings.
+func (p *hybrikProvider) splicedAudioStreamsFrom(presets map[string]db.Preset,
+	cfg jobCfg) ([]hybrik.Element, []hybrik.DoViMP4MuxElementaryStream, error) {
+	audioElements, audioPresetsToFilename, err := p.audioElementsFrom(presets, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(audioPresetsToFilename) > 1 {
+		return nil, nil, errors.New("spliced dolby vision jobs require the same audio settings on every output")
+	}
+
+	streams := []hybrik.DoViMP4MuxElementaryStream{}
+	for _, filename := range audioPresetsToFilename {
+		streams = append(streams, hybrik.DoViMP4MuxElementaryStream{
+			AssetURL: p.assetURLFrom(storageLocation{
+				provider: cfg.destination.provider,
+				path:     fmt.Sprintf("%s/%s", cfg.destination.path, filename),
+			}, cfg.executionEnvironment.OutputAlias),
+		})
+	}
+
+	return audioElements, streams, nil
 }
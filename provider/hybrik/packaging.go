@@ -81,6 +81,12 @@ func (p *hybrikProvider) enrichCreateJobWithPackagingCfg(cj hybrik.CreateJob, jo
 		Kind:    elementKindPackage,
 		Payload: packagePayload,
 	}
+	if jobCfg.encryption != nil {
+		packageElement.Payload = encryptedPackagePayload{
+			PackagePayload: packagePayload,
+			Encryption:     jobCfg.encryption,
+		}
+	}
 
 	if tag, found := jobCfg.computeTags[db.ComputeClassTranscodeDefault]; found {
 		packageElement.Task = &hybrik.ElementTaskOptions{Tags: []string{tag}}
@@ -3,9 +3,11 @@ package hybrik
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/cbsinteractive/hybrik-sdk-go"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
 	"github.com/mitchellh/hashstructure"
 	"github.com/pkg/errors"
 )
@@ -98,6 +100,11 @@ func (p *hybrikProvider) transcodeElementFromPreset(preset db.Preset, uid string
 		},
 	}
 
+	if len(cfg.keyframeOffsets) > 0 && videoTarget != nil {
+		videoTarget.FFMPEGArgs = fmt.Sprintf("%s -force_key_frames %s", videoTarget.FFMPEGArgs,
+			forcedKeyframesFrom(cfg.keyframeOffsets))
+	}
+
 	if cfg.executionFeatures.segmentedRendering != nil {
 		payload.SourcePipeline = hybrik.TranscodeSourcePipeline{SegmentedRendering: cfg.executionFeatures.segmentedRendering}
 	}
@@ -109,6 +116,12 @@ func (p *hybrikProvider) transcodeElementFromPreset(preset db.Preset, uid string
 		}
 	}
 
+	// subtitles are added last, the modifiers only handle the sdk targets
+	payload.Targets, err = captionedTargetsFrom(payload, provider.Captions(cfg.captions, filename), filename)
+	if err != nil {
+		return hybrik.Element{}, errors.Wrap(err, "adding captions to the transcode targets")
+	}
+
 	transcodeComputeTags := []string{}
 	if tag, found := cfg.computeTags[db.ComputeClassTranscodeDefault]; found {
 		transcodeComputeTags = append(transcodeComputeTags, tag)
@@ -127,6 +140,15 @@ func (p *hybrikProvider) transcodeElementFromPreset(preset db.Preset, uid string
 	return element, nil
 }
 
+// forcedKeyframesFrom returns the times at which ffmpeg is forced to insert keyframes
+func forcedKeyframesFrom(offsets []float64) string {
+	times := make([]string, len(offsets))
+	for i, offset := range offsets {
+		times[i] = strconv.FormatFloat(offset, 'f', -1, 64)
+	}
+	return strings.Join(times, ",")
+}
+
 type transcodePayloadModifier struct {
 	name    string
 	runFunc func(hybrikPreset hybrik.TranscodePayload, preset db.Preset) (hybrik.TranscodePayload, error)
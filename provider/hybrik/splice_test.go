@@ -0,0 +1,231 @@
+package hybrik
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/cbsinteractive/hybrik-sdk-go"
+	"github.com/cbsinteractive/pkg/timecode"
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHybrikProvider_srcFromSplice(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		splice   timecode.Splice
+		sidecars map[db.SidecarAssetKind]string
+		captions []db.Caption
+		file     string
+		wantErr  string
+	}{
+		{
+			name:   "a single range trims the source",
+			source: "s3://some/path.mp4",
+			splice: timecode.Splice{{10, 20.5}},
+			file:   "testdata/source_splice_trim.json",
+		},
+		{
+			name:   "sidecar captions are trimmed along with the source",
+			source: "s3://some/path.mp4",
+			splice: timecode.Splice{{10, 20.5}},
+			captions: []db.Caption{
+				{Format: db.CaptionFormatEmbedded, Language: "es"},
+				{Format: db.CaptionFormatSRT, Language: "en", Source: "s3://some/captions/en.srt"},
+			},
+			file: "testdata/source_splice_captions.json",
+		},
+		{
+			name:   "several ranges are concatenated in a sequence along with the dolby vision metadata",
+			source: "gs://some-bucket/path/file.mp4",
+			splice: timecode.Splice{{0, 5}, {12, 30}},
+			sidecars: map[db.SidecarAssetKind]string{
+				db.SidecarAssetKindDolbyVisionMetadata: "s3://test_sidecar_location/path/file.xml",
+			},
+			file: "testdata/source_splice_sequence.json",
+		},
+		{
+			name:    "empty ranges are rejected",
+			source:  "s3://some/path.mp4",
+			splice:  timecode.Splice{{0, 5}, {8, 8}},
+			wantErr: "splice range #1 (8s-8s) must start before it ends",
+		},
+		{
+			name:    "overlapping ranges are rejected",
+			source:  "s3://some/path.mp4",
+			splice:  timecode.Splice{{0, 5}, {4, 8}},
+			wantErr: "splice range #1 (4s-8s) overlaps or precedes range #0 (0s-5s)",
+		},
+		{
+			name:    "imf sources can't be concatenated",
+			source:  "s3://some/cpl.xml",
+			splice:  timecode.Splice{{0, 5}, {8, 10}},
+			wantErr: "splicing several ranges of an IMF source is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &hybrikProvider{config: &config.Hybrik{GCPCredentialsKey: "gcp_creds"}}
+
+			src, err := storageProviderFrom(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			job := &db.Job{SourceMedia: tt.source, SourceSplice: tt.splice, SidecarAssets: tt.sidecars, Captions: tt.captions}
+
+			got, err := p.srcFrom(job, storageLocation{provider: src, path: tt.source})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want, err := ioutil.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSONEqual(t, want, got)
+		})
+	}
+}
+
+func TestHybrikProvider_splicedJobs(t *testing.T) {
+	doViPreset := db.Preset{
+		Name:      defaultPreset.Name,
+		Container: "mp4",
+		Video: db.VideoPreset{
+			Profile:             "main10",
+			Width:               "300",
+			Codec:               "h265",
+			Bitrate:             "12000",
+			GopSize:             "120",
+			GopMode:             "fixed",
+			InterlaceMode:       "progressive",
+			DolbyVisionSettings: db.DolbyVisionSettings{Enabled: true},
+		},
+		Audio: db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	}
+
+	tests := []struct {
+		name      string
+		preset    db.Preset
+		features  db.ExecutionFeatures
+		assertion func(hybrik.CreateJob, *testing.T)
+	}{
+		{
+			name:     "segmented rendering is kept for a spliced source",
+			preset:   defaultPreset,
+			features: db.ExecutionFeatures{featureSegmentedRendering: SegmentedRendering{Duration: 50}},
+			assertion: func(createJob hybrik.CreateJob, t *testing.T) {
+				elements := createJob.Payload.Elements
+				if g, e := elements[0].Payload.(hybrik.ElementPayload).Kind, "asset_complex"; g != e {
+					t.Errorf("wrong source kind: got %q, expected %q", g, e)
+				}
+
+				transcode, ok := elements[1].Payload.(hybrik.TranscodePayload)
+				if !ok {
+					t.Fatal("could not find a transcode payload in the job")
+				}
+				if transcode.SourcePipeline.SegmentedRendering == nil {
+					t.Error("segmented rendering was removed from the transcode")
+				}
+			},
+		},
+		{
+			name:   "the legacy dolby vision task muxes audio transcoded from the spliced source",
+			preset: doViPreset,
+			assertion: func(createJob hybrik.CreateJob, t *testing.T) {
+				elements := createJob.Payload.Elements
+				if len(elements) != 3 {
+					t.Fatalf("wrong number of elements: got %d, expected 3", len(elements))
+				}
+				if g, e := elements[1].UID, "audio_0"; g != e {
+					t.Errorf("wrong audio element: got %q, expected %q", g, e)
+				}
+
+				doVi := elements[2]
+				if diff := cmp.Diff([]string{"source_file"}, doVi.Task.SourceElementUIDs); diff != "" {
+					t.Errorf("wrong source of the dolby vision task: %s", diff)
+				}
+
+				payload, ok := doVi.Payload.(hybrik.DolbyVisionTaskPayload)
+				if !ok {
+					t.Fatal("could not find a dolby vision payload in the job")
+				}
+				expectStreams := []hybrik.DoViMP4MuxElementaryStream{{
+					AssetURL: hybrik.AssetURL{StorageProvider: "s3", URL: "s3://some-dest/path/jobID/audio_output_0.aac"},
+				}}
+				if diff := cmp.Diff(expectStreams, payload.PostTranscode.MP4Mux.ElementaryStreams); diff != "" {
+					t.Errorf("wrong elementary streams: %s", diff)
+				}
+
+				expectConnections := []hybrik.Connection{
+					{
+						From: []hybrik.ConnectionFrom{{Element: "source_file"}},
+						To:   hybrik.ConnectionTo{Success: []hybrik.ToSuccess{{Element: "audio_0"}}},
+					},
+					{
+						From: []hybrik.ConnectionFrom{{Element: "audio_0"}},
+						To:   hybrik.ConnectionTo{Success: []hybrik.ToSuccess{{Element: "dolby_vision_task"}}},
+					},
+				}
+				if diff := cmp.Diff(expectConnections, createJob.Payload.Connections); diff != "" {
+					t.Errorf("wrong connections: %s", diff)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDB, err := fakeDBWithPreset(tt.preset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := &hybrikProvider{
+				config:     &config.Hybrik{Destination: "s3://some-dest/path", PresetPath: "some_preset_path"},
+				repository: fakeDB,
+			}
+
+			job := defaultJob
+			job.SourceSplice = timecode.Splice{{0, 5}, {30, 40}}
+			job.ExecutionFeatures = tt.features
+
+			got, err := p.createJobReqFrom(context.Background(), &job)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.assertion(got, t)
+		})
+	}
+}
+
+func assertJSONEqual(t *testing.T, want []byte, got interface{}) {
+	t.Helper()
+
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g, e interface{}
+	if err := json.Unmarshal(gotJSON, &g); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(want, &e); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(e, g); diff != "" {
+		t.Errorf("wrong json: %s", diff)
+	}
+}
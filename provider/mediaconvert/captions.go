@@ -0,0 +1,162 @@
+package mediaconvert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+// maxEmbeddedCaptions is the number of CEA-608 channels an output can carry
+const maxEmbeddedCaptions = 4
+
+var captionSourceTypes = map[db.CaptionFormat]mediaconvert.CaptionSourceType{
+	db.CaptionFormatSCC:      mediaconvert.CaptionSourceTypeScc,
+	db.CaptionFormatSRT:      mediaconvert.CaptionSourceTypeSrt,
+	db.CaptionFormatTTML:     mediaconvert.CaptionSourceTypeTtml,
+	db.CaptionFormatIMSC:     mediaconvert.CaptionSourceTypeImsc,
+	db.CaptionFormatEmbedded: mediaconvert.CaptionSourceTypeEmbedded,
+}
+
+// iso6392Codes maps the two letter codes of common languages to the three
+// letter codes MediaConvert expects
+var iso6392Codes = map[string]string{
+	"ar": "ara", "da": "dan", "de": "deu", "en": "eng", "es": "spa", "fi": "fin", "fr": "fra",
+	"he": "heb", "hi": "hin", "it": "ita", "ja": "jpn", "ko": "kor", "nl": "nld", "no": "nor",
+	"pl": "pol", "pt": "por", "ru": "rus", "sv": "swe", "tr": "tur", "zh": "zho",
+}
+
+// captionSelectorName returns the name of the input selector of the job caption at index i
+func captionSelectorName(i int) string {
+	return fmt.Sprintf("Captions Selector %d", i+1)
+}
+
+// languageCodeFrom returns the ISO 639-2 code of an RFC 5646 language, or nil
+// when it isn't known
+func languageCodeFrom(language string) *string {
+	primary := strings.ToLower(strings.SplitN(language, "-", 2)[0])
+	if len(primary) == 3 {
+		return aws.String(primary)
+	}
+	if code, ok := iso6392Codes[primary]; ok {
+		return aws.String(code)
+	}
+	return nil
+}
+
+// captionSelectorsFrom returns the input caption selectors of the captions of a job
+func captionSelectorsFrom(captions []db.Caption) (map[string]mediaconvert.CaptionSelector, error) {
+	if len(captions) == 0 {
+		return nil, nil
+	}
+
+	selectors := make(map[string]mediaconvert.CaptionSelector, len(captions))
+	for i, c := range captions {
+		sourceType, ok := captionSourceTypes[c.Format]
+		if !ok {
+			return nil, fmt.Errorf("%s captions are not supported as a source", c.Format)
+		}
+
+		settings := &mediaconvert.CaptionSourceSettings{SourceType: sourceType}
+		if c.Format == db.CaptionFormatEmbedded {
+			settings.EmbeddedSourceSettings = &mediaconvert.EmbeddedSourceSettings{
+				Convert608To708:        mediaconvert.EmbeddedConvert608To708Upconvert,
+				Source608ChannelNumber: aws.Int64(int64(c.CEA608Channel())),
+			}
+		} else {
+			settings.FileSourceSettings = &mediaconvert.FileSourceSettings{SourceFile: aws.String(c.Source)}
+			if c.Format == db.CaptionFormatSCC {
+				settings.FileSourceSettings.Convert608To708 = mediaconvert.FileSourceConvert608To708Upconvert
+			}
+		}
+
+		selectors[captionSelectorName(i)] = mediaconvert.CaptionSelector{
+			CustomLanguageCode: languageCodeFrom(c.Language),
+			SourceSettings:     settings,
+		}
+	}
+	return selectors, nil
+}
+
+// captionDescriptionFrom returns the description of the job caption at index i
+// in an output, written with the given destination settings
+func captionDescriptionFrom(c db.Caption, i int, destination *mediaconvert.CaptionDestinationSettings) mediaconvert.CaptionDescription {
+	return mediaconvert.CaptionDescription{
+		CaptionSelectorName: aws.String(captionSelectorName(i)),
+		CustomLanguageCode:  languageCodeFrom(c.Language),
+		LanguageDescription: aws.String(c.DisplayName()),
+		DestinationSettings: destination,
+	}
+}
+
+// embeddedCaptionsFrom returns the captions of a progressive output, embedded
+// as CEA-608/708 in its video
+func embeddedCaptionsFrom(job *db.Job, o outputCfg) ([]mediaconvert.CaptionDescription, error) {
+	var descriptions []mediaconvert.CaptionDescription
+	for i, c := range job.Captions {
+		if !c.AppliesTo(o.filename) {
+			continue
+		}
+		if o.output.VideoDescription == nil {
+			return nil, fmt.Errorf("output %q has no video to embed captions in", o.filename)
+		}
+		switch o.output.ContainerSettings.Container {
+		case mediaconvert.ContainerTypeMp4, mediaconvert.ContainerTypeMov:
+		default:
+			return nil, fmt.Errorf("captions can't be embedded in %s output %q", o.output.ContainerSettings.Container, o.filename)
+		}
+		if len(descriptions) == maxEmbeddedCaptions {
+			return nil, fmt.Errorf("output %q can't embed more than %d captions", o.filename, maxEmbeddedCaptions)
+		}
+
+		channel := int64(len(descriptions) + 1)
+		descriptions = append(descriptions, captionDescriptionFrom(c, i, &mediaconvert.CaptionDestinationSettings{
+			DestinationType: mediaconvert.CaptionDestinationTypeEmbedded,
+			EmbeddedDestinationSettings: &mediaconvert.EmbeddedDestinationSettings{
+				Destination608ChannelNumber: aws.Int64(channel),
+				Destination708ServiceNumber: aws.Int64(channel),
+			},
+		}))
+	}
+	return descriptions, nil
+}
+
+// captionOutputsFrom returns the WebVTT renditions of a streaming output group,
+// one per caption carried by any of its outputs
+func captionOutputsFrom(job *db.Job, container mediaconvert.ContainerType, outputs []outputCfg) []mediaconvert.Output {
+	var captionOutputs []mediaconvert.Output
+	for i, c := range job.Captions {
+		for _, o := range outputs {
+			if !c.AppliesTo(o.filename) {
+				continue
+			}
+			captionOutputs = append(captionOutputs, mediaconvert.Output{
+				NameModifier:      aws.String(fmt.Sprintf("captions_%d", i)),
+				ContainerSettings: containerSettingsFrom(container),
+				CaptionDescriptions: []mediaconvert.CaptionDescription{
+					captionDescriptionFrom(c, i, &mediaconvert.CaptionDestinationSettings{
+						DestinationType: mediaconvert.CaptionDestinationTypeWebvtt,
+					}),
+				},
+			})
+			break
+		}
+	}
+	return captionOutputs
+}
+
+// captionLanguagesFrom returns the languages of the job captions written by the
+// caption descriptions of an output
+func captionLanguagesFrom(job *db.Job, descriptions []mediaconvert.CaptionDescription) []string {
+	var languages []string
+	for _, d := range descriptions {
+		for i, c := range job.Captions {
+			if aws.StringValue(d.CaptionSelectorName) == captionSelectorName(i) {
+				languages = append(languages, c.Language)
+			}
+		}
+	}
+	return languages
+}
@@ -0,0 +1,99 @@
+package mediaconvert
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+func TestCaptionSelectorsFrom(t *testing.T) {
+	selectors, err := captionSelectorsFrom([]db.Caption{
+		{Format: db.CaptionFormatEmbedded, Language: "en", Channel: 3},
+		{Format: db.CaptionFormatSCC, Language: "es-MX", Source: "s3://bucket/captions.scc"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embedded := selectors["Captions Selector 1"]
+	if g, e := embedded.SourceSettings.SourceType, mediaconvert.CaptionSourceTypeEmbedded; g != e {
+		t.Errorf("wrong embedded source type: got %q, expected %q", g, e)
+	}
+	if g, e := *embedded.SourceSettings.EmbeddedSourceSettings.Source608ChannelNumber, int64(3); g != e {
+		t.Errorf("wrong embedded channel: got %d, expected %d", g, e)
+	}
+	if g, e := aws.StringValue(embedded.CustomLanguageCode), "eng"; g != e {
+		t.Errorf("wrong embedded language: got %q, expected %q", g, e)
+	}
+
+	scc := selectors["Captions Selector 2"]
+	if g, e := aws.StringValue(scc.SourceSettings.FileSourceSettings.SourceFile), "s3://bucket/captions.scc"; g != e {
+		t.Errorf("wrong sidecar source: got %q, expected %q", g, e)
+	}
+	if g, e := aws.StringValue(scc.CustomLanguageCode), "spa"; g != e {
+		t.Errorf("wrong sidecar language: got %q, expected %q", g, e)
+	}
+
+	if _, err := captionSelectorsFrom([]db.Caption{{Format: db.CaptionFormatWebVTT, Source: "s3://bucket/c.vtt"}}); err == nil {
+		t.Error("expected an error for webvtt sources, got nil")
+	}
+}
+
+func TestEmbeddedCaptionsFrom(t *testing.T) {
+	job := &db.Job{Captions: []db.Caption{
+		{Format: db.CaptionFormatEmbedded, Language: "en"},
+		{Format: db.CaptionFormatSRT, Language: "fr", Source: "s3://bucket/fr.srt", Outputs: []string{"other.mp4"}},
+		{Format: db.CaptionFormatSRT, Language: "de", Source: "s3://bucket/de.srt"},
+	}}
+	mp4 := outputCfg{
+		filename: "video.mp4",
+		output: mediaconvert.Output{
+			ContainerSettings: &mediaconvert.ContainerSettings{Container: mediaconvert.ContainerTypeMp4},
+			VideoDescription:  &mediaconvert.VideoDescription{},
+		},
+	}
+
+	descriptions, err := embeddedCaptionsFrom(job, mp4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := captionLanguagesFrom(job, descriptions), []string{"en", "de"}; len(g) != len(e) || g[0] != e[0] || g[1] != e[1] {
+		t.Fatalf("wrong captions: got %v, expected %v", g, e)
+	}
+	if g, e := *descriptions[1].DestinationSettings.EmbeddedDestinationSettings.Destination608ChannelNumber, int64(2); g != e {
+		t.Errorf("wrong channel: got %d, expected %d", g, e)
+	}
+
+	webm := mp4
+	webm.output.ContainerSettings = &mediaconvert.ContainerSettings{Container: mediaconvert.ContainerTypeWebm}
+	if _, err := embeddedCaptionsFrom(job, webm); err == nil {
+		t.Error("expected an error embedding captions in webm, got nil")
+	}
+
+	audio := mp4
+	audio.output.VideoDescription = nil
+	if _, err := embeddedCaptionsFrom(job, audio); err == nil {
+		t.Error("expected an error embedding captions without video, got nil")
+	}
+}
+
+func TestCaptionOutputsFrom(t *testing.T) {
+	job := &db.Job{Captions: []db.Caption{
+		{Format: db.CaptionFormatTTML, Language: "en", Source: "s3://bucket/en.ttml"},
+		{Format: db.CaptionFormatTTML, Language: "fr", Source: "s3://bucket/fr.ttml", Outputs: []string{"other.m3u8"}},
+	}}
+	outputs := []outputCfg{{filename: "hls/720p.m3u8"}, {filename: "hls/1080p.m3u8"}}
+
+	captionOutputs := captionOutputsFrom(job, mediaconvert.ContainerTypeM3u8, outputs)
+	if len(captionOutputs) != 1 {
+		t.Fatalf("expected 1 caption output, got %d", len(captionOutputs))
+	}
+	if g, e := aws.StringValue(captionOutputs[0].NameModifier), "captions_0"; g != e {
+		t.Errorf("wrong name modifier: got %q, expected %q", g, e)
+	}
+	if g, e := captionOutputs[0].CaptionDescriptions[0].DestinationSettings.DestinationType, mediaconvert.CaptionDestinationTypeWebvtt; g != e {
+		t.Errorf("wrong destination: got %q, expected %q", g, e)
+	}
+}
@@ -0,0 +1,145 @@
+package mediaconvert
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// groupContainerFrom returns the container of the output group the outputs of
+// a preset are written to. Streaming outputs follow the protocols of the job:
+// DASH only jobs are written to DASH ISO groups, and HLS outputs of jobs also
+// producing DASH share CMAF segments. Jobs without protocols keep the container
+// of their presets.
+func groupContainerFrom(container mediaconvert.ContainerType, streaming db.StreamingParams) (mediaconvert.ContainerType, error) {
+	hls, dash := streaming.HasProtocol(db.ProtocolHLS), streaming.HasProtocol(db.ProtocolDASH)
+
+	switch container {
+	case mediaconvert.ContainerTypeM3u8, mediaconvert.ContainerTypeCmfc:
+		switch {
+		case dash && !hls:
+			return mediaconvert.ContainerTypeMpd, nil
+		case dash:
+			return mediaconvert.ContainerTypeCmfc, nil
+		}
+	case mediaconvert.ContainerTypeMpd:
+		if len(streaming.Protocols()) > 0 && !dash {
+			return "", fmt.Errorf("mpd outputs need the dash protocol, not %q", streaming.Protocol)
+		}
+	}
+	return container, nil
+}
+
+// cmafManifestsFrom returns whether CMAF groups write an HLS and a DASH manifest
+// according to the protocols of the job, both being written for jobs without
+// protocols
+func cmafManifestsFrom(streaming db.StreamingParams) (mediaconvert.CmafWriteHLSManifest, mediaconvert.CmafWriteDASHManifest) {
+	hls, dash := mediaconvert.CmafWriteHLSManifestEnabled, mediaconvert.CmafWriteDASHManifestEnabled
+	if len(streaming.Protocols()) == 0 {
+		return hls, dash
+	}
+	if !streaming.HasProtocol(db.ProtocolHLS) {
+		hls = mediaconvert.CmafWriteHLSManifestDisabled
+	}
+	if !streaming.HasProtocol(db.ProtocolDASH) {
+		dash = mediaconvert.CmafWriteDASHManifestDisabled
+	}
+	return hls, dash
+}
+
+// dashIsoGroupSettingsFrom returns the settings of a DASH ISO group writing its
+// manifest and segments under destination
+func dashIsoGroupSettingsFrom(job *db.Job, destination string) (*mediaconvert.DashIsoGroupSettings, error) {
+	encryption, err := dashEncryptionFrom(job.StreamingParams.Encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := mediaconvert.DashIsoWriteSegmentTimelineInRepresentationDisabled
+	if job.StreamingParams.SegmentTemplate == db.SegmentTemplateTimeline {
+		timeline = mediaconvert.DashIsoWriteSegmentTimelineInRepresentationEnabled
+	}
+
+	return &mediaconvert.DashIsoGroupSettings{
+		Destination:                          aws.String(destination),
+		DestinationSettings:                  &defaultDestinationSettings,
+		FragmentLength:                       aws.Int64(int64(job.StreamingParams.SegmentDuration)),
+		SegmentLength:                        aws.Int64(int64(job.StreamingParams.SegmentDuration)),
+		SegmentControl:                       mediaconvert.DashIsoSegmentControlSegmentedFiles,
+		MpdProfile:                           mediaconvert.DashIsoMpdProfileMainProfile,
+		HbbtvCompliance:                      mediaconvert.DashIsoHbbtvComplianceNone,
+		WriteSegmentTimelineInRepresentation: timeline,
+		Encryption:                           encryption,
+	}, nil
+}
+
+// cmafSegmentTimelineFrom returns whether the DASH manifest of CMAF groups lists
+// its segments in a segment timeline
+func cmafSegmentTimelineFrom(streaming db.StreamingParams) mediaconvert.CmafWriteSegmentTimelineInRepresentation {
+	if streaming.SegmentTemplate == db.SegmentTemplateTimeline {
+		return mediaconvert.CmafWriteSegmentTimelineInRepresentationEnabled
+	}
+	return mediaconvert.CmafWriteSegmentTimelineInRepresentationDisabled
+}
+
+// streamingDestinationFrom returns the destination of the streaming groups of a
+// job. MediaConvert names the manifests and segments after the source, unless
+// playlist naming is enabled and the job has a playlist to name them after.
+func (p *mcProvider) streamingDestinationFrom(job *db.Job) string {
+	if !p.cfg.PlaylistNaming {
+		return p.destinationPathFrom(job)
+	}
+	playlist := job.StreamingParams.PlaylistFileName
+	return p.destinationPathFrom(job) + strings.TrimSuffix(playlist, path.Ext(playlist))
+}
+
+// manifestFilesFrom returns the manifests written by a streaming output group
+func manifestFilesFrom(job *db.Job, group mediaconvert.OutputGroup) []provider.OutputFile {
+	settings := group.OutputGroupSettings
+	if settings == nil {
+		return nil
+	}
+
+	var destination *string
+	var containers []string
+	switch settings.Type {
+	case mediaconvert.OutputGroupTypeHlsGroupSettings:
+		if settings.HlsGroupSettings != nil {
+			destination, containers = settings.HlsGroupSettings.Destination, []string{"m3u8"}
+		}
+	case mediaconvert.OutputGroupTypeDashIsoGroupSettings:
+		if settings.DashIsoGroupSettings != nil {
+			destination, containers = settings.DashIsoGroupSettings.Destination, []string{"mpd"}
+		}
+	case mediaconvert.OutputGroupTypeCmafGroupSettings:
+		if cmaf := settings.CmafGroupSettings; cmaf != nil {
+			destination = cmaf.Destination
+			if cmaf.WriteHlsManifest != mediaconvert.CmafWriteHLSManifestDisabled {
+				containers = append(containers, "m3u8")
+			}
+			if cmaf.WriteDashManifest != mediaconvert.CmafWriteDASHManifestDisabled {
+				containers = append(containers, "mpd")
+			}
+		}
+	}
+	if destination == nil {
+		return nil
+	}
+
+	name := groupNameFrom(job, *destination)
+
+	files := make([]provider.OutputFile, len(containers))
+	for i, container := range containers {
+		files[i] = provider.OutputFile{
+			Path:      name + "." + container,
+			Container: container,
+			Type:      provider.OutputFileTypeManifest,
+		}
+	}
+	return files
+}
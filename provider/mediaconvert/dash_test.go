@@ -0,0 +1,182 @@
+package mediaconvert
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGroupContainerFrom(t *testing.T) {
+	tests := []struct {
+		name      string
+		container mediaconvert.ContainerType
+		protocol  string
+		want      mediaconvert.ContainerType
+		wantErr   bool
+	}{
+		{name: "hls presets without protocols", container: mediaconvert.ContainerTypeM3u8, want: mediaconvert.ContainerTypeM3u8},
+		{name: "hls presets for hls", container: mediaconvert.ContainerTypeM3u8, protocol: "hls", want: mediaconvert.ContainerTypeM3u8},
+		{name: "hls presets for dash", container: mediaconvert.ContainerTypeM3u8, protocol: "dash", want: mediaconvert.ContainerTypeMpd},
+		{name: "cmaf presets for dash", container: mediaconvert.ContainerTypeCmfc, protocol: "dash", want: mediaconvert.ContainerTypeMpd},
+		{name: "hls presets for hls and dash", container: mediaconvert.ContainerTypeM3u8, protocol: "hls,dash", want: mediaconvert.ContainerTypeCmfc},
+		{name: "mp4 presets", container: mediaconvert.ContainerTypeMp4, protocol: "dash", want: mediaconvert.ContainerTypeMp4},
+		{name: "mpd presets for dash", container: mediaconvert.ContainerTypeMpd, protocol: "dash", want: mediaconvert.ContainerTypeMpd},
+		{name: "mpd presets for hls", container: mediaconvert.ContainerTypeMpd, protocol: "hls", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := groupContainerFrom(tt.container, db.StreamingParams{Protocol: tt.protocol})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("groupContainerFrom() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("wrong container: got %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCMAFManifestsFrom(t *testing.T) {
+	for protocol, want := range map[string][2]string{
+		"":         {"ENABLED", "ENABLED"},
+		"hls":      {"ENABLED", "DISABLED"},
+		"dash":     {"DISABLED", "ENABLED"},
+		"hls,dash": {"ENABLED", "ENABLED"},
+	} {
+		hls, dash := cmafManifestsFrom(db.StreamingParams{Protocol: protocol})
+		if g := [2]string{string(hls), string(dash)}; g != want {
+			t.Errorf("wrong manifests for %q: got %v, expected %v", protocol, g, want)
+		}
+	}
+}
+
+func TestDashIsoGroupSettingsFrom(t *testing.T) {
+	job := &db.Job{StreamingParams: db.StreamingParams{
+		Protocol:        "dash",
+		SegmentDuration: 4,
+		SegmentTemplate: db.SegmentTemplateTimeline,
+	}}
+
+	settings, err := dashIsoGroupSettingsFrom(job, "s3://bucket/job/master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := aws.StringValue(settings.Destination), "s3://bucket/job/master"; g != e {
+		t.Errorf("wrong destination: got %q, expected %q", g, e)
+	}
+	if g, e := aws.Int64Value(settings.SegmentLength), int64(4); g != e {
+		t.Errorf("wrong segment length: got %d, expected %d", g, e)
+	}
+	if g, e := settings.WriteSegmentTimelineInRepresentation, mediaconvert.DashIsoWriteSegmentTimelineInRepresentationEnabled; g != e {
+		t.Errorf("wrong segment timeline: got %q, expected %q", g, e)
+	}
+
+	job.StreamingParams.Encryption = &db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: testKeyServer}
+	if _, err := dashIsoGroupSettingsFrom(job, "s3://bucket/job/master"); err == nil {
+		t.Error("expected an error for aes-128 encryption, got nil")
+	}
+}
+
+func TestManifestFilesFrom(t *testing.T) {
+	job := &db.Job{SourceMedia: "s3://bucket/source/video.mov"}
+
+	tests := []struct {
+		name     string
+		settings *mediaconvert.OutputGroupSettings
+		want     []provider.OutputFile
+	}{
+		{
+			name: "hls groups named after their playlist",
+			settings: &mediaconvert.OutputGroupSettings{
+				Type:             mediaconvert.OutputGroupTypeHlsGroupSettings,
+				HlsGroupSettings: &mediaconvert.HlsGroupSettings{Destination: aws.String("s3://bucket/job/master")},
+			},
+			want: []provider.OutputFile{
+				{Path: "s3://bucket/job/master.m3u8", Container: "m3u8", Type: provider.OutputFileTypeManifest},
+			},
+		},
+		{
+			name: "dash groups named after the source",
+			settings: &mediaconvert.OutputGroupSettings{
+				Type:                 mediaconvert.OutputGroupTypeDashIsoGroupSettings,
+				DashIsoGroupSettings: &mediaconvert.DashIsoGroupSettings{Destination: aws.String("s3://bucket/job/")},
+			},
+			want: []provider.OutputFile{
+				{Path: "s3://bucket/job/video.mpd", Container: "mpd", Type: provider.OutputFileTypeManifest},
+			},
+		},
+		{
+			name: "cmaf groups writing both manifests",
+			settings: &mediaconvert.OutputGroupSettings{
+				Type: mediaconvert.OutputGroupTypeCmafGroupSettings,
+				CmafGroupSettings: &mediaconvert.CmafGroupSettings{
+					Destination:       aws.String("s3://bucket/job/master"),
+					WriteHlsManifest:  mediaconvert.CmafWriteHLSManifestEnabled,
+					WriteDashManifest: mediaconvert.CmafWriteDASHManifestEnabled,
+				},
+			},
+			want: []provider.OutputFile{
+				{Path: "s3://bucket/job/master.m3u8", Container: "m3u8", Type: provider.OutputFileTypeManifest},
+				{Path: "s3://bucket/job/master.mpd", Container: "mpd", Type: provider.OutputFileTypeManifest},
+			},
+		},
+		{
+			name: "cmaf groups writing a dash manifest",
+			settings: &mediaconvert.OutputGroupSettings{
+				Type: mediaconvert.OutputGroupTypeCmafGroupSettings,
+				CmafGroupSettings: &mediaconvert.CmafGroupSettings{
+					Destination:       aws.String("s3://bucket/job/master"),
+					WriteHlsManifest:  mediaconvert.CmafWriteHLSManifestDisabled,
+					WriteDashManifest: mediaconvert.CmafWriteDASHManifestEnabled,
+				},
+			},
+			want: []provider.OutputFile{
+				{Path: "s3://bucket/job/master.mpd", Container: "mpd", Type: provider.OutputFileTypeManifest},
+			},
+		},
+		{
+			name: "file groups",
+			settings: &mediaconvert.OutputGroupSettings{
+				Type:              mediaconvert.OutputGroupTypeFileGroupSettings,
+				FileGroupSettings: &mediaconvert.FileGroupSettings{Destination: aws.String("s3://bucket/job/m")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := manifestFilesFrom(job, mediaconvert.OutputGroup{OutputGroupSettings: tt.settings})
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong manifests: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStreamingDestinationFrom(t *testing.T) {
+	job := &db.Job{ID: "job", StreamingParams: db.StreamingParams{PlaylistFileName: "hls/index.m3u8"}}
+
+	tests := []struct {
+		name           string
+		playlistNaming bool
+		want           string
+	}{
+		{name: "outputs named after the source", want: "s3://bucket/job/"},
+		{name: "outputs named after the playlist", playlistNaming: true, want: "s3://bucket/job/hls/index"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mcProvider{cfg: &config.MediaConvert{Destination: "s3://bucket", PlaylistNaming: tt.playlistNaming}}
+			if g := p.streamingDestinationFrom(job); g != tt.want {
+				t.Errorf("wrong destination: got %q, expected %q", g, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package mediaconvert
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// errKeyRotation is returned for encryptions rotating keys, which MediaConvert
+// can't do with VOD outputs
+var errKeyRotation = errors.New("encryption: key rotation is not supported")
+
+// hlsEncryptionFrom returns the encryption of an HLS group in TS segments, with
+// a static key or keys requested by MediaConvert from a SPEKE key server
+func hlsEncryptionFrom(e *db.Encryption) (*mediaconvert.HlsEncryptionSettings, error) {
+	if e == nil {
+		return nil, nil
+	}
+	if e.KeyRotationInterval > 0 {
+		return nil, errKeyRotation
+	}
+
+	settings := &mediaconvert.HlsEncryptionSettings{}
+	switch e.Scheme {
+	case db.EncryptionSchemeAES128:
+		settings.EncryptionMethod = mediaconvert.HlsEncryptionTypeAes128
+	case db.EncryptionSchemeSampleAES:
+		settings.EncryptionMethod = mediaconvert.HlsEncryptionTypeSampleAes
+	default:
+		return nil, fmt.Errorf("encryption: %s needs cmaf outputs", e.Scheme)
+	}
+	if e.IV != "" {
+		settings.ConstantInitializationVector = aws.String(e.IV)
+		settings.InitializationVectorInManifest = mediaconvert.HlsInitializationVectorInManifestInclude
+	}
+
+	if e.KeyServerURL == "" {
+		settings.Type = mediaconvert.HlsKeyProviderTypeStaticKey
+		settings.StaticKeyProvider = staticKeyProviderFrom(e)
+		return settings, nil
+	}
+
+	systemIDs := e.SystemIDs()
+	if len(systemIDs) == 0 {
+		systemIDs = []string{provider.AES128SystemID}
+	}
+	settings.Type = mediaconvert.HlsKeyProviderTypeSpeke
+	settings.SpekeKeyProvider = &mediaconvert.SpekeKeyProvider{
+		Url:        aws.String(e.KeyServerURL),
+		ResourceId: aws.String(e.ResourceID),
+		SystemIds:  systemIDs,
+	}
+	return settings, nil
+}
+
+// cmafEncryptionFrom returns the encryption of a CMAF group, whose DASH manifest
+// signals Widevine and PlayReady and whose HLS manifest signals FairPlay.
+// Common encryption needs keys from a key server.
+func cmafEncryptionFrom(e *db.Encryption) (*mediaconvert.CmafEncryptionSettings, error) {
+	if e == nil {
+		return nil, nil
+	}
+	if e.KeyRotationInterval > 0 {
+		return nil, errKeyRotation
+	}
+
+	settings := &mediaconvert.CmafEncryptionSettings{}
+	switch e.Scheme {
+	case db.EncryptionSchemeCENC:
+		settings.EncryptionMethod = mediaconvert.CmafEncryptionTypeAesCtr
+	case db.EncryptionSchemeSampleAES:
+		settings.EncryptionMethod = mediaconvert.CmafEncryptionTypeSampleAes
+	default:
+		return nil, fmt.Errorf("encryption: %s needs hls outputs in ts segments", e.Scheme)
+	}
+	if e.IV != "" {
+		settings.ConstantInitializationVector = aws.String(e.IV)
+		settings.InitializationVectorInManifest = mediaconvert.CmafInitializationVectorInManifestInclude
+	}
+
+	if e.KeyServerURL == "" {
+		if e.Scheme == db.EncryptionSchemeCENC {
+			return nil, errors.New("encryption: cenc needs a key server")
+		}
+		settings.Type = mediaconvert.CmafKeyProviderTypeStaticKey
+		settings.StaticKeyProvider = staticKeyProviderFrom(e)
+		return settings, nil
+	}
+
+	speke := &mediaconvert.SpekeKeyProviderCmaf{
+		Url:        aws.String(e.KeyServerURL),
+		ResourceId: aws.String(e.ResourceID),
+	}
+	for _, system := range e.DRMSystems {
+		if system == db.DRMSystemFairPlay {
+			speke.HlsSignaledSystemIds = append(speke.HlsSignaledSystemIds, db.DRMSystemIDs[system])
+		} else {
+			speke.DashSignaledSystemIds = append(speke.DashSignaledSystemIds, db.DRMSystemIDs[system])
+		}
+	}
+	if len(speke.HlsSignaledSystemIds)+len(speke.DashSignaledSystemIds) == 0 {
+		return nil, errors.New("encryption: cmaf outputs need drm systems")
+	}
+	settings.Type = mediaconvert.CmafKeyProviderTypeSpeke
+	settings.SpekeKeyProvider = speke
+	return settings, nil
+}
+
+// dashEncryptionFrom returns the encryption of a DASH ISO group, signaling the
+// DRM systems of keys requested by MediaConvert from a SPEKE key server
+func dashEncryptionFrom(e *db.Encryption) (*mediaconvert.DashIsoEncryptionSettings, error) {
+	if e == nil {
+		return nil, nil
+	}
+	if e.KeyRotationInterval > 0 {
+		return nil, errKeyRotation
+	}
+	if e.Scheme != db.EncryptionSchemeCENC {
+		return nil, fmt.Errorf("encryption: %s needs hls outputs", e.Scheme)
+	}
+	if e.KeyServerURL == "" {
+		return nil, errors.New("encryption: cenc needs a key server")
+	}
+
+	systemIDs := e.SystemIDs()
+	if len(systemIDs) == 0 {
+		return nil, errors.New("encryption: dash outputs need drm systems")
+	}
+	return &mediaconvert.DashIsoEncryptionSettings{
+		SpekeKeyProvider: &mediaconvert.SpekeKeyProvider{
+			Url:        aws.String(e.KeyServerURL),
+			ResourceId: aws.String(e.ResourceID),
+			SystemIds:  systemIDs,
+		},
+	}, nil
+}
+
+// staticKeyProviderFrom returns the static key of an encryption, fetched by
+// players from its key URL
+func staticKeyProviderFrom(e *db.Encryption) *mediaconvert.StaticKeyProvider {
+	return &mediaconvert.StaticKeyProvider{
+		KeyFormat:         aws.String("identity"),
+		KeyFormatVersions: aws.String("1"),
+		StaticKeyValue:    aws.String(e.Key),
+		Url:               aws.String(e.KeyURL),
+	}
+}
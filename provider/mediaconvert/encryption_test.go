@@ -0,0 +1,181 @@
+package mediaconvert
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testKey       = "3c1fa5d8b8e5c6a2f0b3d9e1a7c4f6b2"
+	testKeyServer = "https://keys.example.com/speke/v1.0/copyProtection"
+)
+
+func TestHLSEncryptionFrom(t *testing.T) {
+	tests := []struct {
+		name       string
+		encryption *db.Encryption
+		want       *mediaconvert.HlsEncryptionSettings
+		wantErr    string
+	}{
+		{
+			name: "static aes-128 keys",
+			encryption: &db.Encryption{
+				Scheme: db.EncryptionSchemeAES128, Key: testKey, KeyURL: "https://keys.example.com/key", IV: testKey,
+			},
+			want: &mediaconvert.HlsEncryptionSettings{
+				EncryptionMethod:               mediaconvert.HlsEncryptionTypeAes128,
+				ConstantInitializationVector:   aws.String(testKey),
+				InitializationVectorInManifest: mediaconvert.HlsInitializationVectorInManifestInclude,
+				Type:                           mediaconvert.HlsKeyProviderTypeStaticKey,
+				StaticKeyProvider: &mediaconvert.StaticKeyProvider{
+					KeyFormat:         aws.String("identity"),
+					KeyFormatVersions: aws.String("1"),
+					StaticKeyValue:    aws.String(testKey),
+					Url:               aws.String("https://keys.example.com/key"),
+				},
+			},
+		},
+		{
+			name: "fairplay keys from a key server",
+			encryption: &db.Encryption{
+				Scheme:       db.EncryptionSchemeSampleAES,
+				KeyServerURL: testKeyServer,
+				ResourceID:   "job-1",
+				DRMSystems:   []db.DRMSystem{db.DRMSystemFairPlay},
+			},
+			want: &mediaconvert.HlsEncryptionSettings{
+				EncryptionMethod: mediaconvert.HlsEncryptionTypeSampleAes,
+				Type:             mediaconvert.HlsKeyProviderTypeSpeke,
+				SpekeKeyProvider: &mediaconvert.SpekeKeyProvider{
+					Url:        aws.String(testKeyServer),
+					ResourceId: aws.String("job-1"),
+					SystemIds:  []string{"94ce86fb-07ff-4f43-adb8-93d2fa968ca2"},
+				},
+			},
+		},
+		{
+			name:       "clear aes-128 keys from a key server",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: testKeyServer, ResourceID: "job-1"},
+			want: &mediaconvert.HlsEncryptionSettings{
+				EncryptionMethod: mediaconvert.HlsEncryptionTypeAes128,
+				Type:             mediaconvert.HlsKeyProviderTypeSpeke,
+				SpekeKeyProvider: &mediaconvert.SpekeKeyProvider{
+					Url:        aws.String(testKeyServer),
+					ResourceId: aws.String("job-1"),
+					SystemIds:  []string{"3ea8778f-7742-4bf9-b18f-e834b2acbd47"},
+				},
+			},
+		},
+		{
+			name:       "cenc needs cmaf outputs",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeCENC, KeyServerURL: testKeyServer},
+			wantErr:    "encryption: cenc needs cmaf outputs",
+		},
+		{
+			name:       "keys aren't rotated",
+			encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: testKeyServer, KeyRotationInterval: 60},
+			wantErr:    "encryption: key rotation is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hlsEncryptionFrom(tt.encryption)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong encryption: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCMAFEncryptionFrom(t *testing.T) {
+	got, err := cmafEncryptionFrom(&db.Encryption{
+		Scheme:       db.EncryptionSchemeCENC,
+		KeyServerURL: testKeyServer,
+		ResourceID:   "job-1",
+		DRMSystems:   []db.DRMSystem{db.DRMSystemWidevine, db.DRMSystemPlayReady},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &mediaconvert.CmafEncryptionSettings{
+		EncryptionMethod: mediaconvert.CmafEncryptionTypeAesCtr,
+		Type:             mediaconvert.CmafKeyProviderTypeSpeke,
+		SpekeKeyProvider: &mediaconvert.SpekeKeyProviderCmaf{
+			Url:        aws.String(testKeyServer),
+			ResourceId: aws.String("job-1"),
+			DashSignaledSystemIds: []string{
+				"edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",
+				"9a04f079-9840-4286-ab92-e65be0885f95",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong encryption: %s", diff)
+	}
+
+	for _, tt := range []struct {
+		encryption *db.Encryption
+		wantErr    string
+	}{
+		{&db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: testKeyServer}, "encryption: aes-128 needs hls outputs in ts segments"},
+		{&db.Encryption{Scheme: db.EncryptionSchemeCENC, Key: testKey, KeyID: testKey}, "encryption: cenc needs a key server"},
+		{&db.Encryption{Scheme: db.EncryptionSchemeCENC, KeyServerURL: testKeyServer}, "encryption: cmaf outputs need drm systems"},
+	} {
+		if _, err := cmafEncryptionFrom(tt.encryption); err == nil || err.Error() != tt.wantErr {
+			t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+		}
+	}
+
+	if settings, err := cmafEncryptionFrom(nil); settings != nil || err != nil {
+		t.Errorf("expected no encryption, got %+v, %v", settings, err)
+	}
+}
+
+func TestDASHEncryptionFrom(t *testing.T) {
+	got, err := dashEncryptionFrom(&db.Encryption{
+		Scheme:       db.EncryptionSchemeCENC,
+		KeyServerURL: testKeyServer,
+		ResourceID:   "job-1",
+		DRMSystems:   []db.DRMSystem{db.DRMSystemWidevine},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &mediaconvert.DashIsoEncryptionSettings{
+		SpekeKeyProvider: &mediaconvert.SpekeKeyProvider{
+			Url:        aws.String(testKeyServer),
+			ResourceId: aws.String("job-1"),
+			SystemIds:  []string{"edef8ba9-79d6-4ace-a3c8-27dcd51d21ed"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong encryption: %s", diff)
+	}
+
+	for _, tt := range []struct {
+		encryption *db.Encryption
+		wantErr    string
+	}{
+		{&db.Encryption{Scheme: db.EncryptionSchemeSampleAES, KeyServerURL: testKeyServer}, "encryption: sample-aes needs hls outputs"},
+		{&db.Encryption{Scheme: db.EncryptionSchemeCENC, Key: testKey, KeyID: testKey}, "encryption: cenc needs a key server"},
+		{&db.Encryption{Scheme: db.EncryptionSchemeCENC, KeyServerURL: testKeyServer}, "encryption: dash outputs need drm systems"},
+	} {
+		if _, err := dashEncryptionFrom(tt.encryption); err == nil || err.Error() != tt.wantErr {
+			t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+		}
+	}
+}
@@ -79,6 +79,16 @@ func Test_mediaconvertFactory(t *testing.T) {
 			cfg:        config.Config{MediaConvert: &config.MediaConvert{}},
 			wantErrMsg: "incomplete MediaConvert config",
 		},
+		{
+			name: "invalid queue rules result in an error returned",
+			cfg: config.Config{MediaConvert: &config.MediaConvert{
+				Endpoint:        "http://some/endpoint",
+				DefaultQueueARN: "arn:some:queue",
+				Role:            "arn:some:role",
+				QueueRules:      `[{"labels":["news"]}]`,
+			}},
+			wantErrMsg: "loading MediaConvert config: queue rule 0: missing queue",
+		},
 	}
 
 	for _, tt := range tests {
@@ -16,6 +16,7 @@ type testMediaConvertClient struct {
 	createPresetCalledWith *mediaconvert.CreatePresetInput
 	getPresetCalledWith    string
 	deletePresetCalledWith string
+	updatePresetCalledWith *mediaconvert.UpdatePresetInput
 	createJobCalledWith    mediaconvert.CreateJobInput
 	cancelJobCalledWith    string
 	listJobsCalled         bool
@@ -23,6 +24,16 @@ type testMediaConvertClient struct {
 	jobReturnedByGetJob      mediaconvert.Job
 	jobIDReturnedByCreateJob string
 	getPresetContainerType   mediaconvert.ContainerType
+
+	// presetReturnedByGetPreset replaces the preset returned by GetPreset, and
+	// presetErr is returned by GetPreset and DeletePreset when set
+	presetReturnedByGetPreset *mediaconvert.Preset
+	presetErr                 error
+
+	// accelerationErr is returned by CreateJob for accelerated jobs when set,
+	// and createJobCalls counts the jobs submitted
+	accelerationErr error
+	createJobCalls  int
 }
 
 func (c *testMediaConvertClient) CreatePresetRequest(input *mediaconvert.CreatePresetInput) mediaconvert.CreatePresetRequest {
@@ -62,13 +73,16 @@ func (c *testMediaConvertClient) ListJobsRequest(*mediaconvert.ListJobsInput) me
 
 func (c *testMediaConvertClient) CreateJobRequest(input *mediaconvert.CreateJobInput) mediaconvert.CreateJobRequest {
 	c.createJobCalledWith = *input
-	return mediaconvert.CreateJobRequest{
-		Request: &aws.Request{HTTPRequest: &http.Request{}, Retryer: aws.NoOpRetryer{}, Data: &mediaconvert.CreateJobOutput{
-			Job: &mediaconvert.Job{
-				Id: aws.String(c.jobIDReturnedByCreateJob),
-			},
-		}},
+	c.createJobCalls++
+	req := &aws.Request{HTTPRequest: &http.Request{}, Retryer: aws.NoOpRetryer{}, Data: &mediaconvert.CreateJobOutput{
+		Job: &mediaconvert.Job{
+			Id: aws.String(c.jobIDReturnedByCreateJob),
+		},
+	}}
+	if input.AccelerationSettings != nil {
+		req.Error = c.accelerationErr
 	}
+	return mediaconvert.CreateJobRequest{Request: req}
 }
 
 func (c *testMediaConvertClient) CancelJobRequest(input *mediaconvert.CancelJobInput) mediaconvert.CancelJobRequest {
@@ -82,6 +96,14 @@ func (c *testMediaConvertClient) CancelJobRequest(input *mediaconvert.CancelJobI
 
 func (c *testMediaConvertClient) GetPresetRequest(input *mediaconvert.GetPresetInput) mediaconvert.GetPresetRequest {
 	c.getPresetCalledWith = *input.Name
+	if c.presetReturnedByGetPreset != nil || c.presetErr != nil {
+		return mediaconvert.GetPresetRequest{Request: &aws.Request{
+			HTTPRequest: &http.Request{},
+			Retryer:     aws.NoOpRetryer{},
+			Data:        &mediaconvert.GetPresetOutput{Preset: c.presetReturnedByGetPreset},
+			Error:       c.presetErr,
+		}}
+	}
 	return mediaconvert.GetPresetRequest{
 		Request: &aws.Request{HTTPRequest: &http.Request{}, Retryer: aws.NoOpRetryer{}, Data: &mediaconvert.GetPresetOutput{
 			Preset: &mediaconvert.Preset{
@@ -102,5 +124,15 @@ func (c *testMediaConvertClient) DeletePresetRequest(input *mediaconvert.DeleteP
 		HTTPRequest: &http.Request{},
 		Retryer:     aws.NoOpRetryer{},
 		Data:        &mediaconvert.DeletePresetOutput{},
+		Error:       c.presetErr,
+	}}
+}
+
+func (c *testMediaConvertClient) UpdatePresetRequest(input *mediaconvert.UpdatePresetInput) mediaconvert.UpdatePresetRequest {
+	c.updatePresetCalledWith = input
+	return mediaconvert.UpdatePresetRequest{Request: &aws.Request{
+		HTTPRequest: &http.Request{},
+		Retryer:     aws.NoOpRetryer{},
+		Data:        &mediaconvert.UpdatePresetOutput{Preset: &mediaconvert.Preset{Name: input.Name, Settings: input.Settings}},
 	}}
 }
@@ -0,0 +1,155 @@
+package mediaconvert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// frameCaptureRateScale is the precision intervals are converted to capture
+// rates with, in captures per thousand seconds
+const frameCaptureRateScale = 1000
+
+// imageOutputsFrom returns the frame capture outputs of the image outputs of a
+// job. MediaConvert captures JPEG frames at a fixed rate from the start of the
+// output, so thumbnails at given offsets and sprite sheets can't be produced.
+func imageOutputsFrom(job *db.Job) ([]outputCfg, error) {
+	var outputs []outputCfg
+	for _, o := range job.ImageOutputs {
+		if o.ImageFormat() != db.ImageFormatJPEG {
+			return nil, fmt.Errorf("image output %q: only jpg images can be captured", o.FileName)
+		}
+
+		settings := &mediaconvert.FrameCaptureSettings{}
+		switch o.Kind {
+		case db.ImageOutputKindThumbnails:
+			if len(o.Offsets) > 0 {
+				return nil, fmt.Errorf("image output %q: thumbnails can only be captured at an interval", o.FileName)
+			}
+			settings.FramerateNumerator, settings.FramerateDenominator = frameCaptureRateFrom(o.Interval)
+		case db.ImageOutputKindPoster:
+			if len(o.Offsets) > 0 && o.Offsets[0] != 0 {
+				return nil, fmt.Errorf("image output %q: poster frames can only be captured at the start of the output", o.FileName)
+			}
+			settings.FramerateNumerator, settings.FramerateDenominator = aws.Int64(1), aws.Int64(1)
+			settings.MaxCaptures = aws.Int64(1)
+		default:
+			return nil, fmt.Errorf("image output %q: %s outputs are not supported", o.FileName, o.Kind)
+		}
+
+		video := &mediaconvert.VideoDescription{
+			CodecSettings: &mediaconvert.VideoCodecSettings{
+				Codec:                mediaconvert.VideoCodecFrameCapture,
+				FrameCaptureSettings: settings,
+			},
+		}
+		if o.Width > 0 {
+			video.Width = aws.Int64(int64(o.Width))
+		}
+		if o.Height > 0 {
+			video.Height = aws.Int64(int64(o.Height))
+		}
+
+		outputs = append(outputs, outputCfg{
+			filename: o.FileName,
+			output: mediaconvert.Output{
+				ContainerSettings: &mediaconvert.ContainerSettings{Container: mediaconvert.ContainerTypeRaw},
+				VideoDescription:  video,
+			},
+		})
+	}
+	return outputs, nil
+}
+
+// frameCaptureRateFrom converts an interval in seconds into a capture rate,
+// as a reduced fraction
+func frameCaptureRateFrom(interval float64) (numerator, denominator *int64) {
+	num := int64(frameCaptureRateScale)
+	den := int64(math.Round(interval * frameCaptureRateScale))
+	if den < 1 {
+		den = 1
+	}
+	d := gcd(num, den)
+	return aws.Int64(num / d), aws.Int64(den / d)
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// iFramePlaylistsFrom returns the output settings adding I-frame only playlists
+// to the video outputs of HLS groups when an image output of the job asks for
+// trick-play playlists. CMAF groups can't carry them.
+func iFramePlaylistsFrom(job *db.Job, container mediaconvert.ContainerType) (*mediaconvert.OutputSettings, error) {
+	playlist := false
+	for _, o := range job.ImageOutputs {
+		playlist = playlist || o.Playlist
+	}
+	if !playlist {
+		return nil, nil
+	}
+
+	switch container {
+	case mediaconvert.ContainerTypeM3u8:
+		return &mediaconvert.OutputSettings{
+			HlsSettings: &mediaconvert.HlsSettings{
+				IFrameOnlyManifest: mediaconvert.HlsIFrameOnlyManifestInclude,
+			},
+		}, nil
+	case mediaconvert.ContainerTypeCmfc, mediaconvert.ContainerTypeMpd:
+		return nil, errors.New("trick-play playlists are only supported with hls outputs in ts segments")
+	}
+	return nil, nil
+}
+
+// imageFilesFrom returns the images captured by a frame capture output. Captures
+// are numbered by MediaConvert, they are all listed when the duration of the
+// output is known and only the first one otherwise.
+func imageFilesFrom(job *db.Job, destination string, output mediaconvert.Output) []provider.OutputFile {
+	if output.NameModifier == nil || output.VideoDescription == nil {
+		return nil
+	}
+	video := output.VideoDescription
+
+	count := int64(1)
+	if codec := video.CodecSettings; codec != nil && codec.FrameCaptureSettings != nil {
+		settings := codec.FrameCaptureSettings
+		duration := job.SourceInfo.Duration
+		if len(job.SourceSplice) > 0 {
+			duration = job.SourceSplice.Size()
+		}
+		num, den := aws.Int64Value(settings.FramerateNumerator), aws.Int64Value(settings.FramerateDenominator)
+		if duration > 0 && num > 0 && den > 0 {
+			count = int64(math.Ceil(duration.Seconds() * float64(num) / float64(den)))
+		}
+		if max := aws.Int64Value(settings.MaxCaptures); max > 0 && count > max {
+			count = max
+		}
+	}
+
+	extension := strings.TrimPrefix(aws.StringValue(output.Extension), ".")
+	if extension == "" {
+		extension = db.ImageFormatJPEG
+	}
+
+	files := make([]provider.OutputFile, count)
+	for i := range files {
+		files[i] = provider.OutputFile{
+			Path:      fmt.Sprintf("%s%s.%07d.%s", destination, *output.NameModifier, i, extension),
+			Container: extension,
+			Type:      provider.OutputFileTypeImage,
+			Width:     aws.Int64Value(video.Width),
+			Height:    aws.Int64Value(video.Height),
+		}
+	}
+	return files
+}
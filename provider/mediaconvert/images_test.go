@@ -0,0 +1,114 @@
+package mediaconvert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/pkg/timecode"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImageOutputsFrom(t *testing.T) {
+	outputs, err := imageOutputsFrom(&db.Job{ImageOutputs: []db.ImageOutput{
+		{Kind: db.ImageOutputKindThumbnails, FileName: "thumbs/thumb.jpg", Interval: 2.5, Width: 320},
+		{Kind: db.ImageOutputKindPoster, FileName: "poster.jpg"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 outputs, got %d", len(outputs))
+	}
+
+	thumbs := outputs[0].output
+	if g, e := thumbs.ContainerSettings.Container, mediaconvert.ContainerTypeRaw; g != e {
+		t.Errorf("wrong container: got %q, expected %q", g, e)
+	}
+	settings := thumbs.VideoDescription.CodecSettings.FrameCaptureSettings
+	if g, e := [2]int64{*settings.FramerateNumerator, *settings.FramerateDenominator}, [2]int64{2, 5}; g != e {
+		t.Errorf("wrong capture rate: got %v, expected %v", g, e)
+	}
+	if g, e := aws.Int64Value(thumbs.VideoDescription.Width), int64(320); g != e {
+		t.Errorf("wrong width: got %d, expected %d", g, e)
+	}
+	if thumbs.VideoDescription.Height != nil {
+		t.Errorf("expected the height to follow the aspect ratio, got %d", *thumbs.VideoDescription.Height)
+	}
+
+	poster := outputs[1].output.VideoDescription.CodecSettings.FrameCaptureSettings
+	if g, e := aws.Int64Value(poster.MaxCaptures), int64(1); g != e {
+		t.Errorf("wrong poster captures: got %d, expected %d", g, e)
+	}
+
+	for _, o := range []db.ImageOutput{
+		{Kind: db.ImageOutputKindThumbnails, FileName: "t.jpg", Offsets: []float64{1}},
+		{Kind: db.ImageOutputKindPoster, FileName: "p.jpg", Offsets: []float64{5}},
+		{Kind: db.ImageOutputKindSprite, FileName: "s.jpg", Interval: 5},
+		{Kind: db.ImageOutputKindPoster, FileName: "p.png", Format: db.ImageFormatPNG},
+	} {
+		if _, err := imageOutputsFrom(&db.Job{ImageOutputs: []db.ImageOutput{o}}); err == nil {
+			t.Errorf("expected an error for %+v, got nil", o)
+		}
+	}
+}
+
+func TestIFramePlaylistsFrom(t *testing.T) {
+	job := &db.Job{ImageOutputs: []db.ImageOutput{{Kind: db.ImageOutputKindThumbnails, Interval: 5, Playlist: true}}}
+
+	settings, err := iFramePlaylistsFrom(job, mediaconvert.ContainerTypeM3u8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := settings.HlsSettings.IFrameOnlyManifest, mediaconvert.HlsIFrameOnlyManifestInclude; g != e {
+		t.Errorf("wrong i-frame manifest: got %q, expected %q", g, e)
+	}
+
+	if _, err := iFramePlaylistsFrom(job, mediaconvert.ContainerTypeCmfc); err == nil {
+		t.Error("expected an error for cmaf outputs, got nil")
+	}
+
+	job.ImageOutputs[0].Playlist = false
+	if settings, _ := iFramePlaylistsFrom(job, mediaconvert.ContainerTypeM3u8); settings != nil {
+		t.Errorf("expected no settings without playlists, got %+v", settings)
+	}
+}
+
+func TestImageFilesFrom(t *testing.T) {
+	output := mediaconvert.Output{
+		NameModifier: aws.String("thumb"),
+		Extension:    aws.String("jpg"),
+		VideoDescription: &mediaconvert.VideoDescription{
+			Width: aws.Int64(320),
+			CodecSettings: &mediaconvert.VideoCodecSettings{
+				FrameCaptureSettings: &mediaconvert.FrameCaptureSettings{
+					FramerateNumerator:   aws.Int64(1),
+					FramerateDenominator: aws.Int64(10),
+				},
+			},
+		},
+	}
+	job := &db.Job{
+		SourceInfo:   db.File{Duration: time.Minute},
+		SourceSplice: timecode.Splice{{0, 10}, {20, 35}},
+	}
+
+	files := imageFilesFrom(job, "s3://bucket/job/m", output)
+
+	want := []provider.OutputFile{
+		{Path: "s3://bucket/job/mthumb.0000000.jpg", Container: "jpg", Type: provider.OutputFileTypeImage, Width: 320},
+		{Path: "s3://bucket/job/mthumb.0000001.jpg", Container: "jpg", Type: provider.OutputFileTypeImage, Width: 320},
+		{Path: "s3://bucket/job/mthumb.0000002.jpg", Container: "jpg", Type: provider.OutputFileTypeImage, Width: 320},
+	}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Errorf("wrong files: %s", diff)
+	}
+
+	job.SourceSplice, job.SourceInfo.Duration = nil, 0
+	if files := imageFilesFrom(job, "s3://bucket/job/m", output); len(files) != 1 {
+		t.Errorf("expected the first capture only without a duration, got %d files", len(files))
+	}
+}
@@ -0,0 +1,97 @@
+package mediaconvert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+)
+
+const (
+	esamAcquisitionPointIdentity = "transcode-orchestrator"
+
+	// esamSegmentationTypeID signals a provider placement opportunity start,
+	// where ads are usually inserted
+	esamSegmentationTypeID = 52
+
+	esamNotificationHeader = `<SignalProcessingNotification xmlns="urn:cablelabs:iptvservices:esam:xsd:signal:1"` +
+		` xmlns:sig="urn:cablelabs:md:xsd:signaling:3.0" xmlns:common="urn:cablelabs:iptvservices:esam:xsd:common:1">` +
+		`<common:BatchInfo batchId="1"><common:Source xsi:type="content:MovieType"` +
+		` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"` +
+		` xmlns:content="http://www.cablelabs.com/namespaces/metadata/xsd/content/2"/></common:BatchInfo>`
+	esamResponseSignalTmpl = `<ResponseSignal action="create" acquisitionPointIdentity="%s" acquisitionSignalID="%d" signalPointID="%d">` +
+		`<sig:NPTPoint nptPoint="%.3f"/><sig:SCTE35PointDescriptor spliceCommandType="6">` +
+		`<sig:SegmentationDescriptorInfo segmentEventId="%d" segmentTypeId="%d"/></sig:SCTE35PointDescriptor></ResponseSignal>`
+	esamNotificationFooter = `</SignalProcessingNotification>`
+)
+
+// esamFrom returns ESAM settings conditioning a signal at every offset of the
+// output, MediaConvert inserting an IDR frame and starting a new segment at each
+// of them. Nil is returned without offsets.
+func esamFrom(offsets []float64) *mediaconvert.EsamSettings {
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	var scc strings.Builder
+	scc.WriteString(esamNotificationHeader)
+	for i, offset := range offsets {
+		id := i + 1
+		fmt.Fprintf(&scc, esamResponseSignalTmpl, esamAcquisitionPointIdentity, id, id, offset, id, esamSegmentationTypeID)
+	}
+	scc.WriteString(esamNotificationFooter)
+
+	return &mediaconvert.EsamSettings{
+		SignalProcessingNotification: &mediaconvert.EsamSignalProcessingNotification{
+			SccXml: aws.String(scc.String()),
+		},
+		ResponseSignalPreroll: aws.Int64(0),
+	}
+}
+
+// enableEsamOutputs has the outputs of the groups insert the signals conditioned
+// by the ESAM settings of the job. Only CMAF, DASH and transport stream outputs
+// support ESAM, keyframes can't be placed in outputs of other containers. Frame
+// captures and caption renditions are left as is.
+func enableEsamOutputs(groups []mediaconvert.OutputGroup) error {
+	for _, group := range groups {
+		for _, output := range group.Outputs {
+			cs := output.ContainerSettings
+			if cs == nil || cs.Container == mediaconvert.ContainerTypeRaw {
+				continue
+			}
+			if output.VideoDescription == nil && len(output.AudioDescriptions) == 0 {
+				continue
+			}
+
+			switch cs.Container {
+			case mediaconvert.ContainerTypeCmfc:
+				if cs.CmfcSettings == nil {
+					cs.CmfcSettings = &mediaconvert.CmfcSettings{}
+				}
+				cs.CmfcSettings.Scte35Esam = mediaconvert.CmfcScte35EsamInsert
+			case mediaconvert.ContainerTypeMpd:
+				if cs.MpdSettings == nil {
+					cs.MpdSettings = &mediaconvert.MpdSettings{}
+				}
+				cs.MpdSettings.Scte35Esam = mediaconvert.MpdScte35EsamInsert
+			case mediaconvert.ContainerTypeM3u8:
+				// signals from ESAM are inserted without manifest conditioning
+				if cs.M3u8Settings == nil {
+					cs.M3u8Settings = &mediaconvert.M3u8Settings{}
+				}
+				cs.M3u8Settings.Scte35Source = mediaconvert.M3u8Scte35SourceNone
+			case mediaconvert.ContainerTypeM2ts:
+				if cs.M2tsSettings == nil {
+					cs.M2tsSettings = &mediaconvert.M2tsSettings{}
+				}
+				cs.M2tsSettings.Scte35Esam = &mediaconvert.M2tsScte35Esam{}
+				cs.M2tsSettings.Scte35Source = mediaconvert.M2tsScte35SourceNone
+			default:
+				return fmt.Errorf("explicit keyframe offsets: %s outputs don't support ESAM", strings.ToLower(string(cs.Container)))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package mediaconvert
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+func TestEsamFrom(t *testing.T) {
+	if esam := esamFrom(nil); esam != nil {
+		t.Errorf("expected no esam settings without offsets, got %v", esam)
+	}
+
+	esam := esamFrom([]float64{10, 62.5})
+	scc := *esam.SignalProcessingNotification.SccXml
+
+	var nptPoints []string
+	decoder := xml.NewDecoder(strings.NewReader(scc))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid scc xml: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "NPTPoint" {
+			nptPoints = append(nptPoints, start.Attr[0].Value)
+		}
+	}
+
+	if g, e := strings.Join(nptPoints, ","), "10.000,62.500"; g != e {
+		t.Errorf("wrong npt points: got %q, expected %q", g, e)
+	}
+	if g, e := *esam.ResponseSignalPreroll, int64(0); g != e {
+		t.Errorf("wrong response signal preroll: got %d, expected %d", g, e)
+	}
+}
+
+func TestTranscodeWithKeyframeOffsets(t *testing.T) {
+	cmafPreset, hlsPreset := defaultPreset, defaultPreset
+	cmafPreset.Name, cmafPreset.Container = "cmaf_preset", "cmaf"
+	hlsPreset.Name, hlsPreset.Container = "hls_preset", "m3u8"
+
+	tests := []struct {
+		name     string
+		preset   db.Preset
+		protocol string
+		check    func(*testing.T, *mediaconvert.ContainerSettings)
+		wantErr  string
+	}{
+		{
+			name:     "cmaf outputs",
+			preset:   cmafPreset,
+			protocol: db.ProtocolHLS,
+			check: func(t *testing.T, cs *mediaconvert.ContainerSettings) {
+				if cs.CmfcSettings == nil || cs.CmfcSettings.Scte35Esam != mediaconvert.CmfcScte35EsamInsert {
+					t.Errorf("expected cmaf outputs to insert esam signals, got %+v", cs.CmfcSettings)
+				}
+			},
+		},
+		{
+			name:     "dash outputs",
+			preset:   cmafPreset,
+			protocol: db.ProtocolDASH,
+			check: func(t *testing.T, cs *mediaconvert.ContainerSettings) {
+				if cs.MpdSettings == nil || cs.MpdSettings.Scte35Esam != mediaconvert.MpdScte35EsamInsert {
+					t.Errorf("expected dash outputs to insert esam signals, got %+v", cs.MpdSettings)
+				}
+			},
+		},
+		{
+			name:     "hls outputs",
+			preset:   hlsPreset,
+			protocol: db.ProtocolHLS,
+			check: func(t *testing.T, cs *mediaconvert.ContainerSettings) {
+				if cs.M3u8Settings == nil || cs.M3u8Settings.Scte35Source != mediaconvert.M3u8Scte35SourceNone {
+					t.Errorf("expected hls outputs to take signals from esam only, got %+v", cs.M3u8Settings)
+				}
+			},
+		},
+		{
+			name:    "mp4 outputs",
+			preset:  defaultPreset,
+			wantErr: "mediaconvert: explicit keyframe offsets: mp4 outputs don't support ESAM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := fakeDBWithPresets(tt.preset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &testMediaConvertClient{t: t}
+			p := &mcProvider{
+				client:     client,
+				cfg:        &config.MediaConvert{Destination: "s3://some/destination"},
+				repository: repo,
+			}
+
+			_, err = p.Transcode(context.Background(), &db.Job{
+				ID:                      "jobID",
+				SourceMedia:             "s3://some/path.mp4",
+				StreamingParams:         db.StreamingParams{SegmentDuration: 6, Protocol: tt.protocol},
+				ExplicitKeyframeOffsets: []float64{10, 62.5},
+				Outputs:                 []db.TranscodeOutput{{Preset: db.PresetMap{Name: tt.preset.Name}, FileName: "file1"}},
+			})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				if client.createJobCalls != 0 {
+					t.Error("expected the job not to be submitted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			settings := client.createJobCalledWith.Settings
+			if settings.Esam == nil {
+				t.Fatal("expected the job to carry esam settings")
+			}
+			tt.check(t, settings.OutputGroups[0].Outputs[0].ContainerSettings)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/external"
@@ -14,6 +15,7 @@ import (
 	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/db/redis"
 	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/cbsinteractive/transcode-orchestrator/smpte"
 	"github.com/pkg/errors"
 )
 
@@ -40,17 +42,27 @@ type mediaconvertClient interface {
 	CreatePresetRequest(*mediaconvert.CreatePresetInput) mediaconvert.CreatePresetRequest
 	GetPresetRequest(*mediaconvert.GetPresetInput) mediaconvert.GetPresetRequest
 	DeletePresetRequest(*mediaconvert.DeletePresetInput) mediaconvert.DeletePresetRequest
+	UpdatePresetRequest(*mediaconvert.UpdatePresetInput) mediaconvert.UpdatePresetRequest
 }
 
 type mcProvider struct {
 	client     mediaconvertClient
 	cfg        *config.MediaConvert
 	repository db.Repository
+
+	// queueRules route jobs to queues, the first matching rule applying
+	queueRules []queueRule
+
+	// foundNativePresets holds the names of the native presets known to exist
+	foundNativePresets sync.Map
 }
 
 type outputCfg struct {
 	output   mediaconvert.Output
 	filename string
+
+	// preset is the native preset holding the settings of the output, if any
+	preset string
 }
 
 func splice2clippings(s timecode.Splice, fps float64) (ic []mediaconvert.InputClipping) {
@@ -58,12 +70,15 @@ func splice2clippings(s timecode.Splice, fps float64) (ic []mediaconvert.InputCl
 	// we probably don't want the uglyness of importing the AWS API in that package
 	// and having to recognize mediaconvert.InputClippings
 
-	// NOTE(as): We need to take into account embedded timecodes. Maybe it would
-	// be better to have this be a method on a timecode object or have it passed in as
-	// a reference argument (object could also provide fps info)
-
+	// Splices are relative to the start of the source, so the clippings use zero-based
+	// timecodes. With a known frame rate, the timecodes count the frames of the source,
+	// NTSC rates using drop-frame timecodes, otherwise whole seconds are used.
 	for _, r := range s {
 		s, e := r.Timecodes(fps)
+		if fps > 0 {
+			s = smpte.FromFrame(smpte.FrameAt(r[0], fps), fps, true).String()
+			e = smpte.FromFrame(smpte.FrameAt(r[1], fps), fps, true).String()
+		}
 		ic = append(ic, mediaconvert.InputClipping{
 			StartTimecode: &s,
 			EndTimecode:   &e,
@@ -73,28 +88,26 @@ func splice2clippings(s timecode.Splice, fps float64) (ic []mediaconvert.InputCl
 }
 
 func (p *mcProvider) Transcode(ctx context.Context, job *db.Job) (*provider.JobStatus, error) {
+	splice := provider.FrameAccurateSplice(job.SourceSplice, job.SourceInfo.FrameRate)
+	if err := provider.ValidateSplice(splice, job.SourceInfo); err != nil {
+		return nil, fmt.Errorf("mediaconvert: %w", err)
+	}
+
 	outputGroups, err := p.outputGroupsFrom(ctx, job)
 	if err != nil {
 		return nil, fmt.Errorf("mediaconvert: output group generator: %w", err)
 	}
 
-	queue := aws.String(p.cfg.DefaultQueueARN)
-
-	var hopDestinations []mediaconvert.HopDestination
-	if preferred := p.cfg.PreferredQueueARN; p.canUsePreferredQueue(job.SourceInfo) && preferred != "" {
-		queue = aws.String(preferred)
-		hopDestinations = append(hopDestinations, mediaconvert.HopDestination{
-			WaitMinutes: aws.Int64(defaultQueueHopTimeoutMins),
-		})
-	}
-
-	var accelerationSettings *mediaconvert.AccelerationSettings
-	if p.requiresAcceleration(job.SourceInfo) {
-		accelerationSettings = &mediaconvert.AccelerationSettings{
-			Mode: mediaconvert.AccelerationModePreferred,
+	// keyframes are placed on the timeline of the clipped input
+	esam := esamFrom(provider.SplicedOffsets(job.ExplicitKeyframeOffsets, splice))
+	if esam != nil {
+		if err := enableEsamOutputs(outputGroups); err != nil {
+			return nil, fmt.Errorf("mediaconvert: %w", err)
 		}
 	}
 
+	queue := p.queuePlanFrom(job)
+
 	audioSelector := mediaconvert.AudioSelector{
 		DefaultSelection: mediaconvert.AudioDefaultSelectionDefault,
 	}
@@ -104,19 +117,26 @@ func (p *mcProvider) Transcode(ctx context.Context, job *db.Job) (*provider.JobS
 		}
 	}
 
-	resp, err := p.client.CreateJobRequest(&mediaconvert.CreateJobInput{
-		AccelerationSettings: accelerationSettings,
-		Queue:                queue,
-		HopDestinations:      hopDestinations,
+	captionSelectors, err := captionSelectorsFrom(job.Captions)
+	if err != nil {
+		return nil, fmt.Errorf("mediaconvert: caption selectors generator: %w", err)
+	}
+
+	input := &mediaconvert.CreateJobInput{
+		AccelerationSettings: p.accelerationSettingsFrom(job, queue),
+		Priority:             priorityFrom(job.Priority),
+		Queue:                aws.String(queue.queue),
+		HopDestinations:      queue.hops,
 		Role:                 aws.String(p.cfg.Role),
 		Settings: &mediaconvert.JobSettings{
 			Inputs: []mediaconvert.Input{
 				{
-					InputClippings: splice2clippings(job.SourceSplice, 0), // TODO(as): Find FPS in job
+					InputClippings: splice2clippings(job.SourceSplice, job.SourceInfo.FrameRate),
 					FileInput:      aws.String(job.SourceMedia),
 					AudioSelectors: map[string]mediaconvert.AudioSelector{
 						"Audio Selector 1": audioSelector,
 					},
+					CaptionSelectors: captionSelectors,
 					VideoSelector: &mediaconvert.VideoSelector{
 						ColorSpace: mediaconvert.ColorSpaceFollow,
 					},
@@ -124,13 +144,20 @@ func (p *mcProvider) Transcode(ctx context.Context, job *db.Job) (*provider.JobS
 				},
 			},
 			OutputGroups: outputGroups,
+			Esam:         esam,
 			TimecodeConfig: &mediaconvert.TimecodeConfig{
 				Source: mediaconvert.TimecodeSourceZerobased,
 			},
 		},
 		Tags:              p.tagsFrom(job.Labels),
 		BillingTagsSource: "JOB",
-	}).Send(ctx)
+	}
+
+	resp, err := p.client.CreateJobRequest(input).Send(ctx)
+	if isAccelerationRejected(input, err) {
+		input.AccelerationSettings = nil
+		resp, err = p.client.CreateJobRequest(input).Send(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -175,16 +202,45 @@ func (p *mcProvider) outputGroupsFrom(ctx context.Context, job *db.Job) ([]media
 			return nil, fmt.Errorf("no container was found on outout settings %+v", mcOutput)
 		}
 
-		outputGroups[cSettings.Container] = append(outputGroups[cSettings.Container], outputCfg{
-			output:   mcOutput,
-			filename: output.FileName,
-		})
+		container, err := groupContainerFrom(cSettings.Container, job.StreamingParams)
+		if err != nil {
+			return nil, err
+		}
+		if container != cSettings.Container {
+			mcOutput.ContainerSettings = containerSettingsFrom(container)
+		}
+
+		cfg := outputCfg{output: mcOutput, filename: output.FileName}
+		// outputs placing explicit keyframes need container settings of their own
+		if p.nativePresets() && len(job.ExplicitKeyframeOffsets) == 0 && usesNativePreset(localPreset.Preset, mcOutput) &&
+			p.nativePresetExists(ctx, presetName) {
+			cfg.preset = presetName
+		}
+		outputGroups[container] = append(outputGroups[container], cfg)
+	}
+
+	images, err := imageOutputsFrom(job)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) > 0 {
+		outputGroups[mediaconvert.ContainerTypeRaw] = append(outputGroups[mediaconvert.ContainerTypeRaw], images...)
 	}
 
 	mcOutputGroups := []mediaconvert.OutputGroup{}
 	for container, outputs := range outputGroups {
 		mcOutputGroup := mediaconvert.OutputGroup{}
 
+		// captions are embedded in the video of progressive outputs, streaming
+		// groups get a WebVTT rendition for each of them instead
+		streaming := container == mediaconvert.ContainerTypeCmfc || container == mediaconvert.ContainerTypeM3u8 ||
+			container == mediaconvert.ContainerTypeMpd
+
+		playlists, err := iFramePlaylistsFrom(job, container)
+		if err != nil {
+			return nil, err
+		}
+
 		mcOutputs := make([]mediaconvert.Output, len(outputs))
 		for i, o := range outputs {
 			rawExtension := path.Ext(o.filename)
@@ -198,27 +254,76 @@ func (p *mcProvider) outputGroupsFrom(ctx context.Context, job *db.Job) ([]media
 				AudioDescriptions: o.output.AudioDescriptions,
 				VideoDescription:  o.output.VideoDescription,
 			}
+
+			if o.output.VideoDescription != nil {
+				mcOutputs[i].OutputSettings = playlists
+			}
+
+			if !streaming && container != mediaconvert.ContainerTypeRaw {
+				captions, err := embeddedCaptionsFrom(job, o)
+				if err != nil {
+					return nil, err
+				}
+				mcOutputs[i].CaptionDescriptions = captions
+			}
+
+			// outputs referencing a native preset can't carry settings of their
+			// own, the ones embedding captions keep all of them
+			if o.preset != "" && len(mcOutputs[i].CaptionDescriptions) == 0 {
+				mcOutputs[i].Preset = aws.String(o.preset)
+				mcOutputs[i].ContainerSettings, mcOutputs[i].AudioDescriptions, mcOutputs[i].VideoDescription = nil, nil, nil
+			}
+		}
+		if streaming {
+			captions := captionOutputsFrom(job, container, outputs)
+			if len(captions) > 0 && container == mediaconvert.ContainerTypeMpd {
+				return nil, errors.New("captions are only supported with hls manifests")
+			}
+			mcOutputs = append(mcOutputs, captions...)
 		}
 		mcOutputGroup.Outputs = mcOutputs
 
 		destination := p.destinationPathFrom(job)
+		if streaming {
+			destination = p.streamingDestinationFrom(job)
+		}
 
 		switch container {
 		case mediaconvert.ContainerTypeCmfc:
+			encryption, err := cmafEncryptionFrom(job.StreamingParams.Encryption)
+			if err != nil {
+				return nil, err
+			}
+			hls, dash := cmafManifestsFrom(job.StreamingParams)
 			mcOutputGroup.OutputGroupSettings = &mediaconvert.OutputGroupSettings{
 				Type: mediaconvert.OutputGroupTypeCmafGroupSettings,
 				CmafGroupSettings: &mediaconvert.CmafGroupSettings{
-					Destination:            aws.String(destination),
-					DestinationSettings:    &defaultDestinationSettings,
-					FragmentLength:         aws.Int64(int64(job.StreamingParams.SegmentDuration)),
-					ManifestDurationFormat: mediaconvert.CmafManifestDurationFormatFloatingPoint,
-					SegmentControl:         mediaconvert.CmafSegmentControlSegmentedFiles,
-					SegmentLength:          aws.Int64(int64(job.StreamingParams.SegmentDuration)),
-					WriteDashManifest:      mediaconvert.CmafWriteDASHManifestEnabled,
-					WriteHlsManifest:       mediaconvert.CmafWriteHLSManifestEnabled,
+					Destination:                          aws.String(destination),
+					DestinationSettings:                  &defaultDestinationSettings,
+					FragmentLength:                       aws.Int64(int64(job.StreamingParams.SegmentDuration)),
+					ManifestDurationFormat:               mediaconvert.CmafManifestDurationFormatFloatingPoint,
+					SegmentControl:                       mediaconvert.CmafSegmentControlSegmentedFiles,
+					SegmentLength:                        aws.Int64(int64(job.StreamingParams.SegmentDuration)),
+					Encryption:                           encryption,
+					WriteDashManifest:                    dash,
+					WriteHlsManifest:                     hls,
+					WriteSegmentTimelineInRepresentation: cmafSegmentTimelineFrom(job.StreamingParams),
 				},
 			}
+		case mediaconvert.ContainerTypeMpd:
+			settings, err := dashIsoGroupSettingsFrom(job, destination)
+			if err != nil {
+				return nil, err
+			}
+			mcOutputGroup.OutputGroupSettings = &mediaconvert.OutputGroupSettings{
+				Type:                 mediaconvert.OutputGroupTypeDashIsoGroupSettings,
+				DashIsoGroupSettings: settings,
+			}
 		case mediaconvert.ContainerTypeM3u8:
+			encryption, err := hlsEncryptionFrom(job.StreamingParams.Encryption)
+			if err != nil {
+				return nil, err
+			}
 			mcOutputGroup.OutputGroupSettings = &mediaconvert.OutputGroupSettings{
 				Type: mediaconvert.OutputGroupTypeHlsGroupSettings,
 				HlsGroupSettings: &mediaconvert.HlsGroupSettings{
@@ -230,9 +335,14 @@ func (p *mcProvider) outputGroupsFrom(ctx context.Context, job *db.Job) ([]media
 					ManifestDurationFormat: mediaconvert.HlsManifestDurationFormatFloatingPoint,
 					OutputSelection:        mediaconvert.HlsOutputSelectionManifestsAndSegments,
 					SegmentControl:         mediaconvert.HlsSegmentControlSegmentedFiles,
+					Encryption:             encryption,
 				},
 			}
-		case mediaconvert.ContainerTypeMp4, mediaconvert.ContainerTypeMov, mediaconvert.ContainerTypeWebm, mediaconvert.ContainerTypeMxf:
+		case mediaconvert.ContainerTypeMp4, mediaconvert.ContainerTypeMov, mediaconvert.ContainerTypeWebm, mediaconvert.ContainerTypeMxf,
+			mediaconvert.ContainerTypeRaw:
+			if job.StreamingParams.Encryption != nil && container != mediaconvert.ContainerTypeRaw {
+				return nil, fmt.Errorf("encryption: %s outputs can't be encrypted", strings.ToLower(string(container)))
+			}
 			mcOutputGroup.OutputGroupSettings = &mediaconvert.OutputGroupSettings{
 				Type: mediaconvert.OutputGroupTypeFileGroupSettings,
 				FileGroupSettings: &mediaconvert.FileGroupSettings{
@@ -260,15 +370,23 @@ func (p *mcProvider) destinationPathFrom(job *db.Job) string {
 	return fmt.Sprintf("%s/%s/", strings.TrimRight(basePath, "/"), job.RootFolder())
 }
 
-func (p *mcProvider) CreatePreset(_ context.Context, preset db.Preset) (string, error) {
-	err := p.repository.CreateLocalPreset(&db.LocalPreset{
+func (p *mcProvider) CreatePreset(ctx context.Context, preset db.Preset) (string, error) {
+	local := &db.LocalPreset{
 		Name:   preset.Name,
 		Preset: preset,
-	})
+	}
+	err := p.repository.CreateLocalPreset(local)
 	if err != nil {
 		return "", err
 	}
 
+	if p.nativePresets() {
+		if err := p.createNativePreset(ctx, preset); err != nil {
+			p.repository.DeleteLocalPreset(local)
+			return "", err
+		}
+	}
+
 	return preset.Name, nil
 }
 
@@ -282,6 +400,12 @@ func (p *mcProvider) DeletePreset(ctx context.Context, presetID string) error {
 		return err
 	}
 
+	if p.nativePresets() {
+		if err := p.deleteNativePreset(ctx, presetID); err != nil {
+			return err
+		}
+	}
+
 	return p.repository.DeleteLocalPreset(preset.(*db.LocalPreset))
 }
 
@@ -315,12 +439,23 @@ func (p *mcProvider) jobStatusFrom(providerJobID string, job *db.Job, mcJob *med
 
 	var files []provider.OutputFile
 	if settings := mcJob.Settings; settings != nil {
-		for _, group := range settings.OutputGroups {
+		for _, group := range p.withPresetSettings(settings.OutputGroups) {
+			if manifests := manifestFilesFrom(job, group); len(manifests) > 0 {
+				files = append(files, manifests...)
+				files = append(files, renditionFilesFrom(job, group)...)
+				continue
+			}
+
 			groupDestination, err := outputGroupDestinationFrom(group)
 			if err != nil {
 				continue
 			}
 			for _, output := range group.Outputs {
+				if s := output.ContainerSettings; s != nil && s.Container == mediaconvert.ContainerTypeRaw {
+					files = append(files, imageFilesFrom(job, groupDestination, output)...)
+					continue
+				}
+
 				file := provider.OutputFile{}
 
 				if modifier := output.NameModifier; modifier != nil {
@@ -347,6 +482,10 @@ func (p *mcProvider) jobStatusFrom(providerJobID string, job *db.Job, mcJob *med
 					file.Container = container
 				}
 
+				file.Bitrate = bitrateFrom(output)
+
+				file.Captions = captionLanguagesFrom(job, output.CaptionDescriptions)
+
 				files = append(files, file)
 			}
 		}
@@ -392,6 +531,8 @@ func fileExtensionFromContainer(settings *mediaconvert.ContainerSettings) (strin
 		return ".mov", nil
 	case mediaconvert.ContainerTypeWebm:
 		return ".webm", nil
+	case mediaconvert.ContainerTypeMxf:
+		return ".mxf", nil
 	default:
 		return "", fmt.Errorf("could not determine extension from output container %q", settings.Container)
 	}
@@ -409,6 +550,8 @@ func containerIdentifierFrom(settings *mediaconvert.ContainerSettings) (string,
 		return "mov", nil
 	case mediaconvert.ContainerTypeWebm:
 		return "webm", nil
+	case mediaconvert.ContainerTypeMxf:
+		return "mxf", nil
 	default:
 		return "", fmt.Errorf("could not determine container identifier from output container %q", settings.Container)
 	}
@@ -431,7 +574,13 @@ func (p *mcProvider) CancelJob(ctx context.Context, id string) error {
 }
 
 func (p *mcProvider) Healthcheck() error {
-	_, err := p.client.ListJobsRequest(nil).Send(context.Background()) // TODO(as): plump context
+	return p.HealthcheckContext(context.Background())
+}
+
+// HealthcheckContext checks the health of the provider, giving up when the
+// context is done.
+func (p *mcProvider) HealthcheckContext(ctx context.Context) error {
+	_, err := p.client.ListJobsRequest(&mediaconvert.ListJobsInput{MaxResults: aws.Int64(1)}).Send(ctx)
 	if err != nil {
 		return errors.Wrap(err, "listing jobs")
 	}
@@ -441,7 +590,7 @@ func (p *mcProvider) Healthcheck() error {
 func (p *mcProvider) Capabilities() provider.Capabilities {
 	return provider.Capabilities{
 		InputFormats:  []string{"h264", "h265", "hdr10"},
-		OutputFormats: []string{"mp4", "hls", "hdr10", "cmaf", "mov"},
+		OutputFormats: []string{"mp4", "hls", "dash", "hdr10", "cmaf", "mov"},
 		Destinations:  []string{"s3"},
 	}
 }
@@ -487,6 +636,11 @@ func mediaconvertFactory(cfg *config.Config) (provider.TranscodingProvider, erro
 		URL: cfg.MediaConvert.Endpoint,
 	}
 
+	queueRules, err := queueRulesFrom(cfg.MediaConvert.QueueRules)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading MediaConvert config")
+	}
+
 	dbRepo, err := redis.NewRepository(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing mediaconvert wrapper: %s", err)
@@ -496,5 +650,6 @@ func mediaconvertFactory(cfg *config.Config) (provider.TranscodingProvider, erro
 		client:     mediaconvert.New(mcCfg),
 		cfg:        cfg.MediaConvert,
 		repository: dbRepo,
+		queueRules: queueRules,
 	}, nil
 }
@@ -1289,6 +1289,117 @@ func Test_mcProvider_Transcode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "a low priority job keeps to the default queue and sets the mediaconvert priority",
+			cfg: &config.MediaConvert{
+				DefaultQueueARN:   "some:default:queue:arn",
+				PreferredQueueARN: "some:preferred:queue:arn",
+			},
+			job: &db.Job{
+				ID:           "jobID",
+				ProviderName: Name,
+				SourceMedia:  "s3://some/path.mp4",
+				Priority:     -20,
+				Outputs:      []db.TranscodeOutput{{Preset: db.PresetMap{Name: defaultPreset.Name}, FileName: "file1.mp4"}},
+			},
+			preset:      defaultPreset,
+			destination: "s3://some/destination",
+			wantJobReq: mediaconvert.CreateJobInput{
+				Role:              aws.String(""),
+				Queue:             aws.String("some:default:queue:arn"),
+				Priority:          aws.Int64(-20),
+				BillingTagsSource: "JOB",
+				Tags:              map[string]string{},
+				Settings: &mediaconvert.JobSettings{
+					Inputs: []mediaconvert.Input{
+						{
+							AudioSelectors: map[string]mediaconvert.AudioSelector{
+								"Audio Selector 1": {
+									DefaultSelection: mediaconvert.AudioDefaultSelectionDefault,
+								},
+							},
+							FileInput: aws.String("s3://some/path.mp4"),
+							VideoSelector: &mediaconvert.VideoSelector{
+								ColorSpace: mediaconvert.ColorSpaceFollow,
+							},
+							TimecodeSource: mediaconvert.InputTimecodeSourceZerobased,
+						},
+					},
+					OutputGroups: []mediaconvert.OutputGroup{
+						{
+							OutputGroupSettings: &mediaconvert.OutputGroupSettings{
+								Type: mediaconvert.OutputGroupTypeFileGroupSettings,
+								FileGroupSettings: &mediaconvert.FileGroupSettings{
+									Destination:         aws.String("s3://some/destination/jobID/m"),
+									DestinationSettings: &defaultDestinationSettings,
+								},
+							},
+							Outputs: []mediaconvert.Output{
+								{
+									NameModifier: aws.String("file1"),
+									ContainerSettings: &mediaconvert.ContainerSettings{
+										Container: mediaconvert.ContainerTypeMp4,
+										Mp4Settings: &mediaconvert.Mp4Settings{
+											Mp4MajorBrand: aws.String("isom"),
+											MoovPlacement: mediaconvert.Mp4MoovPlacementProgressiveDownload,
+										},
+									},
+									VideoDescription: &mediaconvert.VideoDescription{
+										Height:            aws.Int64(400),
+										Width:             aws.Int64(300),
+										RespondToAfd:      mediaconvert.RespondToAfdNone,
+										ScalingBehavior:   mediaconvert.ScalingBehaviorDefault,
+										TimecodeInsertion: mediaconvert.VideoTimecodeInsertionDisabled,
+										AntiAlias:         mediaconvert.AntiAliasEnabled,
+										VideoPreprocessors: &mediaconvert.VideoPreprocessor{
+											Deinterlacer: &mediaconvert.Deinterlacer{
+												Algorithm: mediaconvert.DeinterlaceAlgorithmInterpolate,
+												Control:   mediaconvert.DeinterlacerControlNormal,
+												Mode:      mediaconvert.DeinterlacerModeAdaptive,
+											},
+										},
+										CodecSettings: &mediaconvert.VideoCodecSettings{
+											Codec: mediaconvert.VideoCodecH264,
+											H264Settings: &mediaconvert.H264Settings{
+												Bitrate:            aws.Int64(400000),
+												CodecLevel:         mediaconvert.H264CodecLevelAuto,
+												CodecProfile:       mediaconvert.H264CodecProfileHigh,
+												InterlaceMode:      mediaconvert.H264InterlaceModeProgressive,
+												QualityTuningLevel: mediaconvert.H264QualityTuningLevelMultiPassHq,
+												RateControlMode:    mediaconvert.H264RateControlModeVbr,
+												GopSize:            aws.Float64(120),
+												GopSizeUnits:       mediaconvert.H264GopSizeUnitsFrames,
+												ParControl:         mediaconvert.H264ParControlSpecified,
+												ParNumerator:       aws.Int64(1),
+												ParDenominator:     aws.Int64(1),
+											},
+										},
+									},
+									AudioDescriptions: []mediaconvert.AudioDescription{
+										{
+											CodecSettings: &mediaconvert.AudioCodecSettings{
+												Codec: mediaconvert.AudioCodecAac,
+												AacSettings: &mediaconvert.AacSettings{
+													Bitrate:         aws.Int64(20000),
+													CodecProfile:    mediaconvert.AacCodecProfileLc,
+													CodingMode:      mediaconvert.AacCodingModeCodingMode20,
+													RateControlMode: mediaconvert.AacRateControlModeCbr,
+													SampleRate:      aws.Int64(defaultAudioSampleRate),
+												},
+											},
+										},
+									},
+									Extension: aws.String("mp4"),
+								},
+							},
+						},
+					},
+					TimecodeConfig: &mediaconvert.TimecodeConfig{
+						Source: mediaconvert.TimecodeSourceZerobased,
+					},
+				},
+			},
+		},
 		{
 			name: "JobWithAudioDownmixAndTimeCodeBurninForMovOutput",
 			cfg: &config.MediaConvert{
@@ -1422,85 +1533,89 @@ func Test_mcProvider_Transcode(t *testing.T) {
 				},
 			},
 		},
-		//{
-		//	name: "acceleration is enabled and the default queue is used when a source has a large filesize",
-		//	cfg: &config.MediaConvert{
-		//		DefaultQueueARN:   "some:default:queue:arn",
-		//		PreferredQueueARN: "some:preferred:queue:arn",
-		//	},
-		//	job: &db.Job{
-		//		ID:           "jobID",
-		//		ProviderName: Name,
-		//		SourceMedia:  "s3://some/path.mp4",
-		//		SourceInfo:   db.File{FileSize: 1_000_000_000},
-		//		Outputs:      []db.TranscodeOutput{{Preset: db.PresetMap{Name: audioOnlyPreset.Name}, FileName: "file1.mp4"}},
-		//	},
-		//	preset:      audioOnlyPreset,
-		//	destination: "s3://some/destination",
-		//	wantJobReq: mediaconvert.CreateJobInput{
-		//		AccelerationSettings: &mediaconvert.AccelerationSettings{
-		//			Mode: mediaconvert.AccelerationModePreferred,
-		//		},
-		//		Role:  aws.String(""),
-		//		Queue: aws.String("some:default:queue:arn"),
-		//		Tags: map[string]string{},
-		//		Settings: &mediaconvert.JobSettings{
-		//			Inputs: []mediaconvert.Input{
-		//				{
-		//					AudioSelectors: map[string]mediaconvert.AudioSelector{
-		//						"Audio Selector 1": {
-		//							DefaultSelection: mediaconvert.AudioDefaultSelectionDefault,
-		//						},
-		//					},
-		//					FileInput: aws.String("s3://some/path.mp4"),
-		//					VideoSelector: &mediaconvert.VideoSelector{
-		//						ColorSpace: mediaconvert.ColorSpaceFollow,
-		//					},
-		//					TimecodeSource: mediaconvert.InputTimecodeSourceZerobased,
-		//				},
-		//			},
-		//			OutputGroups: []mediaconvert.OutputGroup{
-		//				{
-		//					OutputGroupSettings: &mediaconvert.OutputGroupSettings{
-		//						Type: mediaconvert.OutputGroupTypeFileGroupSettings,
-		//						FileGroupSettings: &mediaconvert.FileGroupSettings{
-		//							Destination: aws.String("s3://some/destination/jobID/m"),
-		//						},
-		//					},
-		//					Outputs: []mediaconvert.Output{
-		//						{
-		//							NameModifier: aws.String("file1"),
-		//							ContainerSettings: &mediaconvert.ContainerSettings{
-		//								Container: mediaconvert.ContainerTypeMp4,
-		//								Mp4Settings: &mediaconvert.Mp4Settings{
-		//									Mp4MajorBrand: aws.String("isom"),
-		//								},
-		//							},
-		//							AudioDescriptions: []mediaconvert.AudioDescription{
-		//								{
-		//									CodecSettings: &mediaconvert.AudioCodecSettings{
-		//										Codec: mediaconvert.AudioCodecAac,
-		//										AacSettings: &mediaconvert.AacSettings{
-		//											Bitrate:         aws.Int64(20000),
-		//											CodecProfile:    mediaconvert.AacCodecProfileLc,
-		//											CodingMode:      mediaconvert.AacCodingModeCodingMode20,
-		//											RateControlMode: mediaconvert.AacRateControlModeCbr,
-		//											SampleRate:      aws.Int64(defaultAudioSampleRate),
-		//										},
-		//									},
-		//								},
-		//							},
-		//							Extension: aws.String("mp4"),
-		//						},
-		//					},
-		//				},
-		//			},
-		//			TimecodeConfig: &mediaconvert.TimecodeConfig{
-		//				Source: mediaconvert.TimecodeSourceZerobased,
-		//			},
-		//		},
-		//	},
-		//},
+		{
+			name: "acceleration is enabled and the default queue is used when a source has a large filesize",
+			cfg: &config.MediaConvert{
+				DefaultQueueARN:     "some:default:queue:arn",
+				PreferredQueueARN:   "some:preferred:queue:arn",
+				AccelerationMinSize: 1_000_000_000,
+			},
+			job: &db.Job{
+				ID:           "jobID",
+				ProviderName: Name,
+				SourceMedia:  "s3://some/path.mp4",
+				SourceInfo:   db.File{FileSize: 1_000_000_000},
+				Outputs:      []db.TranscodeOutput{{Preset: db.PresetMap{Name: audioOnlyPreset.Name}, FileName: "file1.mp4"}},
+			},
+			preset:      audioOnlyPreset,
+			destination: "s3://some/destination",
+			wantJobReq: mediaconvert.CreateJobInput{
+				AccelerationSettings: &mediaconvert.AccelerationSettings{
+					Mode: mediaconvert.AccelerationModePreferred,
+				},
+				Role:              aws.String(""),
+				Queue:             aws.String("some:default:queue:arn"),
+				BillingTagsSource: "JOB",
+				Tags:              map[string]string{},
+				Settings: &mediaconvert.JobSettings{
+					Inputs: []mediaconvert.Input{
+						{
+							AudioSelectors: map[string]mediaconvert.AudioSelector{
+								"Audio Selector 1": {
+									DefaultSelection: mediaconvert.AudioDefaultSelectionDefault,
+								},
+							},
+							FileInput: aws.String("s3://some/path.mp4"),
+							VideoSelector: &mediaconvert.VideoSelector{
+								ColorSpace: mediaconvert.ColorSpaceFollow,
+							},
+							TimecodeSource: mediaconvert.InputTimecodeSourceZerobased,
+						},
+					},
+					OutputGroups: []mediaconvert.OutputGroup{
+						{
+							OutputGroupSettings: &mediaconvert.OutputGroupSettings{
+								Type: mediaconvert.OutputGroupTypeFileGroupSettings,
+								FileGroupSettings: &mediaconvert.FileGroupSettings{
+									Destination:         aws.String("s3://some/destination/jobID/m"),
+									DestinationSettings: &defaultDestinationSettings,
+								},
+							},
+							Outputs: []mediaconvert.Output{
+								{
+									NameModifier: aws.String("file1"),
+									ContainerSettings: &mediaconvert.ContainerSettings{
+										Container: mediaconvert.ContainerTypeMp4,
+										Mp4Settings: &mediaconvert.Mp4Settings{
+											MoovPlacement: mediaconvert.Mp4MoovPlacementProgressiveDownload,
+											Mp4MajorBrand: aws.String("isom"),
+										},
+									},
+									AudioDescriptions: []mediaconvert.AudioDescription{
+										{
+											CodecSettings: &mediaconvert.AudioCodecSettings{
+												Codec: mediaconvert.AudioCodecAac,
+												AacSettings: &mediaconvert.AacSettings{
+													Bitrate:         aws.Int64(20000),
+													CodecProfile:    mediaconvert.AacCodecProfileLc,
+													CodingMode:      mediaconvert.AacCodingModeCodingMode20,
+													RateControlMode: mediaconvert.AacRateControlModeCbr,
+													SampleRate:      aws.Int64(defaultAudioSampleRate),
+												},
+											},
+										},
+									},
+									Extension: aws.String("mp4"),
+								},
+							},
+						},
+					},
+					TimecodeConfig: &mediaconvert.TimecodeConfig{
+						Source: mediaconvert.TimecodeSourceZerobased,
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1680,6 +1795,93 @@ func Test_mcProvider_JobStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "a finished job lists its streaming renditions and mxf files",
+			destination: "s3://some/destination",
+			mcJob: mediaconvert.Job{
+				Status: mediaconvert.JobStatusComplete,
+				Settings: &mediaconvert.JobSettings{
+					OutputGroups: []mediaconvert.OutputGroup{
+						{
+							OutputGroupSettings: &mediaconvert.OutputGroupSettings{
+								Type: mediaconvert.OutputGroupTypeHlsGroupSettings,
+								HlsGroupSettings: &mediaconvert.HlsGroupSettings{
+									Destination: aws.String("s3://some/destination/jobID/master"),
+								},
+							},
+							Outputs: []mediaconvert.Output{
+								{
+									NameModifier: aws.String("_1080p"),
+									VideoDescription: &mediaconvert.VideoDescription{
+										Height: aws.Int64(1080),
+										Width:  aws.Int64(1920),
+										CodecSettings: &mediaconvert.VideoCodecSettings{
+											H264Settings: &mediaconvert.H264Settings{Bitrate: aws.Int64(6500000)},
+										},
+									},
+									ContainerSettings: &mediaconvert.ContainerSettings{
+										Container: mediaconvert.ContainerTypeM3u8,
+									},
+								},
+							},
+						},
+						{
+							OutputGroupSettings: &mediaconvert.OutputGroupSettings{
+								Type: mediaconvert.OutputGroupTypeFileGroupSettings,
+								FileGroupSettings: &mediaconvert.FileGroupSettings{
+									Destination: aws.String("s3://some/destination/jobID/m"),
+								},
+							},
+							Outputs: []mediaconvert.Output{
+								{
+									NameModifier: aws.String("_mezzanine"),
+									VideoDescription: &mediaconvert.VideoDescription{
+										Height: aws.Int64(1080),
+										Width:  aws.Int64(1920),
+										CodecSettings: &mediaconvert.VideoCodecSettings{
+											Mpeg2Settings: &mediaconvert.Mpeg2Settings{Bitrate: aws.Int64(50000000)},
+										},
+									},
+									ContainerSettings: &mediaconvert.ContainerSettings{
+										Container: mediaconvert.ContainerTypeMxf,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantStatus: provider.JobStatus{
+				Status:       provider.StatusFinished,
+				ProviderName: Name,
+				Progress:     100,
+				Output: provider.JobOutput{
+					Destination: "s3://some/destination/jobID/",
+					Files: []provider.OutputFile{
+						{
+							Path:      "s3://some/destination/jobID/master.m3u8",
+							Container: "m3u8",
+							Type:      provider.OutputFileTypeManifest,
+						},
+						{
+							Path:      "s3://some/destination/jobID/master_1080p.m3u8",
+							Container: "ts",
+							Height:    1080,
+							Width:     1920,
+							Bitrate:   6500000,
+							Segments:  "s3://some/destination/jobID/master_1080p_%05d.ts",
+						},
+						{
+							Path:      "s3://some/destination/jobID/m_mezzanine.mxf",
+							Container: "mxf",
+							Height:    1080,
+							Width:     1920,
+							Bitrate:   50000000,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -86,6 +86,8 @@ func containerFrom(container string) (mediaconvert.ContainerType, error) {
 		return mediaconvert.ContainerTypeM3u8, nil
 	case "cmaf":
 		return mediaconvert.ContainerTypeCmfc, nil
+	case "mpd":
+		return mediaconvert.ContainerTypeMpd, nil
 	case "mp4":
 		return mediaconvert.ContainerTypeMp4, nil
 	case "mov":
@@ -0,0 +1,286 @@
+package mediaconvert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// nativePresetDescription describes the native presets mirroring the presets of
+// the API, which are overwritten when synchronized
+const nativePresetDescription = "Mirrors the transcode-orchestrator preset of the same name, " +
+	"changes drift from it until synchronized"
+
+// nativePresets reports whether presets are mirrored into native MediaConvert
+// presets
+func (p *mcProvider) nativePresets() bool {
+	return p.cfg != nil && p.cfg.NativePresets
+}
+
+// nativePresetSettingsFrom returns the settings of the native preset mirroring
+// a preset, which don't depend on the source of any job
+func nativePresetSettingsFrom(preset db.Preset) (*mediaconvert.PresetSettings, error) {
+	output, err := outputFrom(preset, db.File{})
+	if err != nil {
+		return nil, fmt.Errorf("could not determine output settings from db.Preset %v: %w", preset, err)
+	}
+	return presetSettingsFrom(output), nil
+}
+
+// presetSettingsFrom returns the settings of an output that can be held by a
+// native preset
+func presetSettingsFrom(output mediaconvert.Output) *mediaconvert.PresetSettings {
+	return &mediaconvert.PresetSettings{
+		AudioDescriptions: output.AudioDescriptions,
+		ContainerSettings: output.ContainerSettings,
+		VideoDescription:  output.VideoDescription,
+	}
+}
+
+// usesNativePreset reports whether the output of a job can reference the native
+// preset mirroring preset, instead of carrying its own settings. Outputs whose
+// settings depend on the source or on the output group they're written to
+// carry their own.
+func usesNativePreset(preset db.Preset, output mediaconvert.Output) bool {
+	settings, err := nativePresetSettingsFrom(preset)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(settings, presetSettingsFrom(output))
+}
+
+// nativePresetExists reports whether the native preset mirroring a preset
+// exists. Presets created before native presets were enabled have none until
+// synchronized, the outputs using them carrying their own settings meanwhile.
+// Found presets are remembered, failures to look them up count as missing.
+func (p *mcProvider) nativePresetExists(ctx context.Context, name string) bool {
+	if _, found := p.foundNativePresets.Load(name); found {
+		return true
+	}
+	_, err := p.client.GetPresetRequest(&mediaconvert.GetPresetInput{Name: aws.String(name)}).Send(ctx)
+	if err != nil {
+		return false
+	}
+	p.foundNativePresets.Store(name, struct{}{})
+	return true
+}
+
+// withPresetSettings returns output groups whose outputs referencing a native
+// preset are filled in with the settings of the stored preset, so their files
+// can be listed. Outputs whose preset is gone are left as they are.
+func (p *mcProvider) withPresetSettings(groups []mediaconvert.OutputGroup) []mediaconvert.OutputGroup {
+	settings := map[string]*mediaconvert.PresetSettings{}
+	expanded := make([]mediaconvert.OutputGroup, len(groups))
+	for i, group := range groups {
+		expanded[i] = group
+		expanded[i].Outputs = make([]mediaconvert.Output, len(group.Outputs))
+		for j, output := range group.Outputs {
+			expanded[i].Outputs[j] = output
+			if output.Preset == nil || output.ContainerSettings != nil {
+				continue
+			}
+
+			name := aws.StringValue(output.Preset)
+			if _, ok := settings[name]; !ok {
+				settings[name] = nil
+				if local, err := p.repository.GetLocalPreset(name); err == nil {
+					settings[name], _ = nativePresetSettingsFrom(local.Preset)
+				}
+			}
+			if s := settings[name]; s != nil {
+				expanded[i].Outputs[j].ContainerSettings = s.ContainerSettings
+				expanded[i].Outputs[j].AudioDescriptions = s.AudioDescriptions
+				expanded[i].Outputs[j].VideoDescription = s.VideoDescription
+			}
+		}
+	}
+	return expanded
+}
+
+// createNativePreset creates the native preset mirroring preset
+func (p *mcProvider) createNativePreset(ctx context.Context, preset db.Preset) error {
+	settings, err := nativePresetSettingsFrom(preset)
+	if err != nil {
+		return err
+	}
+	_, err = p.client.CreatePresetRequest(&mediaconvert.CreatePresetInput{
+		Name:        aws.String(preset.Name),
+		Category:    p.presetCategory(),
+		Description: aws.String(nativePresetDescription),
+		Settings:    settings,
+	}).Send(ctx)
+	if err != nil {
+		return fmt.Errorf("creating native preset %q: %w", preset.Name, err)
+	}
+	p.foundNativePresets.Store(preset.Name, struct{}{})
+	return nil
+}
+
+// deleteNativePreset deletes the native preset mirroring a preset, if any
+func (p *mcProvider) deleteNativePreset(ctx context.Context, name string) error {
+	_, err := p.client.DeletePresetRequest(&mediaconvert.DeletePresetInput{Name: aws.String(name)}).Send(ctx)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("deleting native preset %q: %w", name, err)
+	}
+	p.foundNativePresets.Delete(name)
+	return nil
+}
+
+func (p *mcProvider) presetCategory() *string {
+	if p.cfg.PresetCategory == "" {
+		return nil
+	}
+	return aws.String(p.cfg.PresetCategory)
+}
+
+// SyncPreset compares the native preset mirroring a preset with the stored
+// preset, creating it when missing and overwriting it when it drifted, unless
+// dryRun is set.
+func (p *mcProvider) SyncPreset(ctx context.Context, presetID string, dryRun bool) (provider.PresetSync, error) {
+	sync := provider.PresetSync{PresetID: presetID}
+	if !p.nativePresets() {
+		return sync, errors.New("native presets are disabled")
+	}
+
+	local, err := p.repository.GetLocalPreset(presetID)
+	if err != nil {
+		return sync, err
+	}
+	want, err := nativePresetSettingsFrom(local.Preset)
+	if err != nil {
+		return sync, err
+	}
+
+	resp, err := p.client.GetPresetRequest(&mediaconvert.GetPresetInput{Name: aws.String(presetID)}).Send(ctx)
+	switch {
+	case isNotFound(err):
+		p.foundNativePresets.Delete(presetID)
+		sync.Status = provider.PresetSyncMissing
+		if dryRun {
+			return sync, nil
+		}
+		if err := p.createNativePreset(ctx, local.Preset); err != nil {
+			return sync, err
+		}
+		sync.Status = provider.PresetSyncCreated
+		return sync, nil
+	case err != nil:
+		return sync, fmt.Errorf("fetching native preset %q: %w", presetID, err)
+	}
+
+	var got *mediaconvert.PresetSettings
+	if resp.Preset != nil {
+		got = resp.Preset.Settings
+	}
+	sync.Drift, err = presetDrift(want, got)
+	if err != nil {
+		return sync, err
+	}
+	if len(sync.Drift) == 0 {
+		sync.Status = provider.PresetSyncInSync
+		return sync, nil
+	}
+
+	sync.Status = provider.PresetSyncDrifted
+	if dryRun {
+		return sync, nil
+	}
+	_, err = p.client.UpdatePresetRequest(&mediaconvert.UpdatePresetInput{
+		Name:        aws.String(presetID),
+		Category:    p.presetCategory(),
+		Description: aws.String(nativePresetDescription),
+		Settings:    want,
+	}).Send(ctx)
+	if err != nil {
+		return sync, fmt.Errorf("updating native preset %q: %w", presetID, err)
+	}
+	sync.Status = provider.PresetSyncUpdated
+	return sync, nil
+}
+
+// presetDrift lists the settings of a native preset differing from the settings
+// wanted, as dotted paths. Settings only set on the native preset aren't listed,
+// MediaConvert filling in defaults for the settings left unset.
+func presetDrift(want, got *mediaconvert.PresetSettings) ([]string, error) {
+	w, err := settingsTreeFrom(want)
+	if err != nil {
+		return nil, err
+	}
+	g, err := settingsTreeFrom(got)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []string
+	walkDrift("", w, g, &drift)
+	sort.Strings(drift)
+	return drift, nil
+}
+
+// settingsTreeFrom returns preset settings as the tree of their JSON encoding
+func settingsTreeFrom(settings *mediaconvert.PresetSettings) (interface{}, error) {
+	if settings == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("encoding preset settings: %w", err)
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("decoding preset settings: %w", err)
+	}
+	return tree, nil
+}
+
+func walkDrift(path string, want, got interface{}, drift *[]string) {
+	switch w := want.(type) {
+	case nil:
+		return
+	case string:
+		// enumerations left unset are encoded as empty strings
+		if w != "" && w != got {
+			*drift = append(*drift, path)
+		}
+	case map[string]interface{}:
+		g, _ := got.(map[string]interface{})
+		for key, value := range w {
+			walkDrift(joinPath(path, key), value, g[key], drift)
+		}
+	case []interface{}:
+		g, _ := got.([]interface{})
+		if len(g) != len(w) {
+			*drift = append(*drift, path)
+			return
+		}
+		for i := range w {
+			walkDrift(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], drift)
+		}
+	default:
+		if !reflect.DeepEqual(want, got) {
+			*drift = append(*drift, path)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isNotFound reports whether a MediaConvert call failed on a missing resource
+func isNotFound(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == mediaconvert.ErrCodeNotFoundException
+}
@@ -0,0 +1,276 @@
+package mediaconvert
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/db/dbtest"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/google/go-cmp/cmp"
+)
+
+var nativePresetsCfg = &config.MediaConvert{
+	Destination:    "s3://some/destination",
+	NativePresets:  true,
+	PresetCategory: "orchestrator",
+}
+
+func TestNativePresetLifecycle(t *testing.T) {
+	client := &testMediaConvertClient{t: t}
+	p := &mcProvider{client: client, cfg: nativePresetsCfg, repository: dbtest.NewFakeRepository(false)}
+
+	if _, err := p.CreatePreset(context.Background(), defaultPreset); err != nil {
+		t.Fatal(err)
+	}
+	input := client.createPresetCalledWith
+	if input == nil {
+		t.Fatal("expected a native preset to be created")
+	}
+	if g, e := [2]string{aws.StringValue(input.Name), aws.StringValue(input.Category)}, [2]string{"preset_name", "orchestrator"}; g != e {
+		t.Errorf("wrong native preset: got %v, expected %v", g, e)
+	}
+	want, err := nativePresetSettingsFrom(defaultPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, input.Settings); diff != "" {
+		t.Errorf("wrong native preset settings: %s", diff)
+	}
+
+	client.presetErr = awserr.New(mediaconvert.ErrCodeNotFoundException, "preset not found", nil)
+	if err := p.DeletePreset(context.Background(), defaultPreset.Name); err != nil {
+		t.Fatalf("expected missing native presets to be ignored, got %v", err)
+	}
+	if g, e := client.deletePresetCalledWith, defaultPreset.Name; g != e {
+		t.Errorf("wrong native preset deleted: got %q, expected %q", g, e)
+	}
+	if _, err := p.GetPreset(context.Background(), defaultPreset.Name); err == nil {
+		t.Error("expected the stored preset to be deleted")
+	}
+}
+
+func TestSyncPreset(t *testing.T) {
+	inSync, err := nativePresetSettingsFrom(defaultPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drifted, err := nativePresetSettingsFrom(defaultPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drifted.VideoDescription.CodecSettings.H264Settings.Bitrate = aws.Int64(800000)
+	drifted.VideoDescription.AfdSignaling = mediaconvert.AfdSignalingAuto
+
+	notFound := awserr.New(mediaconvert.ErrCodeNotFoundException, "preset not found", nil)
+
+	tests := []struct {
+		name        string
+		native      *mediaconvert.PresetSettings
+		presetErr   error
+		dryRun      bool
+		want        provider.PresetSync
+		wantCreated bool
+		wantUpdated bool
+	}{
+		{
+			name:   "presets matching their native copy",
+			native: inSync,
+			want:   provider.PresetSync{PresetID: "preset_name", Status: provider.PresetSyncInSync},
+		},
+		{
+			name:   "drifted presets are reported by dry runs",
+			native: drifted,
+			dryRun: true,
+			want: provider.PresetSync{
+				PresetID: "preset_name",
+				Status:   provider.PresetSyncDrifted,
+				Drift:    []string{"VideoDescription.CodecSettings.H264Settings.Bitrate"},
+			},
+		},
+		{
+			name:   "drifted presets are overwritten",
+			native: drifted,
+			want: provider.PresetSync{
+				PresetID: "preset_name",
+				Status:   provider.PresetSyncUpdated,
+				Drift:    []string{"VideoDescription.CodecSettings.H264Settings.Bitrate"},
+			},
+			wantUpdated: true,
+		},
+		{
+			name:      "missing presets are reported by dry runs",
+			presetErr: notFound,
+			dryRun:    true,
+			want:      provider.PresetSync{PresetID: "preset_name", Status: provider.PresetSyncMissing},
+		},
+		{
+			name:        "missing presets are created",
+			presetErr:   notFound,
+			want:        provider.PresetSync{PresetID: "preset_name", Status: provider.PresetSyncCreated},
+			wantCreated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository, err := fakeDBWithPresets(defaultPreset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &testMediaConvertClient{t: t, presetErr: tt.presetErr}
+			if tt.native != nil {
+				client.presetReturnedByGetPreset = &mediaconvert.Preset{Name: aws.String("preset_name"), Settings: tt.native}
+			}
+			p := &mcProvider{client: client, cfg: nativePresetsCfg, repository: repository}
+
+			got, err := p.SyncPreset(context.Background(), "preset_name", tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong sync: %s", diff)
+			}
+			if g := client.createPresetCalledWith != nil; g != tt.wantCreated {
+				t.Errorf("wrong creation: got %t, expected %t", g, tt.wantCreated)
+			}
+			if g := client.updatePresetCalledWith != nil; g != tt.wantUpdated {
+				t.Errorf("wrong update: got %t, expected %t", g, tt.wantUpdated)
+			}
+			if tt.wantUpdated {
+				if diff := cmp.Diff(inSync, client.updatePresetCalledWith.Settings); diff != "" {
+					t.Errorf("wrong settings written: %s", diff)
+				}
+			}
+		})
+	}
+
+	p := &mcProvider{client: &testMediaConvertClient{t: t}, cfg: &config.MediaConvert{}, repository: dbtest.NewFakeRepository(false)}
+	if _, err := p.SyncPreset(context.Background(), "preset_name", true); err == nil || err.Error() != "native presets are disabled" {
+		t.Errorf("wrong error: got %v", err)
+	}
+
+	p.cfg = nativePresetsCfg
+	p.client = &testMediaConvertClient{t: t, presetErr: errors.New("throttled")}
+	if _, err := p.SyncPreset(context.Background(), "preset_name", true); !errors.Is(err, db.ErrLocalPresetNotFound) {
+		t.Errorf("expected presets to be looked up before their native copy, got %v", err)
+	}
+}
+
+func TestOutputsReferenceNativePresets(t *testing.T) {
+	repository, err := fakeDBWithPresets(defaultPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mcProvider{client: &testMediaConvertClient{t: t}, cfg: nativePresetsCfg, repository: repository}
+
+	job := &db.Job{
+		ID:          "jobID",
+		SourceMedia: "s3://some/path.mp4",
+		Outputs: []db.TranscodeOutput{
+			{Preset: db.PresetMap{Name: defaultPreset.Name}, FileName: "file1.mp4"},
+		},
+	}
+
+	groups, err := p.outputGroupsFrom(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := groups[0].Outputs[0]
+	if g, e := aws.StringValue(output.Preset), defaultPreset.Name; g != e {
+		t.Errorf("wrong preset: got %q, expected %q", g, e)
+	}
+	if output.ContainerSettings != nil || output.VideoDescription != nil || output.AudioDescriptions != nil {
+		t.Errorf("expected outputs referencing a preset to carry no settings, got %+v", output)
+	}
+
+	// progressive sources aren't deinterlaced, unlike the native preset
+	job.SourceInfo.ScanType = db.ScanTypeProgressive
+	groups, err = p.outputGroupsFrom(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := groups[0].Outputs[0]; output.Preset != nil || output.VideoDescription == nil {
+		t.Errorf("expected outputs depending on the source to carry their settings, got %+v", output)
+	}
+
+	// outputs placing explicit keyframes carry the settings of their container
+	job.SourceInfo.ScanType = ""
+	job.ExplicitKeyframeOffsets = []float64{10}
+	groups, err = p.outputGroupsFrom(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := groups[0].Outputs[0]; output.Preset != nil || output.ContainerSettings == nil {
+		t.Errorf("expected outputs placing keyframes to carry their settings, got %+v", output)
+	}
+
+	// presets created before native presets were enabled have no native copy
+	job.ExplicitKeyframeOffsets = nil
+	p = &mcProvider{
+		client:     &testMediaConvertClient{t: t, presetErr: awserr.New(mediaconvert.ErrCodeNotFoundException, "preset not found", nil)},
+		cfg:        nativePresetsCfg,
+		repository: repository,
+	}
+	groups, err = p.outputGroupsFrom(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := groups[0].Outputs[0]; output.Preset != nil || output.VideoDescription == nil {
+		t.Errorf("expected outputs of presets missing a native copy to carry their settings, got %+v", output)
+	}
+}
+
+func TestJobStatusWithNativePresets(t *testing.T) {
+	hlsPreset := defaultPreset
+	hlsPreset.Name = "hls_preset"
+	hlsPreset.Container = "m3u8"
+	repository, err := fakeDBWithPresets(defaultPreset, hlsPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mcProvider{client: &testMediaConvertClient{t: t}, cfg: nativePresetsCfg, repository: repository}
+
+	job := &db.Job{
+		ID:          "jobID",
+		SourceMedia: "s3://some/path.mp4",
+		Outputs: []db.TranscodeOutput{
+			{Preset: db.PresetMap{Name: defaultPreset.Name}, FileName: "file1.mp4"},
+			{Preset: db.PresetMap{Name: hlsPreset.Name}, FileName: "hls/file2.m3u8"},
+		},
+		StreamingParams: db.StreamingParams{SegmentDuration: 6, Protocol: "hls", PlaylistFileName: "hls/index.m3u8"},
+	}
+	groups, err := p.outputGroupsFrom(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, group := range groups {
+		if output := group.Outputs[0]; output.Preset == nil || output.ContainerSettings != nil {
+			t.Fatalf("expected outputs to reference their native preset, got %+v", output)
+		}
+	}
+
+	status := p.jobStatusFrom("mcJobID", job, &mediaconvert.Job{
+		Status:   mediaconvert.JobStatusComplete,
+		Settings: &mediaconvert.JobSettings{OutputGroups: groups},
+	})
+
+	files := map[string]provider.OutputFile{}
+	for _, file := range status.Output.Files {
+		files[file.Container] = file
+	}
+	if mp4 := files["mp4"]; mp4.Path != "s3://some/destination/jobID/mfile1.mp4" || mp4.Width != 300 || mp4.Height != 400 || mp4.Bitrate != 420000 {
+		t.Errorf("wrong file output: %+v", mp4)
+	}
+	if ts := files["ts"]; ts.Width != 300 || ts.Height != 400 || ts.Bitrate != 420000 || ts.Segments == "" {
+		t.Errorf("wrong streaming rendition: %+v", ts)
+	}
+	if _, ok := files["vtt"]; ok {
+		t.Errorf("expected no caption rendition, got %+v", status.Output.Files)
+	}
+}
@@ -1,14 +1,231 @@
 package mediaconvert
 
-import "github.com/cbsinteractive/transcode-orchestrator/db"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
-func (p *mcProvider) canUsePreferredQueue(info db.File) bool {
-	return !p.requiresAcceleration(info)
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+// maxHopDestinations is the number of queues MediaConvert lets a job hop to
+const maxHopDestinations = 3
+
+// queueRule routes the jobs it matches to a queue. Jobs match a rule when they
+// carry one of its labels, if any, and their priority and source fall within
+// its bounds. Bounds on the source size or duration aren't matched by sources
+// of unknown size or duration. Durations are expressed in seconds.
+type queueRule struct {
+	Labels      []string `json:"labels,omitempty"`
+	MinPriority *int     `json:"minPriority,omitempty"`
+	MaxPriority *int     `json:"maxPriority,omitempty"`
+	MinSize     int64    `json:"minSize,omitempty"`
+	MaxSize     int64    `json:"maxSize,omitempty"`
+	MinDuration uint     `json:"minDuration,omitempty"`
+	MaxDuration uint     `json:"maxDuration,omitempty"`
+
+	Queue string `json:"queue"`
+
+	// Reserved is set for queues billed for their capacity rather than on
+	// demand, which don't support accelerated transcoding
+	Reserved bool `json:"reserved,omitempty"`
+
+	// Hops are the queues the job moves to, in order, when left waiting
+	Hops []queueHop `json:"hops,omitempty"`
+}
+
+// queueHop moves a job to another queue after it waited for WaitMinutes,
+// replacing its priority when set
+type queueHop struct {
+	Queue       string `json:"queue"`
+	WaitMinutes int64  `json:"waitMinutes"`
+	Priority    *int   `json:"priority,omitempty"`
+	Reserved    bool   `json:"reserved,omitempty"`
+}
+
+// queueRulesFrom decodes and validates the queue rules of the config, if any
+func queueRulesFrom(rules string) ([]queueRule, error) {
+	if rules == "" {
+		return nil, nil
+	}
+
+	var parsed []queueRule
+	if err := json.Unmarshal([]byte(rules), &parsed); err != nil {
+		return nil, fmt.Errorf("decoding queue rules: %w", err)
+	}
+
+	for i, rule := range parsed {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("queue rule %d: %w", i, err)
+		}
+	}
+	return parsed, nil
+}
+
+func (r queueRule) validate() error {
+	if r.Queue == "" {
+		return errors.New("missing queue")
+	}
+	if len(r.Hops) > maxHopDestinations {
+		return fmt.Errorf("%d hops exceed the limit of %d", len(r.Hops), maxHopDestinations)
+	}
+	for i, hop := range r.Hops {
+		if hop.Queue == "" {
+			return fmt.Errorf("hop %d: missing queue", i)
+		}
+		if hop.WaitMinutes < 1 {
+			return fmt.Errorf("hop %d: wait must last at least a minute", i)
+		}
+		if p := hop.Priority; p != nil && (*p < db.MinJobPriority || *p > db.MaxJobPriority) {
+			return fmt.Errorf("hop %d: priority %d is out of the range %d to %d", i, *p, db.MinJobPriority, db.MaxJobPriority)
+		}
+	}
+	return nil
+}
+
+// matches reports whether a job is routed by the rule
+func (r queueRule) matches(job *db.Job) bool {
+	if len(r.Labels) > 0 && !hasAnyLabel(job.Labels, r.Labels) {
+		return false
+	}
+	if r.MinPriority != nil && job.Priority < *r.MinPriority {
+		return false
+	}
+	if r.MaxPriority != nil && job.Priority > *r.MaxPriority {
+		return false
+	}
+
+	size := job.SourceInfo.FileSize
+	if (r.MinSize > 0 || r.MaxSize > 0) && size <= 0 {
+		return false
+	}
+	if (r.MinSize > 0 && size < r.MinSize) || (r.MaxSize > 0 && size > r.MaxSize) {
+		return false
+	}
+
+	duration := job.SourceInfo.Duration
+	if (r.MinDuration > 0 || r.MaxDuration > 0) && duration <= 0 {
+		return false
+	}
+	if r.MinDuration > 0 && duration < time.Duration(r.MinDuration)*time.Second {
+		return false
+	}
+	if r.MaxDuration > 0 && duration > time.Duration(r.MaxDuration)*time.Second {
+		return false
+	}
+	return true
+}
+
+// queuePlan holds the queue a job is submitted to and the queues it hops to
+type queuePlan struct {
+	queue string
+	hops  []mediaconvert.HopDestination
+
+	// reserved is set when any of the queues is a reserved queue
+	reserved bool
+}
+
+// queuePlanFrom routes a job with the first queue rule it matches. Jobs matching
+// no rule are sent to the preferred queue when they can use it, hopping to the
+// default queue after a minute, and to the default queue otherwise.
+func (p *mcProvider) queuePlanFrom(job *db.Job) queuePlan {
+	for _, rule := range p.queueRules {
+		if !rule.matches(job) {
+			continue
+		}
+
+		plan := queuePlan{queue: rule.Queue, reserved: rule.Reserved}
+		for _, hop := range rule.Hops {
+			dest := mediaconvert.HopDestination{
+				Queue:       aws.String(hop.Queue),
+				WaitMinutes: aws.Int64(hop.WaitMinutes),
+			}
+			if hop.Priority != nil {
+				dest.Priority = aws.Int64(int64(*hop.Priority))
+			}
+			plan.hops = append(plan.hops, dest)
+			plan.reserved = plan.reserved || hop.Reserved
+		}
+		return plan
+	}
+
+	if preferred := p.cfg.PreferredQueueARN; p.canUsePreferredQueue(job) && preferred != "" {
+		return queuePlan{
+			queue: preferred,
+			hops: []mediaconvert.HopDestination{{
+				WaitMinutes: aws.Int64(defaultQueueHopTimeoutMins),
+			}},
+		}
+	}
+	return queuePlan{queue: p.cfg.DefaultQueueARN}
+}
+
+// canUsePreferredQueue reports whether a job may be submitted to the preferred
+// queue. Low priority jobs are kept on the default queue so they never compete
+// with urgent work for the preferred queue's capacity
+func (p *mcProvider) canUsePreferredQueue(job *db.Job) bool {
+	return job.Priority >= 0 && !p.requiresAcceleration(job)
+}
+
+// requiresAcceleration reports whether the acceleration policy of the config
+// applies to a job: its source must be at least as large as the minimum size,
+// and the job must carry one of the allowed labels when any is configured
+func (p *mcProvider) requiresAcceleration(job *db.Job) bool {
+	minSize := p.cfg.AccelerationMinSize
+	if minSize <= 0 || job.SourceInfo.FileSize < minSize {
+		return false
+	}
+	return len(p.cfg.AccelerationLabels) == 0 || hasAnyLabel(job.Labels, p.cfg.AccelerationLabels)
+}
+
+// accelerationSettingsFrom returns the acceleration settings of a job routed
+// with plan. Reserved queues don't support acceleration, so jobs that may run
+// on one aren't accelerated.
+func (p *mcProvider) accelerationSettingsFrom(job *db.Job, plan queuePlan) *mediaconvert.AccelerationSettings {
+	if plan.reserved || !p.requiresAcceleration(job) {
+		return nil
+	}
+	return &mediaconvert.AccelerationSettings{
+		Mode: mediaconvert.AccelerationModePreferred,
+	}
+}
+
+// isAccelerationRejected reports whether MediaConvert rejected the submission of
+// an accelerated job because of its acceleration, which is resubmitted without
+// it. Jobs rejected for other reasons aren't resubmitted.
+func isAccelerationRejected(input *mediaconvert.CreateJobInput, err error) bool {
+	if input.AccelerationSettings == nil {
+		return false
+	}
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || aerr.Code() != mediaconvert.ErrCodeBadRequestException {
+		return false
+	}
+	return strings.Contains(strings.ToLower(aerr.Message()), "accelerat")
+}
+
+func hasAnyLabel(labels, wanted []string) bool {
+	for _, label := range labels {
+		for _, w := range wanted {
+			if label == w {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-const minSizeForAcceleration = 1_000_000_000
+// priorityFrom maps a job priority to the MediaConvert priority, which shares the
+// same -50 to 50 range. A zero priority is left unset so the queue default applies
+func priorityFrom(priority int) *int64 {
+	if priority == 0 {
+		return nil
+	}
 
-func (p *mcProvider) requiresAcceleration(info db.File) bool {
-	return false // hack: (ts) temporarily disabled this due to bugs in EMC (9/JUNE/2020)
-	//return info.FileSize > 0 && info.FileSize/minSizeForAcceleration >= 1
+	return aws.Int64(int64(priority))
 }
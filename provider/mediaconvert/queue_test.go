@@ -0,0 +1,247 @@
+package mediaconvert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQueueRulesFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		want    []queueRule
+		wantErr string
+	}{
+		{
+			name: "no rules",
+		},
+		{
+			name:  "rules with hops",
+			rules: `[{"labels":["news"],"minPriority":10,"queue":"arn:news","hops":[{"queue":"arn:spill","waitMinutes":5,"priority":-10}]}]`,
+			want: []queueRule{{
+				Labels:      []string{"news"},
+				MinPriority: intPtr(10),
+				Queue:       "arn:news",
+				Hops:        []queueHop{{Queue: "arn:spill", WaitMinutes: 5, Priority: intPtr(-10)}},
+			}},
+		},
+		{
+			name:    "malformed rules",
+			rules:   `{"queue":"arn:news"}`,
+			wantErr: "decoding queue rules: json: cannot unmarshal object into Go value of type []mediaconvert.queueRule",
+		},
+		{
+			name:    "rules without a queue",
+			rules:   `[{"queue":"arn:news"},{"labels":["news"]}]`,
+			wantErr: "queue rule 1: missing queue",
+		},
+		{
+			name:    "too many hops",
+			rules:   `[{"queue":"a","hops":[{"queue":"b","waitMinutes":1},{"queue":"c","waitMinutes":1},{"queue":"d","waitMinutes":1},{"queue":"e","waitMinutes":1}]}]`,
+			wantErr: "queue rule 0: 4 hops exceed the limit of 3",
+		},
+		{
+			name:    "hops without a wait",
+			rules:   `[{"queue":"a","hops":[{"queue":"b"}]}]`,
+			wantErr: "queue rule 0: hop 0: wait must last at least a minute",
+		},
+		{
+			name:    "hops with an invalid priority",
+			rules:   `[{"queue":"a","hops":[{"queue":"b","waitMinutes":1,"priority":60}]}]`,
+			wantErr: "queue rule 0: hop 0: priority 60 is out of the range -50 to 50",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := queueRulesFrom(tt.rules)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %q, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong rules: %s", diff)
+			}
+		})
+	}
+}
+
+func TestQueuePlanFrom(t *testing.T) {
+	rules := []queueRule{
+		{
+			Labels:   []string{"news"},
+			Queue:    "arn:news",
+			Reserved: true,
+			Hops: []queueHop{
+				{Queue: "arn:on-demand", WaitMinutes: 5},
+				{Queue: "arn:spill", WaitMinutes: 30, Priority: intPtr(-20)},
+			},
+		},
+		{MinSize: 10_000_000_000, Queue: "arn:large"},
+		{MinDuration: 3600, MaxPriority: intPtr(-1), Queue: "arn:long"},
+	}
+	cfg := &config.MediaConvert{DefaultQueueARN: "arn:default", PreferredQueueARN: "arn:preferred"}
+
+	tests := []struct {
+		name string
+		job  db.Job
+		want queuePlan
+	}{
+		{
+			name: "labeled jobs hop across the queues of their rule",
+			job:  db.Job{Labels: []string{"bill:newsroom", "news"}, SourceInfo: db.File{FileSize: 20_000_000_000}},
+			want: queuePlan{
+				queue: "arn:news",
+				hops: []mediaconvert.HopDestination{
+					{Queue: aws.String("arn:on-demand"), WaitMinutes: aws.Int64(5)},
+					{Queue: aws.String("arn:spill"), WaitMinutes: aws.Int64(30), Priority: aws.Int64(-20)},
+				},
+				reserved: true,
+			},
+		},
+		{
+			name: "large sources",
+			job:  db.Job{SourceInfo: db.File{FileSize: 20_000_000_000}},
+			want: queuePlan{queue: "arn:large"},
+		},
+		{
+			name: "long sources of low priority jobs",
+			job:  db.Job{Priority: -10, SourceInfo: db.File{Duration: 2 * time.Hour}},
+			want: queuePlan{queue: "arn:long"},
+		},
+		{
+			name: "long sources of urgent jobs use the preferred queue",
+			job:  db.Job{Priority: 10, SourceInfo: db.File{Duration: 2 * time.Hour}},
+			want: queuePlan{
+				queue: "arn:preferred",
+				hops:  []mediaconvert.HopDestination{{WaitMinutes: aws.Int64(defaultQueueHopTimeoutMins)}},
+			},
+		},
+		{
+			name: "sources of unknown duration don't match duration bounds",
+			job:  db.Job{Priority: -10},
+			want: queuePlan{queue: "arn:default"},
+		},
+	}
+
+	p := &mcProvider{cfg: cfg, queueRules: rules}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.queuePlanFrom(&tt.job)
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(queuePlan{})); diff != "" {
+				t.Errorf("wrong queue plan: %s", diff)
+			}
+		})
+	}
+}
+
+func TestAccelerationSettingsFrom(t *testing.T) {
+	cfg := &config.MediaConvert{AccelerationMinSize: 1_000_000_000, AccelerationLabels: []string{"movies"}}
+	large := db.File{FileSize: 5_000_000_000}
+
+	tests := []struct {
+		name string
+		job  db.Job
+		plan queuePlan
+		want bool
+	}{
+		{name: "large sources of allowed jobs", job: db.Job{Labels: []string{"movies"}, SourceInfo: large}, want: true},
+		{name: "small sources", job: db.Job{Labels: []string{"movies"}, SourceInfo: db.File{FileSize: 1000}}},
+		{name: "jobs missing from the allow list", job: db.Job{Labels: []string{"news"}, SourceInfo: large}},
+		{
+			name: "jobs that may run on a reserved queue",
+			job:  db.Job{Labels: []string{"movies"}, SourceInfo: large},
+			plan: queuePlan{reserved: true},
+		},
+	}
+
+	p := &mcProvider{cfg: cfg}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if g := p.accelerationSettingsFrom(&tt.job, tt.plan) != nil; g != tt.want {
+				t.Errorf("wrong acceleration: got %t, expected %t", g, tt.want)
+			}
+		})
+	}
+
+	p.cfg = &config.MediaConvert{}
+	if p.requiresAcceleration(&db.Job{SourceInfo: large}) {
+		t.Error("expected acceleration to be disabled without a minimum size")
+	}
+}
+
+func TestTranscodeAccelerationFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "rejected acceleration",
+			err:       awserr.New(mediaconvert.ErrCodeBadRequestException, "Accelerated transcoding isn't supported for this input", nil),
+			wantCalls: 2,
+		},
+		{
+			name:      "jobs rejected for other reasons",
+			err:       awserr.New(mediaconvert.ErrCodeBadRequestException, "invalid output group settings", nil),
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "other failures",
+			err:       errors.New("throttled"),
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := fakeDBWithPresets(audioOnlyPreset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &testMediaConvertClient{t: t, accelerationErr: tt.err}
+			p := &mcProvider{
+				client:     client,
+				cfg:        &config.MediaConvert{Destination: "s3://some/destination", AccelerationMinSize: 1_000_000_000},
+				repository: repo,
+			}
+
+			_, err = p.Transcode(context.Background(), &db.Job{
+				ID:          "jobID",
+				SourceMedia: "s3://some/path.mp4",
+				SourceInfo:  db.File{FileSize: 5_000_000_000},
+				Outputs:     []db.TranscodeOutput{{Preset: db.PresetMap{Name: audioOnlyPreset.Name}, FileName: "file1.mp4"}},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wrong error: got %v, wantErr %t", err, tt.wantErr)
+			}
+			if g, e := client.createJobCalls, tt.wantCalls; g != e {
+				t.Errorf("wrong number of submissions: got %d, expected %d", g, e)
+			}
+			if accelerated := client.createJobCalledWith.AccelerationSettings != nil; accelerated != tt.wantErr {
+				t.Errorf("wrong acceleration of the last submission: got %t", accelerated)
+			}
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
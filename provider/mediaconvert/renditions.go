@@ -0,0 +1,162 @@
+package mediaconvert
+
+import (
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// renditionFilesFrom returns the renditions written by a streaming output group.
+// Renditions are listed with their variant playlist when the group writes an
+// HLS manifest, and with their segments otherwise.
+func renditionFilesFrom(job *db.Job, group mediaconvert.OutputGroup) []provider.OutputFile {
+	settings := group.OutputGroupSettings
+	if settings == nil {
+		return nil
+	}
+
+	var destination *string
+	var playlists bool
+	switch settings.Type {
+	case mediaconvert.OutputGroupTypeHlsGroupSettings:
+		if settings.HlsGroupSettings != nil {
+			destination, playlists = settings.HlsGroupSettings.Destination, true
+		}
+	case mediaconvert.OutputGroupTypeDashIsoGroupSettings:
+		if settings.DashIsoGroupSettings != nil {
+			destination = settings.DashIsoGroupSettings.Destination
+		}
+	case mediaconvert.OutputGroupTypeCmafGroupSettings:
+		if cmaf := settings.CmafGroupSettings; cmaf != nil {
+			destination = cmaf.Destination
+			playlists = cmaf.WriteHlsManifest != mediaconvert.CmafWriteHLSManifestDisabled
+		}
+	}
+	if destination == nil {
+		return nil
+	}
+	name := groupNameFrom(job, *destination)
+
+	var files []provider.OutputFile
+	for _, output := range group.Outputs {
+		if output.NameModifier == nil {
+			continue
+		}
+		rendition := name + *output.NameModifier
+		container, extension := segmentContainerFrom(settings.Type, output)
+		segments := rendition + "_%05d." + extension
+
+		file := provider.OutputFile{
+			Path:      segments,
+			Container: container,
+			Bitrate:   bitrateFrom(output),
+			Captions:  captionLanguagesFrom(job, output.CaptionDescriptions),
+			Segments:  segments,
+		}
+		if playlists {
+			file.Path = rendition + ".m3u8"
+		}
+		if video := output.VideoDescription; video != nil {
+			file.Width, file.Height = aws.Int64Value(video.Width), aws.Int64Value(video.Height)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// segmentContainerFrom returns the container and file extension of the segments
+// written by an output of a streaming group
+func segmentContainerFrom(group mediaconvert.OutputGroupType, output mediaconvert.Output) (container, extension string) {
+	if output.VideoDescription == nil && len(output.AudioDescriptions) == 0 {
+		return "vtt", "vtt"
+	}
+
+	switch group {
+	case mediaconvert.OutputGroupTypeCmafGroupSettings:
+		if output.VideoDescription == nil {
+			return "cmaf", "cmfa"
+		}
+		return "cmaf", "cmfv"
+	case mediaconvert.OutputGroupTypeDashIsoGroupSettings:
+		return "mp4", "mp4"
+	default:
+		// audio-only HLS renditions are written in raw AAC rather than in TS
+		if output.VideoDescription == nil {
+			return "aac", "aac"
+		}
+		return "ts", "ts"
+	}
+}
+
+// bitrateFrom returns the bitrate of an output in bits per second, adding the
+// bitrate of its video to the bitrates of its audio tracks. The maximum bitrate
+// is used for codecs encoding at a variable quality.
+func bitrateFrom(output mediaconvert.Output) int64 {
+	var bitrate int64
+	if video := output.VideoDescription; video != nil && video.CodecSettings != nil {
+		bitrate += videoBitrateFrom(video.CodecSettings)
+	}
+	for _, audio := range output.AudioDescriptions {
+		if audio.CodecSettings != nil {
+			bitrate += audioBitrateFrom(audio.CodecSettings)
+		}
+	}
+	return bitrate
+}
+
+func videoBitrateFrom(s *mediaconvert.VideoCodecSettings) int64 {
+	switch {
+	case s.H264Settings != nil:
+		return firstInt64(s.H264Settings.Bitrate, s.H264Settings.MaxBitrate)
+	case s.H265Settings != nil:
+		return firstInt64(s.H265Settings.Bitrate, s.H265Settings.MaxBitrate)
+	case s.Mpeg2Settings != nil:
+		return firstInt64(s.Mpeg2Settings.Bitrate, s.Mpeg2Settings.MaxBitrate)
+	case s.Vp8Settings != nil:
+		return firstInt64(s.Vp8Settings.Bitrate, s.Vp8Settings.MaxBitrate)
+	case s.Av1Settings != nil:
+		return aws.Int64Value(s.Av1Settings.MaxBitrate)
+	default:
+		return 0
+	}
+}
+
+func audioBitrateFrom(s *mediaconvert.AudioCodecSettings) int64 {
+	switch {
+	case s.AacSettings != nil:
+		return aws.Int64Value(s.AacSettings.Bitrate)
+	case s.Ac3Settings != nil:
+		return aws.Int64Value(s.Ac3Settings.Bitrate)
+	case s.Eac3Settings != nil:
+		return aws.Int64Value(s.Eac3Settings.Bitrate)
+	case s.Mp2Settings != nil:
+		return aws.Int64Value(s.Mp2Settings.Bitrate)
+	default:
+		return 0
+	}
+}
+
+// firstInt64 returns the first of values that is set
+func firstInt64(values ...*int64) int64 {
+	for _, v := range values {
+		if v != nil {
+			return *v
+		}
+	}
+	return 0
+}
+
+// groupNameFrom returns the prefix of the files of a streaming group written to
+// destination, MediaConvert naming them after the source when the destination
+// is a folder
+func groupNameFrom(job *db.Job, destination string) string {
+	if !strings.HasSuffix(destination, "/") {
+		return destination
+	}
+	source := path.Base(job.SourceMedia)
+	return destination + strings.TrimSuffix(source, path.Ext(source))
+}
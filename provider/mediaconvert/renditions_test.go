@@ -0,0 +1,125 @@
+package mediaconvert
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/mediaconvert"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenditionFilesFrom(t *testing.T) {
+	job := &db.Job{
+		SourceMedia: "s3://bucket/source/video.mov",
+		Captions:    []db.Caption{{Language: "en"}},
+	}
+	outputs := []mediaconvert.Output{
+		{
+			NameModifier: aws.String("_720p"),
+			VideoDescription: &mediaconvert.VideoDescription{
+				Width:  aws.Int64(1280),
+				Height: aws.Int64(720),
+				CodecSettings: &mediaconvert.VideoCodecSettings{
+					H264Settings: &mediaconvert.H264Settings{Bitrate: aws.Int64(3000000)},
+				},
+			},
+			AudioDescriptions: []mediaconvert.AudioDescription{{
+				CodecSettings: &mediaconvert.AudioCodecSettings{
+					AacSettings: &mediaconvert.AacSettings{Bitrate: aws.Int64(128000)},
+				},
+			}},
+		},
+		{
+			NameModifier: aws.String("_audio"),
+			AudioDescriptions: []mediaconvert.AudioDescription{{
+				CodecSettings: &mediaconvert.AudioCodecSettings{
+					AacSettings: &mediaconvert.AacSettings{Bitrate: aws.Int64(96000)},
+				},
+			}},
+		},
+		{
+			NameModifier:        aws.String("captions_0"),
+			CaptionDescriptions: []mediaconvert.CaptionDescription{{CaptionSelectorName: aws.String(captionSelectorName(0))}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		settings *mediaconvert.OutputGroupSettings
+		want     []provider.OutputFile
+	}{
+		{
+			name: "hls groups list variant playlists",
+			settings: &mediaconvert.OutputGroupSettings{
+				Type:             mediaconvert.OutputGroupTypeHlsGroupSettings,
+				HlsGroupSettings: &mediaconvert.HlsGroupSettings{Destination: aws.String("s3://bucket/job/master")},
+			},
+			want: []provider.OutputFile{
+				{
+					Path: "s3://bucket/job/master_720p.m3u8", Container: "ts", Width: 1280, Height: 720, Bitrate: 3128000,
+					Segments: "s3://bucket/job/master_720p_%05d.ts",
+				},
+				{
+					Path: "s3://bucket/job/master_audio.m3u8", Container: "aac", Bitrate: 96000,
+					Segments: "s3://bucket/job/master_audio_%05d.aac",
+				},
+				{
+					Path: "s3://bucket/job/mastercaptions_0.m3u8", Container: "vtt", Captions: []string{"en"},
+					Segments: "s3://bucket/job/mastercaptions_0_%05d.vtt",
+				},
+			},
+		},
+		{
+			name: "dash only cmaf groups list segments",
+			settings: &mediaconvert.OutputGroupSettings{
+				Type: mediaconvert.OutputGroupTypeCmafGroupSettings,
+				CmafGroupSettings: &mediaconvert.CmafGroupSettings{
+					Destination:      aws.String("s3://bucket/job/"),
+					WriteHlsManifest: mediaconvert.CmafWriteHLSManifestDisabled,
+				},
+			},
+			want: []provider.OutputFile{
+				{
+					Path: "s3://bucket/job/video_720p_%05d.cmfv", Container: "cmaf", Width: 1280, Height: 720, Bitrate: 3128000,
+					Segments: "s3://bucket/job/video_720p_%05d.cmfv",
+				},
+				{
+					Path: "s3://bucket/job/video_audio_%05d.cmfa", Container: "cmaf", Bitrate: 96000,
+					Segments: "s3://bucket/job/video_audio_%05d.cmfa",
+				},
+				{
+					Path: "s3://bucket/job/videocaptions_0_%05d.vtt", Container: "vtt", Captions: []string{"en"},
+					Segments: "s3://bucket/job/videocaptions_0_%05d.vtt",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renditionFilesFrom(job, mediaconvert.OutputGroup{OutputGroupSettings: tt.settings, Outputs: outputs})
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong renditions: %s", diff)
+			}
+		})
+	}
+}
+
+func TestBitrateFrom(t *testing.T) {
+	output := mediaconvert.Output{
+		VideoDescription: &mediaconvert.VideoDescription{
+			CodecSettings: &mediaconvert.VideoCodecSettings{
+				H265Settings: &mediaconvert.H265Settings{MaxBitrate: aws.Int64(5000000)},
+			},
+		},
+		AudioDescriptions: []mediaconvert.AudioDescription{
+			{CodecSettings: &mediaconvert.AudioCodecSettings{AacSettings: &mediaconvert.AacSettings{Bitrate: aws.Int64(128000)}}},
+			{CodecSettings: &mediaconvert.AudioCodecSettings{Ac3Settings: &mediaconvert.Ac3Settings{Bitrate: aws.Int64(384000)}}},
+		},
+	}
+	if g, e := bitrateFrom(output), int64(5512000); g != e {
+		t.Errorf("wrong bitrate: got %d, expected %d", g, e)
+	}
+}
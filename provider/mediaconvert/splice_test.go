@@ -12,12 +12,15 @@ func TestSplice2Clippings(t *testing.T) {
 	for _, tc := range []struct {
 		name  string
 		input timecode.Splice
+		fps   float64
 		want  []mediaconvert.InputClipping
 	}{
-		{"5-10s", timecode.Splice{{5, 10}}, makeIC("00:00:05:00", "00:00:10:00")},
+		{"5-10s", timecode.Splice{{5, 10}}, 0, makeIC("00:00:05:00", "00:00:10:00")},
+		{"frames at 25fps", timecode.Splice{{5.2, 10.48}}, 25, makeIC("00:00:05:05", "00:00:10:12")},
+		{"drop-frame at 29.97fps", timecode.Splice{{0, 60.06}}, 29.97, makeIC("00:00:00;00", "00:01:00;02")},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			have := splice2clippings(tc.input, 0)
+			have := splice2clippings(tc.input, tc.fps)
 			want := tc.want
 			if !reflect.DeepEqual(have, want) {
 				t.Fatalf("have %v, want %v", have, want)
@@ -0,0 +1,58 @@
+package provider
+
+import "context"
+
+// PresetSyncer is implemented by providers mirroring the presets of the API into
+// presets of their own, whose copy may drift when edited with the tools of the
+// provider.
+type PresetSyncer interface {
+	// SyncPreset compares the copy of a preset held by the provider with the
+	// stored preset, overwriting the copy when it drifted unless dryRun is set.
+	SyncPreset(ctx context.Context, presetID string, dryRun bool) (PresetSync, error)
+}
+
+// PresetSync is the outcome of the synchronization of a preset
+type PresetSync struct {
+	PresetID string           `json:"presetId"`
+	Status   PresetSyncStatus `json:"status,omitempty"`
+
+	// Drift lists the settings of the copy held by the provider that differ
+	// from the stored preset
+	Drift []string `json:"drift,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// PresetSyncStatus describes the copy of a preset held by a provider
+type PresetSyncStatus = string
+
+const (
+	// PresetSyncInSync is the status of copies matching the stored preset
+	PresetSyncInSync PresetSyncStatus = "inSync"
+
+	// PresetSyncDrifted is the status of copies differing from the stored
+	// preset, left as they are by dry runs
+	PresetSyncDrifted PresetSyncStatus = "drifted"
+
+	// PresetSyncMissing is the status of copies missing from the provider, left
+	// missing by dry runs
+	PresetSyncMissing PresetSyncStatus = "missing"
+
+	// PresetSyncUpdated is the status of drifted copies overwritten with the
+	// stored preset
+	PresetSyncUpdated PresetSyncStatus = "updated"
+
+	// PresetSyncCreated is the status of missing copies created from the stored
+	// preset
+	PresetSyncCreated PresetSyncStatus = "created"
+)
+
+// AsPresetSyncer returns the PresetSyncer implemented by a provider, looking
+// through the circuit breaker guarding it
+func AsPresetSyncer(p TranscodingProvider) (PresetSyncer, bool) {
+	if guarded, ok := p.(*guardedProvider); ok {
+		p = guarded.TranscodingProvider
+	}
+	syncer, ok := p.(PresetSyncer)
+	return syncer, ok
+}
@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+type syncingProvider struct {
+	fakeProvider
+}
+
+func (*syncingProvider) SyncPreset(_ context.Context, presetID string, _ bool) (PresetSync, error) {
+	return PresetSync{PresetID: presetID, Status: PresetSyncInSync}, nil
+}
+
+func TestAsPresetSyncer(t *testing.T) {
+	syncing := &syncingProvider{}
+	for _, p := range []TranscodingProvider{
+		syncing,
+		&guardedProvider{TranscodingProvider: syncing, breaker: NewBreaker("syncing", 1, 0)},
+	} {
+		if _, ok := AsPresetSyncer(p); !ok {
+			t.Errorf("expected %T to sync presets", p)
+		}
+	}
+
+	if _, ok := AsPresetSyncer(&fakeProvider{}); ok {
+		t.Error("expected providers without their own presets not to sync them")
+	}
+}
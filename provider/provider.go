@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
 	"time"
 
 	"github.com/cbsinteractive/transcode-orchestrator/config"
@@ -81,6 +80,8 @@ type JobStatus struct {
 	Output         JobOutput              `json:"output"`
 	SourceInfo     SourceInfo             `json:"sourceInfo,omitempty"`
 	Labels         []string               `json:"labels,omitempty"`
+	StalledReason  string                 `json:"stalledReason,omitempty"`
+	ResubmittedAs  string                 `json:"resubmittedAs,omitempty"`
 }
 
 // JobOutput represents information about a job output.
@@ -97,8 +98,29 @@ type OutputFile struct {
 	Height     int64  `json:"height,omitempty"`
 	Width      int64  `json:"width,omitempty"`
 	FileSize   int64  `json:"fileSize,omitempty"`
+	Bitrate    int64  `json:"bitrate,omitempty"`
+
+	// Captions are the languages of the caption tracks carried by the file
+	Captions []string `json:"captions,omitempty"`
+
+	// Segments is the location of the segments of a streaming rendition, their
+	// number replacing the %05d verb
+	Segments string `json:"segments,omitempty"`
+
+	// Type is OutputFileTypeImage for the files of image outputs,
+	// OutputFileTypeManifest for manifests, and empty for media files
+	Type string `json:"type,omitempty"`
 }
 
+const (
+	// OutputFileTypeImage is the type of the images and sprite indexes of image outputs
+	OutputFileTypeImage = "image"
+
+	// OutputFileTypeManifest is the type of the HLS and DASH manifests of
+	// streaming outputs
+	OutputFileTypeManifest = "manifest"
+)
+
 // SourceInfo contains information about media transcoded using the Transcoding
 // API.
 type SourceInfo struct {
@@ -117,6 +139,10 @@ type SourceInfo struct {
 type Status string
 
 const (
+	// StatusScheduled is the status for a job that is waiting for its
+	// scheduled time before being submitted to the provider.
+	StatusScheduled = Status("scheduled")
+
 	// StatusQueued is the status for a job that is in the queue for
 	// execution.
 	StatusQueued = Status("queued")
@@ -137,6 +163,16 @@ const (
 	StatusUnknown = Status("unknown")
 )
 
+// Terminal returns whether the status is final, meaning the job will not
+// change anymore.
+func (s Status) Terminal() bool {
+	switch s {
+	case StatusFinished, StatusFailed, StatusCanceled:
+		return true
+	}
+	return false
+}
+
 var providers map[string]Factory
 
 // Register register a new provider in the internal list of providers.
@@ -160,37 +196,3 @@ func GetProviderFactory(name string) (Factory, error) {
 	}
 	return factory, nil
 }
-
-// ListProviders returns the list of currently registered providers,
-// alphabetically ordered.
-func ListProviders(c *config.Config) []string {
-	providerNames := make([]string, 0, len(providers))
-	for name, factory := range providers {
-		if _, err := factory(c); err == nil {
-			providerNames = append(providerNames, name)
-		}
-	}
-	sort.Strings(providerNames)
-	return providerNames
-}
-
-// DescribeProvider describes the given provider. It includes information about
-// the provider's capabilities and its current health state.
-func DescribeProvider(name string, c *config.Config) (*Description, error) {
-	factory, err := GetProviderFactory(name)
-	if err != nil {
-		return nil, err
-	}
-	description := Description{Name: name}
-	provider, err := factory(c)
-	if err != nil {
-		return &description, nil
-	}
-	description.Enabled = true
-	description.Capabilities = provider.Capabilities()
-	description.Health = Health{OK: true}
-	if err = provider.Healthcheck(); err != nil {
-		description.Health = Health{OK: false, Message: err.Error()}
-	}
-	return &description, nil
-}
@@ -96,15 +96,15 @@ func TestListProviders(t *testing.T) {
 		"cap-and-healthy":   getFactory(nil, nil, cap),
 	}
 	expected := []string{"cap-and-healthy", "cap-and-unhealthy"}
-	got := ListProviders(&config.Config{})
+	got := NewRegistry(&config.Config{}).List()
 	if !reflect.DeepEqual(got, expected) {
-		t.Errorf("DescribeProviders: want %#v. Got %#v", expected, got)
+		t.Errorf("List: want %#v. Got %#v", expected, got)
 	}
 }
 
 func TestListProvidersEmpty(t *testing.T) {
 	providers = nil
-	providerNames := ListProviders(&config.Config{})
+	providerNames := NewRegistry(&config.Config{}).List()
 	if len(providerNames) != 0 {
 		t.Errorf("Unexpected non-empty provider list: %#v", providerNames)
 	}
@@ -127,7 +127,7 @@ func TestDescribeProvider(t *testing.T) {
 	}{
 		{
 			"factory-err",
-			Description{Name: "factory-err", Enabled: false},
+			Description{Name: "factory-err", Enabled: false, ConfigError: "invalid config"},
 		},
 		{
 			"cap-and-healthy",
@@ -148,20 +148,21 @@ func TestDescribeProvider(t *testing.T) {
 			},
 		},
 	}
+	r := NewRegistry(&config.Config{})
 	for _, test := range tests {
-		description, err := DescribeProvider(test.input, &config.Config{})
+		description, err := r.Describe(test.input)
 		if err != nil {
 			t.Error(err)
 		}
 		if !reflect.DeepEqual(*description, test.expected) {
-			t.Errorf("DescribeProvider(%q): want %#v. Got %#v", test.input, test.expected, *description)
+			t.Errorf("Describe(%q): want %#v. Got %#v", test.input, test.expected, *description)
 		}
 	}
 }
 
 func TestDescribeProviderNotFound(t *testing.T) {
 	providers = nil
-	description, err := DescribeProvider("anything", nil)
+	description, err := NewRegistry(&config.Config{}).Describe("anything")
 	if err != ErrProviderNotFound {
 		t.Errorf("Wrong error. Want %#v. Got %#v", ErrProviderNotFound, err)
 	}
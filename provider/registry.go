@@ -0,0 +1,319 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+)
+
+// Starter is implemented by providers that need to be started once built,
+// before being used.
+type Starter interface {
+	Start(context.Context) error
+}
+
+// Registry builds each registered provider once and shares the instance
+// between callers, instead of building a new one on every use. Providers
+// that fail to build are remembered along with the error, so it can be
+// reported without building them again.
+//
+// Providers implementing Starter are started when built, and the ones
+// implementing io.Closer are closed when the registry is closed or reloaded.
+// Instances replaced by a reload are closed after a grace period, letting the
+// callers that got them before the reload finish using them.
+//
+// When a breaker threshold is configured, the instances returned by the
+// registry are guarded by a circuit breaker, and their health is probed in the
+// background by RunHealthChecks.
+type Registry struct {
+	mu        sync.RWMutex
+	cfg       *config.Config
+	instances map[string]*registryEntry
+	health    map[string]healthResult
+
+	// reloadGrace is how long instances replaced by a reload stay open
+	reloadGrace time.Duration
+}
+
+// DefaultReloadGrace is how long instances replaced by a reload stay open
+// before being closed.
+const DefaultReloadGrace = 2 * time.Minute
+
+type registryEntry struct {
+	provider TranscodingProvider
+	guarded  TranscodingProvider
+	breaker  *Breaker
+	err      error
+}
+
+// NewRegistry returns a registry that builds providers with the given
+// configuration.
+func NewRegistry(cfg *config.Config) *Registry {
+	return &Registry{
+		cfg:         cfg,
+		instances:   make(map[string]*registryEntry),
+		health:      make(map[string]healthResult),
+		reloadGrace: DefaultReloadGrace,
+	}
+}
+
+// Start builds and starts every registered provider. Providers that are not
+// configured are skipped, the returned error only reports the ones that
+// failed to start.
+func (r *Registry) Start(ctx context.Context) error {
+	var errs []string
+	for _, name := range registeredNames() {
+		_, err := r.get(ctx, name)
+		if err != nil {
+			if _, ok := err.(startError); ok {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("starting providers: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Get returns the instance of the given provider, building it on first use.
+func (r *Registry) Get(name string) (TranscodingProvider, error) {
+	return r.get(context.Background(), name)
+}
+
+func (r *Registry) get(ctx context.Context, name string) (TranscodingProvider, error) {
+	r.mu.RLock()
+	entry, ok := r.instances[name]
+	r.mu.RUnlock()
+	if ok {
+		return entry.guarded, entry.err
+	}
+
+	factory, err := GetProviderFactory(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, ok := r.instances[name]; ok {
+		return entry.guarded, entry.err
+	}
+	entry = &registryEntry{}
+	entry.provider, entry.err = factory(r.cfg)
+	if entry.err == nil {
+		if starter, ok := entry.provider.(Starter); ok {
+			if err := starter.Start(ctx); err != nil {
+				entry.provider, entry.err = nil, startError{name: name, err: err}
+			}
+		}
+	}
+	entry.guarded = entry.provider
+	if cfg := r.healthConfig(); entry.err == nil && cfg.BreakerThreshold > 0 {
+		entry.breaker = NewBreaker(name, cfg.BreakerThreshold, time.Duration(cfg.BreakerCooldown)*time.Second)
+		entry.guarded = &guardedProvider{TranscodingProvider: entry.provider, breaker: entry.breaker}
+	}
+	r.instances[name] = entry
+	return entry.guarded, entry.err
+}
+
+func (r *Registry) healthConfig() config.Health {
+	if r.cfg.Health == nil {
+		return config.Health{}
+	}
+	return *r.cfg.Health
+}
+
+// List returns the names of the providers that are configured, alphabetically
+// ordered.
+func (r *Registry) List() []string {
+	names := make([]string, 0, len(providers))
+	for _, name := range registeredNames() {
+		if _, err := r.Get(name); err == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Describe describes the given provider, including why it is not enabled
+// when it could not be built.
+func (r *Registry) Describe(name string) (*Description, error) {
+	p, err := r.Get(name)
+	if err == ErrProviderNotFound {
+		return nil, err
+	}
+	description := Description{Name: name}
+	if err != nil {
+		description.ConfigError = err.Error()
+		return &description, nil
+	}
+	description.Enabled = true
+	description.Capabilities = p.Capabilities()
+	description.Health = r.Health(context.Background(), name)
+	if state := r.BreakerState(name); state != BreakerClosed {
+		description.CircuitBreaker = state
+	}
+	return &description, nil
+}
+
+// Health returns the health of the given provider. Results of the background
+// probes are reused until the next probe is due, the provider is checked on
+// the spot otherwise.
+func (r *Registry) Health(ctx context.Context, name string) Health {
+	r.mu.RLock()
+	result, ok := r.health[name]
+	cfg := r.healthConfig()
+	r.mu.RUnlock()
+	interval := time.Duration(cfg.ProbeInterval) * time.Second
+	if ok && time.Since(result.checkedAt) < interval {
+		return result.health
+	}
+	return r.probe(ctx, name)
+}
+
+// BreakerState returns the state of the circuit breaker of the given
+// provider. Providers without a breaker are reported as closed.
+func (r *Registry) BreakerState(name string) BreakerState {
+	r.mu.RLock()
+	entry, ok := r.instances[name]
+	r.mu.RUnlock()
+	if !ok || entry.breaker == nil {
+		return BreakerClosed
+	}
+	return entry.breaker.State()
+}
+
+// RunHealthChecks probes the health of the configured providers at the
+// configured interval until the context is done. It returns right away when
+// no interval is configured.
+func (r *Registry) RunHealthChecks(ctx context.Context) {
+	r.mu.RLock()
+	cfg := r.healthConfig()
+	r.mu.RUnlock()
+	if cfg.ProbeInterval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.ProbeInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		r.CheckHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckHealth probes the health of every configured provider concurrently.
+func (r *Registry) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, name := range r.List() {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			r.probe(ctx, name)
+		}(name)
+	}
+	wg.Wait()
+}
+
+func (r *Registry) probe(ctx context.Context, name string) Health {
+	r.mu.RLock()
+	entry, ok := r.instances[name]
+	cfg := r.healthConfig()
+	r.mu.RUnlock()
+	if !ok || entry.err != nil {
+		return Health{}
+	}
+
+	timeout := time.Duration(cfg.ProbeTimeout) * time.Second
+	if timeout == 0 {
+		timeout = DefaultHealthcheckTimeout
+	}
+	health := checkHealth(ctx, entry.provider, timeout)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.instances[name] == entry {
+		r.health[name] = healthResult{health: health, checkedAt: time.Now()}
+	}
+	return health
+}
+
+// Reload drops every instance, so the providers are built again with the
+// given configuration on next use. The dropped instances that hold resources
+// are closed once the grace period of the registry is over, Reload returning
+// afterwards.
+func (r *Registry) Reload(cfg *config.Config) error {
+	r.mu.Lock()
+	instances := r.instances
+	r.cfg = cfg
+	r.instances = make(map[string]*registryEntry)
+	r.health = make(map[string]healthResult)
+	grace := r.reloadGrace
+	r.mu.Unlock()
+
+	time.Sleep(grace)
+	return closeAll(instances)
+}
+
+// Close closes the providers that hold resources. The registry must not be
+// used afterwards.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	instances := r.instances
+	r.instances = make(map[string]*registryEntry)
+	r.mu.Unlock()
+	return closeAll(instances)
+}
+
+func closeAll(instances map[string]*registryEntry) error {
+	var errs []string
+	for name, entry := range instances {
+		closer, ok := entry.provider.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("closing providers: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func registeredNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// startError is returned by the registry for providers that were built but
+// failed to start
+type startError struct {
+	name string
+	err  error
+}
+
+func (e startError) Error() string {
+	return fmt.Sprintf("%s: %s", e.name, e.err)
+}
+
+func (e startError) Unwrap() error {
+	return e.err
+}
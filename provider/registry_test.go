@@ -0,0 +1,228 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+)
+
+type lifecycleProvider struct {
+	fakeProvider
+	startErr error
+	started  int
+	closed   int
+}
+
+func (p *lifecycleProvider) Start(context.Context) error {
+	p.started++
+	return p.startErr
+}
+
+func (p *lifecycleProvider) Close() error {
+	p.closed++
+	return nil
+}
+
+func TestRegistryBuildsOnce(t *testing.T) {
+	var calls int
+	providers = map[string]Factory{
+		"counted": func(*config.Config) (TranscodingProvider, error) {
+			calls++
+			return &fakeProvider{}, nil
+		},
+		"factory-err": func(*config.Config) (TranscodingProvider, error) {
+			calls++
+			return nil, errors.New("invalid config")
+		},
+	}
+	r := NewRegistry(&config.Config{})
+
+	first, err := r.Get("counted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := r.Get("counted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("expected the same instance to be returned on every call")
+	}
+	for i := 0; i < 2; i++ {
+		_, err = r.Get("factory-err")
+		if err == nil || err.Error() != "invalid config" {
+			t.Errorf("wrong error: got %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("wrong number of factory calls: want 2, got %d", calls)
+	}
+	if g, e := r.List(), []string{"counted"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong list of providers: want %#v, got %#v", e, g)
+	}
+	if calls != 2 {
+		t.Errorf("listing providers built them again: got %d factory calls", calls)
+	}
+
+	_, err = r.Get("unknown")
+	if err != ErrProviderNotFound {
+		t.Errorf("wrong error: want %#v, got %#v", ErrProviderNotFound, err)
+	}
+}
+
+func TestRegistryDescribe(t *testing.T) {
+	providers = map[string]Factory{
+		"factory-err": getFactory(errors.New("invalid config"), nil, Capabilities{}),
+	}
+	description, err := NewRegistry(&config.Config{}).Describe("factory-err")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Description{Name: "factory-err", ConfigError: "invalid config"}
+	if !reflect.DeepEqual(*description, expected) {
+		t.Errorf("wrong description: want %#v, got %#v", expected, *description)
+	}
+}
+
+func TestRegistryLifecycle(t *testing.T) {
+	healthy := &lifecycleProvider{}
+	broken := &lifecycleProvider{startErr: errors.New("connection refused")}
+	var cfgs []*config.Config
+	providers = map[string]Factory{
+		"healthy": func(cfg *config.Config) (TranscodingProvider, error) {
+			cfgs = append(cfgs, cfg)
+			return healthy, nil
+		},
+		"broken": func(*config.Config) (TranscodingProvider, error) {
+			return broken, nil
+		},
+		"unconfigured": getFactory(errors.New("missing credentials"), nil, Capabilities{}),
+	}
+	cfg := &config.Config{}
+	r := NewRegistry(cfg)
+
+	err := r.Start(context.Background())
+	if g, e := err.Error(), "starting providers: broken: connection refused"; g != e {
+		t.Errorf("wrong error: want %q, got %q", e, g)
+	}
+	if healthy.started != 1 || broken.started != 1 {
+		t.Errorf("expected every provider to be started once: got %d and %d", healthy.started, broken.started)
+	}
+	if _, err = r.Get("broken"); !errors.Is(err, broken.startErr) {
+		t.Errorf("wrong error: got %v", err)
+	}
+
+	newCfg := &config.Config{}
+	r.reloadGrace = 50 * time.Millisecond
+	reloadStart := time.Now()
+	err = r.Reload(newCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(reloadStart); elapsed < r.reloadGrace {
+		t.Errorf("expected the provider to be closed after the grace period: closed after %s", elapsed)
+	}
+	if healthy.closed != 1 {
+		t.Errorf("expected the provider to be closed on reload: got %d", healthy.closed)
+	}
+	if _, err = r.Get("healthy"); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfgs) != 2 || cfgs[0] != cfg || cfgs[1] != newCfg {
+		t.Errorf("expected the provider to be rebuilt with the new config: got %#v", cfgs)
+	}
+
+	err = r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if healthy.closed != 2 {
+		t.Errorf("expected the provider to be closed: got %d", healthy.closed)
+	}
+}
+
+type slowProvider struct {
+	fakeProvider
+	release chan struct{}
+	checks  int32
+}
+
+func (p *slowProvider) Healthcheck() error {
+	atomic.AddInt32(&p.checks, 1)
+	<-p.release
+	return nil
+}
+
+func TestRegistryHealth(t *testing.T) {
+	slow := &slowProvider{release: make(chan struct{})}
+	defer close(slow.release)
+	providers = map[string]Factory{
+		"slow": func(*config.Config) (TranscodingProvider, error) {
+			return slow, nil
+		},
+		"unhealthy": getFactory(nil, errors.New("api is down"), Capabilities{}),
+	}
+	r := NewRegistry(&config.Config{Health: &config.Health{ProbeInterval: 60, ProbeTimeout: 1}})
+	r.CheckHealth(context.Background())
+
+	expected := map[string]Health{
+		"slow":      {OK: false, Message: "healthcheck timed out after 1s"},
+		"unhealthy": {OK: false, Message: "api is down"},
+	}
+	for name, want := range expected {
+		if got := r.Health(context.Background(), name); !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong health for %q: want %#v, got %#v", name, want, got)
+		}
+	}
+	if checks := atomic.LoadInt32(&slow.checks); checks != 1 {
+		t.Errorf("expected the cached health to be reused, got %d healthchecks", checks)
+	}
+}
+
+func TestRegistryBreaker(t *testing.T) {
+	failing := &failingProvider{err: UnavailableError{errors.New("service unavailable")}}
+	providers = map[string]Factory{
+		"failing": func(*config.Config) (TranscodingProvider, error) {
+			return failing, nil
+		},
+	}
+	r := NewRegistry(&config.Config{Health: &config.Health{BreakerThreshold: 2, BreakerCooldown: 60}})
+	p, err := r.Get("failing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		p.Transcode(context.Background(), nil)
+	}
+	if failing.calls != 2 {
+		t.Errorf("expected submissions to stop once the breaker opened, got %d calls", failing.calls)
+	}
+	_, err = p.Transcode(context.Background(), nil)
+	if _, ok := err.(CircuitOpenError); !ok {
+		t.Errorf("wrong error: %#v", err)
+	}
+	description, err := r.Describe("failing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if description.CircuitBreaker != BreakerOpen {
+		t.Errorf("wrong circuit breaker state: %q", description.CircuitBreaker)
+	}
+}
+
+type failingProvider struct {
+	fakeProvider
+	err   error
+	calls int
+}
+
+func (p *failingProvider) Transcode(context.Context, *db.Job) (*JobStatus, error) {
+	p.calls++
+	return nil, p.err
+}
@@ -0,0 +1,295 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/sirupsen/logrus"
+	"github.com/zsiec/pkg/tracing"
+)
+
+// RetryableError wraps errors of calls that may succeed when retried, such as
+// rate limited or server errors.
+type RetryableError struct {
+	Err error
+
+	// RetryAfter is the delay requested by the server before retrying, if
+	// any
+	RetryAfter time.Duration
+
+	// NotProcessed is set when the request is known to have been rejected
+	// before being processed, making it safe to retry even when it isn't
+	// idempotent
+	NotProcessed bool
+}
+
+func (e RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// RetryPolicy retries the failed calls of a provider with exponential backoff
+// and jitter. Non idempotent calls, like the ones creating jobs, are only
+// retried when the request is known not to have been processed.
+//
+// The zero value doesn't retry.
+type RetryPolicy struct {
+	Provider    string
+	MaxAttempts uint
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Tracer      tracing.Tracer
+	Logger      logrus.FieldLogger
+
+	sleep  func(context.Context, time.Duration) error
+	jitter func(time.Duration) time.Duration
+}
+
+// NewRetryPolicy returns the retry policy of the given provider, which doesn't
+// retry unless the provider is listed in the retry configuration.
+func NewRetryPolicy(name string, cfg *config.Config) *RetryPolicy {
+	p := RetryPolicy{Provider: name, Tracer: cfg.Tracer, Logger: logrus.StandardLogger()}
+	if p.Tracer == nil {
+		p.Tracer = tracing.NoopTracer{}
+	}
+	if cfg.Retry == nil {
+		return &p
+	}
+	for _, enabled := range cfg.Retry.Providers {
+		if enabled == name {
+			p.MaxAttempts = cfg.Retry.MaxAttempts
+			p.BaseDelay = time.Duration(cfg.Retry.BaseDelay) * time.Millisecond
+			p.MaxDelay = time.Duration(cfg.Retry.MaxDelay) * time.Millisecond
+		}
+	}
+	return &p
+}
+
+// Do calls fn until it succeeds, returns an error that can't be retried, or
+// the attempts are exhausted. The op names the call in logs and tracing
+// subsegments. A RetryableError returned by fn is unwrapped before being
+// returned, so callers see the original error.
+//
+// A nil policy calls fn once.
+func (p *RetryPolicy) Do(ctx context.Context, op string, idempotent bool, fn func(context.Context) error) error {
+	if p == nil {
+		return unwrapRetryable(fn(ctx))
+	}
+	err := fn(ctx)
+	for attempt := uint(2); attempt <= p.MaxAttempts; attempt++ {
+		var retryable RetryableError
+		if err == nil || !errors.As(err, &retryable) || !(idempotent || retryable.NotProcessed) {
+			break
+		}
+
+		delay := p.backoff(attempt - 1)
+		if retryable.RetryAfter > delay {
+			delay = retryable.RetryAfter
+		}
+		p.logger().WithFields(logrus.Fields{
+			"provider": p.Provider,
+			"op":       op,
+			"attempt":  attempt,
+			"delay":    delay.String(),
+		}).WithError(err).Warn("retrying provider call")
+
+		seg := p.tracer().BeginSubsegment(ctx, fmt.Sprintf("%s-retry-%d", op, attempt-1))
+		if sleepErr := p.doSleep(ctx, delay); sleepErr != nil {
+			seg.Close(sleepErr)
+			break
+		}
+		err = fn(ctx)
+		seg.Close(err)
+	}
+	return unwrapRetryable(err)
+}
+
+func unwrapRetryable(err error) error {
+	if retryable, ok := err.(RetryableError); ok {
+		return retryable.Err
+	}
+	return err
+}
+
+// Transport returns a RoundTripper sending requests through base, retrying
+// them according to the policy. POST and PATCH requests are not idempotent,
+// and requests whose body can't be rewound are never retried. The response
+// of the last attempt is returned when the attempts are exhausted.
+func (p *RetryPolicy) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{policy: p, base: base}
+}
+
+type retryTransport struct {
+	policy *RetryPolicy
+	base   http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method != http.MethodPost && req.Method != http.MethodPatch
+	op := fmt.Sprintf("%s-%s", t.policy.Provider, strings.ToLower(req.Method))
+
+	var resp *http.Response
+	var lastErr error
+	attempts := 0
+	err := t.policy.Do(req.Context(), op, idempotent, func(ctx context.Context) error {
+		attemptReq := req
+		if attempts > 0 {
+			if req.Body != nil && req.GetBody == nil {
+				// the failure of the previous attempt is final
+				return unwrapRetryable(lastErr)
+			}
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				attemptReq.Body = body
+			}
+			// attempts failing before getting a response have none to close
+			if resp != nil {
+				resp.Body.Close()
+			}
+		}
+		attempts++
+
+		var err error
+		resp, err = t.base.RoundTrip(attemptReq)
+		if err != nil {
+			resp = nil
+			lastErr = TransportError(err)
+			return lastErr
+		}
+		retryAfter := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		err = StatusError(resp.StatusCode, retryAfter, fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status))
+		if _, ok := err.(RetryableError); ok {
+			lastErr = err
+			return err
+		}
+		return nil
+	})
+	if resp != nil {
+		return resp, nil
+	}
+	return nil, err
+}
+
+// backoff returns the delay before the given retry, doubling the base delay
+// on every retry up to the maximum delay, with full jitter.
+func (p *RetryPolicy) backoff(retry uint) time.Duration {
+	delay := p.BaseDelay
+	for i := uint(1); i < retry && (p.MaxDelay == 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.jitter != nil {
+		return p.jitter(delay)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay))) + 1
+}
+
+func (p *RetryPolicy) doSleep(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		return p.sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (p *RetryPolicy) logger() logrus.FieldLogger {
+	if p.Logger == nil {
+		return logrus.StandardLogger()
+	}
+	return p.Logger
+}
+
+func (p *RetryPolicy) tracer() tracing.Tracer {
+	if p.Tracer == nil {
+		return tracing.NoopTracer{}
+	}
+	return p.Tracer
+}
+
+// UnavailableError wraps the errors of calls that failed because the provider
+// was unavailable, such as server errors, rate limiting, timeouts or transport
+// errors, as opposed to the errors caused by the call itself. They count as
+// failures of the provider in its circuit breaker.
+type UnavailableError struct {
+	Err error
+}
+
+func (e UnavailableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError returns a RetryableError for the status codes worth retrying,
+// or err unchanged otherwise. Rate limited requests are known not to have
+// been processed. Both tell about the availability of the provider.
+func StatusError(status int, retryAfter time.Duration, err error) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return RetryableError{Err: UnavailableError{err}, RetryAfter: retryAfter, NotProcessed: true}
+	case status >= 500 && status != http.StatusNotImplemented:
+		return RetryableError{Err: UnavailableError{err}, RetryAfter: retryAfter}
+	}
+	return err
+}
+
+// TransportError returns an UnavailableError for the network errors and
+// timeouts of requests, which is retryable when the request never reached the
+// server, or err unchanged otherwise.
+func TransportError(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return RetryableError{Err: UnavailableError{err}, NotProcessed: true}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return UnavailableError{err}
+	}
+	return err
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, either in seconds
+// or as an HTTP date.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
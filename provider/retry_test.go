@@ -0,0 +1,276 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/sirupsen/logrus"
+)
+
+func testRetryPolicy(sleeps *[]time.Duration) *RetryPolicy {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	p := NewRetryPolicy("fake", &config.Config{Retry: &config.Retry{
+		Providers:   []string{"fake"},
+		MaxAttempts: 3,
+		BaseDelay:   100,
+		MaxDelay:    150,
+	}})
+	p.Logger = logger
+	p.jitter = func(d time.Duration) time.Duration { return d }
+	p.sleep = func(_ context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		return nil
+	}
+	return p
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	serverErr := errors.New("internal server error")
+	tests := []struct {
+		name       string
+		idempotent bool
+		errs       []error
+		wantCalls  int
+		wantErr    error
+		wantSleeps []time.Duration
+	}{
+		{
+			name:       "server errors of idempotent calls are retried with backoff",
+			idempotent: true,
+			errs:       []error{StatusError(500, 0, serverErr), StatusError(503, 0, serverErr), nil},
+			wantCalls:  3,
+			wantSleeps: []time.Duration{100 * time.Millisecond, 150 * time.Millisecond},
+		},
+		{
+			name:       "attempts are limited",
+			idempotent: true,
+			errs:       []error{StatusError(500, 0, serverErr), StatusError(500, 0, serverErr), StatusError(500, 0, serverErr)},
+			wantCalls:  3,
+			wantErr:    serverErr,
+			wantSleeps: []time.Duration{100 * time.Millisecond, 150 * time.Millisecond},
+		},
+		{
+			name:      "server errors of calls that aren't idempotent are not retried",
+			errs:      []error{StatusError(500, 0, serverErr)},
+			wantCalls: 1,
+			wantErr:   serverErr,
+		},
+		{
+			name:       "rate limited calls are retried after the requested delay",
+			errs:       []error{StatusError(http.StatusTooManyRequests, 2*time.Second, serverErr), nil},
+			wantCalls:  2,
+			wantSleeps: []time.Duration{2 * time.Second},
+		},
+		{
+			name:       "other errors are not retried",
+			idempotent: true,
+			errs:       []error{StatusError(400, 0, serverErr)},
+			wantCalls:  1,
+			wantErr:    serverErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sleeps []time.Duration
+			p := testRetryPolicy(&sleeps)
+
+			calls := 0
+			err := p.Do(context.Background(), "fake-op", tt.idempotent, func(context.Context) error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("wrong error: want %#v, got %#v", tt.wantErr, err)
+			}
+			if _, ok := err.(RetryableError); ok {
+				t.Errorf("expected retryable errors to be unwrapped, got %#v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("wrong number of calls: want %d, got %d", tt.wantCalls, calls)
+			}
+			if len(sleeps) != len(tt.wantSleeps) {
+				t.Fatalf("wrong delays: want %v, got %v", tt.wantSleeps, sleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tt.wantSleeps[i] {
+					t.Errorf("wrong delays: want %v, got %v", tt.wantSleeps, sleeps)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDisabled(t *testing.T) {
+	for _, p := range []*RetryPolicy{nil, NewRetryPolicy("fake", &config.Config{})} {
+		calls := 0
+		p.Do(context.Background(), "fake-op", true, func(context.Context) error {
+			calls++
+			return StatusError(500, 0, errors.New("internal server error"))
+		})
+		if calls != 1 {
+			t.Errorf("expected a single call without retry configuration, got %d", calls)
+		}
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		statuses   []int
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "GET requests are retried until they succeed",
+			method:     http.MethodGet,
+			statuses:   []int{http.StatusBadGateway, http.StatusOK},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:       "POST requests are not retried on server errors",
+			method:     http.MethodPost,
+			statuses:   []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCalls:  1,
+		},
+		{
+			name:       "POST requests are retried with their body when rate limited",
+			method:     http.MethodPost,
+			statuses:   []int{http.StatusTooManyRequests, http.StatusOK},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:       "the last response is returned once the attempts are exhausted",
+			method:     http.MethodDelete,
+			statuses:   []int{500, 500, 500},
+			wantStatus: 500,
+			wantCalls:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				if r.Method == http.MethodPost && string(body) != `{"source":"s3://bucket/file.mov"}` {
+					t.Errorf("wrong body on attempt %d: %q", calls+1, body)
+				}
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer server.Close()
+
+			var sleeps []time.Duration
+			client := &http.Client{Transport: testRetryPolicy(&sleeps).Transport(nil)}
+			req, _ := http.NewRequest(tt.method, server.URL+"/jobs", strings.NewReader(`{"source":"s3://bucket/file.mov"}`))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("wrong status: want %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("wrong number of calls: want %d, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRetryTransportDialError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		body      func() io.Reader
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "requests refused by the server are retried",
+			body:      func() io.Reader { return strings.NewReader(`{"source":"s3://bucket/file.mov"}`) },
+			wantCalls: 2,
+		},
+		{
+			name:      "requests whose body can't be rewound return the dial error",
+			body:      func() io.Reader { return ioutil.NopCloser(strings.NewReader(`{}`)) },
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				if calls == 1 {
+					return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+				}
+				return http.DefaultTransport.RoundTrip(req)
+			})
+
+			var sleeps []time.Duration
+			client := &http.Client{Transport: testRetryPolicy(&sleeps).Transport(base)}
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/jobs", tt.body())
+			resp, err := client.Do(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wrong error: got %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("wrong status: want %d, got %d", http.StatusOK, resp.StatusCode)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("wrong number of calls: want %d, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 7, 1, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"Wed, 01 Jul 2020 02:01:00 GMT", time.Minute},
+		{"Wed, 01 Jul 2020 01:59:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q): want %s, got %s", tt.value, tt.want, got)
+		}
+	}
+}
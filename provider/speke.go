@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/gofrs/uuid"
+)
+
+// AES128SystemID is the SPEKE system ID of clear AES-128 and SAMPLE-AES keys,
+// whose URI is written in HLS playlists
+const AES128SystemID = "3ea8778f-7742-4bf9-b18f-e834b2acbd47"
+
+// ContentKey is a content key requested from a key server
+type ContentKey struct {
+	// KeyID and Key are in hexadecimal
+	KeyID, Key string
+
+	// URL is the URI of the key written in HLS playlists, when given by the
+	// key server
+	URL string
+}
+
+// cpixDocument is the CPIX document of a SPEKE exchange, children inherit the
+// CPIX namespace
+type cpixDocument struct {
+	XMLName     xml.Name         `xml:"urn:dashif:org:cpix CPIX"`
+	ID          string           `xml:"id,attr"`
+	ContentKeys []cpixContentKey `xml:"ContentKeyList>ContentKey"`
+	DRMSystems  []cpixDRMSystem  `xml:"DRMSystemList>DRMSystem"`
+}
+
+type cpixContentKey struct {
+	KID   string `xml:"kid,attr"`
+	Value string `xml:"Data>Secret>PlainValue,omitempty"`
+}
+
+type cpixDRMSystem struct {
+	KID        string `xml:"kid,attr"`
+	SystemID   string `xml:"systemId,attr"`
+	URIExtXKey string `xml:"URIExtXKey"`
+}
+
+// RequestContentKey requests the content key of an encryption from its SPEKE
+// key server. The key ID of the encryption is used when set, a random one
+// otherwise. HLS schemes also request the key URI written in playlists.
+func RequestContentKey(ctx context.Context, client *http.Client, e db.Encryption) (ContentKey, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	kid, err := keyIDFrom(e.KeyID)
+	if err != nil {
+		return ContentKey{}, err
+	}
+
+	request := cpixDocument{ID: e.ResourceID, ContentKeys: []cpixContentKey{{KID: kid}}}
+	if e.Scheme != db.EncryptionSchemeCENC {
+		request.DRMSystems = []cpixDRMSystem{{KID: kid, SystemID: AES128SystemID}}
+	}
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return ContentKey{}, fmt.Errorf("marshalling cpix request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.KeyServerURL, bytes.NewReader(body))
+	if err != nil {
+		return ContentKey{}, err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return ContentKey{}, fmt.Errorf("requesting content key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ContentKey{}, fmt.Errorf("reading content key: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ContentKey{}, fmt.Errorf("requesting content key: key server returned %s", resp.Status)
+	}
+
+	var response cpixDocument
+	if err := xml.Unmarshal(data, &response); err != nil {
+		return ContentKey{}, fmt.Errorf("parsing cpix response: %w", err)
+	}
+	return contentKeyFrom(response, kid)
+}
+
+// contentKeyFrom returns the content key with the given ID of a CPIX response
+func contentKeyFrom(doc cpixDocument, kid string) (ContentKey, error) {
+	var key ContentKey
+	for _, k := range doc.ContentKeys {
+		if !strings.EqualFold(k.KID, kid) {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(k.Value))
+		if err != nil || len(value) != 16 {
+			return ContentKey{}, errors.New("key server returned an invalid content key")
+		}
+		key = ContentKey{KeyID: strings.Replace(kid, "-", "", -1), Key: hex.EncodeToString(value)}
+	}
+	if key.Key == "" {
+		return ContentKey{}, fmt.Errorf("key server returned no content key %s", kid)
+	}
+
+	for _, system := range doc.DRMSystems {
+		if system.SystemID != AES128SystemID || system.URIExtXKey == "" {
+			continue
+		}
+		uri, err := base64.StdEncoding.DecodeString(strings.TrimSpace(system.URIExtXKey))
+		if err != nil {
+			return ContentKey{}, errors.New("key server returned an invalid key uri")
+		}
+		key.URL = string(uri)
+	}
+	return key, nil
+}
+
+// keyIDFrom returns a key ID in hexadecimal as the UUID identifying it in CPIX
+// documents, or a random one
+func keyIDFrom(keyID string) (string, error) {
+	if keyID == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return "", fmt.Errorf("generating key id: %w", err)
+		}
+		return id.String(), nil
+	}
+
+	b, err := hex.DecodeString(keyID)
+	if err != nil {
+		return "", fmt.Errorf("invalid key id %q", keyID)
+	}
+	id, err := uuid.FromBytes(b)
+	if err != nil {
+		return "", fmt.Errorf("invalid key id %q", keyID)
+	}
+	return id.String(), nil
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider/speketest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRequestContentKey(t *testing.T) {
+	const key = "3c1fa5d8b8e5c6a2f0b3d9e1a7c4f6b2"
+	server := speketest.NewServer(key, "skd://keys.example.com/job-1")
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		encryption db.Encryption
+		want       ContentKey
+	}{
+		{
+			name: "hls keys come with their uri",
+			encryption: db.Encryption{
+				Scheme: db.EncryptionSchemeAES128, KeyID: "0123456789abcdef0123456789abcdef",
+			},
+			want: ContentKey{KeyID: "0123456789abcdef0123456789abcdef", Key: key, URL: "skd://keys.example.com/job-1"},
+		},
+		{
+			name:       "cenc keys have no uri",
+			encryption: db.Encryption{Scheme: db.EncryptionSchemeCENC, KeyID: "0123456789abcdef0123456789abcdef"},
+			want:       ContentKey{KeyID: "0123456789abcdef0123456789abcdef", Key: key},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.encryption.KeyServerURL, tt.encryption.ResourceID = server.URL, "job-1"
+			got, err := RequestContentKey(context.Background(), server.Client(), tt.encryption)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wrong content key: %s", diff)
+			}
+		})
+	}
+
+	if g, e := server.ResourceIDs(), []string{"job-1", "job-1"}; !cmp.Equal(g, e) {
+		t.Errorf("wrong resource ids: got %v, expected %v", g, e)
+	}
+
+	random, err := RequestContentKey(context.Background(), server.Client(), db.Encryption{
+		Scheme: db.EncryptionSchemeCENC, KeyServerURL: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(random.KeyID) != 32 {
+		t.Errorf("expected a random key id, got %q", random.KeyID)
+	}
+}
+
+func TestRequestContentKeyErrors(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer failing.Close()
+
+	_, err := RequestContentKey(context.Background(), failing.Client(), db.Encryption{
+		Scheme: db.EncryptionSchemeCENC, KeyServerURL: failing.URL,
+	})
+	if g, e := err, "requesting content key: key server returned 403 Forbidden"; g == nil || g.Error() != e {
+		t.Errorf("wrong error: got %v, expected %q", g, e)
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<CPIX xmlns="urn:dashif:org:cpix" id="job-1"></CPIX>`))
+	}))
+	defer empty.Close()
+
+	_, err = RequestContentKey(context.Background(), empty.Client(), db.Encryption{
+		Scheme: db.EncryptionSchemeCENC, KeyServerURL: empty.URL, KeyID: "0123456789abcdef0123456789abcdef",
+	})
+	if g, e := err, "key server returned no content key 01234567-89ab-cdef-0123-456789abcdef"; g == nil || g.Error() != e {
+		t.Errorf("wrong error: got %v, expected %q", g, e)
+	}
+}
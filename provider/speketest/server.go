@@ -0,0 +1,87 @@
+package speketest
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+type document struct {
+	XMLName     xml.Name     `xml:"urn:dashif:org:cpix CPIX"`
+	ID          string       `xml:"id,attr"`
+	ContentKeys []contentKey `xml:"ContentKeyList>ContentKey"`
+	DRMSystems  []drmSystem  `xml:"DRMSystemList>DRMSystem"`
+}
+
+type contentKey struct {
+	KID   string `xml:"kid,attr"`
+	Value string `xml:"Data>Secret>PlainValue,omitempty"`
+}
+
+type drmSystem struct {
+	KID        string `xml:"kid,attr"`
+	SystemID   string `xml:"systemId,attr"`
+	URIExtXKey string `xml:"URIExtXKey"`
+}
+
+// Server is a stand-in SPEKE key server answering every CPIX request with the
+// same content key, and with the same key URI for HLS systems
+type Server struct {
+	*httptest.Server
+
+	key    []byte
+	keyURL string
+
+	mu          sync.Mutex
+	resourceIDs []string
+}
+
+// NewServer starts a key server returning key, in hexadecimal, and keyURL. It
+// must be closed once done.
+func NewServer(key, keyURL string) *Server {
+	k, err := hex.DecodeString(key)
+	if err != nil {
+		panic("speketest: invalid key " + key)
+	}
+	s := &Server{key: k, keyURL: keyURL}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveCPIX))
+	return s
+}
+
+// ResourceIDs returns the resource IDs keys were requested for
+func (s *Server) ResourceIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.resourceIDs...)
+}
+
+func (s *Server) serveCPIX(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "cpix documents must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var doc document
+	if err := xml.NewDecoder(r.Body).Decode(&doc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.resourceIDs = append(s.resourceIDs, doc.ID)
+	s.mu.Unlock()
+
+	for i := range doc.ContentKeys {
+		doc.ContentKeys[i].Value = base64.StdEncoding.EncodeToString(s.key)
+	}
+	for i := range doc.DRMSystems {
+		doc.DRMSystems[i].URIExtXKey = base64.StdEncoding.EncodeToString([]byte(s.keyURL))
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	if err := xml.NewEncoder(w).Encode(doc); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
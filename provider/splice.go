@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/cbsinteractive/pkg/timecode"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/smpte"
+)
+
+// ValidateSplice checks that the ranges of a splice are in order, don't overlap
+// and fall within the source, when its duration is known
+func ValidateSplice(splice timecode.Splice, src db.File) error {
+	for i, r := range splice {
+		if r[0] < 0 {
+			return fmt.Errorf("splice range #%d %s starts before the source", i, r)
+		}
+		if r[1] <= r[0] {
+			return fmt.Errorf("splice range #%d %s must start before it ends", i, r)
+		}
+		if i > 0 && r[0] < splice[i-1][1] {
+			return fmt.Errorf("splice range #%d %s overlaps or precedes range #%d %s", i, r, i-1, splice[i-1])
+		}
+		if src.Duration > 0 && r[1] > src.Duration.Seconds() {
+			return fmt.Errorf("splice range #%d %s ends after the source, which is %s long", i, r, src.Duration)
+		}
+	}
+	return nil
+}
+
+// FrameAccurateSplice moves the bounds of the ranges of a splice to the start of
+// the closest frames at a frame rate, so the cuts don't fall between frames. The
+// splice is returned as is when the frame rate is unknown.
+func FrameAccurateSplice(splice timecode.Splice, fps float64) timecode.Splice {
+	if fps <= 0 || len(splice) == 0 {
+		return splice
+	}
+
+	snapped := make(timecode.Splice, len(splice))
+	for i, r := range splice {
+		snapped[i] = timecode.Range{
+			smpte.Seconds(smpte.FrameAt(r[0], fps), fps),
+			smpte.Seconds(smpte.FrameAt(r[1], fps), fps),
+		}
+	}
+	return snapped
+}
+
+// SplicedOffsets maps offsets in the source onto the timeline of its splice, where
+// the ranges follow each other. Offsets in parts of the source that are cut out are
+// dropped, and offsets are returned as is when the source isn't spliced.
+func SplicedOffsets(offsets []float64, splice timecode.Splice) []float64 {
+	if len(splice) == 0 {
+		return offsets
+	}
+
+	var mapped []float64
+	for _, offset := range offsets {
+		var start float64
+		for _, r := range splice {
+			if offset >= r[0] && offset < r[1] {
+				mapped = append(mapped, start+offset-r[0])
+				break
+			}
+			start += r[1] - r[0]
+		}
+	}
+	return mapped
+}
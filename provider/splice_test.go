@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cbsinteractive/pkg/timecode"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateSplice(t *testing.T) {
+	tests := []struct {
+		name    string
+		splice  timecode.Splice
+		src     db.File
+		wantErr string
+	}{
+		{
+			name:   "ordered ranges within the source are valid",
+			splice: timecode.Splice{{0, 5}, {5, 10}, {20, 30}},
+			src:    db.File{Duration: 30 * time.Second},
+		},
+		{
+			name:   "ranges can't be checked against a source of unknown duration",
+			splice: timecode.Splice{{100, 200}},
+		},
+		{
+			name:    "reversed ranges are rejected",
+			splice:  timecode.Splice{{10, 5}},
+			wantErr: "splice range #0 (10s-5s) must start before it ends",
+		},
+		{
+			name:    "negative ranges are rejected",
+			splice:  timecode.Splice{{-1, 5}},
+			wantErr: "splice range #0 (-1s-5s) starts before the source",
+		},
+		{
+			name:    "overlapping ranges are rejected",
+			splice:  timecode.Splice{{0, 5}, {4, 10}},
+			wantErr: "splice range #1 (4s-10s) overlaps or precedes range #0 (0s-5s)",
+		},
+		{
+			name:    "ranges out of order are rejected",
+			splice:  timecode.Splice{{20, 30}, {0, 5}},
+			wantErr: "splice range #1 (0s-5s) overlaps or precedes range #0 (20s-30s)",
+		},
+		{
+			name:    "ranges past the end of the source are rejected",
+			splice:  timecode.Splice{{20, 31}},
+			src:     db.File{Duration: 30 * time.Second},
+			wantErr: "splice range #0 (20s-31s) ends after the source, which is 30s long",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSplice(tt.splice, tt.src)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFrameAccurateSplice(t *testing.T) {
+	splice := timecode.Splice{{1.01, 2.5}}
+
+	if diff := cmp.Diff(splice, FrameAccurateSplice(splice, 0)); diff != "" {
+		t.Errorf("splice was modified without a frame rate: %s", diff)
+	}
+	if diff := cmp.Diff(timecode.Splice{{1, 2.52}}, FrameAccurateSplice(splice, 25)); diff != "" {
+		t.Errorf("wrong splice: %s", diff)
+	}
+}
+
+func TestSplicedOffsets(t *testing.T) {
+	offsets := []float64{0, 4, 12, 25, 31}
+
+	if diff := cmp.Diff(offsets, SplicedOffsets(offsets, nil)); diff != "" {
+		t.Errorf("offsets were modified without a splice: %s", diff)
+	}
+
+	got := SplicedOffsets(offsets, timecode.Splice{{2, 5}, {10, 30}})
+	if diff := cmp.Diff([]float64{2, 5, 18}, got); diff != "" {
+		t.Errorf("wrong offsets: %s", diff)
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// Types of the events streamed on /jobs/{jobId}/events. The data of status,
+// progress and output events is the current status of the job.
+const (
+	jobEventStatus   = "status"
+	jobEventProgress = "progress"
+	jobEventOutput   = "output"
+	jobEventError    = "error"
+)
+
+// subscriberBuffer is the number of events kept for a subscriber that is slow
+// to consume them, newer events are dropped once it fills up
+const subscriberBuffer = 16
+
+type jobEvent struct {
+	Type string
+	Data interface{}
+}
+
+// jobPoller polls the status of the jobs being watched and fans the changes out
+// to every subscriber, so the provider is queried once per interval regardless
+// of the number of subscribers of a job
+type jobPoller struct {
+	interval time.Duration
+	fetch    func(ctx context.Context, jobID string) (*provider.JobStatus, error)
+
+	mu      sync.Mutex
+	watches map[string]*jobWatch
+}
+
+type jobWatch struct {
+	subs map[chan jobEvent]struct{}
+	last *provider.JobStatus
+}
+
+func newJobPoller(interval time.Duration, fetch func(context.Context, string) (*provider.JobStatus, error)) *jobPoller {
+	return &jobPoller{
+		interval: interval,
+		fetch:    fetch,
+		watches:  make(map[string]*jobWatch),
+	}
+}
+
+// subscribe returns a channel with the events of the given job and a function
+// to stop receiving them. The channel is closed once the job reaches a terminal
+// status.
+func (p *jobPoller) subscribe(jobID string) (<-chan jobEvent, func()) {
+	ch := make(chan jobEvent, subscriberBuffer)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w, ok := p.watches[jobID]
+	if !ok {
+		w = &jobWatch{subs: make(map[chan jobEvent]struct{})}
+		p.watches[jobID] = w
+		go p.poll(jobID, w)
+	}
+	w.subs[ch] = struct{}{}
+	if w.last != nil {
+		ch <- jobEvent{Type: jobEventStatus, Data: w.last}
+	}
+
+	return ch, func() { p.unsubscribe(jobID, w, ch) }
+}
+
+func (p *jobPoller) unsubscribe(jobID string, w *jobWatch, ch chan jobEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := w.subs[ch]; !ok {
+		return
+	}
+	delete(w.subs, ch)
+	close(ch)
+	if len(w.subs) == 0 && p.watches[jobID] == w {
+		delete(p.watches, jobID)
+	}
+}
+
+func (p *jobPoller) poll(jobID string, w *jobWatch) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		status, err := p.fetch(context.Background(), jobID)
+		if !p.publish(jobID, w, status, err) {
+			return
+		}
+		<-ticker.C
+	}
+}
+
+// publish sends the events derived from the latest poll to the subscribers of
+// the job, and reports whether the job should keep being polled
+func (p *jobPoller) publish(jobID string, w *jobWatch, status *provider.JobStatus, err error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(w.subs) == 0 {
+		return false
+	}
+
+	var events []jobEvent
+	done := false
+	if err != nil {
+		events = append(events, jobEvent{Type: jobEventError, Data: map[string]string{"error": err.Error()}})
+		_, notFound := err.(provider.JobNotFoundError)
+		done = err == db.ErrJobNotFound || notFound
+	} else {
+		events = statusEvents(w.last, status)
+		w.last = status
+		done = status.Status.Terminal()
+	}
+
+	for ch := range w.subs {
+		for _, event := range events {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+
+	if done {
+		for ch := range w.subs {
+			close(ch)
+		}
+		w.subs = nil
+		if p.watches[jobID] == w {
+			delete(p.watches, jobID)
+		}
+	}
+	return !done
+}
+
+// statusEvents returns the events describing what changed between two
+// statuses of a job
+func statusEvents(prev, cur *provider.JobStatus) []jobEvent {
+	if prev == nil {
+		return []jobEvent{{Type: jobEventStatus, Data: cur}}
+	}
+	var events []jobEvent
+	if prev.Status != cur.Status || prev.StatusMessage != cur.StatusMessage {
+		events = append(events, jobEvent{Type: jobEventStatus, Data: cur})
+	}
+	if prev.Progress != cur.Progress {
+		events = append(events, jobEvent{Type: jobEventProgress, Data: cur})
+	}
+	if !reflect.DeepEqual(prev.Output, cur.Output) {
+		events = append(events, jobEvent{Type: jobEventOutput, Data: cur})
+	}
+	return events
+}
+
+func (s *TranscodingService) fetchJobStatus(ctx context.Context, jobID string) (*provider.JobStatus, error) {
+	_, status, _, err := s.getTranscodeJobByID(ctx, jobID)
+	return status, err
+}
+
+// swagger:route GET /jobs/{jobId}/events jobs jobEvents
+//
+// Streams the status, progress and output events of a job as Server-Sent
+// Events. The stream is closed once the job reaches a terminal status.
+//
+//     Produces:
+//     - text/event-stream
+//
+//     Responses:
+//       404: jobNotFound
+//       500: genericError
+func (s *TranscodingService) jobEvents(w http.ResponseWriter, r *http.Request) {
+	var params getTranscodeJobInput
+	params.loadParams(server.Vars(r))
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	_, err := s.db.GetJob(params.JobID)
+	if err != nil {
+		if err == db.ErrJobNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := s.poller.subscribe(params.JobID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				s.logger.WithError(err).WithField("job_id", params.JobID).Error("encoding job event")
+				continue
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/db/dbtest"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/sirupsen/logrus"
+)
+
+func TestJobPoller(t *testing.T) {
+	statuses := []provider.JobStatus{
+		{Status: provider.StatusQueued},
+		{Status: provider.StatusStarted, Progress: 10},
+		{Status: provider.StatusStarted, Progress: 10},
+		{Status: provider.StatusStarted, Progress: 60},
+		{Status: provider.StatusFinished, Progress: 100, Output: provider.JobOutput{Destination: "s3://bucket/job-123"}},
+	}
+
+	start := make(chan struct{})
+	var mu sync.Mutex
+	calls := 0
+	fetch := func(_ context.Context, jobID string) (*provider.JobStatus, error) {
+		<-start
+		mu.Lock()
+		defer mu.Unlock()
+		status := statuses[calls]
+		calls++
+		return &status, nil
+	}
+
+	p := newJobPoller(time.Millisecond, fetch)
+	first, unsubscribeFirst := p.subscribe("job-123")
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := p.subscribe("job-123")
+	defer unsubscribeSecond()
+	close(start)
+
+	collect := func(events <-chan jobEvent) []string {
+		var types []string
+		for event := range events {
+			types = append(types, event.Type)
+		}
+		return types
+	}
+	var wg sync.WaitGroup
+	var gotFirst, gotSecond []string
+	wg.Add(2)
+	go func() { defer wg.Done(); gotFirst = collect(first) }()
+	go func() { defer wg.Done(); gotSecond = collect(second) }()
+	wg.Wait()
+
+	want := []string{
+		jobEventStatus,
+		jobEventStatus, jobEventProgress,
+		jobEventProgress,
+		jobEventStatus, jobEventProgress, jobEventOutput,
+	}
+	if !reflect.DeepEqual(gotFirst, want) {
+		t.Errorf("wrong events for the first subscriber\nwant %v\ngot  %v", want, gotFirst)
+	}
+	if !reflect.DeepEqual(gotSecond, want) {
+		t.Errorf("wrong events for the second subscriber\nwant %v\ngot  %v", want, gotSecond)
+	}
+	if calls != len(statuses) {
+		t.Errorf("wrong number of status calls: want %d, got %d", len(statuses), calls)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.watches) != 0 {
+		t.Errorf("finished job is still being watched: %v", p.watches)
+	}
+}
+
+func TestJobEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		jobID    string
+		wantCode int
+		wantBody []string
+	}{
+		{
+			name:     "streams the status of a job until it is finished",
+			jobID:    "job-123",
+			wantCode: http.StatusOK,
+			wantBody: []string{"event: status\n", `"status":"finished"`, `"providerName":"fake"`},
+		},
+		{
+			name:     "unknown job",
+			jobID:    "job-unknown",
+			wantCode: http.StatusNotFound,
+			wantBody: []string{db.ErrJobNotFound.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srvr := server.NewSimpleServer(&server.Config{})
+			fakeDBObj := dbtest.NewFakeRepository(false)
+			fakeDBObj.CreateJob(&db.Job{
+				ID:            "job-123",
+				ProviderName:  "fake",
+				ProviderJobID: "provider-job-123",
+			})
+			service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+			service.db = fakeDBObj
+			srvr.Register(service)
+
+			r, _ := http.NewRequest("GET", "/jobs/"+tt.jobID+"/events", nil)
+			r.Header.Set("Accept", "text/event-stream")
+			w := httptest.NewRecorder()
+			srvr.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("wrong code: want %d, got %d", tt.wantCode, w.Code)
+			}
+			body := w.Body.String()
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("missing %q from the response body:\n%s", want, body)
+				}
+			}
+			if tt.wantCode == http.StatusOK {
+				if g, e := w.Header().Get("Content-Type"), "text/event-stream"; g != e {
+					t.Errorf("wrong content type: want %q, got %q", e, g)
+				}
+			}
+		})
+	}
+}
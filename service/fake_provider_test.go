@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/cbsinteractive/transcode-orchestrator/config"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
@@ -50,6 +51,16 @@ func (*fakeProvider) DeletePreset(_ context.Context, presetID string) error {
 	return nil
 }
 
+func (*fakeProvider) SyncPreset(_ context.Context, presetID string, dryRun bool) (provider.PresetSync, error) {
+	if presetID == "unsyncable" {
+		return provider.PresetSync{}, errors.New("native presets are disabled")
+	}
+	if dryRun {
+		return provider.PresetSync{PresetID: presetID, Status: provider.PresetSyncDrifted, Drift: []string{"Bitrate"}}, nil
+	}
+	return provider.PresetSync{PresetID: presetID, Status: provider.PresetSyncUpdated, Drift: []string{"Bitrate"}}, nil
+}
+
 func (p *fakeProvider) JobStatus(_ context.Context, job *db.Job) (*provider.JobStatus, error) {
 	id := job.ProviderJobID
 	if id == "provider-job-123" {
@@ -32,14 +32,9 @@ func (s *TranscodingService) deletePreset(r *http.Request) swagger.GizmoJSONResp
 		output.PresetMap = "couldn't retrieve: " + err.Error()
 	} else {
 		for p, presetID := range presetmap.ProviderMapping {
-			providerFactory, ierr := provider.GetProviderFactory(p)
+			providerObj, ierr := s.providers.Get(p)
 			if ierr != nil {
-				output.Results[p] = deletePresetOutput{PresetID: "", Error: "getting factory: " + ierr.Error()}
-				continue
-			}
-			providerObj, ierr := providerFactory(s.config)
-			if ierr != nil {
-				output.Results[p] = deletePresetOutput{PresetID: "", Error: "initializing provider: " + ierr.Error()}
+				output.Results[p] = deletePresetOutput{PresetID: "", Error: providerErrorMessage(ierr)}
 				continue
 			}
 			ierr = providerObj.DeletePreset(r.Context(), presetID)
@@ -118,14 +113,9 @@ func (s *TranscodingService) newPreset(r *http.Request) swagger.GizmoJSONRespons
 	}
 
 	for _, p := range providers {
-		providerFactory, ierr := provider.GetProviderFactory(p)
+		providerObj, ierr := s.providers.Get(p)
 		if ierr != nil {
-			output.Results[p] = newPresetOutput{PresetID: "", Error: "getting factory: " + ierr.Error()}
-			continue
-		}
-		providerObj, ierr := providerFactory(s.config)
-		if ierr != nil {
-			output.Results[p] = newPresetOutput{PresetID: "", Error: "initializing provider: " + ierr.Error()}
+			output.Results[p] = newPresetOutput{PresetID: "", Error: providerErrorMessage(ierr)}
 			continue
 		}
 		presetID, ierr := providerObj.CreatePreset(r.Context(), input.Preset)
@@ -175,3 +165,10 @@ func (s *TranscodingService) getMissingProviders(inputProviders []string, provid
 	}
 	return missingProviders
 }
+
+func providerErrorMessage(err error) string {
+	if err == provider.ErrProviderNotFound {
+		return "getting factory: " + err.Error()
+	}
+	return "initializing provider: " + err.Error()
+}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/NYTimes/gizmo/server"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/provider"
 	"github.com/cbsinteractive/transcode-orchestrator/swagger"
 )
@@ -17,7 +20,7 @@ import (
 //       200: listProviders
 //       500: genericError
 func (s *TranscodingService) listProviders(*http.Request) swagger.GizmoJSONResponse {
-	return newListProvidersResponse(provider.ListProviders(s.config))
+	return newListProvidersResponse(s.providers.List())
 }
 
 // swagger:route GET /providers/{name} providers getProvider
@@ -32,7 +35,7 @@ func (s *TranscodingService) listProviders(*http.Request) swagger.GizmoJSONRespo
 func (s *TranscodingService) getProvider(r *http.Request) swagger.GizmoJSONResponse {
 	var params getProviderInput
 	params.loadParams(server.Vars(r))
-	description, err := provider.DescribeProvider(params.Name, s.config)
+	description, err := s.providers.Describe(params.Name)
 	switch err {
 	case nil:
 		return newGetProviderResponse(description)
@@ -42,3 +45,90 @@ func (s *TranscodingService) getProvider(r *http.Request) swagger.GizmoJSONRespo
 		return swagger.NewErrorResponse(err)
 	}
 }
+
+// swagger:route POST /providers/{name}/presets/sync providers syncProviderPresets
+//
+// Compares the copies of the presets held by a provider with the stored
+// presets, creating the missing copies and overwriting the ones that drifted,
+// unless it's a dry run.
+//
+//     Responses:
+//       200: presetSyncs
+//       400: invalidPresetSync
+//       404: providerNotFound
+//       500: genericError
+func (s *TranscodingService) syncProviderPresets(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var params syncProviderPresetsInput
+	err := params.loadParams(server.Vars(r), r.Body)
+	if err != nil {
+		return newInvalidPresetSyncResponse(err)
+	}
+
+	p, err := s.providers.Get(params.Name)
+	switch err {
+	case nil:
+	case provider.ErrProviderNotFound:
+		return newProviderNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+	syncer, ok := provider.AsPresetSyncer(p)
+	if !ok {
+		return newInvalidPresetSyncResponse(fmt.Errorf("provider %q doesn't hold copies of the presets", params.Name))
+	}
+
+	presetIDs, err := s.providerPresetIDs(params.Name, params.Payload.Presets)
+	if err != nil {
+		if err == db.ErrPresetMapNotFound {
+			return newPresetMapNotFoundResponse(err)
+		}
+		return newInvalidPresetSyncResponse(err)
+	}
+
+	results := make([]provider.PresetSync, len(presetIDs))
+	for i, id := range presetIDs {
+		sync, err := syncer.SyncPreset(r.Context(), id, params.Payload.DryRun)
+		if err != nil {
+			sync = provider.PresetSync{PresetID: id, Error: err.Error()}
+		}
+		results[i] = sync
+	}
+	return newPresetSyncsResponse(results)
+}
+
+// providerPresetIDs returns the IDs of the given presets on a provider, or of
+// every preset mapped to the provider when none is given
+func (s *TranscodingService) providerPresetIDs(providerName string, names []string) ([]string, error) {
+	var presetMaps []db.PresetMap
+	if len(names) == 0 {
+		all, err := s.db.ListPresetMaps()
+		if err != nil {
+			return nil, err
+		}
+		for _, presetMap := range all {
+			if _, ok := presetMap.ProviderMapping[providerName]; ok {
+				presetMaps = append(presetMaps, presetMap)
+			}
+		}
+	}
+	for _, name := range names {
+		presetMap, err := s.db.GetPresetMap(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := presetMap.ProviderMapping[providerName]; !ok {
+			return nil, fmt.Errorf("preset %q isn't mapped to provider %q", name, providerName)
+		}
+		presetMaps = append(presetMaps, *presetMap)
+	}
+
+	ids := make([]string, len(presetMaps))
+	for i, presetMap := range presetMaps {
+		ids[i] = presetMap.ProviderMapping[providerName]
+	}
+	if len(names) == 0 {
+		sort.Strings(ids)
+	}
+	return ids, nil
+}
@@ -1,5 +1,10 @@
 package service
 
+import (
+	"encoding/json"
+	"io"
+)
+
 // swagger:parameters getProvider deleteProvider
 type getProviderInput struct {
 	// in: path
@@ -10,3 +15,27 @@ type getProviderInput struct {
 func (p *getProviderInput) loadParams(paramsMap map[string]string) {
 	p.Name = paramsMap["name"]
 }
+
+// swagger:parameters syncProviderPresets
+type syncProviderPresetsInput struct {
+	getProviderInput
+
+	// in: body
+	Payload struct {
+		// DryRun reports the presets that drifted without overwriting them
+		DryRun bool `json:"dryRun"`
+
+		// Presets are the names of the presets to synchronize, all the presets
+		// mapped to the provider are synchronized when empty
+		Presets []string `json:"presets,omitempty"`
+	}
+}
+
+func (p *syncProviderPresetsInput) loadParams(paramsMap map[string]string, body io.Reader) error {
+	p.getProviderInput.loadParams(paramsMap)
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
@@ -55,3 +55,36 @@ func newProviderNotFoundResponse(err error) *providerNotFoundResponse {
 func (r *providerNotFoundResponse) Result() (int, interface{}, error) {
 	return r.Error.Result()
 }
+
+// response for the syncProviderPresets operation, listing the outcome of the
+// synchronization of each preset.
+//
+// swagger:response presetSyncs
+type presetSyncsResponse struct {
+	// in: body
+	Results []provider.PresetSync
+
+	baseResponse
+}
+
+func newPresetSyncsResponse(results []provider.PresetSync) *presetSyncsResponse {
+	return &presetSyncsResponse{
+		baseResponse: baseResponse{payload: results, status: http.StatusOK},
+	}
+}
+
+// error returned when the presets of a provider can't be synchronized.
+//
+// swagger:response invalidPresetSync
+type invalidPresetSyncResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newInvalidPresetSyncResponse(err error) *invalidPresetSyncResponse {
+	return &invalidPresetSyncResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidPresetSyncResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,10 @@ import (
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/db/dbtest"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 )
 
@@ -89,3 +94,91 @@ func TestGetProvider(t *testing.T) {
 		}
 	}
 }
+
+func TestSyncProviderPresets(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		body     string
+
+		wantCode    int
+		wantResults []provider.PresetSync
+	}{
+		{
+			name:     "every preset of the provider is synchronized",
+			provider: "fake",
+			wantCode: http.StatusOK,
+			wantResults: []provider.PresetSync{
+				{PresetID: "preset-1-id", Status: provider.PresetSyncUpdated, Drift: []string{"Bitrate"}},
+				{PresetID: "preset-2-id", Status: provider.PresetSyncUpdated, Drift: []string{"Bitrate"}},
+				{PresetID: "unsyncable", Error: "native presets are disabled"},
+			},
+		},
+		{
+			name:     "dry runs of the given presets",
+			provider: "fake",
+			body:     `{"dryRun": true, "presets": ["preset-2"]}`,
+			wantCode: http.StatusOK,
+			wantResults: []provider.PresetSync{
+				{PresetID: "preset-2-id", Status: provider.PresetSyncDrifted, Drift: []string{"Bitrate"}},
+			},
+		},
+		{
+			name:     "presets not mapped to the provider",
+			provider: "fake",
+			body:     `{"presets": ["preset-other"]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown presets",
+			provider: "fake",
+			body:     `{"presets": ["preset-unknown"]}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "unknown providers",
+			provider: "whatever",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDB := dbtest.NewFakeRepository(false)
+			for name, mapping := range map[string]map[string]string{
+				"preset-2":     {"fake": "preset-2-id"},
+				"preset-1":     {"fake": "preset-1-id", "zencoder": "z-1"},
+				"preset-3":     {"fake": "unsyncable"},
+				"preset-other": {"zencoder": "z-2"},
+			} {
+				fakeDB.CreatePresetMap(&db.PresetMap{Name: name, ProviderMapping: mapping})
+			}
+
+			srvr := server.NewSimpleServer(&server.Config{})
+			service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+			service.db = fakeDB
+			srvr.Register(service)
+
+			r, _ := http.NewRequest("POST", "/providers/"+tt.provider+"/presets/sync", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			srvr.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Fatalf("wrong status code. Want %d. Got %d: %s", tt.wantCode, w.Code, w.Body)
+			}
+			if tt.wantResults == nil {
+				return
+			}
+
+			var results []provider.PresetSync
+			if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantResults, results); diff != "" {
+				t.Errorf("wrong results: %s", diff)
+			}
+		})
+	}
+}
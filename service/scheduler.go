@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/event"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// scheduler submits scheduled jobs to their providers once their NotBefore
+// time is reached
+type scheduler struct {
+	svc        *TranscodingService
+	now        func() time.Time
+	providerOf func(name string) (provider.TranscodingProvider, error)
+
+	// saveDelay is the delay between the attempts to save a submitted job
+	saveDelay time.Duration
+
+	// claimTimeout is the time after which a job left submitting is put back
+	// on the schedule, zero disables it
+	claimTimeout time.Duration
+
+	// unsaved holds the jobs submitted to their provider that couldn't be
+	// saved, by id, saving them is retried on the next runs
+	unsaved map[string]submittedJob
+}
+
+type submittedJob struct {
+	job    *db.Job
+	status *provider.JobStatus
+}
+
+// submittedJobSaveAttempts is the number of attempts made to save a job once
+// submitted to its provider, its provider job id being lost otherwise
+const submittedJobSaveAttempts = 3
+
+func newScheduler(svc *TranscodingService) *scheduler {
+	sc := &scheduler{
+		svc:        svc,
+		now:        time.Now,
+		providerOf: svc.providers.Get,
+		saveDelay:  500 * time.Millisecond,
+		unsaved:    make(map[string]submittedJob),
+	}
+	if cfg := svc.config.Scheduler; cfg != nil {
+		sc.claimTimeout = seconds(cfg.ClaimTimeout)
+	}
+	return sc
+}
+
+// RunScheduler submits due scheduled jobs at the configured interval until the
+// context is done. It returns right away when no interval is configured.
+func (s *TranscodingService) RunScheduler(ctx context.Context) {
+	cfg := s.config.Scheduler
+	if cfg == nil || cfg.Interval == 0 {
+		return
+	}
+
+	sc := newScheduler(s)
+	ticker := time.NewTicker(seconds(cfg.Interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sc.run(ctx)
+		}
+	}
+}
+
+func (sc *scheduler) run(ctx context.Context) {
+	// unsaved jobs are saved before stale claims are requeued, they would be
+	// submitted again otherwise
+	sc.saveUnsaved(ctx)
+	sc.requeueStale()
+
+	now := sc.now()
+	jobs, err := sc.svc.db.ListScheduledJobs(now)
+	if err != nil {
+		sc.svc.logger.WithError(err).Error("scheduler: listing scheduled jobs")
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		claimed, err := sc.svc.db.ClaimScheduledJob(job.ID, now)
+		if err != nil {
+			sc.svc.logger.WithError(err).WithField("job_id", job.ID).Error("scheduler: claiming job")
+			continue
+		}
+		if !claimed {
+			// another instance got to it first, or the job was canceled
+			continue
+		}
+		job.State = db.JobStateSubmitting
+		job.ClaimedAt = now
+		err = sc.submit(ctx, job)
+		if err != nil {
+			sc.fail(ctx, job, err)
+		}
+	}
+}
+
+func (sc *scheduler) submit(ctx context.Context, job *db.Job) error {
+	prov, err := sc.providerOf(job.ProviderName)
+	if err != nil {
+		return fmt.Errorf("initializing provider %q: %w", job.ProviderName, err)
+	}
+	status, providerName, err := sc.svc.transcode(ctx, prov, job.ProviderName, job)
+	if err != nil {
+		return fmt.Errorf("error with provider %q: %w", providerName, err)
+	}
+
+	job.ProviderName = providerName
+	job.ProviderJobID = status.ProviderJobID
+	job.State = ""
+	job.StateMessage = ""
+	job.ClaimedAt = time.Time{}
+	err = sc.saveSubmitted(job)
+	if err != nil {
+		// the job runs at the provider, so it isn't flagged as failed, it
+		// stays stored as submitting until it's saved on a later run
+		sc.svc.logger.WithError(err).WithField("job_id", job.ID).
+			WithField("provider_job_id", job.ProviderJobID).Error("scheduler: saving submitted job")
+		if sc.svc.errReporter != nil {
+			sc.svc.errReporter.ReportException(fmt.Errorf("saving scheduled job %q submitted as %q: %w", job.ID, job.ProviderJobID, err))
+		}
+		sc.unsaved[job.ID] = submittedJob{job: job, status: status}
+		return nil
+	}
+	sc.svc.publish(ctx, event.New(event.TypeSubmitted, job).WithStatus(status))
+	return nil
+}
+
+// saveUnsaved retries saving the jobs submitted on previous runs
+func (sc *scheduler) saveUnsaved(ctx context.Context) {
+	for id, submitted := range sc.unsaved {
+		err := sc.svc.db.UpdateJob(submitted.job)
+		if err != nil {
+			sc.svc.logger.WithError(err).WithField("job_id", id).Error("scheduler: saving submitted job")
+			continue
+		}
+		delete(sc.unsaved, id)
+		sc.svc.publish(ctx, event.New(event.TypeSubmitted, submitted.job).WithStatus(submitted.status))
+	}
+}
+
+// requeueStale puts the jobs claimed longer than the claim timeout ago back on
+// the schedule, the instance submitting them having stopped. A job submitted
+// to its provider right before its instance stopped is submitted again.
+func (sc *scheduler) requeueStale() {
+	if sc.claimTimeout == 0 {
+		return
+	}
+	ids, err := sc.svc.db.RequeueClaimedJobs(sc.now().Add(-sc.claimTimeout))
+	if err != nil {
+		sc.svc.logger.WithError(err).Error("scheduler: requeuing claimed jobs")
+	}
+	for _, id := range ids {
+		sc.svc.logger.WithField("job_id", id).Warn("scheduler: requeued job left submitting")
+	}
+}
+
+func (sc *scheduler) saveSubmitted(job *db.Job) error {
+	var err error
+	for attempt := 1; attempt <= submittedJobSaveAttempts; attempt++ {
+		if err = sc.svc.db.UpdateJob(job); err == nil {
+			return nil
+		}
+		if attempt < submittedJobSaveAttempts {
+			time.Sleep(sc.saveDelay)
+		}
+	}
+	return err
+}
+
+func (sc *scheduler) fail(ctx context.Context, job *db.Job, err error) {
+	e := event.New(event.TypeFailed, job)
+	e.Error = err.Error()
+	sc.svc.publish(ctx, e)
+	if sc.svc.errReporter != nil {
+		sc.svc.errReporter.ReportException(fmt.Errorf("submitting scheduled job %q: %w", job.ID, err))
+	}
+	job.State = db.JobStateFailed
+	job.StateMessage = err.Error()
+	job.ClaimedAt = time.Time{}
+	err = sc.svc.db.UpdateJob(job)
+	if err != nil {
+		sc.svc.logger.WithError(err).WithField("job_id", job.ID).Error("scheduler: flagging failed job")
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/db/dbtest"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScheduler(t *testing.T) {
+	now := time.Date(2020, 7, 1, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		notBefore    time.Time
+		claimedAt    time.Time
+		factoryErr   error
+		wantState    db.JobState
+		wantSubmit   bool
+		wantReported bool
+	}{
+		{
+			name:       "a due job is submitted to its provider",
+			notBefore:  now.Add(-time.Minute),
+			wantSubmit: true,
+		},
+		{
+			name:      "a job that is not due yet is left alone",
+			notBefore: now.Add(time.Minute),
+			wantState: db.JobStateScheduled,
+		},
+		{
+			name:      "a job claimed elsewhere is not submitted",
+			notBefore: now.Add(-time.Minute),
+			claimedAt: now.Add(-time.Minute),
+			wantState: db.JobStateSubmitting,
+		},
+		{
+			name:       "a job left submitting past the claim timeout is submitted again",
+			notBefore:  now.Add(-time.Hour),
+			claimedAt:  now.Add(-time.Hour),
+			wantSubmit: true,
+		},
+		{
+			name:         "a job that can't be submitted is flagged as failed",
+			notBefore:    now.Add(-time.Minute),
+			factoryErr:   errors.New("provider not found"),
+			wantState:    db.JobStateFailed,
+			wantReported: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fprovider.jobs = nil
+
+			repo := dbtest.NewFakeRepository(false)
+			job := db.Job{
+				ID:           "job-123",
+				ProviderName: "fake",
+				NotBefore:    tt.notBefore,
+				State:        db.JobStateScheduled,
+			}
+			if err := repo.CreateJob(&job); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.claimedAt.IsZero() {
+				if _, err := repo.ClaimScheduledJob(job.ID, tt.claimedAt); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			svc, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+			reporter := &fakeReporter{}
+			svc.db = repo
+			svc.errReporter = reporter
+
+			sc := newScheduler(svc)
+			sc.now = func() time.Time { return now }
+			sc.claimTimeout = 10 * time.Minute
+			if tt.factoryErr != nil {
+				sc.providerOf = func(string) (provider.TranscodingProvider, error) {
+					return nil, tt.factoryErr
+				}
+			}
+			sc.run(context.Background())
+
+			got, err := repo.GetJob(job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g, e := got.State, tt.wantState; g != e {
+				t.Errorf("wrong state: got %q, expected %q", g, e)
+			}
+			if g, e := len(fprovider.jobs) > 0, tt.wantSubmit; g != e {
+				t.Errorf("job submitted: got %t, expected %t", g, e)
+			}
+			if tt.wantSubmit && got.ProviderJobID != "provider-preset-job-123" {
+				t.Errorf("wrong provider job id: got %q", got.ProviderJobID)
+			}
+			if g, e := len(reporter.errs) > 0, tt.wantReported; g != e {
+				t.Errorf("failure reported: got %t, expected %t", g, e)
+			}
+			if tt.wantState == db.JobStateFailed && got.StateMessage == "" {
+				t.Error("expected the failure to be recorded on the job")
+			}
+		})
+	}
+}
+
+// failingUpdates fails the first updates of jobs
+type failingUpdates struct {
+	db.Repository
+	failures int
+}
+
+func (r *failingUpdates) UpdateJob(job *db.Job) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("database error")
+	}
+	return r.Repository.UpdateJob(job)
+}
+
+func TestSchedulerSaveFailures(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		wantSubmitted bool
+	}{
+		{
+			name:          "saving a submitted job is retried",
+			failures:      submittedJobSaveAttempts - 1,
+			wantSubmitted: true,
+		},
+		{
+			name:     "jobs that can't be saved aren't reported as submitted",
+			failures: submittedJobSaveAttempts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fprovider.jobs = nil
+
+			fake := dbtest.NewFakeRepository(false)
+			job := db.Job{
+				ID:           "job-123",
+				ProviderName: "fake",
+				NotBefore:    time.Date(2020, 7, 1, 1, 0, 0, 0, time.UTC),
+				State:        db.JobStateScheduled,
+			}
+			if err := fake.CreateJob(&job); err != nil {
+				t.Fatal(err)
+			}
+
+			svc, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+			reporter := &fakeReporter{}
+			sink := &recordingSink{}
+			svc.db = &failingUpdates{Repository: fake, failures: tt.failures}
+			svc.errReporter = reporter
+			svc.events = sink
+
+			sc := newScheduler(svc)
+			sc.now = func() time.Time { return time.Date(2020, 7, 1, 2, 0, 0, 0, time.UTC) }
+			sc.saveDelay = 0
+			sc.run(context.Background())
+
+			if len(fprovider.jobs) == 0 {
+				t.Fatal("expected the job to be submitted")
+			}
+			got, err := fake.GetJob(job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g, e := got.ProviderJobID != "", tt.wantSubmitted; g != e {
+				t.Errorf("provider job id saved: got %t, expected %t", g, e)
+			}
+			if got.State == db.JobStateFailed {
+				t.Error("expected a job running at its provider not to be flagged as failed")
+			}
+			if g, e := len(sink.events) > 0, tt.wantSubmitted; g != e {
+				t.Errorf("submission published: got %t, expected %t", g, e)
+			}
+			if g, e := len(reporter.errs) > 0, !tt.wantSubmitted; g != e {
+				t.Errorf("failure reported: got %t, expected %t", g, e)
+			}
+
+			// the next run saves the job instead of submitting it again
+			sc.run(context.Background())
+			got, err = fake.GetJob(job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ProviderJobID == "" || got.State != "" {
+				t.Errorf("wrong job after the next run: %+v", got)
+			}
+			if g, e := len(fprovider.jobs), 1; g != e {
+				t.Errorf("wrong number of submissions: got %d, expected %d", g, e)
+			}
+			if g, e := len(sink.events), 1; g != e {
+				t.Errorf("wrong number of published events: got %d, expected %d", g, e)
+			}
+		})
+	}
+}
@@ -1,14 +1,18 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/NYTimes/gziphandler"
 	"github.com/cbsinteractive/transcode-orchestrator/config"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/db/redis"
+	"github.com/cbsinteractive/transcode-orchestrator/event"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
 	"github.com/cbsinteractive/transcode-orchestrator/service/exceptions"
 	"github.com/cbsinteractive/transcode-orchestrator/swagger"
 	"github.com/fsouza/ctxlogger"
@@ -17,6 +21,10 @@ import (
 	"github.com/zsiec/pkg/tracing"
 )
 
+// defaultJobEventsPollInterval is used when no poll interval is configured for
+// job event streams
+const defaultJobEventsPollInterval = 5 * time.Second
+
 // TranscodingService will implement server.JSONService and handle all requests
 // to the server.
 type TranscodingService struct {
@@ -25,6 +33,9 @@ type TranscodingService struct {
 	logger      *logrus.Logger
 	errReporter exceptions.Reporter
 	tracer      tracing.Tracer
+	poller      *jobPoller
+	events      event.Sink
+	providers   *provider.Registry
 }
 
 // NewTranscodingService will instantiate a JSONService
@@ -51,13 +62,54 @@ func NewTranscodingService(cfg *config.Config, logger *logrus.Logger) (*Transcod
 		tracer = tracing.NoopTracer{}
 	}
 
-	return &TranscodingService{
+	pollInterval := time.Duration(cfg.JobEventsPollInterval) * time.Second
+	if pollInterval <= 0 {
+		pollInterval = defaultJobEventsPollInterval
+	}
+
+	var events event.Sink = event.NoopSink{}
+	if cfg.Events != nil && cfg.Events.StreamEnabled {
+		events, err = redis.NewEventSink(dbRepo, *cfg.Events)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing the event stream: %s", err)
+		}
+	}
+
+	s := &TranscodingService{
 		config:      cfg,
 		db:          dbRepo,
 		logger:      logger,
 		errReporter: errReporter,
 		tracer:      tracer,
-	}, nil
+		events:      events,
+		providers:   provider.NewRegistry(cfg),
+	}
+	s.poller = newJobPoller(pollInterval, s.fetchJobStatus)
+	return s, nil
+}
+
+// StartProviders builds and starts every configured provider, so the first
+// requests don't pay for it.
+func (s *TranscodingService) StartProviders(ctx context.Context) error {
+	return s.providers.Start(ctx)
+}
+
+// ReloadProviders discards the provider instances, which are built again with
+// the given configuration on next use. It returns once the discarded instances
+// are closed, after the calls in progress had a grace period to complete.
+func (s *TranscodingService) ReloadProviders(cfg *config.Config) error {
+	return s.providers.Reload(cfg)
+}
+
+// RunProviderHealthChecks probes the health of the providers in the background
+// until the context is done.
+func (s *TranscodingService) RunProviderHealthChecks(ctx context.Context) {
+	s.providers.RunHealthChecks(ctx)
+}
+
+// CloseProviders releases the resources held by the providers.
+func (s *TranscodingService) CloseProviders() error {
+	return s.providers.Close()
 }
 
 // Prefix returns the string prefix used for all endpoints within
@@ -75,9 +127,19 @@ func (s *TranscodingService) Middleware(h http.Handler) http.Handler {
 	if s.config.Server.HTTPAccessLog == nil {
 		h = handlers.LoggingHandler(s.logger.Writer(), h)
 	}
+	h = server.CORSHandler(h, "")
+	gzipped := gziphandler.GzipHandler(h)
 	return s.tracer.Handle(
 		tracing.FixedNamer("transcode-orchestrator"),
-		gziphandler.GzipHandler(server.CORSHandler(h, "")),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// event streams are written incrementally, compressing them
+			// would hold the events back until the buffer fills up
+			if r.Header.Get("Accept") == "text/event-stream" {
+				h.ServeHTTP(w, r)
+				return
+			}
+			gzipped.ServeHTTP(w, r)
+		}),
 	)
 }
 
@@ -108,6 +170,12 @@ func (s *TranscodingService) JSONEndpoints() map[string]map[string]server.JSONEn
 		"/jobs/{jobId}/cancel": {
 			"POST": swagger.HandlerToJSONEndpoint(s.cancelTranscodeJob),
 		},
+		"/jobs/{jobId}/schedule": {
+			"PUT": swagger.HandlerToJSONEndpoint(s.rescheduleJob),
+		},
+		"/scheduledjobs": {
+			"GET": swagger.HandlerToJSONEndpoint(s.listScheduledJobs),
+		},
 		"/presets": {
 			"POST": swagger.HandlerToJSONEndpoint(s.newPreset),
 		},
@@ -129,6 +197,9 @@ func (s *TranscodingService) JSONEndpoints() map[string]map[string]server.JSONEn
 		"/providers/{name}": {
 			"GET": swagger.HandlerToJSONEndpoint(s.getProvider),
 		},
+		"/providers/{name}/presets/sync": {
+			"POST": swagger.HandlerToJSONEndpoint(s.syncProviderPresets),
+		},
 	}
 }
 
@@ -138,6 +209,9 @@ func (s *TranscodingService) Endpoints() map[string]map[string]http.HandlerFunc
 		"/swagger.json": {
 			"GET": s.swaggerManifest,
 		},
+		"/jobs/{jobId}/events": {
+			"GET": s.jobEvents,
+		},
 	}
 }
 
@@ -158,3 +232,45 @@ func (s *TranscodingService) updatePresetMapResolvingConflicts(presetMap *db.Pre
 
 	return nil
 }
+
+// publish sends a job lifecycle event to the configured sink. Failing to
+// publish an event is reported but never fails the operation that caused it.
+func (s *TranscodingService) publish(ctx context.Context, e event.Event) {
+	err := s.events.Publish(ctx, e)
+	if err != nil {
+		s.logger.WithError(err).WithField("job_id", e.JobID).Error("publishing job event")
+		if s.errReporter != nil {
+			s.errReporter.ReportException(fmt.Errorf("publishing %s event for job %q: %w", e.Type, e.JobID, err))
+		}
+	}
+}
+
+// observeStatus publishes the events for a job whose status changed since it
+// was last seen. The transition is recorded atomically, so a change observed by
+// several instances is only published once.
+func (s *TranscodingService) observeStatus(ctx context.Context, job *db.Job, status *provider.JobStatus) {
+	next := string(status.Status)
+	if next == job.LastStatus {
+		return
+	}
+	swapped, err := s.db.SwapJobLastStatus(job.ID, job.LastStatus, next)
+	if err != nil {
+		s.logger.WithError(err).WithField("job_id", job.ID).Error("saving job status")
+		return
+	}
+	if !swapped {
+		return
+	}
+	job.LastStatus = next
+
+	e := event.New(event.TypeStatusChanged, job).WithStatus(status)
+	s.publish(ctx, e)
+	switch status.Status {
+	case provider.StatusFinished:
+		e.Type = event.TypeOutputReady
+		s.publish(ctx, e)
+	case provider.StatusFailed:
+		e.Type = event.TypeFailed
+		s.publish(ctx, e)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// statusObserver periodically checks the status of recent jobs, publishing the
+// lifecycle events of the ones whose status changed. It's the only publisher of
+// status events, so they're published whether or not the jobs are read.
+type statusObserver struct {
+	svc        *TranscodingService
+	lookback   time.Duration
+	now        func() time.Time
+	providerOf func(name string) (provider.TranscodingProvider, error)
+}
+
+func newStatusObserver(svc *TranscodingService, lookback time.Duration) *statusObserver {
+	return &statusObserver{
+		svc:        svc,
+		lookback:   lookback,
+		now:        time.Now,
+		providerOf: svc.providers.Get,
+	}
+}
+
+// RunStatusObserver publishes the status changes of jobs at the configured
+// interval until the context is done. It returns right away when the event
+// stream is disabled.
+func (s *TranscodingService) RunStatusObserver(ctx context.Context) {
+	cfg := s.config.Events
+	if cfg == nil || !cfg.StreamEnabled || cfg.PollInterval == 0 {
+		return
+	}
+
+	o := newStatusObserver(s, seconds(cfg.PollLookback))
+	ticker := time.NewTicker(seconds(cfg.PollInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.check(ctx)
+		}
+	}
+}
+
+func (o *statusObserver) check(ctx context.Context) {
+	jobs, err := o.svc.db.ListJobs(db.JobFilter{Since: o.now().Add(-o.lookback)})
+	if err != nil {
+		o.svc.logger.WithError(err).Error("status observer: listing jobs")
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.State != "" || provider.Status(job.LastStatus).Terminal() {
+			continue
+		}
+		err = o.checkJob(ctx, job)
+		if err != nil {
+			o.svc.logger.WithError(err).WithField("job_id", job.ID).Error("status observer: checking job")
+		}
+	}
+}
+
+func (o *statusObserver) checkJob(ctx context.Context, job *db.Job) error {
+	status, err := o.svc.polledJobStatus(ctx, job, o.providerOf)
+	if err != nil {
+		return err
+	}
+	o.svc.observeStatus(ctx, job, status)
+	return nil
+}
+
+// polledJobStatus returns the status of a job submitted to its provider for the
+// background checks, going through the job status cache like reads do so that
+// every instance polling the same jobs doesn't multiply the provider calls
+func (s *TranscodingService) polledJobStatus(ctx context.Context, job *db.Job, providerOf func(string) (provider.TranscodingProvider, error)) (*provider.JobStatus, error) {
+	if status := s.cachedJobStatus(job); status != nil {
+		return status, nil
+	}
+	prov, err := providerOf(job.ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("initializing provider %q: %w", job.ProviderName, err)
+	}
+	status, err := prov.JobStatus(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving job status: %w", err)
+	}
+	s.fillJobStatus(job, status)
+	s.cacheJobStatus(job, status)
+	return status, nil
+}
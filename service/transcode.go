@@ -3,14 +3,17 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/event"
 	"github.com/cbsinteractive/transcode-orchestrator/provider"
 	"github.com/cbsinteractive/transcode-orchestrator/swagger"
 )
@@ -23,14 +26,18 @@ import (
 //       200: job
 //       400: invalidJob
 //       500: genericError
+//       503: providerUnavailable
 func (s *TranscodingService) newTranscodeJob(r *http.Request) swagger.GizmoJSONResponse {
 	defer r.Body.Close()
 	var input newTranscodeJobInput
-	providerFactory, err := input.ProviderFactory(r.Body)
+	err := input.Load(r.Body)
 	if err != nil {
 		return newInvalidJobResponse(err)
 	}
-	providerObj, err := providerFactory(s.config)
+	providerObj, err := s.providers.Get(input.Payload.Provider)
+	if err == provider.ErrProviderNotFound {
+		return newInvalidJobResponse(err)
+	}
 	if err != nil {
 		formattedErr := fmt.Errorf("error initializing provider %s for new job: %v %s", input.Payload.Provider, providerObj, err)
 		if _, ok := err.(provider.InvalidConfigError); ok {
@@ -50,7 +57,20 @@ func (s *TranscodingService) newTranscodeJob(r *http.Request) swagger.GizmoJSONR
 		ExecutionFeatures:       input.Payload.ExecutionFeatures,
 		ExecutionCfgReport:      fmt.Sprint(input.Payload.ExecutionFeatures),
 		ExplicitKeyframeOffsets: input.Payload.ExplicitKeyframeOffsets,
+		AudioTracks:             input.Payload.AudioTracks,
+		Captions:                input.Payload.Captions,
+		ImageOutputs:            input.Payload.ImageOutputs,
 		Labels:                  input.Payload.Labels,
+		Priority:                input.Payload.Priority,
+		MaxDuration:             input.Payload.MaxDuration,
+		MaxQueueTime:            input.Payload.MaxQueueTime,
+		NotBefore:               input.Payload.NotBefore.UTC(),
+	}
+	if job.MaxDuration == 0 {
+		job.MaxDuration = s.config.DefaultMaxDuration
+	}
+	if job.MaxQueueTime == 0 {
+		job.MaxQueueTime = s.config.DefaultMaxQueueTime
 	}
 	outputs := make([]db.TranscodeOutput, len(input.Payload.Outputs))
 	for i, output := range input.Payload.Outputs {
@@ -68,36 +88,118 @@ func (s *TranscodingService) newTranscodeJob(r *http.Request) swagger.GizmoJSONR
 		outputs[i] = db.TranscodeOutput{FileName: fileName, Preset: *presetMap}
 	}
 	job.Outputs = outputs
+	if err = validateCaptionOutputs(&job, s.config.RequireStreamingCaptions); err != nil {
+		return newInvalidJobResponse(err)
+	}
 	job.ID, err = s.genID()
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
-	if job.StreamingParams.Protocol == "hls" {
+	if playlist := defaultPlaylistFileName(job.StreamingParams); playlist != "" {
 		if job.StreamingParams.PlaylistFileName == "" {
-			job.StreamingParams.PlaylistFileName = "hls/index.m3u8"
+			job.StreamingParams.PlaylistFileName = playlist
 		}
 		if job.StreamingParams.SegmentDuration == 0 {
 			job.StreamingParams.SegmentDuration = s.config.DefaultSegmentDuration
 		}
 	}
-	jobStatus, err := providerObj.Transcode(r.Context(), &job)
+	if err = validateKeyRotation(job.StreamingParams); err != nil {
+		return newInvalidJobResponse(err)
+	}
+	if e := job.StreamingParams.Encryption; e != nil && e.KeyServerURL != "" && e.ResourceID == "" {
+		encryption := *e
+		encryption.ResourceID = job.ID
+		job.StreamingParams.Encryption = &encryption
+	}
+	if job.NotBefore.After(time.Now()) {
+		job.State = db.JobStateScheduled
+		job.ProviderName = input.Payload.Provider
+		err = s.db.CreateJob(&job)
+		if err != nil {
+			return swagger.NewErrorResponse(err)
+		}
+		s.publish(r.Context(), event.New(event.TypeCreated, &job))
+		return newJobResponse(job.ID)
+	}
+	jobStatus, providerName, err := s.transcode(r.Context(), providerObj, input.Payload.Provider, &job)
 	if err == provider.ErrPresetMapNotFound {
 		return newInvalidJobResponse(err)
 	}
+	if _, ok := err.(provider.CircuitOpenError); ok {
+		return newProviderUnavailableResponse(err)
+	}
 	if err != nil {
-		providerError := fmt.Errorf("error with provider %q: %s", input.Payload.Provider, err)
+		providerError := fmt.Errorf("error with provider %q: %s", providerName, err)
 		return swagger.NewErrorResponse(providerError)
 	}
-	jobStatus.ProviderName = input.Payload.Provider
+	jobStatus.ProviderName = providerName
 	job.ProviderName = jobStatus.ProviderName
 	job.ProviderJobID = jobStatus.ProviderJobID
 	err = s.db.CreateJob(&job)
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
+	s.publish(r.Context(), event.New(event.TypeCreated, &job))
+	s.publish(r.Context(), event.New(event.TypeSubmitted, &job).WithStatus(jobStatus))
 	return newJobResponse(job.ID)
 }
 
+// transcode submits the job to the given provider. When the circuit breaker of
+// the provider is open, the job is submitted to its failover provider instead,
+// if any. It returns the name of the provider the job was submitted to.
+func (s *TranscodingService) transcode(ctx context.Context, prov provider.TranscodingProvider, providerName string, job *db.Job) (*provider.JobStatus, string, error) {
+	status, err := prov.Transcode(ctx, job)
+	if _, ok := err.(provider.CircuitOpenError); !ok {
+		return status, providerName, err
+	}
+	var failover string
+	if s.config.Health != nil {
+		failover = s.config.Health.Failover[providerName]
+	}
+	if failover == "" || failover == providerName {
+		return nil, providerName, err
+	}
+	failoverProv, ferr := s.providers.Get(failover)
+	if ferr != nil {
+		s.logger.WithError(ferr).WithField("provider", failover).Error("initializing failover provider")
+		return nil, providerName, err
+	}
+	s.logger.WithError(err).WithField("failover_provider", failover).Warn("submitting job to the failover provider")
+	status, err = failoverProv.Transcode(ctx, job)
+	return status, failover, err
+}
+
+// resubmitJob submits a copy of the given job to another provider and persists
+// it under a new ID
+func (s *TranscodingService) resubmitJob(ctx context.Context, job *db.Job, providerName string) (*db.Job, error) {
+	providerObj, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing provider %s: %w", providerName, err)
+	}
+	newJob := *job
+	newJob.ProviderJobID = ""
+	newJob.StalledReason = ""
+	newJob.ResubmittedAs = ""
+	newJob.ID, err = s.genID()
+	if err != nil {
+		return nil, err
+	}
+	jobStatus, err := providerObj.Transcode(ctx, &newJob)
+	if err != nil {
+		return nil, fmt.Errorf("error with provider %q: %w", providerName, err)
+	}
+	newJob.ProviderName = providerName
+	newJob.ProviderJobID = jobStatus.ProviderJobID
+	newJob.LastStatus = ""
+	err = s.db.CreateJob(&newJob)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, event.New(event.TypeCreated, &newJob))
+	s.publish(ctx, event.New(event.TypeSubmitted, &newJob).WithStatus(jobStatus))
+	return &newJob, nil
+}
+
 func (s *TranscodingService) genID() (string, error) {
 	var data [8]byte
 	n, err := rand.Read(data[:])
@@ -110,6 +212,23 @@ func (s *TranscodingService) genID() (string, error) {
 	return fmt.Sprintf("%x", data), nil
 }
 
+// defaultPlaylistFileName returns the default name of the master playlist of
+// jobs producing HLS or DASH, or an empty string for other jobs. Jobs producing
+// both have their manifests side by side, the DASH manifest being named after
+// the playlist.
+func defaultPlaylistFileName(params db.StreamingParams) string {
+	hls, dash := params.HasProtocol(db.ProtocolHLS), params.HasProtocol(db.ProtocolDASH)
+	switch {
+	case hls && dash:
+		return "index.m3u8"
+	case hls:
+		return "hls/index.m3u8"
+	case dash:
+		return "dash/index.mpd"
+	}
+	return ""
+}
+
 func (s *TranscodingService) defaultFileName(source string, preset *db.PresetMap) string {
 	sourceExtension := filepath.Ext(source)
 	_, source = path.Split(source)
@@ -162,22 +281,111 @@ func (s *TranscodingService) getTranscodeJobByID(ctx context.Context, jobID stri
 		}
 		return nil, nil, nil, fmt.Errorf("error retrieving job with id %q: %s", jobID, err)
 	}
-	providerFactory, err := provider.GetProviderFactory(job.ProviderName)
-	if err != nil {
-		return job, nil, nil, fmt.Errorf("unknown provider %q for job id %q", job.ProviderName, jobID)
+	if job.State != "" {
+		return job, stateJobStatus(job), nil, nil
 	}
-	providerObj, err := providerFactory(s.config)
+	if jobStatus := s.cachedJobStatus(job); jobStatus != nil {
+		return job, jobStatus, nil, nil
+	}
+	providerObj, err := s.jobProvider(job)
 	if err != nil {
-		return job, nil, nil, fmt.Errorf("error initializing provider %q on job id %q: %s %s", job.ProviderName, jobID, providerObj, err)
+		return job, nil, nil, err
 	}
 	jobStatus, err := providerObj.JobStatus(ctx, job)
 	if err != nil {
 		return job, nil, providerObj, err
 	}
-	jobStatus.ProviderName = job.ProviderName
+	s.fillJobStatus(job, jobStatus)
+	s.cacheJobStatus(job, jobStatus)
 	return job, jobStatus, providerObj, nil
 }
 
+func (s *TranscodingService) jobProvider(job *db.Job) (provider.TranscodingProvider, error) {
+	providerObj, err := s.providers.Get(job.ProviderName)
+	if err == provider.ErrProviderNotFound {
+		return nil, fmt.Errorf("unknown provider %q for job id %q", job.ProviderName, job.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error initializing provider %q on job id %q: %s", job.ProviderName, job.ID, err)
+	}
+	return providerObj, nil
+}
+
+// fillJobStatus sets the fields of the status that are kept by the API rather
+// than the provider
+func (s *TranscodingService) fillJobStatus(job *db.Job, status *provider.JobStatus) {
+	status.ProviderName = job.ProviderName
+	status.StalledReason = job.StalledReason
+	status.ResubmittedAs = job.ResubmittedAs
+}
+
+// cachedJobStatus returns the cached status of the job, or nil if there is
+// none or it has expired
+func (s *TranscodingService) cachedJobStatus(job *db.Job) *provider.JobStatus {
+	data, err := s.db.GetJobStatus(job.ID)
+	if err != nil {
+		if err != db.ErrJobStatusNotFound {
+			s.logger.WithError(err).WithField("job_id", job.ID).Error("retrieving cached job status")
+		}
+		return nil
+	}
+	var status provider.JobStatus
+	err = json.Unmarshal(data, &status)
+	if err != nil {
+		s.logger.WithError(err).WithField("job_id", job.ID).Error("decoding cached job status")
+		return nil
+	}
+	s.fillJobStatus(job, &status)
+	return &status
+}
+
+// cacheJobStatus caches the status of the job. Terminal statuses don't change
+// anymore and are kept for as long as the job exists, others are kept for the
+// configured TTL, if any.
+func (s *TranscodingService) cacheJobStatus(job *db.Job, status *provider.JobStatus) {
+	var ttl time.Duration
+	if !status.Status.Terminal() {
+		ttl = seconds(s.config.JobStatusCacheTTL)
+		if ttl == 0 {
+			return
+		}
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		s.logger.WithError(err).WithField("job_id", job.ID).Error("encoding job status")
+		return
+	}
+	err = s.db.SaveJobStatus(job.ID, data, ttl)
+	if err != nil {
+		s.logger.WithError(err).WithField("job_id", job.ID).Error("caching job status")
+	}
+}
+
+// stateJobStatus builds the status of a job that was never handed over to its
+// provider, such as a scheduled job
+func stateJobStatus(job *db.Job) *provider.JobStatus {
+	status := provider.JobStatus{
+		ProviderName:  job.ProviderName,
+		StatusMessage: job.StateMessage,
+		Labels:        job.Labels,
+	}
+	switch job.State {
+	case db.JobStateScheduled:
+		status.Status = provider.StatusScheduled
+		status.StatusMessage = fmt.Sprintf("scheduled for %s", job.NotBefore.Format(time.RFC3339))
+	case db.JobStateSubmitting:
+		status.Status = provider.StatusScheduled
+		status.StatusMessage = "being submitted to the provider"
+	case db.JobStateCanceled:
+		status.Status = provider.StatusCanceled
+	case db.JobStateFailed:
+		status.Status = provider.StatusFailed
+	default:
+		status.Status = provider.StatusUnknown
+	}
+	return &status
+}
+
 // swagger:route POST /jobs/{jobId}/cancel jobs cancelJob
 //
 // Creates a new transcoding job.
@@ -200,6 +408,16 @@ func (s *TranscodingService) cancelTranscodeJob(r *http.Request) swagger.GizmoJS
 		}
 		return swagger.NewErrorResponse(err)
 	}
+	if job.State != "" {
+		return s.cancelScheduledJob(job)
+	}
+	if prov == nil {
+		// the status was served from the cache
+		prov, err = s.jobProvider(job)
+		if err != nil {
+			return swagger.NewErrorResponse(err)
+		}
+	}
 	err = prov.CancelJob(r.Context(), job.ProviderJobID)
 	if err != nil {
 		return swagger.NewErrorResponse(err)
@@ -208,6 +426,98 @@ func (s *TranscodingService) cancelTranscodeJob(r *http.Request) swagger.GizmoJS
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
-	status.ProviderName = job.ProviderName
+	s.fillJobStatus(job, status)
+	s.cacheJobStatus(job, status)
 	return newJobStatusResponse(status)
 }
+
+func (s *TranscodingService) cancelScheduledJob(job *db.Job) swagger.GizmoJSONResponse {
+	if job.State == db.JobStateSubmitting {
+		return newJobConflictResponse(fmt.Errorf("job %q is already being submitted to the provider", job.ID))
+	}
+	if job.State != db.JobStateScheduled {
+		return newJobStatusResponse(stateJobStatus(job))
+	}
+	// a job claimed but not saved is put back on the schedule by the
+	// scheduler once its claim times out
+	claimed, err := s.db.ClaimScheduledJob(job.ID, time.Now())
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	if !claimed {
+		return newJobConflictResponse(fmt.Errorf("job %q is already being submitted to the provider", job.ID))
+	}
+	job.State = db.JobStateCanceled
+	job.StateMessage = "canceled before submission"
+	job.ClaimedAt = time.Time{}
+	err = s.db.UpdateJob(job)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newJobStatusResponse(stateJobStatus(job))
+}
+
+// swagger:route GET /scheduledjobs jobs listScheduledJobs
+//
+// Lists the jobs waiting for their scheduled time.
+//
+//     Responses:
+//       200: listScheduledJobs
+//       500: genericError
+func (s *TranscodingService) listScheduledJobs(*http.Request) swagger.GizmoJSONResponse {
+	jobs, err := s.db.ListScheduledJobs(time.Time{})
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListScheduledJobsResponse(jobs)
+}
+
+// swagger:route PUT /jobs/{jobId}/schedule jobs rescheduleJob
+//
+// Changes the time a scheduled job is submitted to the provider.
+//
+//     Responses:
+//       200: scheduledJob
+//       400: invalidJob
+//       404: jobNotFound
+//       409: jobConflict
+//       500: genericError
+func (s *TranscodingService) rescheduleJob(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var params rescheduleJobInput
+	err := params.loadParams(server.Vars(r), r.Body)
+	if err != nil {
+		return newInvalidJobResponse(err)
+	}
+	job, err := s.db.GetJob(params.JobID)
+	if err != nil {
+		if err == db.ErrJobNotFound {
+			return newJobNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	if job.State == db.JobStateSubmitting {
+		return newJobConflictResponse(fmt.Errorf("job %q is already being submitted to the provider", job.ID))
+	}
+	if job.State != db.JobStateScheduled {
+		return newJobConflictResponse(fmt.Errorf("job %q is not scheduled", job.ID))
+	}
+	// the job is taken off the schedule so the scheduler can't submit it
+	// while it's updated, saving it puts it back, and the scheduler does
+	// once the claim times out when saving fails
+	claimed, err := s.db.ClaimScheduledJob(job.ID, time.Now())
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	if !claimed {
+		return newJobConflictResponse(fmt.Errorf("job %q is already being submitted to the provider", job.ID))
+	}
+	job.State = db.JobStateScheduled
+	job.ClaimedAt = time.Time{}
+	job.NotBefore = params.Payload.NotBefore.UTC()
+	err = s.db.UpdateJob(job)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newScheduledJobResponse(job)
+}
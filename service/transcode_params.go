@@ -1,13 +1,18 @@
 package service
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
+	"time"
 
 	"github.com/cbsinteractive/pkg/timecode"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/cbsinteractive/transcode-orchestrator/smpte"
 )
 
 // NewTranscodeJobInputPayload makes up the parameters available for
@@ -24,6 +29,12 @@ type NewTranscodeJobInputPayload struct {
 	// a two-second clip, from the first and last second of a 10s video.
 	SourceSplice timecode.Splice `json:"splice,omitempty"`
 
+	// SpliceTimecodes is a set of ranges of SMPTE timecodes to excise from the input,
+	// for example [["01:00:10;00","01:00:20;00"]]. Timecodes are relative to the start
+	// timecode of the source and drop-frame ones separate frames with a semicolon.
+	// The frame rate of the source is required, and splice can't be used with it.
+	SpliceTimecodes [][2]string `json:"spliceTimecodes,omitempty"`
+
 	// list of outputs in this job
 	Outputs []struct {
 		FileName string `json:"fileName"`
@@ -51,11 +62,40 @@ type NewTranscodeJobInputPayload struct {
 	// provider Adaptive Streaming parameters
 	StreamingParams db.StreamingParams `json:"streamingParams,omitempty"`
 
-	// ExplicitKeyframeOffsets define offsets from the beginning of the media to insert keyframes when encoding
+	// ExplicitKeyframeOffsets define offsets from the beginning of the media to insert keyframes when encoding.
+	// Offsets are in the source, they are moved along with the ranges of a splice and dropped when cut out.
 	ExplicitKeyframeOffsets []float64 `json:"explicitKeyframeOffsets,omitempty"`
 
+	// AudioTracks define the audio renditions of the adaptive streaming outputs,
+	// such as dubbed languages and audio description tracks
+	AudioTracks []db.AudioTrack `json:"audioTracks,omitempty"`
+
+	// Captions define the caption and subtitle tracks of the outputs, declared
+	// per output or for every output of the job
+	Captions []db.Caption `json:"captions,omitempty"`
+
+	// ImageOutputs define the thumbnails, poster frame and sprite sheets taken
+	// from the source alongside the outputs
+	ImageOutputs []db.ImageOutput `json:"imageOutputs,omitempty"`
+
 	// Labels for jobs for grouping/searching later on
 	Labels []string `json:"labels,omitempty"`
+
+	// Priority of the job, from -50 (lowest) to 50 (highest). Jobs default to 0
+	Priority int `json:"priority,omitempty"`
+
+	// MaxDuration is the number of seconds the job may run for before it is considered
+	// stuck, the configured default is used when omitted
+	MaxDuration uint `json:"maxDuration,omitempty"`
+
+	// MaxQueueTime is the number of seconds the job may stay queued before it is considered
+	// stuck, the configured default is used when omitted
+	MaxQueueTime uint `json:"maxQueueTime,omitempty"`
+
+	// NotBefore holds the time the job should be submitted to the provider. Jobs
+	// with a time in the future are persisted right away and submitted by the
+	// scheduler once it is reached
+	NotBefore time.Time `json:"notBefore,omitempty"`
 }
 
 // swagger:parameters newJob
@@ -65,18 +105,13 @@ type newTranscodeJobInput struct {
 	Payload NewTranscodeJobInputPayload
 }
 
-// ProviderFactory loads and validates the parameters, and then returns the
-// provider factory.
-func (p *newTranscodeJobInput) ProviderFactory(body io.Reader) (provider.Factory, error) {
+// Load loads and validates the parameters.
+func (p *newTranscodeJobInput) Load(body io.Reader) error {
 	err := p.loadParams(body)
 	if err != nil {
-		return nil, err
-	}
-	err = p.validate()
-	if err != nil {
-		return nil, err
+		return err
 	}
-	return provider.GetProviderFactory(p.Payload.Provider)
+	return p.validate()
 }
 
 func (p *newTranscodeJobInput) loadParams(body io.Reader) error {
@@ -93,6 +128,351 @@ func (p *newTranscodeJobInput) validate() error {
 	if len(p.Payload.Outputs) == 0 {
 		return errors.New("missing output list from request")
 	}
+	if p.Payload.Priority < db.MinJobPriority || p.Payload.Priority > db.MaxJobPriority {
+		return fmt.Errorf("priority must be between %d and %d", db.MinJobPriority, db.MaxJobPriority)
+	}
+	if len(p.Payload.SpliceTimecodes) > 0 {
+		if len(p.Payload.SourceSplice) > 0 {
+			return errors.New("splice and spliceTimecodes can't be used together")
+		}
+		splice, err := spliceFromTimecodes(p.Payload.SpliceTimecodes, p.Payload.SourceInfo)
+		if err != nil {
+			return err
+		}
+		p.Payload.SourceSplice = splice
+	}
+	splice := provider.FrameAccurateSplice(p.Payload.SourceSplice, p.Payload.SourceInfo.FrameRate)
+	if err := provider.ValidateSplice(splice, p.Payload.SourceInfo); err != nil {
+		return err
+	}
+	for i, offset := range p.Payload.ExplicitKeyframeOffsets {
+		if offset < 0 {
+			return fmt.Errorf("explicit keyframe offset #%d can't be negative", i)
+		}
+	}
+	if err := validateAudioTracks(p.Payload.AudioTracks); err != nil {
+		return err
+	}
+	if err := validateCaptions(p.Payload.Captions); err != nil {
+		return err
+	}
+	if err := validateImageOutputs(p.Payload.ImageOutputs, p.Payload.StreamingParams); err != nil {
+		return err
+	}
+	if err := validateSegmentTemplate(p.Payload.StreamingParams); err != nil {
+		return err
+	}
+	return validateEncryption(p.Payload.StreamingParams)
+}
+
+// validateSegmentTemplate checks the segment template of a job, which only
+// applies to DASH manifests
+func validateSegmentTemplate(streaming db.StreamingParams) error {
+	switch streaming.SegmentTemplate {
+	case "":
+		return nil
+	case db.SegmentTemplateNumber, db.SegmentTemplateTimeline:
+	default:
+		return fmt.Errorf("unknown segment template %q", streaming.SegmentTemplate)
+	}
+	if !streaming.HasProtocol(db.ProtocolDASH) {
+		return errors.New("segment templates only apply to dash manifests")
+	}
+	return nil
+}
+
+// spliceFromTimecodes converts ranges of SMPTE timecodes into ranges of seconds
+// from the start of the source
+func spliceFromTimecodes(ranges [][2]string, src db.File) (timecode.Splice, error) {
+	if src.FrameRate <= 0 {
+		return nil, errors.New("spliceTimecodes require the frame rate of the source")
+	}
+
+	var start int64
+	if src.StartTimecode != "" {
+		tc, err := smpte.Parse(src.StartTimecode)
+		if err != nil {
+			return nil, fmt.Errorf("source start timecode: %w", err)
+		}
+		if start, err = tc.Frame(src.FrameRate); err != nil {
+			return nil, fmt.Errorf("source start timecode: %w", err)
+		}
+	}
+
+	splice := make(timecode.Splice, len(ranges))
+	for i, r := range ranges {
+		for j, s := range r {
+			tc, err := smpte.Parse(s)
+			if err != nil {
+				return nil, fmt.Errorf("splice timecode range #%d: %w", i, err)
+			}
+			frame, err := tc.Frame(src.FrameRate)
+			if err != nil {
+				return nil, fmt.Errorf("splice timecode range #%d: %w", i, err)
+			}
+			if frame < start {
+				return nil, fmt.Errorf("splice timecode range #%d: %s is before the start of the source", i, tc)
+			}
+			splice[i][j] = smpte.Seconds(frame-start, src.FrameRate)
+		}
+	}
+	return splice, nil
+}
+
+func validateAudioTracks(tracks []db.AudioTrack) error {
+	names := make(map[string]bool, len(tracks))
+	defaults := 0
+	for i, track := range tracks {
+		if track.Language == "" {
+			return fmt.Errorf("audio track #%d is missing a language", i)
+		}
+		name := track.DisplayName()
+		if names[name] {
+			return fmt.Errorf("audio track name %q is used by several tracks", name)
+		}
+		names[name] = true
+		if track.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return errors.New("only one audio track can be the default")
+	}
+	return nil
+}
+
+func validateCaptions(captions []db.Caption) error {
+	names := make(map[string]bool, len(captions))
+	defaults := 0
+	for i, caption := range captions {
+		if !knownCaptionFormat(caption.Format) {
+			return fmt.Errorf("caption #%d has an unsupported format %q", i, caption.Format)
+		}
+		if caption.Format == db.CaptionFormatEmbedded {
+			if caption.Source != "" {
+				return fmt.Errorf("caption #%d is embedded in the source and can't have a sidecar", i)
+			}
+			if caption.Channel > 4 {
+				return fmt.Errorf("caption #%d must use a CEA-608 channel between 1 and 4", i)
+			}
+		} else if caption.Source == "" {
+			return fmt.Errorf("caption #%d is missing its sidecar source", i)
+		}
+		if caption.Language == "" {
+			return fmt.Errorf("caption #%d is missing a language", i)
+		}
+		name := caption.DisplayName()
+		if names[name] {
+			return fmt.Errorf("caption name %q is used by several captions", name)
+		}
+		names[name] = true
+		if caption.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return errors.New("only one caption can be the default")
+	}
+	return nil
+}
+
+func knownCaptionFormat(format db.CaptionFormat) bool {
+	for _, f := range db.CaptionFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func validateImageOutputs(outputs []db.ImageOutput, streaming db.StreamingParams) error {
+	fileNames := make(map[string]bool, len(outputs))
+	for i, o := range outputs {
+		if !knownImageOutputKind(o.Kind) {
+			return fmt.Errorf("image output #%d has an unsupported kind %q", i, o.Kind)
+		}
+		if o.FileName == "" {
+			return fmt.Errorf("image output #%d is missing a file name", i)
+		}
+		if fileNames[o.FileName] {
+			return fmt.Errorf("image output file name %q is used by several image outputs", o.FileName)
+		}
+		fileNames[o.FileName] = true
+		if f := o.ImageFormat(); f != db.ImageFormatJPEG && f != db.ImageFormatPNG {
+			return fmt.Errorf("image output #%d has an unsupported format %q", i, f)
+		}
+		if o.Interval < 0 {
+			return fmt.Errorf("image output #%d can't have a negative interval", i)
+		}
+		for _, offset := range o.Offsets {
+			if offset < 0 {
+				return fmt.Errorf("image output #%d can't have negative offsets", i)
+			}
+		}
+
+		switch o.Kind {
+		case db.ImageOutputKindThumbnails:
+			if (o.Interval > 0) == (len(o.Offsets) > 0) {
+				return fmt.Errorf("thumbnails #%d need either an interval or offsets", i)
+			}
+		case db.ImageOutputKindPoster:
+			if o.Interval > 0 || len(o.Offsets) > 1 {
+				return fmt.Errorf("poster #%d takes at most one offset", i)
+			}
+		case db.ImageOutputKindSprite:
+			if o.Interval <= 0 || len(o.Offsets) > 0 {
+				return fmt.Errorf("sprite #%d needs an interval", i)
+			}
+			if (o.Columns == 0) != (o.Rows == 0) {
+				return fmt.Errorf("sprite #%d needs both columns and rows", i)
+			}
+		}
+		if o.Kind != db.ImageOutputKindSprite && (o.Columns > 0 || o.Rows > 0) {
+			return fmt.Errorf("image output #%d can't have columns and rows, only sprites can", i)
+		}
+
+		if o.Playlist {
+			if o.Kind == db.ImageOutputKindPoster {
+				return fmt.Errorf("poster #%d can't have a playlist", i)
+			}
+			if !streaming.HasProtocol(db.ProtocolHLS) {
+				return fmt.Errorf("image output #%d can only have a playlist on hls jobs", i)
+			}
+		}
+	}
+	return nil
+}
+
+// validateEncryption checks that the encryption of a streaming job can be
+// honored, premium content must not be shipped in the clear because a setting
+// was silently dropped
+func validateEncryption(streaming db.StreamingParams) error {
+	e := streaming.Encryption
+	if e == nil {
+		return nil
+	}
+	if len(streaming.Protocols()) == 0 {
+		return errors.New("encryption needs a streaming protocol")
+	}
+	if !knownEncryptionScheme(e.Scheme) {
+		return fmt.Errorf("unsupported encryption scheme %q", e.Scheme)
+	}
+	if e.Scheme != db.EncryptionSchemeCENC && streaming.HasProtocol(db.ProtocolDASH) {
+		return fmt.Errorf("%s encryption is only supported with hls, dash needs cenc", e.Scheme)
+	}
+	for _, v := range []struct{ name, value string }{{"key", e.Key}, {"key id", e.KeyID}, {"iv", e.IV}} {
+		if v.value != "" && !isHex128(v.value) {
+			return fmt.Errorf("encryption %s must be 32 hexadecimal characters", v.name)
+		}
+	}
+
+	if (e.Key == "") == (e.KeyServerURL == "") {
+		return errors.New("encryption needs either a static key or a key server")
+	}
+	if e.KeyServerURL != "" {
+		u, err := url.Parse(e.KeyServerURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid key server url %q", e.KeyServerURL)
+		}
+		if e.KeyURL != "" {
+			return errors.New("the key url is given by the key server")
+		}
+	} else {
+		if e.Scheme == db.EncryptionSchemeCENC && e.KeyID == "" {
+			return errors.New("cenc encryption with a static key needs a key id")
+		}
+		if e.Scheme != db.EncryptionSchemeCENC && e.KeyURL == "" {
+			return fmt.Errorf("%s encryption with a static key needs a key url", e.Scheme)
+		}
+		if len(e.DRMSystems) > 0 {
+			return errors.New("drm systems need a key server")
+		}
+		if e.KeyRotationInterval > 0 {
+			return errors.New("key rotation needs a key server")
+		}
+	}
+
+	seen := make(map[db.DRMSystem]bool, len(e.DRMSystems))
+	for _, system := range e.DRMSystems {
+		if _, ok := db.DRMSystemIDs[system]; !ok {
+			return fmt.Errorf("unsupported drm system %q", system)
+		}
+		if seen[system] {
+			return fmt.Errorf("drm system %q is listed several times", system)
+		}
+		seen[system] = true
+
+		switch {
+		case system == db.DRMSystemFairPlay && e.Scheme != db.EncryptionSchemeSampleAES:
+			return errors.New("fairplay needs sample-aes encryption")
+		case system != db.DRMSystemFairPlay && e.Scheme != db.EncryptionSchemeCENC:
+			return fmt.Errorf("%s needs cenc encryption", system)
+		}
+	}
+	return validateKeyRotation(streaming)
+}
+
+// validateKeyRotation checks that keys are rotated on segment boundaries, once
+// the segment duration is known
+func validateKeyRotation(streaming db.StreamingParams) error {
+	e := streaming.Encryption
+	if e == nil || e.KeyRotationInterval == 0 || streaming.SegmentDuration == 0 {
+		return nil
+	}
+	if e.KeyRotationInterval%streaming.SegmentDuration != 0 {
+		return fmt.Errorf("key rotation interval %ds isn't a multiple of the %ds segment duration",
+			e.KeyRotationInterval, streaming.SegmentDuration)
+	}
+	return nil
+}
+
+func knownEncryptionScheme(scheme db.EncryptionScheme) bool {
+	for _, s := range db.EncryptionSchemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// isHex128 returns whether s is a 128 bit value in hexadecimal
+func isHex128(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == 16
+}
+
+func knownImageOutputKind(kind db.ImageOutputKind) bool {
+	for _, k := range db.ImageOutputKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCaptionOutputs checks that the captions refer to outputs of the job and,
+// when required, that every output of a streaming job carries captions
+func validateCaptionOutputs(job *db.Job, required bool) error {
+	fileNames := make(map[string]bool, len(job.Outputs))
+	for _, output := range job.Outputs {
+		fileNames[output.FileName] = true
+	}
+	for i, caption := range job.Captions {
+		for _, fileName := range caption.Outputs {
+			if !fileNames[fileName] {
+				return fmt.Errorf("caption #%d refers to an unknown output %q", i, fileName)
+			}
+		}
+	}
+
+	if !required || job.StreamingParams.Protocol == "" {
+		return nil
+	}
+	for _, output := range job.Outputs {
+		if len(provider.Captions(job.Captions, output.FileName)) == 0 {
+			return fmt.Errorf("output %q has no captions, which are required on streaming jobs", output.FileName)
+		}
+	}
 	return nil
 }
 
@@ -111,3 +491,27 @@ func (p *getTranscodeJobInput) loadParams(paramsMap map[string]string) {
 type cancelTranscodeJobInput struct {
 	getTranscodeJobInput
 }
+
+// swagger:parameters rescheduleJob
+type rescheduleJobInput struct {
+	getTranscodeJobInput
+
+	// in: body
+	// required: true
+	Payload struct {
+		// NotBefore holds the new time the job should be submitted to the provider
+		NotBefore time.Time `json:"notBefore"`
+	}
+}
+
+func (p *rescheduleJobInput) loadParams(paramsMap map[string]string, body io.Reader) error {
+	p.getTranscodeJobInput.loadParams(paramsMap)
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return err
+	}
+	if p.Payload.NotBefore.IsZero() {
+		return errors.New("missing notBefore from request")
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/provider"
 	"github.com/cbsinteractive/transcode-orchestrator/swagger"
 )
@@ -105,3 +107,101 @@ func newJobNotFoundProviderResponse(err error) *jobNotFoundProviderResponse {
 func (r *jobNotFoundProviderResponse) Result() (int, interface{}, error) {
 	return r.Error.Result()
 }
+
+// ScheduledJob is a job waiting for its scheduled time before being
+// submitted to the provider
+// swagger:model
+type ScheduledJob struct {
+	JobID        string    `json:"jobId"`
+	Name         string    `json:"name,omitempty"`
+	ProviderName string    `json:"providerName"`
+	NotBefore    time.Time `json:"notBefore"`
+	Labels       []string  `json:"labels,omitempty"`
+}
+
+func newScheduledJob(job *db.Job) ScheduledJob {
+	return ScheduledJob{
+		JobID:        job.ID,
+		Name:         job.Name,
+		ProviderName: job.ProviderName,
+		NotBefore:    job.NotBefore,
+		Labels:       job.Labels,
+	}
+}
+
+// JSON-encoded scheduled job.
+//
+// swagger:response scheduledJob
+type scheduledJobResponse struct {
+	// in: body
+	Payload *ScheduledJob
+
+	baseResponse
+}
+
+func newScheduledJobResponse(job *db.Job) *scheduledJobResponse {
+	scheduled := newScheduledJob(job)
+	return &scheduledJobResponse{
+		baseResponse: baseResponse{
+			payload: &scheduled,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// JSON-encoded list of the jobs waiting for their scheduled time, ordered by
+// the time they are due.
+//
+// swagger:response listScheduledJobs
+type listScheduledJobsResponse struct {
+	// in: body
+	Payload []ScheduledJob
+
+	baseResponse
+}
+
+func newListScheduledJobsResponse(jobs []db.Job) *listScheduledJobsResponse {
+	scheduled := make([]ScheduledJob, len(jobs))
+	for i := range jobs {
+		scheduled[i] = newScheduledJob(&jobs[i])
+	}
+	return &listScheduledJobsResponse{
+		baseResponse: baseResponse{
+			payload: scheduled,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// error returned when the job is not in a state that allows the operation.
+//
+// swagger:response jobConflict
+type jobConflictResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newJobConflictResponse(err error) *jobConflictResponse {
+	return &jobConflictResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *jobConflictResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+// error returned when the provider is unavailable and there's no failover
+// provider to send the job to.
+//
+// swagger:response providerUnavailable
+type providerUnavailableResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newProviderUnavailableResponse(err error) *providerUnavailableResponse {
+	return &providerUnavailableResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusServiceUnavailable)}
+}
+
+func (r *providerUnavailableResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -2,18 +2,25 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/NYTimes/gizmo/server"
+	"github.com/cbsinteractive/pkg/timecode"
 	"github.com/cbsinteractive/transcode-orchestrator/config"
 	"github.com/cbsinteractive/transcode-orchestrator/db"
 	"github.com/cbsinteractive/transcode-orchestrator/db/dbtest"
+	"github.com/cbsinteractive/transcode-orchestrator/event"
 	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/sirupsen/logrus"
 )
 
@@ -80,6 +87,40 @@ func TestTranscode(t *testing.T) {
 			"hls/index.m3u8",
 			5,
 		},
+		{
+			"NewJobDefaultDASHManifestFileName",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"mp4_1080p","fileName":"91274824924924-published-supervideo-1080p.mp4"}],
+  "streamingParams": {"protocol":"dash"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"91274824924924-published-supervideo-1080p.mp4"},
+			"dash/index.mpd",
+			5,
+		},
+		{
+			"NewJobMultipleProtocols",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"mp4_1080p","fileName":"91274824924924-published-supervideo-1080p.mp4"}],
+  "streamingParams": {"protocol":["hls","dash"]},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"91274824924924-published-supervideo-1080p.mp4"},
+			"index.m3u8",
+			5,
+		},
 		{
 			"NewJobNoPlaylistFileName",
 			`{
@@ -192,6 +233,322 @@ func TestTranscode(t *testing.T) {
 			"",
 			0,
 		},
+		{
+			"NewJobPriorityOutOfRange",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "priority": 51
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "priority must be between -50 and 50"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobAudioTracks",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}],
+  "audioTracks": [
+    {"sourceTrack": 0, "language": "en", "default": true, "autoselect": true},
+    {"sourceTrack": 1, "language": "es", "name": "Español", "autoselect": true},
+    {"sourceTrack": 2, "language": "en", "name": "English (AD)", "characteristics": ["public.accessibility.describes-video"]}
+  ]
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"video.mp4"},
+			"",
+			0,
+		},
+		{
+			"NewJobAudioTrackMissingLanguage",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "audioTracks": [{"sourceTrack": 1}]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "audio track #0 is missing a language"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobAudioTracksDuplicateName",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "audioTracks": [{"language": "en"}, {"sourceTrack": 2, "language": "en"}]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "audio track name \"en\" is used by several tracks"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobAudioTracksSeveralDefaults",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "audioTracks": [{"language": "en", "default": true}, {"sourceTrack": 1, "language": "fr", "default": true}]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "only one audio track can be the default"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobSpliceTimecodes",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "sourceInfo": {"frameRate": 29.97, "startTimecode": "01:00:00;00", "duration": 600000000000},
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}],
+  "spliceTimecodes": [["01:00:10;00", "01:00:20;00"], ["01:01:00;02", "01:02:00;04"]]
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"video.mp4"},
+			"",
+			0,
+		},
+		{
+			"NewJobSpliceTimecodesWithoutFrameRate",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "spliceTimecodes": [["00:00:10:00", "00:00:20:00"]]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "spliceTimecodes require the frame rate of the source"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobSpliceOverlappingRanges",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "splice": [[0, 10], [5, 20]]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "splice range #1 (5s-20s) overlaps or precedes range #0 (0s-10s)"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobNegativeKeyframeOffset",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "explicitKeyframeOffsets": [10, -2]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "explicit keyframe offset #1 can't be negative"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobSpliceAfterTheSource",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "sourceInfo": {"duration": 15000000000},
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "splice": [[0, 10], [12, 20]]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "splice range #1 (12s-20s) ends after the source, which is 15s long"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobCaptions",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}],
+  "captions": [
+    {"source": "s3://bucket/captions.scc", "format": "scc", "language": "en"},
+    {"format": "embedded", "language": "es", "channel": 3, "outputs": ["video.mp4"]}
+  ]
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"video.mp4"},
+			"",
+			0,
+		},
+		{
+			"NewJobCaptionWithoutSource",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "captions": [{"format": "webvtt", "language": "en"}]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "caption #0 is missing its sidecar source"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobCaptionUnknownOutput",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}],
+  "captions": [{"source": "s3://bucket/captions.srt", "format": "srt", "language": "en", "outputs": ["other.mp4"]}]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "caption #0 refers to an unknown output \"other.mp4\""},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobImageOutputs",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}],
+  "imageOutputs": [
+    {"kind": "thumbnails", "fileName": "thumbs/thumb.jpg", "interval": 10, "width": 320},
+    {"kind": "poster", "fileName": "poster.png", "format": "png", "offsets": [5]}
+  ]
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"video.mp4"},
+			"",
+			0,
+		},
+		{
+			"NewJobSpriteWithoutInterval",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "imageOutputs": [{"kind": "sprite", "fileName": "sprite.jpg", "columns": 10, "rows": 10}]
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "sprite #0 needs an interval"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"NewJobEncryptionKeyServer",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"hls_1080p","fileName":"hls/1080p.m3u8"}],
+  "streamingParams": {
+    "protocol": "hls",
+    "encryption": {
+      "scheme": "sample-aes",
+      "keyServerUrl": "https://keys.example.com/speke/v1.0/copyProtection",
+      "keyRotationInterval": 30,
+      "drmSystems": ["fairplay"]
+    }
+  }
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/1080p.m3u8"},
+			"hls/index.m3u8",
+			5,
+		},
+		{
+			"NewJobEncryptionRotationOffSegments",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "provider": "fake",
+  "outputs": [{"preset":"hls_1080p","fileName":"hls/1080p.m3u8"}],
+  "streamingParams": {
+    "protocol": "hls",
+    "encryption": {
+      "scheme": "aes-128",
+      "keyServerUrl": "https://keys.example.com/speke/v1.0/copyProtection",
+      "keyRotationInterval": 12
+    }
+  }
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "key rotation interval 12s isn't a multiple of the 5s segment duration"},
+			nil,
+			"",
+			0,
+		},
 		{
 			"NewJobLabelsEmptyList",
 			`{
@@ -298,10 +655,339 @@ func TestTranscode(t *testing.T) {
 			if segmentDuration != test.wantSegmentDuration {
 				t.Errorf("%s: wrong segment duration\nwant %d\ngot  %d", test.givenTestCase, test.wantSegmentDuration, segmentDuration)
 			}
+			if e := profile.StreamingParams.Encryption; e != nil && e.KeyServerURL != "" && e.ResourceID != got["jobId"] {
+				t.Errorf("%s: wrong key server resource id\nwant %q\ngot  %q", test.givenTestCase, got["jobId"], e.ResourceID)
+			}
 		}
 	}
 }
 
+func TestSpliceFromTimecodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  [][2]string
+		src     db.File
+		want    timecode.Splice
+		wantErr string
+	}{
+		{
+			name:   "non drop-frame timecodes are relative to the start timecode",
+			ranges: [][2]string{{"10:00:01:00", "10:00:02:12"}},
+			src:    db.File{FrameRate: 25, StartTimecode: "10:00:00:00"},
+			want:   timecode.Splice{{1, 2.48}},
+		},
+		{
+			name:   "drop-frame timecodes count the frames of the source",
+			ranges: [][2]string{{"00:00:00;00", "00:01:00;02"}},
+			src:    db.File{FrameRate: 29.97},
+			want:   timecode.Splice{{0, 60.06}},
+		},
+		{
+			name:    "timecodes before the source are rejected",
+			ranges:  [][2]string{{"00:59:59:00", "01:00:10:00"}},
+			src:     db.File{FrameRate: 25, StartTimecode: "01:00:00:00"},
+			wantErr: "splice timecode range #0: 00:59:59:00 is before the start of the source",
+		},
+		{
+			name:    "dropped timecodes are rejected",
+			ranges:  [][2]string{{"00:01:00;00", "00:02:00;02"}},
+			src:     db.File{FrameRate: 29.97},
+			wantErr: "splice timecode range #0: timecode 00:01:00;00 was dropped and doesn't exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := spliceFromTimecodes(tt.ranges, tt.src)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("wrong splice: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateCaptionOutputs(t *testing.T) {
+	streaming := db.StreamingParams{Protocol: "hls"}
+	outputs := []db.TranscodeOutput{{FileName: "hls/1080p.m3u8"}, {FileName: "hls/720p.m3u8"}}
+
+	tests := []struct {
+		name     string
+		job      db.Job
+		required bool
+		wantErr  string
+	}{
+		{
+			name: "captions are optional unless required",
+			job:  db.Job{StreamingParams: streaming, Outputs: outputs},
+		},
+		{
+			name: "ladder captions cover every streaming output",
+			job: db.Job{StreamingParams: streaming, Outputs: outputs, Captions: []db.Caption{
+				{Format: db.CaptionFormatEmbedded, Language: "en"},
+			}},
+			required: true,
+		},
+		{
+			name: "every streaming output must carry captions when required",
+			job: db.Job{StreamingParams: streaming, Outputs: outputs, Captions: []db.Caption{
+				{Format: db.CaptionFormatEmbedded, Language: "en", Outputs: []string{"hls/1080p.m3u8"}},
+			}},
+			required: true,
+			wantErr:  `output "hls/720p.m3u8" has no captions, which are required on streaming jobs`,
+		},
+		{
+			name:     "progressive jobs don't require captions",
+			job:      db.Job{Outputs: []db.TranscodeOutput{{FileName: "video.mp4"}}},
+			required: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCaptionOutputs(&tt.job, tt.required)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateImageOutputs(t *testing.T) {
+	hls := db.StreamingParams{Protocol: "hls"}
+
+	tests := []struct {
+		name      string
+		outputs   []db.ImageOutput
+		streaming db.StreamingParams
+		wantErr   string
+	}{
+		{
+			name: "thumbnails, poster and sprite with a playlist are valid",
+			outputs: []db.ImageOutput{
+				{Kind: db.ImageOutputKindThumbnails, FileName: "thumbs.jpg", Offsets: []float64{1, 5}},
+				{Kind: db.ImageOutputKindPoster, FileName: "poster.jpg"},
+				{Kind: db.ImageOutputKindSprite, FileName: "sprite.jpg", Interval: 2, Columns: 5, Rows: 5, Playlist: true},
+			},
+			streaming: hls,
+		},
+		{
+			name:    "unknown kinds are rejected",
+			outputs: []db.ImageOutput{{Kind: "gif", FileName: "a.gif"}},
+			wantErr: `image output #0 has an unsupported kind "gif"`,
+		},
+		{
+			name:    "file names are required",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindPoster}},
+			wantErr: "image output #0 is missing a file name",
+		},
+		{
+			name:    "unknown formats are rejected",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindPoster, FileName: "poster.bmp", Format: "bmp"}},
+			wantErr: `image output #0 has an unsupported format "bmp"`,
+		},
+		{
+			name:    "thumbnails can't have both an interval and offsets",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindThumbnails, FileName: "t.jpg", Interval: 5, Offsets: []float64{1}}},
+			wantErr: "thumbnails #0 need either an interval or offsets",
+		},
+		{
+			name:    "posters take a single offset",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindPoster, FileName: "p.jpg", Offsets: []float64{1, 2}}},
+			wantErr: "poster #0 takes at most one offset",
+		},
+		{
+			name:    "sprites need both columns and rows",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindSprite, FileName: "s.jpg", Interval: 2, Columns: 5}},
+			wantErr: "sprite #0 needs both columns and rows",
+		},
+		{
+			name:    "playlists need an hls job",
+			outputs: []db.ImageOutput{{Kind: db.ImageOutputKindThumbnails, FileName: "t.jpg", Interval: 5, Playlist: true}},
+			wantErr: "image output #0 can only have a playlist on hls jobs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateImageOutputs(tt.outputs, tt.streaming)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateEncryption(t *testing.T) {
+	const (
+		key       = "3c1fa5d8b8e5c6a2f0b3d9e1a7c4f6b2"
+		keyServer = "https://keys.example.com/speke/v1.0/copyProtection"
+	)
+
+	tests := []struct {
+		name      string
+		streaming db.StreamingParams
+		wantErr   string
+	}{
+		{
+			name: "static aes-128 keys are valid",
+			streaming: db.StreamingParams{Protocol: "hls", Encryption: &db.Encryption{
+				Scheme: db.EncryptionSchemeAES128, Key: key, KeyURL: "https://keys.example.com/key",
+			}},
+		},
+		{
+			name: "cenc keys from a key server are valid for hls and dash",
+			streaming: db.StreamingParams{Protocol: "hls,dash", SegmentDuration: 6, Encryption: &db.Encryption{
+				Scheme:              db.EncryptionSchemeCENC,
+				KeyServerURL:        keyServer,
+				KeyRotationInterval: 60,
+				DRMSystems:          []db.DRMSystem{db.DRMSystemWidevine, db.DRMSystemPlayReady},
+			}},
+		},
+		{
+			name:      "encryption needs a streaming job",
+			streaming: db.StreamingParams{Encryption: &db.Encryption{Scheme: db.EncryptionSchemeCENC, KeyServerURL: keyServer}},
+			wantErr:   "encryption needs a streaming protocol",
+		},
+		{
+			name:      "unknown schemes are rejected",
+			streaming: db.StreamingParams{Protocol: "hls", Encryption: &db.Encryption{Scheme: "rot13", KeyServerURL: keyServer}},
+			wantErr:   `unsupported encryption scheme "rot13"`,
+		},
+		{
+			name:      "dash needs cenc",
+			streaming: db.StreamingParams{Protocol: "dash", Encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: keyServer}},
+			wantErr:   "aes-128 encryption is only supported with hls, dash needs cenc",
+		},
+		{
+			name:      "keys are 128 bits",
+			streaming: db.StreamingParams{Protocol: "hls", Encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, Key: "abcd", KeyURL: "k"}},
+			wantErr:   "encryption key must be 32 hexadecimal characters",
+		},
+		{
+			name: "keys can't come from both a static key and a key server",
+			streaming: db.StreamingParams{Protocol: "hls", Encryption: &db.Encryption{
+				Scheme: db.EncryptionSchemeAES128, Key: key, KeyServerURL: keyServer,
+			}},
+			wantErr: "encryption needs either a static key or a key server",
+		},
+		{
+			name:      "key servers are http urls",
+			streaming: db.StreamingParams{Protocol: "hls", Encryption: &db.Encryption{Scheme: db.EncryptionSchemeAES128, KeyServerURL: "keys.example.com"}},
+			wantErr:   `invalid key server url "keys.example.com"`,
+		},
+		{
+			name:      "static cenc keys need a key id",
+			streaming: db.StreamingParams{Protocol: "dash", Encryption: &db.Encryption{Scheme: db.EncryptionSchemeCENC, Key: key}},
+			wantErr:   "cenc encryption with a static key needs a key id",
+		},
+		{
+			name:      "static hls keys need a key url",
+			streaming: db.StreamingParams{Protocol: "hls", Encryption: &db.Encryption{Scheme: db.EncryptionSchemeSampleAES, Key: key}},
+			wantErr:   "sample-aes encryption with a static key needs a key url",
+		},
+		{
+			name: "drm systems need a key server",
+			streaming: db.StreamingParams{Protocol: "dash", Encryption: &db.Encryption{
+				Scheme: db.EncryptionSchemeCENC, Key: key, KeyID: key, DRMSystems: []db.DRMSystem{db.DRMSystemWidevine},
+			}},
+			wantErr: "drm systems need a key server",
+		},
+		{
+			name: "fairplay needs sample-aes",
+			streaming: db.StreamingParams{Protocol: "hls", Encryption: &db.Encryption{
+				Scheme: db.EncryptionSchemeCENC, KeyServerURL: keyServer, DRMSystems: []db.DRMSystem{db.DRMSystemFairPlay},
+			}},
+			wantErr: "fairplay needs sample-aes encryption",
+		},
+		{
+			name: "widevine needs cenc",
+			streaming: db.StreamingParams{Protocol: "hls", Encryption: &db.Encryption{
+				Scheme: db.EncryptionSchemeSampleAES, KeyServerURL: keyServer, DRMSystems: []db.DRMSystem{db.DRMSystemWidevine},
+			}},
+			wantErr: "widevine needs cenc encryption",
+		},
+		{
+			name: "keys rotate on segment boundaries",
+			streaming: db.StreamingParams{Protocol: "hls", SegmentDuration: 4, Encryption: &db.Encryption{
+				Scheme: db.EncryptionSchemeAES128, KeyServerURL: keyServer, KeyRotationInterval: 10,
+			}},
+			wantErr: "key rotation interval 10s isn't a multiple of the 4s segment duration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEncryption(tt.streaming)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateSegmentTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		streaming db.StreamingParams
+		wantErr   string
+	}{
+		{name: "jobs without a template", streaming: db.StreamingParams{Protocol: "hls"}},
+		{name: "timelines for dash", streaming: db.StreamingParams{Protocol: "hls,dash", SegmentTemplate: db.SegmentTemplateTimeline}},
+		{
+			name:      "unknown templates are rejected",
+			streaming: db.StreamingParams{Protocol: "dash", SegmentTemplate: "list"},
+			wantErr:   `unknown segment template "list"`,
+		},
+		{
+			name:      "templates need dash",
+			streaming: db.StreamingParams{Protocol: "hls", SegmentTemplate: db.SegmentTemplateNumber},
+			wantErr:   "segment templates only apply to dash manifests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSegmentTemplate(tt.streaming)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 func TestGetTranscodeJob(t *testing.T) {
 	tests := []struct {
 		givenTestCase        string
@@ -634,3 +1320,321 @@ func TestGetTranscodeJobLabels(t *testing.T) {
 		}
 	}
 }
+
+func TestScheduledJobs(t *testing.T) {
+	fprovider.jobs = nil
+	srvr := server.NewSimpleServer(&server.Config{})
+	fakeDBObj := dbtest.NewFakeRepository(false)
+	fakeDBObj.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "18828"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDBObj
+	srvr.Register(service)
+
+	do := func(method, uri, body string) (int, map[string]interface{}) {
+		r, _ := http.NewRequest(method, uri, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		var got map[string]interface{}
+		if w.Body.Len() > 0 && w.Body.Bytes()[0] == '{' {
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("%s %s: unable to JSON decode response body: %s", method, uri, err)
+			}
+		}
+		return w.Code, got
+	}
+
+	code, got := do("POST", "/jobs", `{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video-1080p.mp4"}],
+  "provider": "fake",
+  "notBefore": "2099-01-01T02:00:00Z"
+}`)
+	if code != http.StatusOK {
+		t.Fatalf("creating scheduled job: wrong code %d: %v", code, got)
+	}
+	jobID := got["jobId"].(string)
+	if len(fprovider.jobs) > 0 {
+		t.Error("scheduled job was submitted to the provider right away")
+	}
+
+	code, got = do("GET", "/jobs/"+jobID, "")
+	if code != http.StatusOK || got["status"] != "scheduled" {
+		t.Errorf("getting scheduled job: got %d %v", code, got)
+	}
+
+	r, _ := http.NewRequest("GET", "/scheduledjobs", nil)
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	var list []ScheduledJob
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].JobID != jobID || list[0].ProviderName != "fake" {
+		t.Errorf("listing scheduled jobs: got %+v", list)
+	}
+
+	code, got = do("PUT", "/jobs/"+jobID+"/schedule", `{"notBefore": "2099-02-01T03:00:00Z"}`)
+	if code != http.StatusOK || got["notBefore"] != "2099-02-01T03:00:00Z" {
+		t.Errorf("rescheduling job: got %d %v", code, got)
+	}
+	code, _ = do("PUT", "/jobs/"+jobID+"/schedule", `{}`)
+	if code != http.StatusBadRequest {
+		t.Errorf("rescheduling job without a time: wrong code %d", code)
+	}
+
+	code, got = do("POST", "/jobs/"+jobID+"/cancel", "")
+	if code != http.StatusOK || got["status"] != "canceled" {
+		t.Errorf("canceling scheduled job: got %d %v", code, got)
+	}
+	code, _ = do("PUT", "/jobs/"+jobID+"/schedule", `{"notBefore": "2099-02-01T03:00:00Z"}`)
+	if code != http.StatusConflict {
+		t.Errorf("rescheduling canceled job: wrong code %d", code)
+	}
+	if jobs, _ := fakeDBObj.ListScheduledJobs(time.Time{}); len(jobs) > 0 {
+		t.Errorf("canceled job is still scheduled: %+v", jobs)
+	}
+
+	code, got = do("POST", "/jobs", `{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video-1080p.mp4"}],
+  "provider": "fake",
+  "notBefore": "2099-01-01T02:00:00Z"
+}`)
+	if code != http.StatusOK {
+		t.Fatalf("creating scheduled job: wrong code %d: %v", code, got)
+	}
+	jobID = got["jobId"].(string)
+	// the scheduler claimed the job and is submitting it
+	if _, err := fakeDBObj.ClaimScheduledJob(jobID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	code, _ = do("PUT", "/jobs/"+jobID+"/schedule", `{"notBefore": "2099-02-01T03:00:00Z"}`)
+	if code != http.StatusConflict {
+		t.Errorf("rescheduling job being submitted: wrong code %d", code)
+	}
+	if jobs, _ := fakeDBObj.ListScheduledJobs(time.Time{}); len(jobs) > 0 {
+		t.Errorf("job being submitted was put back on the schedule: %+v", jobs)
+	}
+}
+
+type recordingSink struct {
+	events []event.Event
+}
+
+func (s *recordingSink) Publish(_ context.Context, e event.Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *recordingSink) types() []event.Type {
+	types := make([]event.Type, len(s.events))
+	for i, e := range s.events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestJobLifecycleEvents(t *testing.T) {
+	fprovider.jobs = nil
+	srvr := server.NewSimpleServer(&server.Config{})
+	fakeDBObj := dbtest.NewFakeRepository(false)
+	fakeDBObj.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "18828"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	fakeDBObj.CreateJob(&db.Job{
+		ID:            "job-123",
+		ProviderName:  "fake",
+		ProviderJobID: "provider-job-123",
+	})
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordingSink{}
+	service.db = fakeDBObj
+	service.events = sink
+	srvr.Register(service)
+
+	r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video-1080p.mp4"}],
+  "provider": "fake"
+}`))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("creating job: wrong code %d", w.Code)
+	}
+	if g, e := sink.types(), []event.Type{event.TypeCreated, event.TypeSubmitted}; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong events for a new job: want %v, got %v", e, g)
+	}
+
+	sink.events = nil
+	r, _ = http.NewRequest("GET", "/jobs/job-123", nil)
+	w = httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("getting job: wrong code %d", w.Code)
+	}
+	if len(sink.events) > 0 {
+		t.Fatalf("expected reading a job not to publish events, got %v", sink.types())
+	}
+
+	// the statuses observed by concurrent checks are only published once
+	observer := newStatusObserver(service, time.Hour)
+	jobs, err := fakeDBObj.ListJobs(db.JobFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, job := range jobs {
+		if job.ID == "job-123" {
+			stale := job
+			if err := observer.checkJob(context.Background(), &job); err != nil {
+				t.Fatal(err)
+			}
+			if err := observer.checkJob(context.Background(), &stale); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	observer.check(context.Background())
+	if g, e := sink.types(), []event.Type{event.TypeStatusChanged, event.TypeOutputReady}; !reflect.DeepEqual(g, e) {
+		t.Fatalf("wrong events for a finished job: want %v, got %v", e, g)
+	}
+	ready := sink.events[1]
+	if ready.JobID != "job-123" || ready.Version != event.SchemaVersion || ready.Output == nil ||
+		ready.Output.Destination != "s3://mybucket/some/dir/job-123" {
+		t.Errorf("wrong output ready event: %+v", ready)
+	}
+}
+
+func TestJobStatusCache(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{})
+	fakeDBObj := dbtest.NewFakeRepository(false)
+	job := &db.Job{
+		ID:            "job-123",
+		ProviderName:  "fake",
+		ProviderJobID: "provider-job-123",
+	}
+	fakeDBObj.CreateJob(job)
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDBObj
+	srvr.Register(service)
+
+	get := func() (int, map[string]interface{}) {
+		r, _ := http.NewRequest("GET", "/jobs/job-123", nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, first := get()
+	if code != http.StatusOK {
+		t.Fatalf("wrong code getting the job: %d", code)
+	}
+
+	// the provider no longer knows about the job, so only the cache can answer
+	job.ProviderJobID = "provider-job-unknown"
+	code, cached := get()
+	if code != http.StatusOK {
+		t.Fatalf("terminal status was not served from the cache, got code %d: %v", code, cached)
+	}
+	if !reflect.DeepEqual(first, cached) {
+		t.Errorf("wrong cached status\nwant %#v\ngot  %#v", first, cached)
+	}
+
+	started := &provider.JobStatus{Status: provider.StatusStarted, Progress: 20}
+	service.cacheJobStatus(&db.Job{ID: "job-456"}, started)
+	if _, err := fakeDBObj.GetJobStatus("job-456"); err != db.ErrJobStatusNotFound {
+		t.Errorf("non-terminal status was cached without a TTL, got err %v", err)
+	}
+	service.config.JobStatusCacheTTL = 60
+	service.cacheJobStatus(&db.Job{ID: "job-456"}, started)
+	if _, err := fakeDBObj.GetJobStatus("job-456"); err != nil {
+		t.Errorf("non-terminal status was not cached: %v", err)
+	}
+
+	// the status observer answers from the cache without calling the provider
+	fakeDBObj.CreateJob(&db.Job{ID: "job-456", ProviderName: "fake", ProviderJobID: "provider-job-456"})
+	observer := newStatusObserver(service, time.Hour)
+	observer.providerOf = func(string) (provider.TranscodingProvider, error) {
+		return nil, errors.New("provider called")
+	}
+	job456, err := fakeDBObj.GetJob("job-456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := observer.checkJob(context.Background(), job456); err != nil {
+		t.Errorf("cached status was not used by the status observer: %v", err)
+	}
+}
+
+type unavailableProvider struct {
+	provider.TranscodingProvider
+}
+
+func (unavailableProvider) Transcode(context.Context, *db.Job) (*provider.JobStatus, error) {
+	return nil, provider.CircuitOpenError{Provider: "fake", Until: time.Now().Add(time.Minute)}
+}
+
+func TestTranscodeFailover(t *testing.T) {
+	tests := []struct {
+		name         string
+		failover     map[string]string
+		wantProvider string
+		wantErr      bool
+	}{
+		{
+			name:         "the job is sent to the failover provider",
+			failover:     map[string]string{"fake": "zencoder"},
+			wantProvider: "zencoder",
+		},
+		{
+			name:         "fails fast without a failover provider",
+			wantProvider: "fake",
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fprovider.jobs = nil
+			cfg := &config.Config{Server: &server.Config{}, Health: &config.Health{Failover: tt.failover}}
+			service, err := NewTranscodingService(cfg, logrus.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, providerName, err := service.transcode(context.Background(), unavailableProvider{}, "fake", &db.Job{ID: "job-123"})
+			if g, e := err != nil, tt.wantErr; g != e {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if tt.wantErr {
+				if _, ok := err.(provider.CircuitOpenError); !ok {
+					t.Errorf("wrong error type: %#v", err)
+				}
+			}
+			if providerName != tt.wantProvider {
+				t.Errorf("wrong provider: want %q, got %q", tt.wantProvider, providerName)
+			}
+			if g, e := len(fprovider.jobs) > 0, !tt.wantErr; g != e {
+				t.Errorf("job submitted: got %t, expected %t", g, e)
+			}
+		})
+	}
+}
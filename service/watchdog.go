@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+)
+
+// watchdog periodically checks the status of recent jobs, flagging or canceling
+// the ones that exceeded their time limits or stopped making progress
+type watchdog struct {
+	svc        *TranscodingService
+	cfg        config.Watchdog
+	now        func() time.Time
+	providerOf func(name string) (provider.TranscodingProvider, error)
+
+	progress map[string]progressMark
+	finished map[string]struct{}
+
+	// started holds when jobs were first seen started, their max duration
+	// doesn't include the time they were queued
+	started map[string]time.Time
+}
+
+// progressMark records the last progress seen for a job and when it was first seen
+type progressMark struct {
+	progress float64
+	since    time.Time
+}
+
+func newWatchdog(svc *TranscodingService, cfg config.Watchdog) *watchdog {
+	return &watchdog{
+		svc:        svc,
+		cfg:        cfg,
+		now:        time.Now,
+		providerOf: svc.providers.Get,
+		progress:   make(map[string]progressMark),
+		finished:   make(map[string]struct{}),
+		started:    make(map[string]time.Time),
+	}
+}
+
+// RunWatchdog checks for stuck jobs at the configured interval until the context
+// is done. It returns right away when the watchdog is disabled.
+func (s *TranscodingService) RunWatchdog(ctx context.Context) {
+	cfg := s.config.Watchdog
+	if cfg == nil || !cfg.Enabled || cfg.Interval == 0 {
+		return
+	}
+
+	w := newWatchdog(s, *cfg)
+	ticker := time.NewTicker(seconds(cfg.Interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+func (w *watchdog) check(ctx context.Context) {
+	now := w.now()
+	jobs, err := w.svc.db.ListJobs(db.JobFilter{Since: now.Add(-seconds(w.cfg.Lookback))})
+	if err != nil {
+		w.svc.logger.WithError(err).Error("watchdog: listing jobs")
+		return
+	}
+
+	seen := make(map[string]struct{}, len(jobs))
+	for i := range jobs {
+		job := &jobs[i]
+		seen[job.ID] = struct{}{}
+		if _, done := w.finished[job.ID]; done || job.StalledReason != "" || job.State != "" {
+			continue
+		}
+		err = w.checkJob(ctx, job, now)
+		if err != nil {
+			w.svc.logger.WithError(err).WithField("job_id", job.ID).Error("watchdog: checking job")
+		}
+	}
+
+	// forget about jobs that are no longer within the lookback window
+	for id := range w.progress {
+		if _, ok := seen[id]; !ok {
+			delete(w.progress, id)
+		}
+	}
+	for id := range w.finished {
+		if _, ok := seen[id]; !ok {
+			delete(w.finished, id)
+		}
+	}
+	for id := range w.started {
+		if _, ok := seen[id]; !ok {
+			delete(w.started, id)
+		}
+	}
+}
+
+func (w *watchdog) checkJob(ctx context.Context, job *db.Job, now time.Time) error {
+	// a cached status is at most the status cache TTL old, which is expected
+	// to be well below the stall window
+	status, err := w.svc.polledJobStatus(ctx, job, w.providerOf)
+	if err != nil {
+		return err
+	}
+
+	if status.Status.Terminal() {
+		w.finished[job.ID] = struct{}{}
+		delete(w.progress, job.ID)
+		delete(w.started, job.ID)
+		return nil
+	}
+
+	reason := w.stalledReason(job, status, now)
+	if reason == "" {
+		return nil
+	}
+
+	return w.handleStalled(ctx, job, reason)
+}
+
+// stalledReason returns a description of why a job is considered stuck, or an
+// empty string if the job is healthy
+func (w *watchdog) stalledReason(job *db.Job, status *provider.JobStatus, now time.Time) string {
+	start := job.CreationTime
+	if job.NotBefore.After(start) {
+		start = job.NotBefore
+	}
+	if max := seconds(job.MaxQueueTime); status.Status == provider.StatusQueued && max > 0 && now.Sub(start) > max {
+		return fmt.Sprintf("queued for %s, exceeding the max queue time of %s", now.Sub(start).Round(time.Second), max)
+	}
+
+	// the run time is measured from the first check that saw the job
+	// started, so it's known within the watchdog interval
+	if _, ok := w.started[job.ID]; !ok && status.Status == provider.StatusStarted {
+		w.started[job.ID] = now
+	}
+	if since, ok := w.started[job.ID]; ok {
+		if max := seconds(job.MaxDuration); max > 0 && now.Sub(since) > max {
+			return fmt.Sprintf("running for %s, exceeding the max duration of %s", now.Sub(since).Round(time.Second), max)
+		}
+	}
+
+	mark, ok := w.progress[job.ID]
+	if !ok || mark.progress != status.Progress {
+		w.progress[job.ID] = progressMark{progress: status.Progress, since: now}
+		return ""
+	}
+
+	idle := now.Sub(mark.since)
+	if window := seconds(w.cfg.StallWindow); status.Status == provider.StatusStarted && window > 0 && idle > window {
+		return fmt.Sprintf("progress unchanged at %.1f%% for %s", status.Progress, idle.Round(time.Second))
+	}
+
+	return ""
+}
+
+// handleStalled flags a stalled job, canceling and resubmitting it when
+// configured. Every instance runs a watchdog, so the job is flagged first and
+// only the instance that flagged it acts on it. Jobs whose cancellation fails
+// stay flagged, and are reported for manual handling.
+func (w *watchdog) handleStalled(ctx context.Context, job *db.Job, reason string) error {
+	delete(w.progress, job.ID)
+	delete(w.started, job.ID)
+	flagged, err := w.svc.db.FlagStalledJob(job.ID, reason)
+	if err != nil {
+		return fmt.Errorf("flagging stalled job: %w", err)
+	}
+	if !flagged {
+		return nil
+	}
+	job.StalledReason = reason
+
+	w.report(fmt.Errorf("job %q stalled on provider %q: %s", job.ID, job.ProviderName, reason))
+	if !w.cfg.CancelStalled {
+		return nil
+	}
+
+	prov, err := w.providerOf(job.ProviderName)
+	if err != nil {
+		return fmt.Errorf("initializing provider %q: %w", job.ProviderName, err)
+	}
+	err = prov.CancelJob(ctx, job.ProviderJobID)
+	if err != nil {
+		return fmt.Errorf("canceling stalled job: %w", err)
+	}
+
+	name := w.cfg.ResubmitProvider
+	if name == "" {
+		return nil
+	}
+	newJob, err := w.svc.resubmitJob(ctx, job, name)
+	if err != nil {
+		w.report(fmt.Errorf("resubmitting stalled job %q to provider %q: %w", job.ID, name, err))
+		return nil
+	}
+	job.ResubmittedAs = newJob.ID
+	err = w.svc.db.UpdateJob(job)
+	if err != nil {
+		return fmt.Errorf("recording the resubmission of stalled job as %q: %w", newJob.ID, err)
+	}
+	return nil
+}
+
+func (w *watchdog) report(err error) {
+	if w.svc.errReporter != nil {
+		w.svc.errReporter.ReportException(err)
+	}
+}
+
+func seconds(s uint) time.Duration {
+	return time.Duration(s) * time.Second
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/cbsinteractive/transcode-orchestrator/config"
+	"github.com/cbsinteractive/transcode-orchestrator/db"
+	"github.com/cbsinteractive/transcode-orchestrator/db/dbtest"
+	"github.com/cbsinteractive/transcode-orchestrator/provider"
+	"github.com/sirupsen/logrus"
+)
+
+// stuckProvider reports a fixed status for every job
+type stuckProvider struct {
+	fakeProvider
+	status   provider.JobStatus
+	calls    int
+	canceled []string
+}
+
+var sprovider stuckProvider
+
+func (p *stuckProvider) JobStatus(_ context.Context, job *db.Job) (*provider.JobStatus, error) {
+	p.calls++
+	status := p.status
+	status.ProviderJobID = job.ProviderJobID
+	return &status, nil
+}
+
+func (p *stuckProvider) CancelJob(_ context.Context, id string) error {
+	p.canceled = append(p.canceled, id)
+	return nil
+}
+
+type fakeReporter struct {
+	errs []error
+}
+
+func (r *fakeReporter) ReportException(err error) {
+	r.errs = append(r.errs, err)
+}
+
+func TestWatchdog(t *testing.T) {
+	created := time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		cfg          config.Watchdog
+		job          db.Job
+		status       provider.JobStatus
+		queuedChecks []time.Duration
+		checks       []time.Duration
+		wantReason   string
+		wantCanceled bool
+		wantResubmit bool
+	}{
+		{
+			name:       "a job queued for longer than its max queue time is flagged",
+			job:        db.Job{MaxQueueTime: 600},
+			status:     provider.JobStatus{Status: provider.StatusQueued},
+			checks:     []time.Duration{11 * time.Minute},
+			wantReason: "queued for 11m0s, exceeding the max queue time of 10m0s",
+		},
+		{
+			name:       "a job running for longer than its max duration is flagged",
+			job:        db.Job{MaxDuration: 3600},
+			status:     provider.JobStatus{Status: provider.StatusStarted, Progress: 50},
+			checks:     []time.Duration{time.Minute, 2 * time.Hour},
+			wantReason: "running for 1h59m0s, exceeding the max duration of 1h0m0s",
+		},
+		{
+			name:         "the time a job was queued doesn't count against its max duration",
+			job:          db.Job{MaxDuration: 3600},
+			status:       provider.JobStatus{Status: provider.StatusStarted, Progress: 50},
+			queuedChecks: []time.Duration{time.Minute, 3 * time.Hour},
+			checks:       []time.Duration{3*time.Hour + time.Minute, 3*time.Hour + 30*time.Minute},
+		},
+		{
+			name:       "a started job whose progress does not change within the stall window is flagged",
+			cfg:        config.Watchdog{StallWindow: 1800},
+			status:     provider.JobStatus{Status: provider.StatusStarted},
+			checks:     []time.Duration{time.Minute, 20 * time.Minute, 40 * time.Minute},
+			wantReason: "progress unchanged at 0.0% for 39m0s",
+		},
+		{
+			name:   "a started job within the stall window is left alone",
+			cfg:    config.Watchdog{StallWindow: 1800},
+			status: provider.JobStatus{Status: provider.StatusStarted},
+			checks: []time.Duration{time.Minute, 20 * time.Minute},
+		},
+		{
+			name:   "finished jobs are never flagged",
+			job:    db.Job{MaxDuration: 60},
+			status: provider.JobStatus{Status: provider.StatusFinished},
+			checks: []time.Duration{time.Hour},
+		},
+		{
+			name:         "stalled jobs are canceled when configured",
+			cfg:          config.Watchdog{CancelStalled: true},
+			job:          db.Job{MaxQueueTime: 60},
+			status:       provider.JobStatus{Status: provider.StatusQueued},
+			checks:       []time.Duration{2 * time.Minute},
+			wantReason:   "queued for 2m0s, exceeding the max queue time of 1m0s",
+			wantCanceled: true,
+		},
+		{
+			name:         "canceled stalled jobs are resubmitted to the configured provider",
+			cfg:          config.Watchdog{CancelStalled: true, ResubmitProvider: "fake"},
+			job:          db.Job{MaxQueueTime: 60},
+			status:       provider.JobStatus{Status: provider.StatusQueued},
+			checks:       []time.Duration{2 * time.Minute},
+			wantReason:   "queued for 2m0s, exceeding the max queue time of 1m0s",
+			wantCanceled: true,
+			wantResubmit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sprovider = stuckProvider{status: tt.status}
+			fprovider.jobs = nil
+
+			repo := dbtest.NewFakeRepository(false)
+			job := tt.job
+			job.ID = "job-123"
+			job.ProviderName = "stuck"
+			job.ProviderJobID = "stuck-job-1"
+			job.CreationTime = created
+			if err := repo.CreateJob(&job); err != nil {
+				t.Fatal(err)
+			}
+
+			svc, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+			reporter := &fakeReporter{}
+			svc.db = repo
+			svc.errReporter = reporter
+
+			tt.cfg.Lookback = uint((24 * time.Hour).Seconds())
+			w := newWatchdog(svc, tt.cfg)
+			w.providerOf = func(string) (provider.TranscodingProvider, error) {
+				return &sprovider, nil
+			}
+			sprovider.status = provider.JobStatus{Status: provider.StatusQueued}
+			for _, elapsed := range tt.queuedChecks {
+				elapsed := elapsed
+				w.now = func() time.Time { return created.Add(elapsed) }
+				w.check(context.Background())
+			}
+			sprovider.status = tt.status
+			for _, elapsed := range tt.checks {
+				elapsed := elapsed
+				w.now = func() time.Time { return created.Add(elapsed) }
+				w.check(context.Background())
+			}
+
+			got, err := repo.GetJob(job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g, e := got.StalledReason, tt.wantReason; g != e {
+				t.Errorf("wrong stalled reason: got %q, expected %q", g, e)
+			}
+			if tt.wantReason != "" && len(reporter.errs) == 0 {
+				t.Error("expected the stalled job to be reported")
+			}
+			if tt.wantReason == "" && len(reporter.errs) > 0 {
+				t.Errorf("unexpected reports: %v", reporter.errs)
+			}
+			if g, e := len(sprovider.canceled) > 0, tt.wantCanceled; g != e {
+				t.Errorf("job canceled: got %t, expected %t", g, e)
+			}
+
+			if !tt.wantResubmit {
+				if got.ResubmittedAs != "" {
+					t.Errorf("unexpected resubmission as %q", got.ResubmittedAs)
+				}
+				return
+			}
+			newJob, err := repo.GetJob(got.ResubmittedAs)
+			if err != nil {
+				t.Fatalf("retrieving resubmitted job: %v", err)
+			}
+			if g, e := newJob.ProviderName, "fake"; g != e {
+				t.Errorf("resubmitted job provider: got %q, expected %q", g, e)
+			}
+			if newJob.StalledReason != "" || strings.HasPrefix(newJob.ProviderJobID, "stuck") {
+				t.Errorf("resubmitted job carried over state from the stalled job: %+v", newJob)
+			}
+		})
+	}
+}
+
+func TestWatchdogReplicas(t *testing.T) {
+	created := time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)
+	sprovider = stuckProvider{status: provider.JobStatus{Status: provider.StatusQueued}}
+	fprovider.jobs = nil
+
+	repo := dbtest.NewFakeRepository(false)
+	job := db.Job{ID: "job-123", ProviderName: "stuck", ProviderJobID: "stuck-job-1", CreationTime: created, MaxQueueTime: 60}
+	if err := repo.CreateJob(&job); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Watchdog{CancelStalled: true, ResubmitProvider: "fake", Lookback: uint((24 * time.Hour).Seconds())}
+	now := created.Add(2 * time.Minute)
+	var watchdogs []*watchdog
+	for i := 0; i < 2; i++ {
+		svc, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc.db = repo
+		svc.errReporter = &fakeReporter{}
+		svc.config.JobStatusCacheTTL = 60
+		w := newWatchdog(svc, cfg)
+		w.providerOf = func(string) (provider.TranscodingProvider, error) {
+			return &sprovider, nil
+		}
+		watchdogs = append(watchdogs, w)
+	}
+
+	// both replicas loaded the job before either of them flagged it
+	jobs, err := repo.ListJobs(db.JobFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range watchdogs {
+		stale := jobs[0]
+		if err := w.checkJob(context.Background(), &stale, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the second replica reads the status cached by the first
+	if g, e := sprovider.calls, 1; g != e {
+		t.Errorf("wrong number of status requests: got %d, expected %d", g, e)
+	}
+	if g, e := len(sprovider.canceled), 1; g != e {
+		t.Errorf("wrong number of cancellations: got %d, expected %d", g, e)
+	}
+	if g, e := len(fprovider.jobs), 1; g != e {
+		t.Errorf("wrong number of resubmissions: got %d, expected %d", g, e)
+	}
+	got, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ResubmittedAs == "" || got.StalledReason == "" {
+		t.Errorf("expected the job to be flagged and resubmitted, got %+v", got)
+	}
+}
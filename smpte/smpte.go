@@ -0,0 +1,154 @@
+// Package smpte converts SMPTE timecodes into frame counts and seconds, counting
+// the frames of drop-frame timecodes as broadcast equipment and editors do.
+package smpte
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoFrameRate is returned when a timecode is converted without a frame rate
+var ErrNoFrameRate = errors.New("a frame rate is required to convert timecodes")
+
+// Timecode is a SMPTE timecode, in the HH:MM:SS:FF format. Drop-frame timecodes
+// separate the frames with a semicolon, as in HH:MM:SS;FF.
+type Timecode struct {
+	Hours, Minutes, Seconds, Frames int64
+	DropFrame                       bool
+}
+
+// Parse parses a timecode, either non drop-frame (01:00:00:00) or drop-frame
+// (01:00:00;00 or 01:00:00.00)
+func Parse(s string) (Timecode, error) {
+	var (
+		tc  Timecode
+		sep rune
+	)
+	n, err := fmt.Sscanf(s, "%02d:%02d:%02d%c%02d", &tc.Hours, &tc.Minutes, &tc.Seconds, &sep, &tc.Frames)
+	if err != nil || n != 5 || len(s) != 11 {
+		return Timecode{}, fmt.Errorf("invalid timecode %q, expected HH:MM:SS:FF or HH:MM:SS;FF", s)
+	}
+
+	switch sep {
+	case ':':
+	case ';', '.':
+		tc.DropFrame = true
+	default:
+		return Timecode{}, fmt.Errorf("invalid frame separator %q in timecode %q", sep, s)
+	}
+
+	if tc.Minutes > 59 || tc.Seconds > 59 {
+		return Timecode{}, fmt.Errorf("invalid timecode %q, minutes and seconds must be below 60", s)
+	}
+	return tc, nil
+}
+
+// String returns the timecode in the HH:MM:SS:FF or HH:MM:SS;FF format
+func (tc Timecode) String() string {
+	sep := ':'
+	if tc.DropFrame {
+		sep = ';'
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%c%02d", tc.Hours, tc.Minutes, tc.Seconds, sep, tc.Frames)
+}
+
+// Frame returns the number of frames from 00:00:00:00 to the timecode at a frame rate
+func (tc Timecode) Frame(fps float64) (int64, error) {
+	if fps <= 0 {
+		return 0, ErrNoFrameRate
+	}
+	nominal := nominalRate(fps)
+	if tc.Frames >= nominal {
+		return 0, fmt.Errorf("timecode %s has more frames than the %g fps frame rate", tc, fps)
+	}
+
+	frame := ((tc.Hours*60+tc.Minutes)*60+tc.Seconds)*nominal + tc.Frames
+	if !tc.DropFrame {
+		return frame, nil
+	}
+
+	dropped, ok := droppedFrames(fps)
+	if !ok {
+		return 0, fmt.Errorf("drop-frame timecodes are only valid at 29.97 or 59.94 fps, not %g fps", fps)
+	}
+	if tc.Seconds == 0 && tc.Frames < dropped && tc.Minutes%10 != 0 {
+		return 0, fmt.Errorf("timecode %s was dropped and doesn't exist", tc)
+	}
+	minutes := tc.Hours*60 + tc.Minutes
+	return frame - dropped*(minutes-minutes/10), nil
+}
+
+// FromFrame returns the timecode of a frame at a frame rate, the timecode being
+// drop-frame if requested and valid at that rate
+func FromFrame(frame int64, fps float64, dropFrame bool) Timecode {
+	nominal := nominalRate(fps)
+	if nominal <= 0 {
+		return Timecode{}
+	}
+
+	dropped, ok := droppedFrames(fps)
+	dropFrame = dropFrame && ok
+	if dropFrame {
+		// add back the labels skipped every minute except every tenth minute
+		framesPer10Minutes := nominal*600 - dropped*9
+		framesPerMinute := nominal*60 - dropped
+		tens, rem := frame/framesPer10Minutes, frame%framesPer10Minutes
+		frame += 9 * dropped * tens
+		if rem > dropped {
+			frame += dropped * ((rem - dropped) / framesPerMinute)
+		}
+	}
+
+	return Timecode{
+		Hours:     frame / (nominal * 3600),
+		Minutes:   frame / (nominal * 60) % 60,
+		Seconds:   frame / nominal % 60,
+		Frames:    frame % nominal,
+		DropFrame: dropFrame,
+	}
+}
+
+// Seconds returns the time at which a frame starts
+func Seconds(frame int64, fps float64) float64 {
+	return float64(frame) / ExactRate(fps)
+}
+
+// FrameAt returns the frame closest to a time in seconds
+func FrameAt(seconds, fps float64) int64 {
+	return int64(math.Round(seconds * ExactRate(fps)))
+}
+
+// ExactRate returns the exact value of NTSC frame rates, which are usually
+// rounded, such as 30000/1001 for 29.97. Other rates are returned as is.
+func ExactRate(fps float64) float64 {
+	if isNTSC(fps) {
+		return math.Round(fps) * 1000 / 1001
+	}
+	return fps
+}
+
+func isNTSC(fps float64) bool {
+	return fps >= 1 && math.Abs(fps-math.Round(fps)*1000/1001) < 0.005
+}
+
+// nominalRate is the number of frames counted by a timecode every second, such
+// as 30 for 29.97 fps
+func nominalRate(fps float64) int64 {
+	return int64(math.Round(fps))
+}
+
+// droppedFrames returns the number of timecode labels skipped every minute for
+// frame rates supporting drop-frame timecodes
+func droppedFrames(fps float64) (int64, bool) {
+	if !isNTSC(fps) {
+		return 0, false
+	}
+	switch nominalRate(fps) {
+	case 30:
+		return 2, true
+	case 60:
+		return 4, true
+	}
+	return 0, false
+}
@@ -0,0 +1,116 @@
+package smpte
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Timecode
+		wantErr bool
+	}{
+		{input: "01:02:03:04", want: Timecode{Hours: 1, Minutes: 2, Seconds: 3, Frames: 4}},
+		{input: "01:02:03;04", want: Timecode{Hours: 1, Minutes: 2, Seconds: 3, Frames: 4, DropFrame: true}},
+		{input: "01:02:03.04", want: Timecode{Hours: 1, Minutes: 2, Seconds: 3, Frames: 4, DropFrame: true}},
+		{input: "01:02:03", wantErr: true},
+		{input: "01:02:03/04", wantErr: true},
+		{input: "01:62:03:04", wantErr: true},
+		{input: "01:02:03:04:05", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("wrong timecode: got %+v, expected %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimecodeFrame(t *testing.T) {
+	tests := []struct {
+		timecode string
+		fps      float64
+		want     int64
+		wantErr  string
+	}{
+		{timecode: "00:00:10:12", fps: 25, want: 262},
+		{timecode: "00:00:01:00", fps: 23.976, want: 24},
+		{timecode: "00:01:00;02", fps: 29.97, want: 1800},
+		{timecode: "00:10:00;00", fps: 29.97, want: 17982},
+		{timecode: "01:00:00;00", fps: 29.97, want: 107892},
+		{timecode: "00:01:00;04", fps: 59.94, want: 3600},
+		{timecode: "00:01:00;01", fps: 29.97, wantErr: "timecode 00:01:00;01 was dropped and doesn't exist"},
+		{timecode: "00:00:01;00", fps: 25, wantErr: "drop-frame timecodes are only valid at 29.97 or 59.94 fps, not 25 fps"},
+		{timecode: "00:00:01:25", fps: 25, wantErr: "timecode 00:00:01:25 has more frames than the 25 fps frame rate"},
+		{timecode: "00:00:01:00", wantErr: ErrNoFrameRate.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timecode, func(t *testing.T) {
+			tc, err := Parse(tt.timecode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := tc.Frame(tt.fps)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error: got %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("wrong frame: got %d, expected %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromFrameRoundTrip(t *testing.T) {
+	for _, fps := range []float64{23.976, 25, 29.97, 59.94} {
+		for frame := int64(0); frame < 200000; frame += 7 {
+			tc := FromFrame(frame, fps, true)
+			got, err := tc.Frame(fps)
+			if err != nil {
+				t.Fatalf("%g fps, frame %d: unexpected error for %s: %v", fps, frame, tc, err)
+			}
+			if got != frame {
+				t.Fatalf("%g fps: frame %d became %s, which is frame %d", fps, frame, tc, got)
+			}
+		}
+	}
+
+	if g, e := FromFrame(1800, 29.97, true).String(), "00:01:00;02"; g != e {
+		t.Errorf("wrong timecode: got %q, expected %q", g, e)
+	}
+	if g, e := FromFrame(1800, 29.97, false).String(), "00:01:00:00"; g != e {
+		t.Errorf("wrong timecode: got %q, expected %q", g, e)
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	if g, e := Seconds(30, 29.97), 1.001; math.Abs(g-e) > 1e-9 {
+		t.Errorf("wrong seconds: got %v, expected %v", g, e)
+	}
+	if g, e := FrameAt(1.001, 29.97), int64(30); g != e {
+		t.Errorf("wrong frame: got %d, expected %d", g, e)
+	}
+	if g, e := FrameAt(10.5, 25), int64(263); g != e {
+		t.Errorf("wrong frame: got %d, expected %d", g, e)
+	}
+}